@@ -1,19 +1,30 @@
 package main
 
 import (
+	"Reminders/internal/anchors"
+	"Reminders/internal/blobstore"
 	"Reminders/internal/database"
+	"Reminders/internal/envs"
+	"Reminders/internal/escalation"
 	"Reminders/internal/models"
+	"Reminders/internal/pausing"
+	"Reminders/internal/recurrence"
 	"Reminders/internal/server"
+	"Reminders/internal/templating"
+	"Reminders/internal/tgformat"
+	"Reminders/internal/webhooks"
+	"fmt"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 	"log"
 	"os"
+	"strings"
 	"time"
+	_ "time/tzdata" // образ alpine не содержит базы часовых поясов
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-const myTelegramID = 323993202
-
 var logger *zap.Logger
 
 func init() {
@@ -26,49 +37,481 @@ func init() {
 }
 
 func main() {
+	// Команды управления вебхуком не требуют базы данных
+	if len(os.Args) > 1 {
+		server.InitLogger()
+		server.InitEnvs()
+		bot, err := newBot()
+		if err != nil {
+			logger.Fatal("Ошибка инициализации Telegram бота", zap.Error(err))
+		}
+		if err := runCommand(bot, os.Args[1]); err != nil {
+			logger.Fatal("Ошибка выполнения команды", zap.String("command", os.Args[1]), zap.Error(err))
+		}
+		return
+	}
+
 	server.InitServer()
 
 	// Инициализация бота
-	bot, err := tgbotapi.NewBotAPI(os.Getenv("TOKEN"))
+	bot, err := newBot()
 	if err != nil {
 		logger.Panic("Ошибка инициализации Telegram бота", zap.Error(err))
 	}
 
+	// Обновления приходят через long polling или вебхук, в зависимости от BOT_MODE
+	updates, err := receiveUpdates(bot)
+	if err != nil {
+		logger.Fatal("Ошибка получения обновлений Telegram", zap.Error(err))
+	}
+	// Команды /start <token> и /unlink для привязки чатов, /list <название> для просмотра напоминаний,
+	// /remind <когда> <текст> для создания напоминания
+	go handleUpdates(bot, updates)
+	go deliverWebhooks()
+
 	for {
 		checkAndSendReminders(bot)
+		sendAgendas(bot, time.Now())
+		runEscalations(bot)
 		time.Sleep(1 * time.Minute)
 	}
 }
 
+// newBot подключается к Bot API. TELEGRAM_API_URL позволяет направить бота
+// на свой сервер Bot API или на поддельный из internal/tgfake.
+func newBot() (*tgbotapi.BotAPI, error) {
+	if base := envs.ServerEnvs.TELEGRAM_API_URL; base != "" {
+		return tgbotapi.NewBotAPIWithAPIEndpoint(os.Getenv("TOKEN"), strings.TrimSuffix(base, "/")+"/bot%s/%s")
+	}
+	return tgbotapi.NewBotAPI(os.Getenv("TOKEN"))
+}
+
+// Срок, на который проверка берёт напоминания. Если экземпляр бота упадёт посреди отправки,
+// напоминания возьмёт другой экземпляр по истечении этого срока
+const reminderClaimLease = 5 * time.Minute
+
 // checkAndSendReminders проверяет напоминания и отправляет их, если время отправки прошло.
 func checkAndSendReminders(bot *tgbotapi.BotAPI) {
-	var reminders []models.Reminder
 	now := time.Now()
+	down := startTick(now)
 
-	// Запрос на получение напоминаний, которые ещё не отправлены и время отправки которых прошло
-	if err := database.DB.Where("is_sent = ? AND send_at <= ?", false, now).Find(&reminders).Error; err != nil {
+	// Напоминания и пользователи, у которых закончилась пауза, возвращаются в расписание
+	if n, err := pausing.ResumeExpired(database.DB, now); err != nil {
+		logger.Error("Ошибка при возобновлении после паузы", zap.Error(err))
+	} else if n > 0 {
+		logger.Info("Пауза закончилась", zap.Int("count", n))
+	}
+
+	reminders, err := claimDueReminders(now)
+	if err != nil {
 		logger.Error("Ошибка при получении напоминаний", zap.Error(err))
 		return
 	}
+	defer releaseReminders(reminders)
 
+	var missed missedDigest
+	var digests digestBatch
 	for _, r := range reminders {
-		if sendReminder(bot, r) {
-			// Обновление статуса напоминания в базе данных
-			if err := database.DB.Model(&r).Update("is_sent", true).Error; err != nil {
-				logger.Error("Ошибка при обновлении статуса напоминания", zap.Int("reminder_id", r.ID), zap.Error(err))
+		// Напоминание дождётся, пока получатели привяжут чат или снимут паузу
+		if len(targets(r)) == 0 {
+			logger.Warn("У получателей нет доступных чатов", zap.Int("reminder_id", r.ID), zap.Int("user_id", r.UserID))
+			continue
+		}
+		// Напоминания пользователей со сводкой ждут ближайшей сводки
+		if holdForDigest(r, now, &digests) {
+			continue
+		}
+		// Несрочные напоминания не отправляются в тихие часы и в режиме «не беспокоить»
+		if !r.Urgent && r.User != nil {
+			if until, reason := r.User.DeferUntil(now); until.After(now) {
+				deferReminder(r, until, reason)
+				continue
+			}
+		}
+		// Напоминания, пропущенные пока бот не работал, обрабатываются по политике
+		if !applyMisfire(&r, now, down, &missed) {
+			continue
+		}
+		deliveries, sent := sendReminder(bot, r, down.lateNote(r))
+		if err := recordSent(r, deliveries, sent, "", now); err != nil {
+			logger.Error("Ошибка при обновлении статуса напоминания", zap.Int("reminder_id", r.ID), zap.Error(err))
+		}
+	}
+	sendMissedDigests(bot, missed, now)
+	sendDigests(bot, digests, now)
+}
+
+// claimDueReminders берёт напоминания, которые ещё не отправлены и время отправки которых
+// прошло, и помечает их взятыми на reminderClaimLease. Строки, которые в это время берёт
+// другой экземпляр бота, пропускаются, поэтому каждое напоминание отправляет один экземпляр.
+func claimDueReminders(now time.Time) ([]models.Reminder, error) {
+	var ids []int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := database.SkipLocked(tx.Model(&models.Reminder{})).
+			Where("is_sent = ? AND archived_at IS NULL AND waiting_anchor = ? AND send_at <= ?", false, false, now).
+			Where("deferred_until IS NULL OR deferred_until <= ?", now).
+			Where("paused_at IS NULL").
+			Where("claimed_until IS NULL OR claimed_until <= ?", now).
+			Where("user_id NOT IN (?)", tx.Model(&models.User{}).Select("id").Where("paused_at IS NOT NULL")).
+			Order("id").
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		return tx.Model(&models.Reminder{}).Where("id IN ?", ids).Update("claimed_until", now.Add(reminderClaimLease)).Error
+	})
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var reminders []models.Reminder
+	err = database.DB.Preload("User.TelegramLinks").Preload("Recipients.User.TelegramLinks").
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&reminders).Error
+	return reminders, err
+}
+
+// releaseReminders снимает отметку с напоминаний, взятых проверкой. Отложенные или
+// не отправленные напоминания снова видны следующей проверке любого экземпляра.
+func releaseReminders(reminders []models.Reminder) {
+	if len(reminders) == 0 {
+		return
+	}
+	ids := make([]int, len(reminders))
+	for i, r := range reminders {
+		ids[i] = r.ID
+	}
+	if err := database.DB.Model(&models.Reminder{}).Where("id IN ?", ids).Update("claimed_until", nil).Error; err != nil {
+		logger.Error("Ошибка при освобождении напоминаний", zap.Error(err))
+	}
+}
+
+// recordSent сохраняет результаты доставки напоминания. Если оно дошло хотя бы до одного
+// получателя, повторяющееся напоминание переносится на следующее повторение, остальные
+// помечаются отправленными, а в историю записывается отправка с причиной reason,
+// по умолчанию - состоянием доставки.
+func recordSent(r models.Reminder, deliveries []models.ReminderDelivery, sent int, reason string, now time.Time) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		status := models.DeliveryStatus(sent, len(deliveries)-sent)
+		changed, err := changedDeliveries(tx, deliveries)
+		if err != nil {
+			return err
+		}
+		if len(changed) > 0 {
+			if err := tx.Create(&changed).Error; err != nil {
+				return err
+			}
+		}
+		// Если не удалось доставить ни одному получателю, напоминание отправится при следующей проверке
+		if sent == 0 {
+			// О неудаче сообщается один раз, а не при каждой повторной попытке. Напоминание
+			// могли уже пометить недоставленным при отключении чата
+			result := tx.Model(&r).Where("COALESCE(delivery_status, '') <> ?", status).Update("delivery_status", status)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			return webhooks.Publish(tx, models.EventReminderFailed, r)
+		}
+
+		// Обновление статуса напоминания в базе данных
+		updates := afterSent(r)
+		updates["delivery_status"] = status
+		updates["delivery_error"] = ""
+		if err := tx.Model(&r).Updates(updates).Error; err != nil {
+			return err
+		}
+		if reason == "" {
+			reason = status
+		}
+		err = tx.Create(&models.ReminderHistory{
+			ReminderID: r.ID,
+			Action:     models.HistorySent,
+			Reason:     reason,
+			SendAt:     r.SendAt,
+			CreatedAt:  time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := escalation.Schedule(tx, r, now); err != nil {
+			return err
+		}
+		// Напоминания, отсчитываемые от отправки этого, получают время отправки
+		if _, err := anchors.OnSent(tx, r, now); err != nil {
+			return err
+		}
+		return webhooks.Publish(tx, models.EventReminderSent, r)
+	})
+}
+
+// changedDeliveries отбрасывает результаты доставки, которые повторяют последний записанный
+// результат того же повторения в тот же чат: неудачная отправка, которая повторяется при
+// каждой проверке, записывается один раз.
+func changedDeliveries(tx *gorm.DB, deliveries []models.ReminderDelivery) ([]models.ReminderDelivery, error) {
+	var changed []models.ReminderDelivery
+	for _, d := range deliveries {
+		var last models.ReminderDelivery
+		err := tx.Where("reminder_id = ? AND chat_id = ? AND occurrence = ?", d.ReminderID, d.ChatID, d.Occurrence).
+			Order("id DESC").
+			Limit(1).
+			Find(&last).Error
+		if err != nil {
+			return nil, err
+		}
+		if last.ID != 0 && last.Status == d.Status && last.Error == d.Error {
+			continue
+		}
+		changed = append(changed, d)
+	}
+	return changed, nil
+}
+
+// deferReminder откладывает доставку напоминания и записывает перенос в историю.
+// Время повторения send_at не меняется, чтобы расписание не сдвигалось.
+func deferReminder(r models.Reminder, until time.Time, reason string) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&r).Update("deferred_until", until).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ReminderHistory{
+			ReminderID:    r.ID,
+			Action:        models.HistoryDeferred,
+			Reason:        reason,
+			SendAt:        r.SendAt,
+			DeferredUntil: &until,
+			CreatedAt:     time.Now(),
+		}).Error
+	})
+	if err != nil {
+		logger.Error("Ошибка при переносе напоминания", zap.Int("reminder_id", r.ID), zap.Error(err))
+		return
+	}
+	logger.Info("Доставка напоминания отложена", zap.Int("reminder_id", r.ID), zap.String("reason", reason), zap.Time("deferred_until", until))
+}
+
+// afterSent возвращает изменения напоминания после отправки: повторяющееся напоминание
+// переносится на следующее повторение, остальные помечаются отправленными.
+func afterSent(r models.Reminder) map[string]interface{} {
+	updates := map[string]interface{}{"sent_count": r.SentCount + 1, "is_sent": true, "deferred_until": nil, "acked_at": nil}
+	if r.Recurrence == "" {
+		return updates
+	}
+
+	rule, err := recurrence.Parse(r.Recurrence)
+	if err != nil {
+		logger.Error("Некорректное правило повторения", zap.Int("reminder_id", r.ID), zap.Error(err))
+		return updates
+	}
+	if next, ok := rule.Next(r.SendAt.In(r.ScheduleLocation()), r.SentCount+1); ok {
+		updates["is_sent"] = false
+		updates["send_at"] = next
+		logger.Info("Напоминание перенесено на следующее повторение", zap.Int("reminder_id", r.ID), zap.Time("send_at", next))
+	}
+	return updates
+}
+
+// target - чат, в который отправляется напоминание.
+type target struct {
+	ChatID      int64
+	User        *models.User
+	RecipientID *int
+}
+
+// sendReminder отправляет напоминание во все чаты получателей и возвращает результаты
+// доставки по чатам и число успешных отправок. note дописывается к тексту через withNote.
+func sendReminder(bot *tgbotapi.BotAPI, r models.Reminder, note string) ([]models.ReminderDelivery, int) {
+	var deliveries []models.ReminderDelivery
+	sentCount := 0
+
+	for _, t := range targets(r) {
+		delivery := models.ReminderDelivery{
+			ReminderID:  r.ID,
+			RecipientID: t.RecipientID,
+			ChatID:      t.ChatID,
+			Occurrence:  r.SentCount + 1,
+			Status:      models.DeliverySent,
+			CreatedAt:   time.Now(),
+		}
+		if t.User != nil {
+			delivery.UserID = &t.User.ID
+		}
+
+		msg, err := buildMessage(r, t.ChatID, withNote(r, messageText(bot, r, t), note))
+		if err == nil {
+			var sent tgbotapi.Message
+			if sent, err = bot.Send(msg); err == nil {
+				// После первой отправки файл уже есть в Telegram, остальным чатам уходит его file_id
+				if fileID := rememberFileID(r, sent); fileID != "" {
+					r.AttachmentFileID = fileID
+				}
 			}
 		}
+		if err != nil {
+			logger.Error("Не удалось отправить сообщение", zap.Int("reminder_id", r.ID), zap.Int64("chat_id", t.ChatID), zap.Error(err))
+			delivery.Status = models.DeliveryFailed
+			delivery.Error = err.Error()
+			checkSendError(t.ChatID, err)
+		} else {
+			logger.Info("Сообщение успешно отправлено", zap.Int("reminder_id", r.ID), zap.Int64("chat_id", t.ChatID))
+			sentCount++
+		}
+		deliveries = append(deliveries, delivery)
 	}
+	return deliveries, sentCount
 }
 
-// sendReminder отправляет напоминание пользователю в Telegram.
-func sendReminder(bot *tgbotapi.BotAPI, r models.Reminder) bool {
-	msg := tgbotapi.NewMessage(myTelegramID, r.Message)
-	_, err := bot.Send(msg)
+// targets возвращает чаты получателей напоминания. Без отдельных получателей напоминание
+// уходит в чаты владельца. В чат, общий для нескольких получателей, отправляется одно сообщение.
+func targets(r models.Reminder) []target {
+	var result []target
+	seen := make(map[int64]bool)
+	add := func(chatID int64, user *models.User, recipientID *int) {
+		if seen[chatID] {
+			return
+		}
+		seen[chatID] = true
+		result = append(result, target{ChatID: chatID, User: user, RecipientID: recipientID})
+	}
+
+	if len(r.Recipients) == 0 {
+		if r.User != nil {
+			for _, chatID := range r.User.ActiveChatIDs() {
+				add(chatID, r.User, nil)
+			}
+		}
+		return result
+	}
+
+	for i := range r.Recipients {
+		rec := &r.Recipients[i]
+		switch rec.Kind {
+		case models.RecipientUser:
+			// Приостановленному пользователю напоминания не доставляются
+			if rec.User == nil || rec.User.Paused() {
+				continue
+			}
+			for _, chatID := range rec.User.ActiveChatIDs() {
+				add(chatID, rec.User, &rec.ID)
+			}
+		case models.RecipientChat:
+			// В отключённую группу или канал писать нельзя, пока из них не придёт сообщение
+			if rec.InactiveReason != "" {
+				continue
+			}
+			add(rec.ChatID, nil, &rec.ID)
+		}
+	}
+	return result
+}
+
+// buildMessage выбирает метод отправки по типу вложения. Загруженный файл берётся
+// из хранилища, ссылка на file_id передаётся в Telegram как есть. К важным напоминаниям
+// добавляется кнопка подтверждения.
+func buildMessage(r models.Reminder, chatID int64, text string) (tgbotapi.Chattable, error) {
+	var markup interface{}
+	if r.Priority == models.PriorityHigh {
+		markup = ackKeyboard(r.ID)
+	}
+
+	if r.AttachmentType == "" {
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ParseMode = r.ParseMode
+		msg.ReplyMarkup = markup
+		return msg, nil
+	}
+
+	var file tgbotapi.RequestFileData
+	if r.AttachmentFileID != "" {
+		file = tgbotapi.FileID(r.AttachmentFileID)
+	} else {
+		path, err := blobstore.Files.Path(r.AttachmentKey)
+		if err != nil {
+			return nil, err
+		}
+		file = tgbotapi.FilePath(path)
+	}
+
+	switch r.AttachmentType {
+	case models.AttachmentPhoto:
+		msg := tgbotapi.NewPhoto(chatID, file)
+		msg.Caption = text
+		msg.ParseMode = r.ParseMode
+		msg.ReplyMarkup = markup
+		return msg, nil
+	case models.AttachmentDocument:
+		msg := tgbotapi.NewDocument(chatID, file)
+		msg.Caption = text
+		msg.ParseMode = r.ParseMode
+		msg.ReplyMarkup = markup
+		return msg, nil
+	case models.AttachmentVoice:
+		msg := tgbotapi.NewVoice(chatID, file)
+		msg.Caption = text
+		msg.ParseMode = r.ParseMode
+		msg.ReplyMarkup = markup
+		return msg, nil
+	}
+	return nil, fmt.Errorf("неизвестный тип вложения %q", r.AttachmentType)
+}
+
+// rememberFileID сохраняет file_id загруженного в Telegram файла, чтобы повторные
+// отправки не загружали его заново.
+func rememberFileID(r models.Reminder, sent tgbotapi.Message) string {
+	if r.AttachmentKey == "" || r.AttachmentFileID != "" {
+		return ""
+	}
+
+	var fileID string
+	switch {
+	case len(sent.Photo) > 0:
+		fileID = sent.Photo[len(sent.Photo)-1].FileID
+	case sent.Document != nil:
+		fileID = sent.Document.FileID
+	case sent.Voice != nil:
+		fileID = sent.Voice.FileID
+	}
+	if fileID == "" {
+		return ""
+	}
+	if err := database.DB.Model(&r).Update("attachment_file_id", fileID).Error; err != nil {
+		logger.Error("Не удалось сохранить file_id вложения", zap.Int("reminder_id", r.ID), zap.Error(err))
+	}
+	return fileID
+}
+
+// messageText возвращает текст напоминания, подставляя данные получателя в шаблон.
+// Имя берётся из профиля получателя, а если оно не задано - из чата.
+// Если шаблон не удалось выполнить, отправляется исходный текст.
+func messageText(bot *tgbotapi.BotAPI, r models.Reminder, t target) string {
+	if !r.IsTemplate {
+		return r.Message
+	}
+
+	data := templating.Data{
+		SendAt:     r.SendAt,
+		Location:   r.ScheduleLocation(),
+		Occurrence: r.SentCount + 1,
+		Escape:     func(s string) string { return tgformat.Escape(r.ParseMode, s) },
+	}
+	if t.User != nil {
+		data.Name = t.User.DisplayName
+	}
+	if data.Name == "" {
+		chat, err := bot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: t.ChatID}})
+		if err != nil {
+			logger.Warn("Не удалось получить имя получателя", zap.Int("reminder_id", r.ID), zap.Error(err))
+		} else if chat.Title != "" {
+			data.Name = chat.Title
+		} else {
+			data.Name = chat.FirstName
+		}
+	}
+
+	text, err := templating.Render(r.Message, data)
 	if err != nil {
-		logger.Error("Не удалось отправить сообщение", zap.Int("reminder_id", r.ID), zap.Error(err))
-		return false
+		logger.Error("Ошибка выполнения шаблона напоминания", zap.Int("reminder_id", r.ID), zap.Error(err))
+		return r.Message
 	}
-	logger.Info("Сообщение успешно отправлено", zap.Int("reminder_id", r.ID))
-	return true
+	return text
 }
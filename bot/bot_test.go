@@ -0,0 +1,97 @@
+package main
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/testdb"
+	"testing"
+	"time"
+)
+
+// addDueReminders создаёт пользователя и n напоминаний, время отправки которых прошло.
+func addDueReminders(t *testing.T, now time.Time, n int) []models.Reminder {
+	t.Helper()
+	user := models.User{DisplayName: "claim owner"}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	reminders := make([]models.Reminder, n)
+	for i := range reminders {
+		reminders[i] = models.Reminder{UserID: user.ID, Message: "due", SendAt: now.Add(-time.Minute)}
+		if err := database.DB.Create(&reminders[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return reminders
+}
+
+func claimedIDs(reminders []models.Reminder) []int {
+	ids := make([]int, len(reminders))
+	for i, r := range reminders {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+// Напоминание, взятое одной проверкой, другая проверка не берёт, пока его не освободят
+// или не истечёт срок.
+func TestClaimDueReminders(t *testing.T) {
+	testdb.Open(t)
+	now := time.Now()
+	due := addDueReminders(t, now, 2)
+
+	claimed, err := claimDueReminders(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 2 || claimed[0].ID != due[0].ID || claimed[1].ID != due[1].ID {
+		t.Fatalf("claimed %v, want %v", claimedIDs(claimed), claimedIDs(due))
+	}
+	if claimed[0].User == nil {
+		t.Error("claimed reminder without its user")
+	}
+	if again, err := claimDueReminders(now); err != nil || len(again) != 0 {
+		t.Errorf("second claim = %v, %v; want none", claimedIDs(again), err)
+	}
+	if again, err := claimDueReminders(now.Add(reminderClaimLease + time.Second)); err != nil || len(again) != 2 {
+		t.Errorf("claim after the lease = %v, %v; want both", claimedIDs(again), err)
+	}
+
+	releaseReminders(claimed)
+	if again, err := claimDueReminders(now); err != nil || len(again) != 2 {
+		t.Errorf("claim after release = %v, %v; want both", claimedIDs(again), err)
+	}
+}
+
+// Пока одна транзакция держит напоминание, проверка пропускает его, а не ждёт.
+func TestClaimDueRemindersSkipsLockedRows(t *testing.T) {
+	if !testdb.IsPostgres() {
+		t.Skip("row locks need TEST_DATABASE_URL")
+	}
+	db := testdb.Open(t)
+	now := time.Now()
+	due := addDueReminders(t, now, 2)
+
+	tx := db.Begin()
+	defer tx.Rollback()
+	if err := tx.Exec("SELECT id FROM reminders WHERE id = ? FOR UPDATE", due[0].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan []models.Reminder)
+	go func() {
+		claimed, err := claimDueReminders(now)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- claimed
+	}()
+	select {
+	case claimed := <-done:
+		if len(claimed) != 1 || claimed[0].ID != due[1].ID {
+			t.Errorf("claimed %v, want only %d", claimedIDs(claimed), due[1].ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("claim waits for the locked row")
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/webhooks"
+	"errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// permanentReason сообщает, означает ли ошибка Telegram, что в чат больше нельзя писать,
+// и возвращает причину. Остальные ошибки, например 429 и ошибки сети, считаются временными:
+// сообщение отправится при следующей проверке.
+func permanentReason(err error) (string, bool) {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return "", false
+	}
+	message := strings.ToLower(tgErr.Message)
+	switch {
+	case tgErr.Code == http.StatusForbidden && strings.Contains(message, "blocked by the user"):
+		return models.InactiveBotBlocked, true
+	case tgErr.Code == http.StatusForbidden && strings.Contains(message, "user is deactivated"):
+		return models.InactiveUserDeactivated, true
+	case tgErr.Code == http.StatusForbidden && strings.Contains(message, "kicked"):
+		return models.InactiveBotKicked, true
+	case tgErr.Code == http.StatusForbidden:
+		return models.InactiveForbidden, true
+	case tgErr.Code == http.StatusBadRequest && strings.Contains(message, "chat not found"):
+		return models.InactiveChatNotFound, true
+	}
+	return "", false
+}
+
+// checkSendError отключает привязанный чат, если отправка в него больше невозможна.
+func checkSendError(chatID int64, err error) {
+	reason, ok := permanentReason(err)
+	if !ok {
+		return
+	}
+	if err := deactivateChat(chatID, reason, time.Now()); err != nil {
+		logger.Error("Ошибка при отключении чата", zap.Int64("chat_id", chatID), zap.String("reason", reason), zap.Error(err))
+	}
+}
+
+// deactivateChat отключает чат у получателей напоминаний и привязанный чат. Если у пользователя
+// не осталось включённых чатов, его неотправленные напоминания без отдельных получателей
+// помечаются недоставленными: доставить их некуда, пока пользователь снова не напишет боту.
+func deactivateChat(chatID int64, reason string, now time.Time) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		// Группы и каналы из получателей не привязаны к пользователям и отключаются у каждого напоминания
+		result := tx.Model(&models.ReminderRecipient{}).
+			Where("kind = ? AND chat_id = ? AND COALESCE(inactive_reason, '') = ''", models.RecipientChat, chatID).
+			Updates(map[string]interface{}{"inactive_reason": reason, "deactivated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			logger.Warn("Чат получателя отключён", zap.Int64("chat_id", chatID), zap.String("reason", reason), zap.Int64("recipients", result.RowsAffected))
+		}
+
+		var link models.TelegramLink
+		err := tx.Where("chat_id = ? AND active = ?", chatID, true).First(&link).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&link).Updates(map[string]interface{}{
+			"active":          false,
+			"inactive_reason": reason,
+			"deactivated_at":  now,
+			"updated_at":      now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		logger.Warn("Чат отключён", zap.Int64("chat_id", chatID), zap.Int("user_id", link.UserID), zap.String("reason", reason))
+
+		var active int64
+		if err := tx.Model(&models.TelegramLink{}).Where("user_id = ? AND active = ?", link.UserID, true).Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return nil
+		}
+		return failPending(tx, link.UserID, reason, now)
+	})
+}
+
+// failPending помечает неотправленные напоминания пользователя без отдельных получателей
+// недоставленными с причиной и сообщает об этом подписчикам вебхуков.
+func failPending(tx *gorm.DB, userID int, reason string, now time.Time) error {
+	var reminders []models.Reminder
+	err := tx.Where("user_id = ? AND is_sent = ? AND archived_at IS NULL", userID, false).
+		Where("id NOT IN (?)", tx.Model(&models.ReminderRecipient{}).Select("reminder_id")).
+		Where("COALESCE(delivery_error, '') <> ?", reason).
+		Find(&reminders).Error
+	if err != nil {
+		return err
+	}
+
+	for _, r := range reminders {
+		err := tx.Model(&r).Updates(map[string]interface{}{
+			"delivery_status": models.DeliveryFailed,
+			"delivery_error":  reason,
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Create(&models.ReminderHistory{
+			ReminderID: r.ID,
+			Action:     models.HistoryFailed,
+			Reason:     reason,
+			SendAt:     r.SendAt,
+			CreatedAt:  now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := webhooks.Publish(tx, models.EventReminderFailed, r); err != nil {
+			return err
+		}
+	}
+	if len(reminders) > 0 {
+		logger.Info("Напоминания помечены недоставленными", zap.Int("user_id", userID), zap.String("reason", reason), zap.Int("count", len(reminders)))
+	}
+	return nil
+}
+
+// reactivateChat снова включает чат, отключённый после ошибки Telegram, когда из него пишут
+// боту, и снимает состояние недоставки с напоминаний пользователя.
+func reactivateChat(chatID int64) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ReminderRecipient{}).
+			Where("chat_id = ? AND inactive_reason <> ''", chatID).
+			Updates(map[string]interface{}{"inactive_reason": "", "deactivated_at": nil})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			logger.Info("Чат получателя снова включён", zap.Int64("chat_id", chatID), zap.Int64("recipients", result.RowsAffected))
+		}
+
+		var link models.TelegramLink
+		err := tx.Where("chat_id = ? AND active = ? AND inactive_reason <> ''", chatID, false).First(&link).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&link).Updates(map[string]interface{}{
+			"active":          true,
+			"inactive_reason": "",
+			"deactivated_at":  nil,
+			"updated_at":      time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		logger.Info("Чат снова включён", zap.Int64("chat_id", chatID), zap.Int("user_id", link.UserID))
+
+		return tx.Model(&models.Reminder{}).
+			Where("user_id = ? AND is_sent = ? AND delivery_error <> ''", link.UserID, false).
+			Updates(map[string]interface{}{"delivery_status": "", "delivery_error": ""}).Error
+	})
+	if err != nil {
+		logger.Error("Ошибка при включении чата", zap.Int64("chat_id", chatID), zap.Error(err))
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/templating"
+	"fmt"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Максимальное количество напоминаний в одном сообщении сводки
+	digestChunk = 20
+	// Кнопок подтверждения в одном ряду
+	digestButtonsPerRow = 5
+	// Вечерний обзор не отправляется, если бот пропустил его время больше чем на этот срок
+	agendaWindow = time.Hour
+)
+
+// digestBatch - напоминания, которые уходят в сводки пользователей при этой проверке.
+type digestBatch struct {
+	users     []*models.User
+	reminders map[int][]models.Reminder
+}
+
+// add добавляет напоминание в сводку его владельца.
+func (b *digestBatch) add(r models.Reminder) {
+	if b.reminders == nil {
+		b.reminders = make(map[int][]models.Reminder)
+	}
+	if _, ok := b.reminders[r.UserID]; !ok {
+		b.users = append(b.users, r.User)
+	}
+	b.reminders[r.UserID] = append(b.reminders[r.UserID], r)
+}
+
+// holdForDigest откладывает напоминание пользователя со сводкой до ближайшей сводки
+// или добавляет его в сводку, если её время наступило. Срочные, важные напоминания и
+// напоминания с отдельными получателями приходят как обычно. Возвращает true, если
+// напоминание не нужно отправлять отдельно.
+func holdForDigest(r models.Reminder, now time.Time, b *digestBatch) bool {
+	if r.User == nil || !r.User.Digest.Enabled() {
+		return false
+	}
+	if r.Urgent || r.Priority == models.PriorityHigh || len(r.Recipients) > 0 {
+		return false
+	}
+
+	if r.DeferredUntil != nil && !r.DeferredUntil.After(now) && r.User.IsDigestSlot(*r.DeferredUntil) {
+		// Повторения, наступившие между сводками, попадают в сводку одной строкой
+		skipToLatest(&r, now)
+		b.add(r)
+		return true
+	}
+	deferReminder(r, r.User.NextDigest(now), models.DeferDigest)
+	return true
+}
+
+// sendDigests отправляет каждому пользователю сводку наступивших напоминаний.
+// Под сводкой - кнопки подтверждения каждого напоминания.
+func sendDigests(bot *tgbotapi.BotAPI, b digestBatch, now time.Time) {
+	for _, user := range b.users {
+		reminders := b.reminders[user.ID]
+		for start := 0; start < len(reminders); start += digestChunk {
+			end := start + digestChunk
+			if end > len(reminders) {
+				end = len(reminders)
+			}
+			sendDigest(bot, user, reminders[start:end], start, now)
+		}
+	}
+}
+
+// sendDigest отправляет часть сводки во все чаты пользователя и сохраняет результаты доставки.
+// offset - номер первого напоминания части в сводке.
+func sendDigest(bot *tgbotapi.BotAPI, user *models.User, reminders []models.Reminder, offset int, now time.Time) {
+	var b strings.Builder
+	b.WriteString("Сводка напоминаний:\n")
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, r := range reminders {
+		n := strconv.Itoa(offset + i + 1)
+		fmt.Fprintf(&b, "\n%s. %s — %s", n, r.SendAt.In(r.ScheduleLocation()).Format(dueLayout), digestPreview(r))
+
+		button := tgbotapi.NewInlineKeyboardButtonData("✅ "+n, ackPrefix+strconv.Itoa(r.ID))
+		if i%digestButtonsPerRow == 0 {
+			rows = append(rows, nil)
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], button)
+	}
+
+	deliveries := make(map[int][]models.ReminderDelivery, len(reminders))
+	sent := 0
+	for _, chatID := range user.ActiveChatIDs() {
+		msg := tgbotapi.NewMessage(chatID, b.String())
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		_, err := bot.Send(msg)
+		if err != nil {
+			logger.Error("Не удалось отправить сводку", zap.Int("user_id", user.ID), zap.Int64("chat_id", chatID), zap.Error(err))
+			checkSendError(chatID, err)
+		} else {
+			sent++
+		}
+
+		for _, r := range reminders {
+			delivery := models.ReminderDelivery{
+				ReminderID: r.ID,
+				UserID:     &user.ID,
+				ChatID:     chatID,
+				Occurrence: r.SentCount + 1,
+				Status:     models.DeliverySent,
+				CreatedAt:  time.Now(),
+			}
+			if err != nil {
+				delivery.Status = models.DeliveryFailed
+				delivery.Error = err.Error()
+			}
+			deliveries[r.ID] = append(deliveries[r.ID], delivery)
+		}
+	}
+
+	for _, r := range reminders {
+		if err := recordSent(r, deliveries[r.ID], sent, models.DeferDigest, now); err != nil {
+			logger.Error("Ошибка при обновлении статуса напоминания из сводки", zap.Int("reminder_id", r.ID), zap.Error(err))
+		}
+	}
+	logger.Info("Сводка отправлена", zap.Int("user_id", user.ID), zap.Int("reminders", len(reminders)), zap.Int("chats", sent))
+}
+
+// sendAgendas присылает пользователям с вечерним обзором список напоминаний на завтра.
+func sendAgendas(bot *tgbotapi.BotAPI, now time.Time) {
+	var users []models.User
+	err := database.DB.Preload("TelegramLinks").
+		Where("paused_at IS NULL AND digest->>'agenda_at' <> ''").
+		Find(&users).Error
+	if err != nil {
+		logger.Error("Ошибка при получении пользователей с вечерним обзором", zap.Error(err))
+		return
+	}
+
+	for i := range users {
+		user := &users[i]
+		slot, ok := user.LastAgenda(now)
+		if !ok || now.Sub(slot) > agendaWindow {
+			continue
+		}
+		if user.AgendaSentAt != nil && !user.AgendaSentAt.Before(slot) {
+			continue
+		}
+		sendAgenda(bot, user, slot, now)
+	}
+}
+
+// sendAgenda отправляет пользователю обзор напоминаний на день, следующий за slot.
+// Если на завтра ничего не запланировано, сообщение не отправляется.
+func sendAgenda(bot *tgbotapi.BotAPI, user *models.User, slot, now time.Time) {
+	local := slot.In(user.Location())
+	from := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, local.Location())
+	to := from.AddDate(0, 0, 1)
+
+	var reminders []models.Reminder
+	err := database.DB.
+		Where("user_id = ? AND is_sent = ? AND archived_at IS NULL AND paused_at IS NULL AND waiting_anchor = ?", user.ID, false, false).
+		Where("send_at >= ? AND send_at < ?", from, to).
+		Order("send_at, id").
+		Find(&reminders).Error
+	if err != nil {
+		logger.Error("Ошибка при получении напоминаний на завтра", zap.Int("user_id", user.ID), zap.Error(err))
+		return
+	}
+
+	if len(reminders) > 0 {
+		var b strings.Builder
+		fmt.Fprintf(&b, "План на завтра, %s:\n", from.Format("02.01"))
+		for _, r := range reminders {
+			r.User = user
+			fmt.Fprintf(&b, "\n%s — %s", r.SendAt.In(r.ScheduleLocation()).Format("15:04"), digestPreview(r))
+		}
+		for _, chatID := range user.ActiveChatIDs() {
+			if _, err := bot.Send(tgbotapi.NewMessage(chatID, b.String())); err != nil {
+				logger.Error("Не удалось отправить вечерний обзор", zap.Int("user_id", user.ID), zap.Int64("chat_id", chatID), zap.Error(err))
+				checkSendError(chatID, err)
+			}
+		}
+	}
+
+	if err := database.DB.Model(user).Update("agenda_sent_at", now).Error; err != nil {
+		logger.Error("Ошибка при сохранении времени вечернего обзора", zap.Int("user_id", user.ID), zap.Error(err))
+		return
+	}
+	logger.Info("Вечерний обзор отправлен", zap.Int("user_id", user.ID), zap.Int("reminders", len(reminders)))
+}
+
+// digestPreview возвращает текст напоминания для строки сводки. Шаблон выполняется для
+// владельца, как при отдельной отправке, без экранирования: сводка уходит без разметки.
+// Если шаблон не выполнился, в строку попадает исходный текст.
+func digestPreview(r models.Reminder) string {
+	if r.IsTemplate {
+		data := templating.Data{SendAt: r.SendAt, Location: r.ScheduleLocation(), Occurrence: r.SentCount + 1}
+		if r.User != nil {
+			data.Name = r.User.DisplayName
+		}
+		text, err := templating.Render(r.Message, data)
+		if err != nil {
+			logger.Error("Ошибка выполнения шаблона напоминания", zap.Int("reminder_id", r.ID), zap.Error(err))
+		} else {
+			r.Message = text
+		}
+	}
+	return preview(r)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// В сводке и вечернем обзоре шаблоны выполняются, как при отдельной отправке, а
+// шаблон с ошибкой показывается исходным текстом.
+func TestDigestRendersTemplates(t *testing.T) {
+	fake, bot := newFakeBot(t)
+	now := time.Now().UTC()
+	user := models.User{DisplayName: "Анна"}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := database.DB.Create(&models.TelegramLink{UserID: user.ID, ChatID: e2eChatID, Active: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 10, 0, 0, 0, time.UTC)
+	reminders := []models.Reminder{
+		{UserID: user.ID, Message: "Привет, {{name}}! Повтор №{{occurrence}}", IsTemplate: true, SendAt: tomorrow, SentCount: 2},
+		{UserID: user.ID, Message: "{{call name}}", IsTemplate: true, SendAt: tomorrow.Add(time.Hour)},
+		{UserID: user.ID, Message: "Без {{шаблона}}", SendAt: tomorrow.Add(2 * time.Hour)},
+	}
+	if err := database.DB.Create(&reminders).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Привет, Анна! Повтор №3", "{{call name}}", "Без {{шаблона}}"}
+
+	for i := range reminders {
+		reminders[i].User = &user
+	}
+	if err := database.DB.Preload("TelegramLinks").First(&user, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Сводка помечает напоминания отправленными, поэтому обзор на завтра идёт первым
+	sendAgenda(bot, &user, now, now)
+	sendDigest(bot, &user, reminders, 0, now)
+
+	messages := fake.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("bot sent %d messages, want the agenda and the digest: %+v", len(messages), messages)
+	}
+	for _, msg := range messages {
+		for _, line := range want {
+			if !strings.Contains(msg.Text, line) {
+				t.Errorf("message %q has no line %q", msg.Text, line)
+			}
+		}
+	}
+}
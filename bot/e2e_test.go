@@ -0,0 +1,332 @@
+package main
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/testdb"
+	"Reminders/internal/tgfake"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Чат пользователя в проверках с поддельным Bot API
+const e2eChatID = 100000001
+
+// newFakeBot поднимает пустую базу и поддельный Bot API и возвращает бота, подключённого к нему.
+func newFakeBot(t *testing.T) (*tgfake.Server, *tgbotapi.BotAPI) {
+	t.Helper()
+	prev := logger
+	logger = zap.NewNop()
+	t.Cleanup(func() { logger = prev })
+	testdb.Open(t)
+
+	fake := tgfake.New()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("test-token", tgfake.Endpoint(srv.URL))
+	if err != nil {
+		t.Fatalf("connect to fake Bot API: %v", err)
+	}
+	return fake, bot
+}
+
+// listen получает обновления бота через getUpdates и обрабатывает их, как main.
+func listen(t *testing.T, bot *tgbotapi.BotAPI) {
+	t.Helper()
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 1
+	updates := bot.GetUpdatesChan(u)
+	done := make(chan struct{})
+	go func() {
+		handleUpdates(bot, updates)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		bot.StopReceivingUpdates()
+		<-done
+	})
+}
+
+// waitFor ждёт, пока выполнится условие.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// addLinkedReminder создаёт пользователя с чатом e2eChatID и напоминание с высоким
+// приоритетом, время отправки которого прошло.
+func addLinkedReminder(t *testing.T) models.Reminder {
+	t.Helper()
+	user := models.User{DisplayName: "Анна"}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	link := models.TelegramLink{UserID: user.ID, ChatID: e2eChatID, Active: true}
+	if err := database.DB.Create(&link).Error; err != nil {
+		t.Fatal(err)
+	}
+	r := models.Reminder{UserID: user.ID, Message: "Позвонить маме", Priority: models.PriorityHigh, SendAt: time.Now().Add(-time.Minute)}
+	if err := database.DB.Create(&r).Error; err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func reloadReminder(t *testing.T, id int) models.Reminder {
+	t.Helper()
+	var r models.Reminder
+	if err := database.DB.First(&r, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func reloadLink(t *testing.T) models.TelegramLink {
+	t.Helper()
+	var link models.TelegramLink
+	if err := database.DB.Where("chat_id = ?", e2eChatID).First(&link).Error; err != nil {
+		t.Fatal(err)
+	}
+	return link
+}
+
+// deliveries возвращает результаты доставки напоминания по порядку попыток.
+func deliveries(t *testing.T, reminderID int) []models.ReminderDelivery {
+	t.Helper()
+	var result []models.ReminderDelivery
+	if err := database.DB.Where("reminder_id = ?", reminderID).Order("id").Find(&result).Error; err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// Напоминание уходит в чат с кнопкой подтверждения, отправка попадает в историю,
+// а нажатие кнопки подтверждает напоминание.
+func TestE2ESendAndAcknowledge(t *testing.T) {
+	fake, bot := newFakeBot(t)
+	r := addLinkedReminder(t)
+
+	checkAndSendReminders(bot)
+
+	messages := fake.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("bot sent %d messages, want 1: %+v", len(messages), messages)
+	}
+	msg := messages[0]
+	if msg.Method != "sendMessage" || msg.ChatID != e2eChatID || msg.Text != "Позвонить маме" {
+		t.Errorf("sent %+v", msg)
+	}
+	if want := ackPrefix + strconv.Itoa(r.ID); !strings.Contains(msg.ReplyMarkup, want) {
+		t.Errorf("reply markup %s has no %q button", msg.ReplyMarkup, want)
+	}
+
+	got := reloadReminder(t, r.ID)
+	if !got.IsSent || got.DeliveryStatus != models.DeliverySent || got.SentCount != 1 {
+		t.Errorf("reminder after sending: is_sent=%v delivery_status=%q sent_count=%d", got.IsSent, got.DeliveryStatus, got.SentCount)
+	}
+	var history []models.ReminderHistory
+	if err := database.DB.Where("reminder_id = ?", r.ID).Find(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Action != models.HistorySent {
+		t.Errorf("history %+v, want one %q entry", history, models.HistorySent)
+	}
+	if d := deliveries(t, r.ID); len(d) != 1 || d[0].Status != models.DeliverySent || d[0].ChatID != e2eChatID {
+		t.Errorf("deliveries %+v", d)
+	}
+
+	listen(t, bot)
+	fake.PressButton(e2eChatID, msg.MessageID, ackPrefix+strconv.Itoa(r.ID))
+	waitFor(t, "callback answer", func() bool { return len(fake.Callbacks()) > 0 })
+	if answer := fake.Callbacks()[0]; answer.Text != "Подтверждено" {
+		t.Errorf("callback answer %q", answer.Text)
+	}
+	if got := reloadReminder(t, r.ID); got.AckedAt == nil {
+		t.Error("reminder is not acknowledged after the button press")
+	}
+}
+
+// После 429 напоминание остаётся неотправленным, чат не отключается, и следующая
+// проверка отправляет его.
+func TestE2ERetryAfterTooManyRequests(t *testing.T) {
+	fake, bot := newFakeBot(t)
+	r := addLinkedReminder(t)
+	fault := tgfake.TooManyRequests(1)
+	fault.Method = "sendMessage"
+	fault.Times = 1
+	fake.Fail(fault)
+
+	checkAndSendReminders(bot)
+	if messages := fake.Messages(); len(messages) != 0 {
+		t.Fatalf("bot sent %+v despite 429", messages)
+	}
+	got := reloadReminder(t, r.ID)
+	if got.IsSent || got.DeliveryStatus != models.DeliveryFailed {
+		t.Errorf("reminder after 429: is_sent=%v delivery_status=%q", got.IsSent, got.DeliveryStatus)
+	}
+	if d := deliveries(t, r.ID); len(d) != 1 || d[0].Status != models.DeliveryFailed || !strings.Contains(d[0].Error, "Too Many Requests") {
+		t.Errorf("deliveries after 429: %+v", d)
+	}
+	if link := reloadLink(t); !link.Active {
+		t.Errorf("chat switched off after a temporary error: %+v", link)
+	}
+
+	checkAndSendReminders(bot)
+	if messages := fake.Messages(); len(messages) != 1 || messages[0].ChatID != e2eChatID {
+		t.Fatalf("retry sent %+v, want one message", messages)
+	}
+	got = reloadReminder(t, r.ID)
+	if !got.IsSent || got.DeliveryStatus != models.DeliverySent {
+		t.Errorf("reminder after retry: is_sent=%v delivery_status=%q", got.IsSent, got.DeliveryStatus)
+	}
+}
+
+// Заблокированный чат отключается, напоминание помечается недоставленным и не
+// отправляется, пока пользователь снова не напишет боту.
+func TestE2EBlockedChat(t *testing.T) {
+	fake, bot := newFakeBot(t)
+	r := addLinkedReminder(t)
+	fault := tgfake.BotBlocked(e2eChatID)
+	fault.Times = 1
+	fake.Fail(fault)
+
+	checkAndSendReminders(bot)
+	if messages := fake.Messages(); len(messages) != 0 {
+		t.Fatalf("bot sent %+v to a blocked chat", messages)
+	}
+	if link := reloadLink(t); link.Active || link.InactiveReason != models.InactiveBotBlocked {
+		t.Errorf("link after 403: active=%v reason=%q", link.Active, link.InactiveReason)
+	}
+	got := reloadReminder(t, r.ID)
+	if got.IsSent || got.DeliveryStatus != models.DeliveryFailed || got.DeliveryError != models.InactiveBotBlocked {
+		t.Errorf("reminder after 403: is_sent=%v delivery_status=%q delivery_error=%q", got.IsSent, got.DeliveryStatus, got.DeliveryError)
+	}
+
+	// Без включённых чатов отправлять некуда
+	checkAndSendReminders(bot)
+	if n := len(deliveries(t, r.ID)); n != 1 {
+		t.Errorf("%d delivery attempts while the chat is off, want 1", n)
+	}
+
+	listen(t, bot)
+	fake.SendText(e2eChatID, "я снова здесь")
+	waitFor(t, "chat reactivation", func() bool { return reloadLink(t).Active })
+	if got := reloadReminder(t, r.ID); got.DeliveryStatus != "" || got.DeliveryError != "" {
+		t.Errorf("reminder after reactivation: delivery_status=%q delivery_error=%q", got.DeliveryStatus, got.DeliveryError)
+	}
+
+	checkAndSendReminders(bot)
+	if messages := fake.Messages(); len(messages) != 1 || messages[0].Text != "Позвонить маме" {
+		t.Fatalf("after unblocking sent %+v, want the reminder", messages)
+	}
+	if got := reloadReminder(t, r.ID); !got.IsSent || got.DeliveryStatus != models.DeliverySent || got.DeliveryError != "" {
+		t.Errorf("reminder after unblocking: is_sent=%v delivery_status=%q delivery_error=%q", got.IsSent, got.DeliveryStatus, got.DeliveryError)
+	}
+}
+
+// Повторяющаяся неудачная отправка записывается в результаты доставки один раз.
+func TestE2ERepeatedFailureRecordedOnce(t *testing.T) {
+	fake, bot := newFakeBot(t)
+	r := addLinkedReminder(t)
+	fault := tgfake.TooManyRequests(1)
+	fault.Method = "sendMessage"
+	fault.Times = 3
+	fake.Fail(fault)
+
+	for i := 0; i < 3; i++ {
+		checkAndSendReminders(bot)
+	}
+	if d := deliveries(t, r.ID); len(d) != 1 || d[0].Status != models.DeliveryFailed {
+		t.Errorf("deliveries after three failed attempts: %+v, want one failure", d)
+	}
+
+	checkAndSendReminders(bot)
+	d := deliveries(t, r.ID)
+	if len(d) != 2 || d[1].Status != models.DeliverySent {
+		t.Errorf("deliveries after sending: %+v, want the failure and the success", d)
+	}
+}
+
+// Группа, которой нет, отключается у получателя напоминания и пропускается, пока из неё
+// не придёт сообщение. Остальные получатели продолжают получать напоминания.
+func TestE2EGroupChatNotFound(t *testing.T) {
+	const groupID = -1001234567890
+	fake, bot := newFakeBot(t)
+	r := addLinkedReminder(t)
+	r.Recurrence = "FREQ=DAILY"
+	recipients := []models.ReminderRecipient{
+		{ReminderID: r.ID, Kind: models.RecipientUser, UserID: &r.UserID},
+		{ReminderID: r.ID, Kind: models.RecipientChat, ChatID: groupID},
+	}
+	if err := database.DB.Create(&recipients).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := database.DB.Model(&r).Update("recurrence", r.Recurrence).Error; err != nil {
+		t.Fatal(err)
+	}
+	fault := tgfake.ChatNotFound(groupID)
+	fault.Times = 1
+	fake.Fail(fault)
+
+	checkAndSendReminders(bot)
+	if messages := fake.Messages(); len(messages) != 1 || messages[0].ChatID != e2eChatID {
+		t.Fatalf("bot sent %+v, want one message to the user", messages)
+	}
+	var group models.ReminderRecipient
+	if err := database.DB.First(&group, recipients[1].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if group.InactiveReason != models.InactiveChatNotFound || group.DeactivatedAt == nil {
+		t.Errorf("group recipient after 400: inactive_reason=%q deactivated_at=%v", group.InactiveReason, group.DeactivatedAt)
+	}
+	if got := reloadReminder(t, r.ID); got.DeliveryStatus != models.DeliveryPartial {
+		t.Errorf("delivery_status %q, want %q", got.DeliveryStatus, models.DeliveryPartial)
+	}
+
+	// Следующее повторение уходит только пользователю
+	if err := database.DB.Model(&models.Reminder{}).Where("id = ?", r.ID).Update("send_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	checkAndSendReminders(bot)
+	if messages := fake.Messages(); len(messages) != 2 || messages[1].ChatID != e2eChatID {
+		t.Fatalf("second occurrence sent %+v, want the user only", messages)
+	}
+	var groupDeliveries int64
+	database.DB.Model(&models.ReminderDelivery{}).Where("reminder_id = ? AND chat_id = ?", r.ID, groupID).Count(&groupDeliveries)
+	if groupDeliveries != 1 {
+		t.Errorf("%d deliveries to the switched off group, want 1", groupDeliveries)
+	}
+
+	listen(t, bot)
+	fake.AddUpdate(tgbotapi.Update{Message: &tgbotapi.Message{
+		MessageID: 1,
+		From:      &tgbotapi.User{ID: e2eChatID, FirstName: "User"},
+		Chat:      &tgbotapi.Chat{ID: groupID, Type: "supergroup"},
+		Date:      int(time.Now().Unix()),
+		Text:      "бот снова в группе",
+	}})
+	waitFor(t, "group reactivation", func() bool {
+		var rec models.ReminderRecipient
+		return database.DB.First(&rec, recipients[1].ID).Error == nil && rec.InactiveReason == ""
+	})
+
+	if err := database.DB.Model(&models.Reminder{}).Where("id = ?", r.ID).Update("send_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	checkAndSendReminders(bot)
+	if messages := fake.Messages(); len(messages) != 4 || messages[3].ChatID != groupID {
+		t.Fatalf("after reactivation sent %+v, want the user and the group", messages)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/escalation"
+	"Reminders/internal/models"
+	"errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Префикс данных кнопки подтверждения
+const ackPrefix = "ack:"
+
+// ackKeyboard возвращает кнопку подтверждения напоминания.
+func ackKeyboard(reminderID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Готово", ackPrefix+strconv.Itoa(reminderID)),
+	))
+}
+
+// runEscalations выполняет шаги эскалации, время которых наступило.
+func runEscalations(bot *tgbotapi.BotAPI) {
+	due, err := escalation.Due(database.DB, time.Now())
+	if err != nil {
+		logger.Error("Ошибка при получении шагов эскалации", zap.Error(err))
+		return
+	}
+
+	for _, e := range due {
+		r := e.Reminder
+		// Подтверждённые и удалённые напоминания не эскалируются
+		if r == nil || r.AckedAt != nil {
+			if err := escalation.Cancel(database.DB, e); err != nil {
+				logger.Error("Ошибка при отмене шага эскалации", zap.Int("escalation_id", e.ID), zap.Error(err))
+			}
+			continue
+		}
+
+		chats, err := escalationTargets(e)
+		if err != nil {
+			logger.Error("Не удалось определить получателей эскалации", zap.Int("reminder_id", r.ID), zap.Int("step", e.Step), zap.Error(err))
+			continue
+		}
+
+		delivered := false
+		for _, t := range chats {
+			msg, err := buildMessage(*r, t.ChatID, messageText(bot, *r, t))
+			if err != nil {
+				logger.Error("Не удалось подготовить сообщение", zap.Int("reminder_id", r.ID), zap.Error(err))
+				break
+			}
+			if _, err := bot.Send(msg); err != nil {
+				logger.Error("Не удалось отправить эскалацию", zap.Int("reminder_id", r.ID), zap.Int64("chat_id", t.ChatID), zap.Error(err))
+				checkSendError(t.ChatID, err)
+				continue
+			}
+			delivered = true
+		}
+		// Недоставленный шаг повторяется при следующей проверке
+		if !delivered && len(chats) > 0 {
+			continue
+		}
+
+		if err := escalation.Complete(database.DB, e); err != nil {
+			logger.Error("Ошибка при сохранении шага эскалации", zap.Int("escalation_id", e.ID), zap.Error(err))
+			continue
+		}
+		logger.Info("Шаг эскалации выполнен", zap.Int("reminder_id", r.ID), zap.Int("step", e.Step), zap.String("action", e.Action), zap.Int("chat_count", len(chats)))
+	}
+}
+
+// escalationTargets возвращает чаты, в которые отправляется шаг эскалации.
+func escalationTargets(e models.ReminderEscalation) ([]target, error) {
+	switch e.Action {
+	case models.EscalateChat:
+		return []target{{ChatID: e.ChatID}}, nil
+	case models.EscalateContact:
+		var contact models.User
+		err := database.DB.Preload("TelegramLinks").First(&contact, e.ContactUserID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var result []target
+		for _, chatID := range contact.ActiveChatIDs() {
+			result = append(result, target{ChatID: chatID, User: &contact})
+		}
+		return result, nil
+	}
+	return targets(*e.Reminder), nil
+}
+
+// handleCallback обрабатывает нажатие кнопки подтверждения напоминания.
+func handleCallback(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	answer := "Неизвестная кнопка"
+	if id, err := strconv.Atoi(strings.TrimPrefix(query.Data, ackPrefix)); err == nil && strings.HasPrefix(query.Data, ackPrefix) {
+		answer = acknowledge(id)
+	}
+
+	if _, err := bot.Request(tgbotapi.NewCallback(query.ID, answer)); err != nil {
+		logger.Error("Не удалось ответить на нажатие кнопки", zap.String("data", query.Data), zap.Error(err))
+	}
+}
+
+// acknowledge подтверждает напоминание из Telegram и возвращает текст ответа.
+func acknowledge(reminderID int) string {
+	_, err := escalation.Acknowledge(database.DB, reminderID, escalation.SourceTelegram)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "Напоминание удалено"
+	case err != nil:
+		logger.Error("Ошибка подтверждения напоминания", zap.Int("reminder_id", reminderID), zap.Error(err))
+		return "Не удалось подтвердить, попробуйте позже"
+	}
+	logger.Info("Напоминание подтверждено", zap.Int("reminder_id", reminderID))
+	return "Подтверждено"
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/tokens"
+	"errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	errLinkTokenInvalid    = errors.New("ссылка недействительна или устарела")
+	errChatLinkedElsewhere = errors.New("чат уже привязан к другому пользователю")
+)
+
+// handleUpdates обрабатывает входящие сообщения боту и нажатия кнопок.
+func handleUpdates(bot *tgbotapi.BotAPI, updates tgbotapi.UpdatesChannel) {
+	for update := range updates {
+		// Любое сообщение или нажатие кнопки означает, что пользователь снова доступен
+		if chat := update.FromChat(); chat != nil {
+			reactivateChat(chat.ID)
+		}
+		if update.CallbackQuery != nil {
+			handleCallback(bot, update.CallbackQuery)
+			continue
+		}
+		if update.Message == nil || !update.Message.IsCommand() {
+			continue
+		}
+		handleCommand(bot, update.Message)
+	}
+}
+
+// handleCommand выполняет команду бота и отвечает в чат.
+func handleCommand(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	var reply string
+
+	switch msg.Command() {
+	case "start":
+		reply = startCommand(chatID, strings.TrimSpace(msg.CommandArguments()))
+	case "unlink":
+		reply = unlinkCommand(chatID)
+	case "list":
+		reply = listCommand(chatID, strings.TrimSpace(msg.CommandArguments()))
+	case "remind":
+		reply = remindCommand(chatID, strings.TrimSpace(msg.CommandArguments()))
+	default:
+		reply = "Неизвестная команда. Доступные команды: /start, /unlink, /list, /remind"
+	}
+
+	if _, err := bot.Send(tgbotapi.NewMessage(chatID, reply)); err != nil {
+		logger.Error("Не удалось ответить на команду", zap.Int64("chat_id", chatID), zap.String("command", msg.Command()), zap.Error(err))
+	}
+}
+
+// startCommand привязывает чат к пользователю по токену из ссылки t.me/<bot>?start=<token>.
+func startCommand(chatID int64, token string) string {
+	if token == "" {
+		return "Чтобы получать напоминания, откройте ссылку для привязки из приложения."
+	}
+
+	userID, err := linkChat(chatID, token)
+	switch {
+	case errors.Is(err, errLinkTokenInvalid):
+		logger.Info("Недействительный токен привязки", zap.Int64("chat_id", chatID))
+		return "Ссылка недействительна или устарела. Запросите новую ссылку в приложении."
+	case errors.Is(err, errChatLinkedElsewhere):
+		logger.Info("Чат уже привязан к другому пользователю", zap.Int64("chat_id", chatID))
+		return "Этот чат уже привязан к другому пользователю. Отправьте /unlink, чтобы отвязать его."
+	case err != nil:
+		logger.Error("Ошибка привязки чата", zap.Int64("chat_id", chatID), zap.Error(err))
+		return "Не удалось привязать чат, попробуйте позже."
+	}
+
+	logger.Info("Чат привязан к пользователю", zap.Int64("chat_id", chatID), zap.Int("user_id", userID))
+	return "Чат привязан. Напоминания будут приходить сюда. Отправьте /unlink, чтобы отвязать его."
+}
+
+// linkChat гасит одноразовый токен и привязывает чат к его пользователю.
+// Токен удаляется при первой попытке, даже если привязать чат не удалось.
+func linkChat(chatID int64, token string) (int, error) {
+	var linkToken models.TelegramLinkToken
+	err := database.DB.Where("token_hash = ?", tokens.Hash(token)).First(&linkToken).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, errLinkTokenInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	result := database.DB.Delete(&linkToken)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	// Токен уже погасил параллельный запрос
+	if result.RowsAffected == 0 || linkToken.ExpiresAt.Before(time.Now()) {
+		return 0, errLinkTokenInvalid
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var link models.TelegramLink
+		err := tx.Where("chat_id = ?", chatID).First(&link).Error
+		switch {
+		case err == nil && link.UserID != linkToken.UserID:
+			return errChatLinkedElsewhere
+		case err == nil:
+			return tx.Model(&link).Updates(map[string]interface{}{
+				"active":          true,
+				"inactive_reason": "",
+				"deactivated_at":  nil,
+				"updated_at":      time.Now(),
+			}).Error
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		link = models.TelegramLink{UserID: linkToken.UserID, ChatID: chatID, Active: true, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		return tx.Create(&link).Error
+	})
+	return linkToken.UserID, err
+}
+
+// unlinkCommand отвязывает чат от пользователя.
+func unlinkCommand(chatID int64) string {
+	result := database.DB.Where("chat_id = ?", chatID).Delete(&models.TelegramLink{})
+	if result.Error != nil {
+		logger.Error("Ошибка отвязки чата", zap.Int64("chat_id", chatID), zap.Error(result.Error))
+		return "Не удалось отвязать чат, попробуйте позже."
+	}
+	if result.RowsAffected == 0 {
+		return "Этот чат не привязан."
+	}
+
+	logger.Info("Чат отвязан", zap.Int64("chat_id", chatID))
+	return "Чат отвязан, напоминания сюда больше не придут."
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// Максимальное количество напоминаний в ответе на /list
+	listCommandLimit = 30
+	// Максимальная длина текста напоминания в ответе на /list
+	listMessagePreview = 60
+)
+
+// listCommand показывает неотправленные напоминания пользователя чата с меткой или из списка name.
+// Без названия перечисляет списки и метки пользователя.
+func listCommand(chatID int64, name string) string {
+	var link models.TelegramLink
+	err := database.DB.Where("chat_id = ? AND active = ?", chatID, true).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "Этот чат не привязан. Откройте ссылку для привязки из приложения."
+	}
+	if err != nil {
+		logger.Error("Ошибка поиска привязки чата", zap.Int64("chat_id", chatID), zap.Error(err))
+		return "Не удалось получить напоминания, попробуйте позже."
+	}
+
+	if name == "" {
+		reply, err := listNames(link.UserID)
+		if err != nil {
+			logger.Error("Ошибка получения списков и меток", zap.Int("user_id", link.UserID), zap.Error(err))
+			return "Не удалось получить списки, попробуйте позже."
+		}
+		return reply
+	}
+
+	var reminders []models.Reminder
+	err = database.DB.Preload("User").
+		Where("user_id = ? AND is_sent = ? AND archived_at IS NULL", link.UserID, false).
+		Where(database.DB.
+			Where("list_id IN (?)", database.DB.Model(&models.ReminderList{}).Select("id").Where("user_id = ? AND name = ?", link.UserID, name)).
+			Or("id IN (?)", database.DB.Table("reminder_tags").
+				Select("reminder_tags.reminder_id").
+				Joins("JOIN tags ON tags.id = reminder_tags.tag_id").
+				Where("tags.user_id = ? AND tags.name = ?", link.UserID, name))).
+		Order("send_at, id").
+		Limit(listCommandLimit).
+		Find(&reminders).Error
+	if err != nil {
+		logger.Error("Ошибка получения напоминаний списка", zap.Int("user_id", link.UserID), zap.String("name", name), zap.Error(err))
+		return "Не удалось получить напоминания, попробуйте позже."
+	}
+	if len(reminders) == 0 {
+		return fmt.Sprintf("В «%s» нет запланированных напоминаний.", name)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Напоминания «%s»:\n", name)
+	for _, r := range reminders {
+		b.WriteString("\n")
+		if r.PausedAt != nil {
+			b.WriteString("⏸ ")
+		}
+		if r.WaitingAnchor {
+			b.WriteString("ждёт другого напоминания")
+		} else {
+			b.WriteString(r.SendAt.In(r.ScheduleLocation()).Format("02.01.2006 15:04"))
+		}
+		b.WriteString(" — ")
+		b.WriteString(preview(r))
+	}
+	return b.String()
+}
+
+// listNames перечисляет списки и метки пользователя.
+func listNames(userID int) (string, error) {
+	var lists, tags []string
+	if err := database.DB.Model(&models.ReminderList{}).Where("user_id = ?", userID).Order("name").Pluck("name", &lists).Error; err != nil {
+		return "", err
+	}
+	if err := database.DB.Model(&models.Tag{}).Where("user_id = ?", userID).Order("name").Pluck("name", &tags).Error; err != nil {
+		return "", err
+	}
+	if len(lists) == 0 && len(tags) == 0 {
+		return "У вас пока нет списков и меток.", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("Отправьте /list <название>, чтобы увидеть напоминания.")
+	if len(lists) > 0 {
+		b.WriteString("\n\nСписки: " + strings.Join(lists, ", "))
+	}
+	if len(tags) > 0 {
+		b.WriteString("\n\nМетки: " + strings.Join(tags, ", "))
+	}
+	return b.String(), nil
+}
+
+// preview возвращает начало текста напоминания для списка.
+func preview(r models.Reminder) string {
+	text := strings.Join(strings.Fields(r.Message), " ")
+	if text == "" {
+		return "(" + r.AttachmentType + ")"
+	}
+	if utf8.RuneCountInString(text) <= listMessagePreview {
+		return text
+	}
+	return string([]rune(text)[:listMessagePreview-1]) + "…"
+}
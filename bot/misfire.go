@@ -0,0 +1,263 @@
+package main
+
+import (
+	"Reminders/internal/anchors"
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/pausing"
+	"Reminders/internal/recurrence"
+	"Reminders/internal/tgformat"
+	"Reminders/internal/webhooks"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Опоздание, после которого напоминание считается пропущенным. Бот проверяет
+// напоминания раз в минуту, поэтому небольшая задержка - обычная работа.
+const misfireGrace = 5 * time.Minute
+
+// Формат времени в отметке об опоздании и в сводке
+const dueLayout = "02.01.2006 15:04"
+
+// Максимальная длина подписи к вложению в Telegram, в символах
+const maxCaptionLength = 1024
+
+// id единственной строки models.SchedulerState
+const schedulerStateID = 1
+
+// downtime - промежуток между предыдущей и текущей проверкой напоминаний. Пропущенными
+// считаются только напоминания, время которых пришлось на этот промежуток: более ранние
+// уже видела предыдущая проверка, и их задержала ошибка отправки, отсутствие чата или
+// пауза, а не простой бота.
+type downtime struct {
+	from, to time.Time
+}
+
+// startTick возвращает промежуток с предыдущей проверки и запоминает now как время
+// последней проверки. Если предыдущая проверка неизвестна, простоя нет.
+func startTick(now time.Time) downtime {
+	var state models.SchedulerState
+	if err := database.DB.Where("id = ?", schedulerStateID).Limit(1).Find(&state).Error; err != nil {
+		logger.Error("Ошибка при чтении состояния планировщика", zap.Error(err))
+		return downtime{}
+	}
+	d := downtime{from: state.LastTickAt, to: now}
+
+	state = models.SchedulerState{ID: schedulerStateID, LastTickAt: now}
+	if err := database.DB.Save(&state).Error; err != nil {
+		logger.Error("Ошибка при сохранении состояния планировщика", zap.Error(err))
+	}
+	return d
+}
+
+// dueAt возвращает время, когда напоминание должно было прийти: время отправки
+// или конец переноса из-за тихих часов.
+func dueAt(r models.Reminder) time.Time {
+	if r.DeferredUntil != nil && r.DeferredUntil.After(r.SendAt) {
+		return *r.DeferredUntil
+	}
+	return r.SendAt
+}
+
+// late сообщает, опоздало ли напоминание из-за простоя бота.
+func (d downtime) late(r models.Reminder) bool {
+	if d.from.IsZero() {
+		return false
+	}
+	due := dueAt(r)
+	return !due.Before(d.from) && d.to.Sub(due) > misfireGrace
+}
+
+// lateNote возвращает отметку «должно было прийти» для опоздавшего напоминания.
+func (d downtime) lateNote(r models.Reminder) string {
+	if !d.late(r) {
+		return ""
+	}
+	note := fmt.Sprintf("⏰ Должно было прийти %s", dueAt(r).In(r.ScheduleLocation()).Format(dueLayout))
+	return "\n\n" + tgformat.Escape(r.ParseMode, note)
+}
+
+// withNote дописывает отметку note к тексту напоминания. Подпись к вложению, которая
+// с отметкой не помещается в лимит Telegram, обрезается. Если обрезка ломает разметку,
+// отметка не добавляется.
+func withNote(r models.Reminder, text, note string) string {
+	if note == "" || r.AttachmentType == "" || utf8.RuneCountInString(text+note) <= maxCaptionLength {
+		return text + note
+	}
+	keep := maxCaptionLength - utf8.RuneCountInString(note) - 1
+	if keep < 0 {
+		return text
+	}
+	cut := string([]rune(text)[:keep]) + "…"
+	if tgformat.Validate(r.ParseMode, cut) != nil {
+		return text
+	}
+	return cut + note
+}
+
+// missedDigest - сводка пропущенных напоминаний по чатам.
+type missedDigest struct {
+	chats     []int64
+	reminders map[int64][]models.Reminder
+}
+
+// add добавляет напоминание в сводку всех его чатов.
+func (d *missedDigest) add(r models.Reminder) {
+	if d.reminders == nil {
+		d.reminders = make(map[int64][]models.Reminder)
+	}
+	for _, t := range targets(r) {
+		if _, ok := d.reminders[t.ChatID]; !ok {
+			d.chats = append(d.chats, t.ChatID)
+		}
+		d.reminders[t.ChatID] = append(d.reminders[t.ChatID], r)
+	}
+}
+
+// applyMisfire применяет к напоминанию, опоздавшему из-за простоя down, его политику. Возвращает true,
+// если напоминание нужно отправить сейчас; для MisfireLatest время отправки r
+// переносится на последнее прошедшее повторение.
+func applyMisfire(r *models.Reminder, now time.Time, down downtime, d *missedDigest) bool {
+	if !down.late(*r) {
+		return true
+	}
+
+	policy, threshold := r.Misfire()
+	switch policy {
+	case models.MisfireLatest:
+		if skipped := skipToLatest(r, now); skipped > 0 {
+			logger.Info("Пропущенные повторения не отправляются", zap.Int("reminder_id", r.ID), zap.Int("skipped", skipped))
+		}
+	case models.MisfireSkipOlder:
+		if now.Sub(dueAt(*r)) > threshold {
+			skipMissed(*r, now)
+			return false
+		}
+	case models.MisfireDigest:
+		d.add(*r)
+		return false
+	}
+	return true
+}
+
+// skipToLatest переносит время отправки повторяющегося напоминания на последнее
+// прошедшее повторение и возвращает количество пропущенных более ранних повторений.
+func skipToLatest(r *models.Reminder, now time.Time) int {
+	if r.Recurrence == "" {
+		return 0
+	}
+	rule, err := recurrence.Parse(r.Recurrence)
+	if err != nil {
+		return 0
+	}
+	latest, skipped := rule.Latest(r.SendAt.In(r.ScheduleLocation()), r.SentCount+1, now)
+	if skipped > 0 {
+		r.SendAt = latest
+		r.DeferredUntil = nil
+	}
+	return skipped
+}
+
+// skipMissed пропускает опоздавшее напоминание: повторяющееся переносится на ближайшее
+// будущее повторение, разовое помечается отправленным без доставки.
+func skipMissed(r models.Reminder, now time.Time) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&r).Updates(missedUpdates(r, now, models.DeliverySkipped)).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ReminderHistory{
+			ReminderID: r.ID,
+			Action:     models.HistorySkipped,
+			Reason:     models.MisfireSkipOlder,
+			SendAt:     r.SendAt,
+			CreatedAt:  now,
+		}).Error
+	})
+	if err != nil {
+		logger.Error("Ошибка при пропуске напоминания", zap.Int("reminder_id", r.ID), zap.Error(err))
+		return
+	}
+	logger.Info("Опоздавшее напоминание пропущено", zap.Int("reminder_id", r.ID), zap.Time("due_at", dueAt(r)))
+}
+
+// missedUpdates возвращает изменения опоздавшего напоминания, которое не отправляется отдельно.
+func missedUpdates(r models.Reminder, now time.Time, status string) map[string]interface{} {
+	updates := map[string]interface{}{"deferred_until": nil, "delivery_status": status, "updated_at": now}
+	if r.Recurrence == "" {
+		updates["is_sent"] = true
+		return updates
+	}
+	if next, ok := pausing.SkipMissed(r, now); ok {
+		updates["send_at"] = next
+	} else {
+		updates["is_sent"] = true
+	}
+	return updates
+}
+
+// sendMissedDigests отправляет в каждый чат одно сообщение со списком пропущенных напоминаний.
+// Напоминание считается доставленным, если сводка дошла хотя бы до одного его чата.
+func sendMissedDigests(bot *tgbotapi.BotAPI, d missedDigest, now time.Time) {
+	delivered := make(map[int]bool)
+	var reminders []models.Reminder
+	for _, chatID := range d.chats {
+		items := d.reminders[chatID]
+		if _, err := bot.Send(tgbotapi.NewMessage(chatID, missedDigestText(items))); err != nil {
+			logger.Error("Не удалось отправить сводку пропущенных напоминаний", zap.Int64("chat_id", chatID), zap.Error(err))
+			checkSendError(chatID, err)
+			continue
+		}
+		for _, r := range items {
+			if !delivered[r.ID] {
+				delivered[r.ID] = true
+				reminders = append(reminders, r)
+			}
+		}
+	}
+
+	for _, r := range reminders {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			updates := missedUpdates(r, now, models.DeliverySent)
+			updates["sent_count"] = r.SentCount + 1
+			if err := tx.Model(&r).Updates(updates).Error; err != nil {
+				return err
+			}
+			err := tx.Create(&models.ReminderHistory{
+				ReminderID: r.ID,
+				Action:     models.HistorySent,
+				Reason:     models.MisfireDigest,
+				SendAt:     r.SendAt,
+				CreatedAt:  now,
+			}).Error
+			if err != nil {
+				return err
+			}
+			if _, err := anchors.OnSent(tx, r, now); err != nil {
+				return err
+			}
+			return webhooks.Publish(tx, models.EventReminderSent, r)
+		})
+		if err != nil {
+			logger.Error("Ошибка при обновлении напоминания из сводки", zap.Int("reminder_id", r.ID), zap.Error(err))
+		}
+	}
+}
+
+// missedDigestText возвращает текст сводки пропущенных напоминаний.
+func missedDigestText(reminders []models.Reminder) string {
+	var b strings.Builder
+	b.WriteString("Пропущенные напоминания:\n")
+	for _, r := range reminders {
+		b.WriteString("\n")
+		b.WriteString(dueAt(r).In(r.ScheduleLocation()).Format(dueLayout))
+		b.WriteString(" — ")
+		b.WriteString(digestPreview(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"Reminders/internal/models"
+	"Reminders/internal/testdb"
+	"Reminders/internal/tgformat"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestDowntimeLate(t *testing.T) {
+	lastTick := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	now := lastTick.Add(3 * time.Hour)
+	down := downtime{from: lastTick, to: now}
+	at := func(d time.Time) models.Reminder { return models.Reminder{SendAt: d} }
+
+	cases := []struct {
+		name string
+		down downtime
+		r    models.Reminder
+		want bool
+	}{
+		{"due_during_downtime", down, at(lastTick.Add(time.Hour)), true},
+		{"due_at_last_tick", down, at(lastTick), true},
+		{"due_within_grace", down, at(now.Add(-time.Minute)), false},
+		// Предыдущая проверка уже видела напоминание: его задержала ошибка отправки или нет чата
+		{"due_before_last_tick", down, at(lastTick.Add(-24 * time.Hour)), false},
+		{"no_previous_tick", downtime{to: now}, at(lastTick), false},
+		{"regular_tick", downtime{from: now.Add(-time.Minute), to: now}, at(now.Add(-90 * time.Second)), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.down.late(c.r); got != c.want {
+				t.Errorf("late = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+// skip_older не должен пропускать напоминание, которое не отправлялось по другой причине.
+func TestApplyMisfireSkipsOnlyDowntime(t *testing.T) {
+	lastTick := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	now := lastTick.Add(time.Minute)
+	r := models.Reminder{
+		SendAt:          lastTick.Add(-3 * time.Hour),
+		MisfireSettings: models.MisfireSettings{Policy: models.MisfireSkipOlder, ThresholdMinutes: 60},
+	}
+	var missed missedDigest
+	if !applyMisfire(&r, now, downtime{from: lastTick, to: now}, &missed) {
+		t.Error("reminder held back by a send failure was skipped as missed")
+	}
+}
+
+func TestStartTick(t *testing.T) {
+	testdb.Open(t)
+	first := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	if d := startTick(first); !d.from.IsZero() {
+		t.Errorf("first tick: downtime from %s, want none", d.from)
+	}
+	second := first.Add(2 * time.Hour)
+	if d := startTick(second); !d.from.Equal(first) || !d.to.Equal(second) {
+		t.Errorf("second tick: downtime %s - %s, want %s - %s", d.from, d.to, first, second)
+	}
+}
+
+// Подпись к вложению с отметкой об опоздании не превышает лимит Telegram.
+func TestWithNote(t *testing.T) {
+	note := "\n\n⏰ Должно было прийти 19.10.2026 09:00"
+	fits := strings.Repeat("я", maxCaptionLength-utf8.RuneCountInString(note))
+	long := fits + "ёж"
+	cut := fits[:len(fits)-len("я")] + "…"
+	photo := func(mode string) models.Reminder {
+		return models.Reminder{AttachmentType: models.AttachmentPhoto, ParseMode: mode}
+	}
+
+	cases := []struct {
+		name string
+		r    models.Reminder
+		text string
+		note string
+		want string
+	}{
+		{"text_message", models.Reminder{}, long + long, note, long + long + note},
+		{"short_caption", photo(""), "Фото", note, "Фото" + note},
+		{"caption_at_limit", photo(""), fits, note, fits + note},
+		{"long_caption", photo(""), long, note, cut + note},
+		{"long_caption_markdown", photo(tgformat.ModeMarkdownV2), long, note, cut + note},
+		// Обрезка разорвала бы жирный текст, поэтому отметка не добавляется
+		{"markup_broken_by_cut", photo(tgformat.ModeMarkdownV2), "*" + long + "*", note, "*" + long + "*"},
+		{"no_note", photo(""), long, "", long},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := withNote(c.r, c.text, c.note)
+			if got != c.want {
+				t.Errorf("withNote = %d characters ending with %q, want %d ending with %q",
+					utf8.RuneCountInString(got), lastRunes(got, 45), utf8.RuneCountInString(c.want), lastRunes(c.want, 45))
+			}
+		})
+	}
+}
+
+func lastRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[len(r)-n:])
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/timeparse"
+	"Reminders/internal/webhooks"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Подсказка к /remind без аргументов или с непонятным временем
+const remindUsage = "Напишите, когда и о чём напомнить, например:\n" +
+	"/remind завтра в 9 купить молоко\n" +
+	"/remind через 15 минут позвонить маме\n" +
+	"/remind по будням в 8:30 зарядка"
+
+// remindCommand создаёт напоминание пользователю чата из фразы вида «завтра в 9 купить молоко»
+// или «позвонить маме через час». Время читается в часовом поясе пользователя.
+func remindCommand(chatID int64, text string) string {
+	if text == "" {
+		return remindUsage
+	}
+
+	var link models.TelegramLink
+	err := database.DB.Preload("User").Where("chat_id = ? AND active = ?", chatID, true).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && link.User == nil {
+		return "Этот чат не привязан. Откройте ссылку для привязки из приложения."
+	}
+	if err != nil {
+		logger.Error("Ошибка поиска привязки чата", zap.Int64("chat_id", chatID), zap.Error(err))
+		return "Не удалось создать напоминание, попробуйте позже."
+	}
+	user := *link.User
+
+	loc := user.Location()
+	res, message, err := timeparse.Extract(text, time.Now().In(loc))
+	switch {
+	case errors.Is(err, timeparse.ErrPast):
+		return "Это время уже прошло."
+	case errors.Is(err, timeparse.ErrConflict):
+		return "Во фразе несколько разных дней, оставьте один."
+	case err != nil:
+		return "Не понял, когда напомнить.\n\n" + remindUsage
+	case message == "":
+		return "Напишите, о чём напомнить, например: /remind " + text + " позвонить маме"
+	}
+
+	reminder := models.Reminder{
+		UserID:     user.ID,
+		Message:    message,
+		SendAt:     res.At,
+		TimeZone:   user.TimeZone,
+		Recurrence: res.Recurrence,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&reminder).Error; err != nil {
+			return err
+		}
+		return webhooks.Publish(tx, models.EventReminderCreated, reminder)
+	})
+	if err != nil {
+		logger.Error("Ошибка создания напоминания из чата", zap.Int64("chat_id", chatID), zap.Int("user_id", user.ID), zap.Error(err))
+		return "Не удалось создать напоминание, попробуйте позже."
+	}
+
+	logger.Info("Напоминание создано из чата", zap.Int64("chat_id", chatID), zap.Int("reminder_id", reminder.ID), zap.Time("send_at", reminder.SendAt), zap.String("recurrence", reminder.Recurrence))
+	when := reminder.SendAt.In(loc).Format("02.01.2006 15:04")
+	if reminder.Recurrence != "" {
+		return fmt.Sprintf("Буду напоминать «%s», начиная с %s (%s).", message, when, reminder.Recurrence)
+	}
+	return fmt.Sprintf("Напомню «%s» %s.", message, when)
+}
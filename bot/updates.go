@@ -0,0 +1,239 @@
+package main
+
+import (
+	"Reminders/internal/envs"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Режимы получения обновлений
+const (
+	modePolling = "polling"
+	modeWebhook = "webhook"
+)
+
+const (
+	// Заголовок, в котором Telegram передаёт secret_token из setWebhook
+	secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+	// Сколько обновлений может ждать обработки, прежде чем приём вебхука начнёт отвечать ошибкой
+	updatesBuffer = 100
+	// Таймаут long polling в секундах
+	pollingTimeout = 60
+	// Заголовок с идентификатором запроса к приёму вебхука
+	requestIDHeader = "X-Request-ID"
+	// Ключ контекста запроса с его идентификатором
+	requestIDKey = "request_id"
+)
+
+// Обновления, которые обрабатывает бот
+var allowedUpdates = []string{"message", "callback_query"}
+
+// Идентификатор запроса, принимаемый от клиента; иначе создаётся новый
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Telegram допускает в secret_token 1-256 символов A-Z, a-z, 0-9, _ и -
+var secretTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// receiveUpdates возвращает канал обновлений в режиме из BOT_MODE.
+func receiveUpdates(bot *tgbotapi.BotAPI) (tgbotapi.UpdatesChannel, error) {
+	switch envs.ServerEnvs.BOT_MODE {
+	case modePolling:
+		return pollUpdates(bot)
+	case modeWebhook:
+		return listenWebhook()
+	}
+	return nil, fmt.Errorf("неизвестный режим BOT_MODE %q, ожидается %s или %s", envs.ServerEnvs.BOT_MODE, modePolling, modeWebhook)
+}
+
+// pollUpdates получает обновления через getUpdates. Telegram не отдаёт обновления
+// через getUpdates, пока установлен вебхук, поэтому он снимается.
+func pollUpdates(bot *tgbotapi.BotAPI) (tgbotapi.UpdatesChannel, error) {
+	if _, err := bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return nil, fmt.Errorf("не удалось снять вебхук: %w", err)
+	}
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = pollingTimeout
+	u.AllowedUpdates = allowedUpdates
+	logger.Info("Получение обновлений через long polling")
+	return bot.GetUpdatesChan(u), nil
+}
+
+// listenWebhook запускает HTTP-сервер, на который Telegram присылает обновления.
+// Сам вебхук регистрируется отдельно командой setwebhook.
+func listenWebhook() (tgbotapi.UpdatesChannel, error) {
+	path, err := webhookPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWebhookSecret(); err != nil {
+		return nil, err
+	}
+	secret := envs.ServerEnvs.BOT_WEBHOOK_SECRET
+
+	updates := make(chan tgbotapi.Update, updatesBuffer)
+	gin.SetMode(gin.ReleaseMode)
+	router := newWebhookRouter(path, secret, updates)
+
+	addr := envs.ServerEnvs.BOT_WEBHOOK_ADDR
+	go func() {
+		if err := router.Run(addr); err != nil {
+			logger.Fatal("Ошибка сервера вебхука Telegram", zap.String("addr", addr), zap.Error(err))
+		}
+	}()
+	logger.Info("Получение обновлений через вебхук", zap.String("addr", addr), zap.String("path", path))
+	return updates, nil
+}
+
+// newWebhookRouter возвращает маршрутизатор приёма вебхука: каждый запрос получает
+// идентификатор и попадает в журнал, а паника в обработчике превращается в ответ 500.
+func newWebhookRouter(path, secret string, updates chan<- tgbotapi.Update) *gin.Engine {
+	router := gin.New()
+	router.Use(assignRequestID, logWebhookRequest, gin.CustomRecovery(recoverWebhook))
+	router.POST(path, webhookHandler(secret, updates))
+	return router
+}
+
+// assignRequestID берёт идентификатор запроса из X-Request-ID или создаёт новый
+// и возвращает его в ответе.
+func assignRequestID(ctx *gin.Context) {
+	id := ctx.GetHeader(requestIDHeader)
+	if !requestIDPattern.MatchString(id) {
+		b := make([]byte, 8)
+		rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+	ctx.Set(requestIDKey, id)
+	ctx.Header(requestIDHeader, id)
+	ctx.Next()
+}
+
+// requestLogger возвращает логгер с идентификатором запроса.
+func requestLogger(ctx *gin.Context) *zap.Logger {
+	return logger.With(zap.String("request_id", ctx.GetString(requestIDKey)))
+}
+
+// logWebhookRequest записывает в журнал каждый запрос к приёму вебхука.
+func logWebhookRequest(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+	requestLogger(ctx).Info("Запрос вебхука Telegram",
+		zap.String("method", ctx.Request.Method),
+		zap.String("uri", ctx.Request.RequestURI),
+		zap.String("client_ip", ctx.ClientIP()),
+		zap.Int("status", ctx.Writer.Status()),
+		zap.Duration("elapsed_time", time.Since(start)),
+	)
+}
+
+// recoverWebhook записывает панику обработчика в журнал и отвечает 500, чтобы Telegram
+// повторил обновление.
+func recoverWebhook(ctx *gin.Context, recovered interface{}) {
+	requestLogger(ctx).Error("Паника при обработке вебхука", zap.Any("panic", recovered), zap.Stack("stack"))
+	ctx.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// webhookHandler принимает обновление от Telegram. Запросы без верного секрета отклоняются,
+// чтобы никто, кроме Telegram, не мог отправлять боту обновления.
+func webhookHandler(secret string, updates chan<- tgbotapi.Update) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		got := ctx.GetHeader(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
+			requestLogger(ctx).Warn("Запрос вебхука с неверным секретом", zap.String("remote_addr", ctx.ClientIP()))
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		var update tgbotapi.Update
+		if err := ctx.ShouldBindJSON(&update); err != nil {
+			requestLogger(ctx).Warn("Некорректное обновление вебхука", zap.Error(err))
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		// Если обработка не успевает, Telegram повторит обновление позже
+		select {
+		case updates <- update:
+			ctx.Status(http.StatusOK)
+		case <-ctx.Request.Context().Done():
+			ctx.AbortWithStatus(http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// webhookPath возвращает путь из BOT_WEBHOOK_URL, на котором слушает приём вебхука.
+func webhookPath() (string, error) {
+	u, err := url.Parse(envs.ServerEnvs.BOT_WEBHOOK_URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", errors.New("BOT_WEBHOOK_URL должен быть абсолютным https-адресом")
+	}
+	if u.Path == "" {
+		return "/", nil
+	}
+	return u.Path, nil
+}
+
+// checkWebhookSecret проверяет BOT_WEBHOOK_SECRET по правилам Telegram для secret_token.
+func checkWebhookSecret() error {
+	if !secretTokenPattern.MatchString(envs.ServerEnvs.BOT_WEBHOOK_SECRET) {
+		return errors.New("BOT_WEBHOOK_SECRET должен содержать от 1 до 256 символов A-Z, a-z, 0-9, _ и -")
+	}
+	return nil
+}
+
+// runCommand выполняет команду управления ботом из аргументов командной строки:
+// setwebhook регистрирует BOT_WEBHOOK_URL с секретом, deletewebhook снимает вебхук,
+// webhookinfo показывает его состояние.
+func runCommand(bot *tgbotapi.BotAPI, command string) error {
+	switch command {
+	case "setwebhook":
+		if _, err := webhookPath(); err != nil {
+			return err
+		}
+		if err := checkWebhookSecret(); err != nil {
+			return err
+		}
+		// WebhookConfig библиотеки не поддерживает secret_token, поэтому запрос собирается вручную
+		params := tgbotapi.Params{
+			"url":          envs.ServerEnvs.BOT_WEBHOOK_URL,
+			"secret_token": envs.ServerEnvs.BOT_WEBHOOK_SECRET,
+		}
+		if err := params.AddInterface("allowed_updates", allowedUpdates); err != nil {
+			return err
+		}
+		if _, err := bot.MakeRequest("setWebhook", params); err != nil {
+			return err
+		}
+		logger.Info("Вебхук установлен", zap.String("url", envs.ServerEnvs.BOT_WEBHOOK_URL))
+	case "deletewebhook":
+		if _, err := bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			return err
+		}
+		logger.Info("Вебхук снят")
+	case "webhookinfo":
+		info, err := bot.GetWebhookInfo()
+		if err != nil {
+			return err
+		}
+		logger.Info("Состояние вебхука",
+			zap.String("url", info.URL),
+			zap.Int("pending_update_count", info.PendingUpdateCount),
+			zap.String("last_error_message", info.LastErrorMessage),
+			zap.String("allowed_updates", strings.Join(info.AllowedUpdates, ",")),
+		)
+	default:
+		return fmt.Errorf("неизвестная команда %q, доступны setwebhook, deletewebhook, webhookinfo", command)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	testWebhookPath   = "/telegram/webhook"
+	testWebhookSecret = "s3cret-token_1"
+)
+
+// newTestWebhook поднимает приём вебхука с каналом обновлений на buffer мест.
+func newTestWebhook(t *testing.T, buffer int) (*gin.Engine, chan tgbotapi.Update) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	prev := logger
+	logger = zap.NewNop()
+	t.Cleanup(func() { logger = prev })
+	updates := make(chan tgbotapi.Update, buffer)
+	return newWebhookRouter(testWebhookPath, testWebhookSecret, updates), updates
+}
+
+func postUpdate(router http.Handler, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, testWebhookPath, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebhookSecretToken(t *testing.T) {
+	const update = `{"update_id": 42, "message": {"message_id": 1, "text": "/start", "chat": {"id": 100}}}`
+	cases := []struct {
+		name   string
+		header map[string]string
+		body   string
+		status int
+		queued bool
+	}{
+		{"valid_secret", map[string]string{secretTokenHeader: testWebhookSecret}, update, http.StatusOK, true},
+		{"missing_secret", nil, update, http.StatusUnauthorized, false},
+		{"wrong_secret", map[string]string{secretTokenHeader: "guess"}, update, http.StatusUnauthorized, false},
+		{"secret_prefix", map[string]string{secretTokenHeader: testWebhookSecret[:5]}, update, http.StatusUnauthorized, false},
+		{"secret_with_suffix", map[string]string{secretTokenHeader: testWebhookSecret + "x"}, update, http.StatusUnauthorized, false},
+		// Тело не разбирается, пока не проверен секрет
+		{"invalid_body_without_secret", nil, "{", http.StatusUnauthorized, false},
+		{"invalid_body", map[string]string{secretTokenHeader: testWebhookSecret}, "{", http.StatusBadRequest, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			router, updates := newTestWebhook(t, 1)
+			rec := postUpdate(router, c.body, c.header)
+			if rec.Code != c.status {
+				t.Errorf("status %d, want %d", rec.Code, c.status)
+			}
+			select {
+			case u := <-updates:
+				if !c.queued {
+					t.Errorf("update %d queued", u.UpdateID)
+				} else if u.UpdateID != 42 || u.Message == nil || u.Message.Text != "/start" {
+					t.Errorf("queued update %+v", u)
+				}
+			default:
+				if c.queued {
+					t.Error("update was not queued")
+				}
+			}
+		})
+	}
+}
+
+// Если обработка не успевает, приём отвечает 503, и Telegram повторит обновление.
+func TestWebhookBusy(t *testing.T) {
+	router, _ := newTestWebhook(t, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodPost, testWebhookPath, strings.NewReader(`{"update_id": 1}`)).WithContext(ctx)
+	req.Header.Set(secretTokenHeader, testWebhookSecret)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status %d, want 503", rec.Code)
+	}
+}
+
+func TestWebhookRequestID(t *testing.T) {
+	router, _ := newTestWebhook(t, 1)
+
+	rec := postUpdate(router, "{}", map[string]string{requestIDHeader: "edge-7f3a"})
+	if got := rec.Header().Get(requestIDHeader); got != "edge-7f3a" {
+		t.Errorf("%s = %q, want the one from the request", requestIDHeader, got)
+	}
+	first := postUpdate(router, "{}", nil).Header().Get(requestIDHeader)
+	second := postUpdate(router, "{}", map[string]string{requestIDHeader: "bad id\n"}).Header().Get(requestIDHeader)
+	if !requestIDPattern.MatchString(first) || !requestIDPattern.MatchString(second) || first == second {
+		t.Errorf("generated request ids %q and %q", first, second)
+	}
+}
+
+func TestWebhookRecoversFromPanic(t *testing.T) {
+	router, _ := newTestWebhook(t, 1)
+	router.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", rec.Code)
+	}
+	if rec.Header().Get(requestIDHeader) == "" {
+		t.Error("response without request id")
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/webhooks"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// Как часто проверяется очередь доставки вебхуков
+	webhookInterval = 15 * time.Second
+	// Сколько ждать ответа получателя вебхука
+	webhookTimeout = 10 * time.Second
+	// Сколько хранятся события в журнале для потока изменений и доставок вебхуков
+	eventRetention = 30 * 24 * time.Hour
+	// Как часто удаляются устаревшие события
+	eventPruneInterval = time.Hour
+)
+
+// deliverWebhooks доставляет события напоминаний подпискам. Очередь проверяется чаще
+// основного цикла, чтобы первые повторные попытки не ждали целую минуту.
+func deliverWebhooks() {
+	dispatcher := webhooks.Dispatcher{
+		DB:     database.DB,
+		Client: webhooks.NewClient(webhookTimeout),
+	}
+	var pruned time.Time
+	for {
+		if time.Since(pruned) >= eventPruneInterval {
+			pruneEvents()
+			pruned = time.Now()
+		}
+		n, err := dispatcher.DeliverDue(context.Background(), time.Now())
+		if err != nil {
+			logger.Error("Ошибка при доставке вебхуков", zap.Error(err))
+		} else if n > 0 {
+			logger.Info("Обработаны доставки вебхуков", zap.Int("count", n))
+		}
+		time.Sleep(webhookInterval)
+	}
+}
+
+// pruneEvents удаляет события старше eventRetention.
+func pruneEvents() {
+	n, err := webhooks.PruneEvents(database.DB, time.Now().Add(-eventRetention))
+	if err != nil {
+		logger.Error("Ошибка при удалении устаревших событий", zap.Error(err))
+	} else if n > 0 {
+		logger.Info("Удалены устаревшие события", zap.Int64("count", n))
+	}
+}
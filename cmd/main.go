@@ -3,8 +3,14 @@ package main
 import (
 	"Reminders/internal/handlers"
 	"Reminders/internal/server"
+	_ "time/tzdata" // образ alpine не содержит базы часовых поясов
 )
 
+// @title Reminders API
+// @version 1.0
+// @description Сервис напоминаний с доставкой в Telegram
+// @BasePath /
+
 func init() {
 	server.InitServer()
 	handlers.SetLogger(server.GetLogger())
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"Reminders/internal/timeparse"
+	"Reminders/pkg/client"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Формат времени в выводе
+const timeLayout = "2006-01-02 15:04"
+
+// Сколько символов текста показывает ls
+const maxListMessage = 60
+
+// tagsFlag собирает повторяющийся флаг -tag.
+type tagsFlag []string
+
+func (f *tagsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *tagsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// tags возвращает метки по именам; сервер создаёт отсутствующие.
+func (f tagsFlag) tags() []client.Tag {
+	tags := make([]client.Tag, 0, len(f))
+	for _, name := range f {
+		tags = append(tags, client.Tag{Name: name})
+	}
+	return tags
+}
+
+// newFlags возвращает набор флагов команды, который при ошибке печатает синтаксис.
+func newFlags(name, synopsis string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Использование: remindctl %s %s\n", name, synopsis)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags разбирает флаги и проверяет число оставшихся аргументов.
+func parseFlags(flags *flag.FlagSet, args []string, minArgs int) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() < minArgs {
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func (a *app) add(ctx context.Context, args []string) error {
+	flags := newFlags("add", `[флаги] "текст" <время>`)
+	listID := flags.Int("list", 0, "id списка")
+	every := flags.String("every", "", "правило повторения RRULE, например FREQ=DAILY; заменяет повторение из времени")
+	priority := flags.String("priority", "", "приоритет: low, normal или high")
+	urgent := flags.Bool("urgent", false, "доставлять и в тихие часы")
+	var tags tagsFlag
+	flags.Var(&tags, "tag", "метка, можно повторять")
+	if err := parseFlags(flags, args, 2); err != nil {
+		return err
+	}
+
+	when, err := parseWhen(strings.Join(flags.Args()[1:], " "), time.Now().In(a.loc))
+	if err != nil {
+		return err
+	}
+	if *every != "" {
+		when.Recurrence = *every
+	}
+	reminder := client.Reminder{
+		UserID:     a.profile.UserID,
+		Message:    flags.Arg(0),
+		SendAt:     when.At,
+		TimeZone:   a.profile.TimeZone,
+		Recurrence: when.Recurrence,
+		Priority:   *priority,
+		Urgent:     *urgent,
+		Tags:       tags.tags(),
+	}
+	if *listID > 0 {
+		reminder.ListID = listID
+	}
+
+	created, err := a.client.CreateReminder(ctx, reminder)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Создано напоминание %d на %s", created.ID, created.SendAt.In(a.loc).Format(timeLayout))
+	if created.Recurrence != "" {
+		fmt.Fprintf(a.out, ", повтор %s", created.Recurrence)
+	}
+	fmt.Fprintln(a.out)
+	return nil
+}
+
+func (a *app) list(ctx context.Context, args []string) error {
+	flags := newFlags("ls", "[флаги]")
+	asJSON := flags.Bool("json", false, "вывести JSON")
+	listID := flags.Int("list", 0, "только напоминания списка")
+	tag := flags.String("tag", "", "только напоминания с меткой")
+	sent := flags.Bool("sent", false, "показывать и отправленные")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	it := a.client.Reminders(ctx, client.ReminderFilter{UserID: a.profile.UserID, ListID: *listID, Tag: *tag})
+	reminders := []client.Reminder{}
+	for it.Next() {
+		if r := it.Reminder(); *sent || !r.IsSent {
+			reminders = append(reminders, r)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reminders)
+	}
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tКОГДА\tПОВТОР\tСПИСОК\tМЕТКИ\tТЕКСТ")
+	for _, r := range reminders {
+		list := ""
+		if r.ListID != nil {
+			list = strconv.Itoa(*r.ListID)
+		}
+		names := make([]string, 0, len(r.Tags))
+		for _, t := range r.Tags {
+			names = append(names, t.Name)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			r.ID, r.SendAt.In(a.loc).Format(timeLayout), r.Recurrence, list, strings.Join(names, ","), shorten(r.Message))
+	}
+	return w.Flush()
+}
+
+func (a *app) edit(ctx context.Context, args []string) error {
+	flags := newFlags("edit", "[флаги] <id>")
+	message := flags.String("m", "", "новый текст")
+	at := flags.String("at", "", "новое время; повторение из него заменяет прежнее")
+	every := flags.String("every", "", "правило повторения RRULE, пустое - без повтора")
+	listID := flags.Int("list", 0, "id списка, 0 - убрать из списка")
+	priority := flags.String("priority", "", "приоритет: low, normal или high")
+	var tags tagsFlag
+	flags.Var(&tags, "tag", "метка, можно повторять; заменяет прежние")
+	if err := parseFlags(flags, args, 1); err != nil {
+		return err
+	}
+	id, err := parseID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	reminder, err := a.findReminder(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Меняются только указанные поля, остальные отправляются как были.
+	// Флаги перебираются по алфавиту, поэтому -every применяется после -at
+	var parseErr error
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "m":
+			reminder.Message = *message
+		case "at":
+			var when timeparse.Result
+			if when, parseErr = parseWhen(*at, time.Now().In(a.loc)); parseErr == nil {
+				reminder.SendAt = when.At
+				if when.Recurrence != "" {
+					reminder.Recurrence = when.Recurrence
+				}
+			}
+		case "every":
+			reminder.Recurrence = *every
+		case "list":
+			reminder.ListID = nil
+			if *listID > 0 {
+				reminder.ListID = listID
+			}
+		case "priority":
+			reminder.Priority = *priority
+		case "tag":
+			reminder.Tags = tags.tags()
+		}
+	})
+	if parseErr != nil {
+		return parseErr
+	}
+
+	updated, err := a.client.UpdateReminder(ctx, id, reminder)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Напоминание %d изменено, отправка %s\n", updated.ID, updated.SendAt.In(a.loc).Format(timeLayout))
+	return nil
+}
+
+func (a *app) remove(ctx context.Context, args []string) error {
+	flags := newFlags("rm", "<id>...")
+	if err := parseFlags(flags, args, 1); err != nil {
+		return err
+	}
+	for _, arg := range flags.Args() {
+		id, err := parseID(arg)
+		if err != nil {
+			return err
+		}
+		if err := a.client.DeleteReminder(ctx, id); err != nil {
+			return fmt.Errorf("напоминание %d: %w", id, err)
+		}
+		fmt.Fprintf(a.out, "Напоминание %d удалено\n", id)
+	}
+	return nil
+}
+
+func (a *app) snooze(ctx context.Context, args []string) error {
+	flags := newFlags("snooze", "<id> [время]")
+	if err := parseFlags(flags, args, 1); err != nil {
+		return err
+	}
+	id, err := parseID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	when := "in 10m"
+	if flags.NArg() > 1 {
+		when = strings.Join(flags.Args()[1:], " ")
+	}
+	until, err := parseWhen(when, time.Now().In(a.loc))
+	if err != nil {
+		return err
+	}
+
+	reminder, err := a.findReminder(ctx, id)
+	if err != nil {
+		return err
+	}
+	reminder.SendAt = until.At
+	updated, err := a.client.UpdateReminder(ctx, id, reminder)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Напоминание %d отложено до %s\n", updated.ID, updated.SendAt.In(a.loc).Format(timeLayout))
+	return nil
+}
+
+func (a *app) export(ctx context.Context, args []string) error {
+	flags := newFlags("export", "[флаги]")
+	format := flags.String("format", client.FormatNDJSON, "формат: csv или ndjson")
+	output := flags.String("o", "", "файл, по умолчанию стандартный вывод")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	var w io.Writer = a.out
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return a.client.ExportReminders(ctx, *format, a.profile.UserID, w)
+}
+
+func (a *app) importFile(ctx context.Context, args []string) error {
+	flags := newFlags("import", "[флаги] <файл|->")
+	format := flags.String("format", client.FormatNDJSON, "формат: csv или ndjson")
+	mode := flags.String("mode", client.ImportModeCreate, "create или upsert по external_id")
+	dryRun := flags.Bool("dry-run", false, "только проверить файл")
+	if err := parseFlags(flags, args, 1); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if path := flags.Arg(0); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	resp, err := a.client.ImportReminders(ctx, client.ImportOptions{Format: *format, Mode: *mode, DryRun: *dryRun}, r)
+	if err != nil {
+		return err
+	}
+
+	for _, lineErr := range resp.Errors {
+		fmt.Fprintf(a.out, "строка %d: %s\n", lineErr.Line, lineErr.Error)
+	}
+	fmt.Fprintf(a.out, "Всего %d, создано %d, обновлено %d, с ошибками %d\n", resp.Total, resp.Created, resp.Updated, resp.Failed)
+	if resp.Failed > 0 {
+		return fmt.Errorf("не загружено строк: %d", resp.Failed)
+	}
+	return nil
+}
+
+// findReminder ищет напоминание пользователя профиля. Отдельного маршрута для одного
+// напоминания нет, поэтому перебираются страницы списка.
+func (a *app) findReminder(ctx context.Context, id int) (client.Reminder, error) {
+	it := a.client.Reminders(ctx, client.ReminderFilter{UserID: a.profile.UserID, PageSize: 500})
+	for it.Next() {
+		if r := it.Reminder(); r.ID == id {
+			return r, nil
+		}
+	}
+	if err := it.Err(); err != nil {
+		return client.Reminder{}, err
+	}
+	return client.Reminder{}, fmt.Errorf("напоминание %d не найдено", id)
+}
+
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("некорректный id %q", s)
+	}
+	return id, nil
+}
+
+// shorten укорачивает текст напоминания до одной строки для таблицы.
+func shorten(message string) string {
+	message = strings.Join(strings.Fields(message), " ")
+	if runes := []rune(message); len(runes) > maxListMessage {
+		return string(runes[:maxListMessage-1]) + "…"
+	}
+	return message
+}
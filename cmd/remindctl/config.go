@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// profile - настройки подключения к одному серверу.
+type profile struct {
+	URL    string `json:"url"`
+	APIKey string `json:"api_key"`
+	// UserID - пользователь, от имени которого создаются и перечисляются напоминания
+	UserID int `json:"user_id"`
+	// TimeZone - зона для разбора времени и вывода, по умолчанию локальная
+	TimeZone string `json:"time_zone"`
+}
+
+// config - файл профилей:
+//
+//	{
+//	  "default": "local",
+//	  "profiles": {
+//	    "local": {"url": "http://localhost:8080", "api_key": "...", "user_id": 1, "time_zone": "Europe/Moscow"}
+//	  }
+//	}
+type config struct {
+	Default  string             `json:"default"`
+	Profiles map[string]profile `json:"profiles"`
+}
+
+// configPath возвращает путь к файлу профилей: REMINDCTL_CONFIG или
+// <каталог настроек пользователя>/remindctl/config.json.
+func configPath() (string, error) {
+	if path := os.Getenv("REMINDCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "remindctl", "config.json"), nil
+}
+
+// loadProfile читает профиль name, при пустом name - REMINDCTL_PROFILE или профиль по умолчанию.
+func loadProfile(name string) (profile, *time.Location, error) {
+	path, err := configPath()
+	if err != nil {
+		return profile{}, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return profile{}, nil, fmt.Errorf("файл профилей %s не найден", path)
+	}
+	if err != nil {
+		return profile{}, nil, err
+	}
+
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return profile{}, nil, fmt.Errorf("некорректный файл профилей %s: %w", path, err)
+	}
+	if name == "" {
+		name = os.Getenv("REMINDCTL_PROFILE")
+	}
+	if name == "" {
+		name = cfg.Default
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return profile{}, nil, fmt.Errorf("профиль %q не найден в %s", name, path)
+	}
+	if p.URL == "" {
+		return profile{}, nil, fmt.Errorf("в профиле %q не указан url", name)
+	}
+
+	loc := time.Local
+	if p.TimeZone != "" {
+		if loc, err = time.LoadLocation(p.TimeZone); err != nil {
+			return profile{}, nil, fmt.Errorf("неизвестный часовой пояс %q в профиле %q", p.TimeZone, name)
+		}
+	}
+	return p, loc, nil
+}
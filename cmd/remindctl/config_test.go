@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `{
+	"default": "local",
+	"profiles": {
+		"local": {"url": "http://localhost:8080", "api_key": "local-key", "user_id": 1, "time_zone": "Europe/Moscow"},
+		"prod": {"url": "https://reminders.example.com", "api_key": "prod-key", "user_id": 2},
+		"no_url": {"api_key": "k"},
+		"bad_zone": {"url": "http://localhost", "time_zone": "Mars/Olympus"}
+	}
+}`
+
+func TestLoadProfile(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  string
+		profile string
+		env     string
+		// wantURL и wantZone - ожидаемый профиль, err - подстрока ошибки
+		wantURL  string
+		wantZone string
+		err      string
+	}{
+		{name: "default", config: testConfig, wantURL: "http://localhost:8080", wantZone: "Europe/Moscow"},
+		{name: "by_name", config: testConfig, profile: "prod", wantURL: "https://reminders.example.com", wantZone: "Local"},
+		{name: "from_env", config: testConfig, env: "prod", wantURL: "https://reminders.example.com", wantZone: "Local"},
+		{name: "name_over_env", config: testConfig, profile: "local", env: "prod", wantURL: "http://localhost:8080", wantZone: "Europe/Moscow"},
+		{name: "unknown_profile", config: testConfig, profile: "staging", err: `профиль "staging" не найден`},
+		{name: "no_default", config: `{"profiles": {}}`, err: `профиль "" не найден`},
+		{name: "missing_url", config: testConfig, profile: "no_url", err: `в профиле "no_url" не указан url`},
+		{name: "bad_time_zone", config: testConfig, profile: "bad_zone", err: `неизвестный часовой пояс "Mars/Olympus"`},
+		{name: "invalid_json", config: `{"default": `, err: "некорректный файл профилей"},
+		{name: "no_file", err: "не найден"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if c.config != "" {
+				if err := os.WriteFile(path, []byte(c.config), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("REMINDCTL_CONFIG", path)
+			t.Setenv("REMINDCTL_PROFILE", c.env)
+
+			p, loc, err := loadProfile(c.profile)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Errorf("error %v, want %q", err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.URL != c.wantURL || loc.String() != c.wantZone {
+				t.Errorf("profile %s in %s, want %s in %s", p.URL, loc, c.wantURL, c.wantZone)
+			}
+		})
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	t.Setenv("REMINDCTL_CONFIG", "/etc/remindctl.json")
+	if path, err := configPath(); err != nil || path != "/etc/remindctl.json" {
+		t.Errorf("configPath = %q, %v; want REMINDCTL_CONFIG", path, err)
+	}
+
+	t.Setenv("REMINDCTL_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "/home/anna/.config")
+	t.Setenv("HOME", "/home/anna")
+	path, err := configPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, filepath.Join("remindctl", "config.json")) {
+		t.Errorf("configPath = %q, want <config dir>/remindctl/config.json", path)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"Reminders/pkg/client"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// Клиент командной строки для API напоминаний:
+//
+//	go run ./cmd/remindctl add "Позвонить маме" завтра в 9
+//	go run ./cmd/remindctl ls -tag work
+//	go run ./cmd/remindctl snooze 42 1h
+//
+// Адрес сервера, ключ API и пользователь берутся из файла профилей (см. config).
+const usage = `Использование: remindctl [-profile имя] <команда> [флаги] [аргументы]
+
+Команды:
+  add     создать напоминание: add [флаги] "текст" <время>
+  ls      показать напоминания: ls [-json] [-list id] [-tag метка] [-sent]
+  edit    изменить напоминание: edit [флаги] <id>
+  rm      удалить напоминания: rm <id>...
+  snooze  отложить напоминание: snooze <id> [время], по умолчанию на 10 минут
+  export  выгрузить напоминания: export [-format csv|ndjson] [-o файл]
+  import  загрузить напоминания: import [-format csv|ndjson] [-mode create|upsert] [-dry-run] <файл|->
+
+Время: «in 15 minutes», «30m», «через 2 часа», «tomorrow at 9», «завтра в 18:30», «next Monday»,
+«2025-01-02 15:04». Повторение тоже можно задать словами: «every weekday at 8:30», «по будням в 9».
+Флаги команды: remindctl <команда> -h.
+`
+
+// errUsage - неверные флаги или аргументы команды, подсказка уже напечатана.
+var errUsage = errors.New("usage")
+
+// app - окружение команд.
+type app struct {
+	client  *client.Client
+	profile profile
+	loc     *time.Location
+	out     io.Writer
+}
+
+// Команды по имени
+var commands = map[string]func(*app, context.Context, []string) error{
+	"add":    (*app).add,
+	"ls":     (*app).list,
+	"edit":   (*app).edit,
+	"rm":     (*app).remove,
+	"snooze": (*app).snooze,
+	"export": (*app).export,
+	"import": (*app).importFile,
+}
+
+func main() {
+	flags := flag.NewFlagSet("remindctl", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	profileName := flags.String("profile", "", "профиль из файла настроек")
+	_ = flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	run, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "remindctl: неизвестная команда %q\n\n", flags.Arg(0))
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	p, loc, err := loadProfile(*profileName)
+	if err != nil {
+		fail(err)
+	}
+	a := &app{client: client.New(p.URL, p.APIKey), profile: p, loc: loc, out: os.Stdout}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(a, ctx, flags.Args()[1:]); err != nil {
+		// Ошибку разбора флагов FlagSet уже напечатал
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "remindctl:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"Reminders/internal/timeparse"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseWhen переводит выражение времени в момент и правило повторения относительно now.
+// Кроме фраз timeparse понимает короткую запись длительности: «30m», «+2h».
+func parseWhen(expr string, now time.Time) (timeparse.Result, error) {
+	res, err := timeparse.Parse(expr, now)
+	if errors.Is(err, timeparse.ErrUnrecognized) {
+		duration := strings.TrimPrefix(strings.TrimSpace(expr), "+")
+		if short, shortErr := timeparse.Parse("in "+duration, now); shortErr == nil {
+			return short, nil
+		}
+	}
+	switch {
+	case errors.Is(err, timeparse.ErrPast):
+		return res, fmt.Errorf("время %q уже прошло", expr)
+	case errors.Is(err, timeparse.ErrConflict):
+		return res, fmt.Errorf("в %q несколько разных дней", expr)
+	case err != nil:
+		return res, fmt.Errorf("не удалось разобрать время %q", expr)
+	}
+	return res, nil
+}
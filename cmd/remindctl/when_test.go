@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWhen(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 15, 30, 0, 0, moscow)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, moscow)
+	}
+
+	cases := []struct {
+		name  string
+		expr  string
+		want  time.Time
+		rrule string
+		// err - подстрока ошибки
+		err string
+	}{
+		{name: "phrase", expr: "tomorrow at 9", want: at(20, 9, 0)},
+		{name: "russian_phrase", expr: "завтра в 9", want: at(20, 9, 0)},
+		{name: "rule", expr: "every day at 18", want: at(19, 18, 0), rrule: "FREQ=DAILY"},
+		{name: "short_minutes", expr: "30m", want: at(19, 16, 0)},
+		{name: "short_plus_hours", expr: "+2h", want: at(19, 17, 30)},
+		{name: "short_with_spaces", expr: " + 2h ", want: at(19, 17, 30)},
+		{name: "short_days", expr: "2d", want: at(21, 15, 30)},
+		{name: "past_date", expr: "on 1 march 2020", err: "уже прошло"},
+		{name: "conflict", expr: "завтра в понедельник", err: "несколько разных дней"},
+		{name: "unrecognized", expr: "когда-нибудь", err: `не удалось разобрать время "когда-нибудь"`},
+		{name: "negative_duration", expr: "-2h", err: "не удалось разобрать время"},
+		{name: "zero_duration", expr: "+0m", err: "не удалось разобрать время"},
+		{name: "compound_duration", expr: "1h30m", err: "не удалось разобрать время"},
+		{name: "empty", expr: "", err: "не удалось разобрать время"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseWhen(c.expr, now)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Errorf("parseWhen(%q) error %v, want %q", c.expr, err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseWhen(%q): %v", c.expr, err)
+			}
+			if !got.At.Equal(c.want) || got.Recurrence != c.rrule {
+				t.Errorf("parseWhen(%q) = %s %q, want %s %q", c.expr, got.At, got.Recurrence, c.want, c.rrule)
+			}
+		})
+	}
+}
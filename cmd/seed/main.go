@@ -0,0 +1,37 @@
+package main
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/fixtures"
+	"Reminders/internal/server"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// Загрузка подготовленных данных в базу из .env, например перед прогоном коллекции Postman:
+//
+//	go run ./cmd/seed test_api/fixtures.json
+func main() {
+	server.InitServer()
+	logger := server.GetLogger()
+
+	if len(os.Args) < 2 {
+		logger.Fatal("Не указан файл с данными: go run ./cmd/seed <file.json>...")
+	}
+	for _, path := range os.Args[1:] {
+		file, err := os.Open(path)
+		if err != nil {
+			logger.Fatal("Не удалось открыть файл с данными", zap.String("path", path), zap.Error(err))
+		}
+		set, err := fixtures.Decode(file)
+		file.Close()
+		if err != nil {
+			logger.Fatal("Некорректный файл с данными", zap.String("path", path), zap.Error(err))
+		}
+		if err := fixtures.Load(database.DB, &set); err != nil {
+			logger.Fatal("Ошибка загрузки данных", zap.String("path", path), zap.Error(err))
+		}
+		logger.Info("Данные загружены", zap.String("path", path), zap.Any("count", set.Count()))
+	}
+}
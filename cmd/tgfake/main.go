@@ -0,0 +1,24 @@
+package main
+
+import (
+	"Reminders/internal/tgfake"
+	"flag"
+	"log"
+	"net/http"
+)
+
+// Поддельный Telegram Bot API для локального запуска бота:
+//
+//	go run ./cmd/tgfake -addr :8082
+//	TELEGRAM_API_URL=http://localhost:8082 go run ./bot
+//
+// Отправленные ботом сообщения - GET /_fake/messages, сообщение боту - POST /_fake/updates,
+// ошибка Telegram - POST /_fake/faults, например {"chat_id": 42, "error_code": 403,
+// "description": "Forbidden: bot was blocked by the user"}.
+func main() {
+	addr := flag.String("addr", ":8082", "адрес, на котором слушает сервер")
+	flag.Parse()
+
+	log.Printf("Поддельный Telegram Bot API слушает %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, tgfake.New()))
+}
@@ -0,0 +1,299 @@
+// Package anchors вычисляет время отправки напоминаний, заданное относительно события
+// или другого напоминания, и переносит зависимые напоминания вслед за их якорями.
+package anchors
+
+import (
+	"Reminders/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Максимальная глубина цепочки зависимых напоминаний
+	maxDepth = 16
+	// Максимальное смещение от якоря
+	maxOffset = 366 * 24 * 60
+)
+
+// ErrInvalidAnchor возвращается, если якорь задан неверно, не найден или образует цикл.
+var ErrInvalidAnchor = errors.New("invalid anchor")
+
+// Validate проверяет согласованность полей якоря напоминания.
+func Validate(r models.Reminder) error {
+	if r.AnchorEventID != nil && r.AnchorReminderID != nil {
+		return fmt.Errorf("%w: anchor_event_id and anchor_reminder_id are mutually exclusive", ErrInvalidAnchor)
+	}
+	if r.AnchorOffset < -maxOffset || r.AnchorOffset > maxOffset {
+		return fmt.Errorf("%w: anchor_offset_minutes must be within %d", ErrInvalidAnchor, maxOffset)
+	}
+	if r.AnchorReminderID == nil {
+		if r.AnchorOn != "" {
+			return fmt.Errorf("%w: anchor_on requires anchor_reminder_id", ErrInvalidAnchor)
+		}
+		return nil
+	}
+
+	switch r.AnchorOn {
+	case "", models.AnchorScheduled, models.AnchorSent, models.AnchorAcknowledged:
+	default:
+		return fmt.Errorf("%w: anchor_on must be one of %s, %s, %s", ErrInvalidAnchor,
+			models.AnchorScheduled, models.AnchorSent, models.AnchorAcknowledged)
+	}
+	if *r.AnchorReminderID == r.ID {
+		return fmt.Errorf("%w: reminder cannot be anchored to itself", ErrInvalidAnchor)
+	}
+	return nil
+}
+
+// Resolve вычисляет время отправки напоминания по его якорю. Якорь должен принадлежать
+// тому же пользователю. Пока напоминание-якорь не отправлено или не подтверждено,
+// зависимое напоминание ждёт и не отправляется.
+func Resolve(tx *gorm.DB, r *models.Reminder) error {
+	if !r.Anchored() {
+		r.AnchorOn = ""
+		r.AnchorOffset = 0
+		r.WaitingAnchor = false
+		return nil
+	}
+	if err := Validate(*r); err != nil {
+		return err
+	}
+
+	offset := time.Duration(r.AnchorOffset) * time.Minute
+	if r.AnchorEventID != nil {
+		var event models.Event
+		err := tx.Where("id = ? AND user_id = ?", *r.AnchorEventID, r.UserID).First(&event).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: event %d not found", ErrInvalidAnchor, *r.AnchorEventID)
+		}
+		if err != nil {
+			return err
+		}
+		r.SendAt = event.StartsAt.Add(offset)
+		r.WaitingAnchor = false
+		return nil
+	}
+
+	if r.AnchorOn == "" {
+		r.AnchorOn = models.AnchorScheduled
+	}
+	anchor, err := findAnchorReminder(tx, *r)
+	if err != nil {
+		return err
+	}
+
+	switch r.AnchorOn {
+	case models.AnchorScheduled:
+		if anchor.WaitingAnchor {
+			r.WaitingAnchor = true
+			return nil
+		}
+		r.SendAt, r.WaitingAnchor = anchor.SendAt.Add(offset), false
+	case models.AnchorSent:
+		// Якорь срабатывает на следующей отправке, уже прошедшие не учитываются
+		r.WaitingAnchor = true
+	case models.AnchorAcknowledged:
+		r.WaitingAnchor = true
+	}
+	return nil
+}
+
+// findAnchorReminder ищет напоминание-якорь и проверяет, что цепочка якорей не замыкается.
+func findAnchorReminder(tx *gorm.DB, r models.Reminder) (models.Reminder, error) {
+	var anchor models.Reminder
+	err := tx.Where("id = ? AND user_id = ?", *r.AnchorReminderID, r.UserID).First(&anchor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return anchor, fmt.Errorf("%w: reminder %d not found", ErrInvalidAnchor, *r.AnchorReminderID)
+	}
+	if err != nil {
+		return anchor, err
+	}
+
+	// У нового напоминания ещё нет зависимых, цикл возможен только при изменении
+	if r.ID == 0 {
+		return anchor, nil
+	}
+	next := anchor
+	for depth := 0; next.AnchorReminderID != nil; depth++ {
+		if *next.AnchorReminderID == r.ID {
+			return anchor, fmt.Errorf("%w: anchors form a cycle", ErrInvalidAnchor)
+		}
+		if depth >= maxDepth {
+			return anchor, fmt.Errorf("%w: anchor chain is longer than %d", ErrInvalidAnchor, maxDepth)
+		}
+		id := *next.AnchorReminderID
+		next = models.Reminder{}
+		if err := tx.Select("id", "anchor_reminder_id").First(&next, id).Error; err != nil {
+			return anchor, err
+		}
+	}
+	return anchor, nil
+}
+
+// RescheduleEvent пересчитывает время отправки напоминаний, привязанных к событию.
+func RescheduleEvent(tx *gorm.DB, eventID int) (int, error) {
+	var event models.Event
+	if err := tx.First(&event, eventID).Error; err != nil {
+		return 0, err
+	}
+
+	var dependents []models.Reminder
+	if err := pending(tx).Where("anchor_event_id = ?", eventID).Find(&dependents).Error; err != nil {
+		return 0, err
+	}
+	return reschedule(tx, dependents, func(r models.Reminder) time.Time {
+		return event.StartsAt.Add(time.Duration(r.AnchorOffset) * time.Minute)
+	}, 0)
+}
+
+// RescheduleReminder пересчитывает напоминания, отсчитываемые от запланированного
+// времени изменённого напоминания, и всю цепочку после них.
+// Если само напоминание ждёт своего якоря, зависимые тоже переходят в ожидание.
+func RescheduleReminder(tx *gorm.DB, r models.Reminder) (int, error) {
+	if r.WaitingAnchor {
+		return wait(tx, r.ID, 0)
+	}
+	return rescheduleFrom(tx, r, models.AnchorScheduled, r.SendAt, 0)
+}
+
+// OnSent запускает напоминания, ждущие отправки напоминания-якоря. Если повторяющийся якорь
+// перенесён на следующее повторение, за ним переходят и напоминания, отсчитываемые от его
+// запланированного времени, если их время для отправленного повторения уже наступило.
+func OnSent(tx *gorm.DB, r models.Reminder, at time.Time) (int, error) {
+	count, err := rescheduleFrom(tx, r, models.AnchorSent, at, 0)
+	if err != nil {
+		return count, err
+	}
+
+	var current models.Reminder
+	if err := tx.First(&current, r.ID).Error; err != nil {
+		return count, err
+	}
+	if current.IsSent || current.WaitingAnchor || !current.SendAt.After(at) {
+		return count, nil
+	}
+	var dependents []models.Reminder
+	err = pending(tx).
+		Where("anchor_reminder_id = ? AND anchor_on = ? AND waiting_anchor = ? AND send_at <= ?",
+			r.ID, models.AnchorScheduled, false, at).
+		Find(&dependents).Error
+	if err != nil {
+		return count, err
+	}
+	n, err := reschedule(tx, dependents, func(d models.Reminder) time.Time {
+		return current.SendAt.Add(time.Duration(d.AnchorOffset) * time.Minute)
+	}, 0)
+	return count + n, err
+}
+
+// OnAcknowledged запускает напоминания, ждущие подтверждения напоминания-якоря.
+func OnAcknowledged(tx *gorm.DB, r models.Reminder, at time.Time) (int, error) {
+	return rescheduleFrom(tx, r, models.AnchorAcknowledged, at, 0)
+}
+
+// Detach отвязывает напоминания от удаляемого напоминания-якоря. Ждущие якоря напоминания
+// уже не дождутся его и архивируются.
+func Detach(tx *gorm.DB, reminderID int) error {
+	err := pending(tx).Model(&models.Reminder{}).
+		Where("anchor_reminder_id = ? AND waiting_anchor = ?", reminderID, true).
+		Updates(map[string]interface{}{"archived_at": time.Now(), "waiting_anchor": false}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.Reminder{}).
+		Where("anchor_reminder_id = ?", reminderID).
+		Updates(map[string]interface{}{"anchor_reminder_id": nil, "anchor_on": "", "anchor_offset": 0}).Error
+}
+
+// rescheduleFrom пересчитывает напоминания, отсчитываемые от момента on напоминания r.
+func rescheduleFrom(tx *gorm.DB, r models.Reminder, on string, at time.Time, depth int) (int, error) {
+	var dependents []models.Reminder
+	err := pending(tx).Where("anchor_reminder_id = ? AND anchor_on = ?", r.ID, on).Find(&dependents).Error
+	if err != nil {
+		return 0, err
+	}
+	return reschedule(tx, dependents, func(d models.Reminder) time.Time {
+		return at.Add(time.Duration(d.AnchorOffset) * time.Minute)
+	}, depth)
+}
+
+// reschedule переносит зависимые напоминания и рекурсивно их собственных зависимых.
+// Возвращает общее количество перенесённых напоминаний.
+func reschedule(tx *gorm.DB, dependents []models.Reminder, sendAt func(models.Reminder) time.Time, depth int) (int, error) {
+	if depth >= maxDepth {
+		return 0, fmt.Errorf("%w: anchor chain is longer than %d", ErrInvalidAnchor, maxDepth)
+	}
+
+	count := 0
+	for _, d := range dependents {
+		next := sendAt(d)
+		if next.Equal(d.SendAt) && !d.WaitingAnchor {
+			continue
+		}
+
+		err := tx.Model(&d).Updates(map[string]interface{}{
+			"send_at":        next,
+			"waiting_anchor": false,
+			"deferred_until": nil,
+			"updated_at":     time.Now(),
+		}).Error
+		if err != nil {
+			return count, err
+		}
+		err = tx.Create(&models.ReminderHistory{
+			ReminderID: d.ID,
+			Action:     models.HistoryRescheduled,
+			SendAt:     next,
+			CreatedAt:  time.Now(),
+		}).Error
+		if err != nil {
+			return count, err
+		}
+		count++
+
+		d.SendAt = next
+		n, err := rescheduleFrom(tx, d, models.AnchorScheduled, next, depth+1)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// wait переводит в ожидание напоминания, отсчитываемые от запланированного времени
+// напоминания reminderID, и их зависимых.
+func wait(tx *gorm.DB, reminderID int, depth int) (int, error) {
+	if depth >= maxDepth {
+		return 0, fmt.Errorf("%w: anchor chain is longer than %d", ErrInvalidAnchor, maxDepth)
+	}
+
+	var ids []int
+	err := pending(tx).Model(&models.Reminder{}).
+		Where("anchor_reminder_id = ? AND anchor_on = ? AND waiting_anchor = ?", reminderID, models.AnchorScheduled, false).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	if err := tx.Model(&models.Reminder{}).Where("id IN ?", ids).Update("waiting_anchor", true).Error; err != nil {
+		return 0, err
+	}
+
+	count := len(ids)
+	for _, id := range ids {
+		n, err := wait(tx, id, depth+1)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// pending ограничивает запрос напоминаниями, которые ещё будут отправлены.
+func pending(tx *gorm.DB) *gorm.DB {
+	return tx.Where("is_sent = ? AND archived_at IS NULL", false)
+}
@@ -0,0 +1,333 @@
+package anchors
+
+import (
+	"Reminders/internal/models"
+	"Reminders/internal/testdb"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var base = time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+
+// fixture - пользователь и событие, к которым привязываются напоминания теста.
+type fixture struct {
+	db    *gorm.DB
+	user  models.User
+	event models.Event
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	db := testdb.Open(t)
+	f := fixture{db: db, user: models.User{DisplayName: "anchors"}}
+	if err := db.Create(&f.user).Error; err != nil {
+		t.Fatal(err)
+	}
+	f.event = models.Event{UserID: f.user.ID, Name: "Созвон", StartsAt: base}
+	if err := db.Create(&f.event).Error; err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// add сохраняет напоминание r пользователя фикстуры, вычислив время по якорю.
+func (f fixture) add(t *testing.T, r models.Reminder) models.Reminder {
+	t.Helper()
+	r.UserID = f.user.ID
+	if r.Message == "" {
+		r.Message = "напоминание"
+	}
+	if r.SendAt.IsZero() {
+		r.SendAt = base
+	}
+	if err := Resolve(f.db, &r); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.db.Create(&r).Error; err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func (f fixture) reload(t *testing.T, id int) models.Reminder {
+	t.Helper()
+	var r models.Reminder
+	if err := f.db.First(&r, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func ptr(id int) *int { return &id }
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		r    models.Reminder
+		ok   bool
+	}{
+		{"not_anchored", models.Reminder{}, true},
+		{"event", models.Reminder{AnchorEventID: ptr(1), AnchorOffset: -30}, true},
+		{"reminder_sent", models.Reminder{ID: 2, AnchorReminderID: ptr(1), AnchorOn: models.AnchorSent}, true},
+		{"both", models.Reminder{AnchorEventID: ptr(1), AnchorReminderID: ptr(1)}, false},
+		{"offset_too_large", models.Reminder{AnchorEventID: ptr(1), AnchorOffset: maxOffset + 1}, false},
+		{"anchor_on_without_reminder", models.Reminder{AnchorEventID: ptr(1), AnchorOn: models.AnchorSent}, false},
+		{"unknown_anchor_on", models.Reminder{AnchorReminderID: ptr(1), AnchorOn: "read"}, false},
+		{"self", models.Reminder{ID: 1, AnchorReminderID: ptr(1)}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Validate(c.r)
+			if c.ok && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !c.ok && !errors.Is(err, ErrInvalidAnchor) {
+				t.Errorf("error %v, want ErrInvalidAnchor", err)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	f := newFixture(t)
+	anchor := f.add(t, models.Reminder{SendAt: base})
+	waiting := f.add(t, models.Reminder{AnchorReminderID: &anchor.ID, AnchorOn: models.AnchorSent})
+
+	other := models.User{DisplayName: "other"}
+	if err := f.db.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+	foreign := models.Reminder{UserID: other.ID, Message: "чужое", SendAt: base}
+	if err := f.db.Create(&foreign).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		r       models.Reminder
+		sendAt  time.Time
+		waiting bool
+		err     bool
+	}{
+		{"not_anchored_clears_fields", models.Reminder{SendAt: base, AnchorOn: models.AnchorSent, AnchorOffset: 5, WaitingAnchor: true}, base, false, false},
+		{"event", models.Reminder{AnchorEventID: &f.event.ID, AnchorOffset: -15}, base.Add(-15 * time.Minute), false, false},
+		{"unknown_event", models.Reminder{AnchorEventID: ptr(99)}, time.Time{}, false, true},
+		{"scheduled_by_default", models.Reminder{AnchorReminderID: &anchor.ID, AnchorOffset: 60}, base.Add(time.Hour), false, false},
+		{"scheduled_on_waiting_anchor", models.Reminder{AnchorReminderID: &waiting.ID}, time.Time{}, true, false},
+		{"sent", models.Reminder{AnchorReminderID: &anchor.ID, AnchorOn: models.AnchorSent}, time.Time{}, true, false},
+		{"acknowledged", models.Reminder{AnchorReminderID: &anchor.ID, AnchorOn: models.AnchorAcknowledged}, time.Time{}, true, false},
+		{"unknown_reminder", models.Reminder{AnchorReminderID: ptr(99)}, time.Time{}, false, true},
+		{"other_users_reminder", models.Reminder{AnchorReminderID: &foreign.ID}, time.Time{}, false, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := c.r
+			r.UserID = f.user.ID
+			err := Resolve(f.db, &r)
+			if c.err {
+				if !errors.Is(err, ErrInvalidAnchor) {
+					t.Errorf("error %v, want ErrInvalidAnchor", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.WaitingAnchor != c.waiting || (!c.sendAt.IsZero() && !r.SendAt.Equal(c.sendAt)) {
+				t.Errorf("send_at %s waiting %v, want %s waiting %v", r.SendAt, r.WaitingAnchor, c.sendAt, c.waiting)
+			}
+			if !r.Anchored() && (r.AnchorOn != "" || r.AnchorOffset != 0) {
+				t.Errorf("anchor fields left on a plain reminder: %+v", r)
+			}
+		})
+	}
+}
+
+// Напоминание нельзя привязать к собственному зависимому, ни прямо, ни через цепочку.
+func TestResolveRejectsCycle(t *testing.T) {
+	f := newFixture(t)
+	a := f.add(t, models.Reminder{})
+	b := f.add(t, models.Reminder{AnchorReminderID: &a.ID})
+	c := f.add(t, models.Reminder{AnchorReminderID: &b.ID})
+
+	for _, anchorID := range []int{b.ID, c.ID} {
+		r := f.reload(t, a.ID)
+		r.AnchorReminderID = ptr(anchorID)
+		if err := Resolve(f.db, &r); !errors.Is(err, ErrInvalidAnchor) {
+			t.Errorf("anchoring %d to %d: error %v, want a cycle", a.ID, anchorID, err)
+		}
+	}
+
+	// Независимое напоминание можно привязать к концу цепочки
+	d := f.add(t, models.Reminder{})
+	d.AnchorReminderID = &c.ID
+	if err := Resolve(f.db, &d); err != nil {
+		t.Errorf("anchoring to the chain end: %v", err)
+	}
+}
+
+// Перенос якоря сдвигает всю цепочку зависимых от запланированного времени, а зависимые
+// от отправки остаются ждать.
+func TestRescheduleReminderCascade(t *testing.T) {
+	f := newFixture(t)
+	a := f.add(t, models.Reminder{SendAt: base})
+	b := f.add(t, models.Reminder{AnchorReminderID: &a.ID, AnchorOffset: 30})
+	c := f.add(t, models.Reminder{AnchorReminderID: &b.ID, AnchorOffset: -10})
+	onSent := f.add(t, models.Reminder{AnchorReminderID: &a.ID, AnchorOn: models.AnchorSent})
+
+	a.SendAt = base.Add(24 * time.Hour)
+	if err := f.db.Model(&a).Update("send_at", a.SendAt).Error; err != nil {
+		t.Fatal(err)
+	}
+	n, err := RescheduleReminder(f.db, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("rescheduled %d reminders, want 2", n)
+	}
+	if got := f.reload(t, b.ID).SendAt; !got.Equal(a.SendAt.Add(30 * time.Minute)) {
+		t.Errorf("b send_at %s", got)
+	}
+	if got := f.reload(t, c.ID).SendAt; !got.Equal(a.SendAt.Add(20 * time.Minute)) {
+		t.Errorf("c send_at %s", got)
+	}
+	if !f.reload(t, onSent.ID).WaitingAnchor {
+		t.Error("reminder anchored on sent stopped waiting")
+	}
+
+	var history int64
+	f.db.Model(&models.ReminderHistory{}).Where("action = ?", models.HistoryRescheduled).Count(&history)
+	if history != 2 {
+		t.Errorf("%d history rows, want 2", history)
+	}
+
+	// Якорь, который сам ждёт, переводит в ожидание всю цепочку
+	a.WaitingAnchor = true
+	if n, err := RescheduleReminder(f.db, a); err != nil || n != 2 {
+		t.Errorf("wait = %d, %v; want 2", n, err)
+	}
+	if !f.reload(t, b.ID).WaitingAnchor || !f.reload(t, c.ID).WaitingAnchor {
+		t.Error("chain is not waiting for the anchor")
+	}
+}
+
+func TestOnSentAndAcknowledged(t *testing.T) {
+	f := newFixture(t)
+	a := f.add(t, models.Reminder{SendAt: base})
+	afterSent := f.add(t, models.Reminder{AnchorReminderID: &a.ID, AnchorOn: models.AnchorSent, AnchorOffset: 60})
+	afterAck := f.add(t, models.Reminder{AnchorReminderID: &a.ID, AnchorOn: models.AnchorAcknowledged, AnchorOffset: 5})
+	chained := f.add(t, models.Reminder{AnchorReminderID: &afterSent.ID, AnchorOffset: 10})
+	if !chained.WaitingAnchor {
+		t.Fatal("reminder anchored on a waiting reminder does not wait")
+	}
+
+	sentAt := base.Add(2 * time.Minute)
+	if err := f.db.Model(&a).Update("is_sent", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := OnSent(f.db, a, sentAt); err != nil || n != 2 {
+		t.Errorf("OnSent = %d, %v; want 2", n, err)
+	}
+	if r := f.reload(t, afterSent.ID); r.WaitingAnchor || !r.SendAt.Equal(sentAt.Add(time.Hour)) {
+		t.Errorf("after sent: send_at %s waiting %v", r.SendAt, r.WaitingAnchor)
+	}
+	if r := f.reload(t, chained.ID); r.WaitingAnchor || !r.SendAt.Equal(sentAt.Add(70*time.Minute)) {
+		t.Errorf("chained: send_at %s waiting %v", r.SendAt, r.WaitingAnchor)
+	}
+	if !f.reload(t, afterAck.ID).WaitingAnchor {
+		t.Error("reminder anchored on acknowledgement started on send")
+	}
+
+	ackAt := base.Add(7 * time.Minute)
+	if n, err := OnAcknowledged(f.db, a, ackAt); err != nil || n != 1 {
+		t.Errorf("OnAcknowledged = %d, %v; want 1", n, err)
+	}
+	if r := f.reload(t, afterAck.ID); r.WaitingAnchor || !r.SendAt.Equal(ackAt.Add(5*time.Minute)) {
+		t.Errorf("after ack: send_at %s waiting %v", r.SendAt, r.WaitingAnchor)
+	}
+}
+
+// Когда повторяющийся якорь после отправки переходит на следующее повторение, зависимые
+// от его запланированного времени переходят вместе с ним, если их время уже наступило.
+func TestOnSentMovesScheduledDependents(t *testing.T) {
+	f := newFixture(t)
+	a := f.add(t, models.Reminder{SendAt: base, Recurrence: "FREQ=DAILY"})
+	before := f.add(t, models.Reminder{AnchorReminderID: &a.ID, AnchorOffset: -15})
+	after := f.add(t, models.Reminder{AnchorReminderID: &a.ID, AnchorOffset: 30})
+	chained := f.add(t, models.Reminder{AnchorReminderID: &before.ID, AnchorOffset: 5})
+
+	next := base.Add(24 * time.Hour)
+	if err := f.db.Model(&a).Update("send_at", next).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := OnSent(f.db, a, base); err != nil || n != 2 {
+		t.Errorf("OnSent = %d, %v; want 2", n, err)
+	}
+	if got := f.reload(t, before.ID).SendAt; !got.Equal(next.Add(-15 * time.Minute)) {
+		t.Errorf("before send_at %s, want the next occurrence", got)
+	}
+	if got := f.reload(t, chained.ID).SendAt; !got.Equal(next.Add(-10 * time.Minute)) {
+		t.Errorf("chained send_at %s, want to follow its anchor", got)
+	}
+	// Время после отправленного повторения ещё не наступило, напоминание отправится за ним
+	if got := f.reload(t, after.ID).SendAt; !got.Equal(base.Add(30 * time.Minute)) {
+		t.Errorf("after send_at %s, want the sent occurrence", got)
+	}
+
+	// Отправленное неповторяющееся напоминание никого не переносит
+	single := f.add(t, models.Reminder{SendAt: base})
+	dependent := f.add(t, models.Reminder{AnchorReminderID: &single.ID, AnchorOffset: -5})
+	if err := f.db.Model(&single).Update("is_sent", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := OnSent(f.db, single, base); err != nil || n != 0 {
+		t.Errorf("OnSent = %d, %v; want 0", n, err)
+	}
+	if got := f.reload(t, dependent.ID).SendAt; !got.Equal(base.Add(-5 * time.Minute)) {
+		t.Errorf("dependent send_at %s", got)
+	}
+}
+
+func TestRescheduleEvent(t *testing.T) {
+	f := newFixture(t)
+	r := f.add(t, models.Reminder{AnchorEventID: &f.event.ID, AnchorOffset: -30})
+	chained := f.add(t, models.Reminder{AnchorReminderID: &r.ID, AnchorOffset: 10})
+
+	starts := base.Add(3 * time.Hour)
+	if err := f.db.Model(&f.event).Update("starts_at", starts).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := RescheduleEvent(f.db, f.event.ID); err != nil || n != 2 {
+		t.Errorf("RescheduleEvent = %d, %v; want 2", n, err)
+	}
+	if got := f.reload(t, chained.ID).SendAt; !got.Equal(starts.Add(-20 * time.Minute)) {
+		t.Errorf("chained send_at %s", got)
+	}
+}
+
+// Удаление якоря архивирует ждущих его и отвязывает остальных с сохранением времени.
+func TestDetach(t *testing.T) {
+	f := newFixture(t)
+	a := f.add(t, models.Reminder{SendAt: base})
+	scheduled := f.add(t, models.Reminder{AnchorReminderID: &a.ID, AnchorOffset: 30})
+	waiting := f.add(t, models.Reminder{AnchorReminderID: &a.ID, AnchorOn: models.AnchorAcknowledged})
+
+	if err := Detach(f.db, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	s := f.reload(t, scheduled.ID)
+	if s.AnchorReminderID != nil || s.AnchorOn != "" || s.AnchorOffset != 0 || s.ArchivedAt != nil {
+		t.Errorf("scheduled dependent %+v, want a plain reminder", s)
+	}
+	if !s.SendAt.Equal(base.Add(30 * time.Minute)) {
+		t.Errorf("scheduled dependent moved to %s", s.SendAt)
+	}
+	w := f.reload(t, waiting.ID)
+	if w.ArchivedAt == nil || w.WaitingAnchor || w.AnchorReminderID != nil {
+		t.Errorf("waiting dependent %+v, want archived and detached", w)
+	}
+}
@@ -0,0 +1,95 @@
+// Package blobstore хранит загруженные вложения напоминаний в локальной файловой системе.
+package blobstore
+
+import (
+	"Reminders/internal/tokens"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Объявление переменной Files, хранящей хранилище вложений
+var Files *Store
+
+// ErrInvalidKey возвращается для ключей, не выданных хранилищем.
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Ключ - случайный токен и необязательное расширение исходного файла
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{48}(\.[0-9A-Za-z]{1,10})?$`)
+
+// Store - хранилище файлов в каталоге dir.
+type Store struct {
+	dir string
+}
+
+// InitBlobStore создаёт каталог хранилища и инициализирует Files.
+func InitBlobStore(dir string) error {
+	store, err := New(dir)
+	if err != nil {
+		return err
+	}
+	Files = store
+	return nil
+}
+
+// New возвращает хранилище в каталоге dir, создавая его при необходимости.
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Put сохраняет содержимое r и возвращает ключ нового файла. Расширение берётся из name.
+func (s *Store) Put(r io.Reader, name string) (string, error) {
+	token, err := tokens.New()
+	if err != nil {
+		return "", err
+	}
+	key := token
+	if ext := strings.ToLower(filepath.Ext(name)); ext != "" && keyPattern.MatchString(token+ext) {
+		key += ext
+	}
+
+	// Запись во временный файл и переименование, чтобы не оставлять обрезанные файлы
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, key)); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Path возвращает путь к файлу с ключом key.
+func (s *Store) Path(key string) (string, error) {
+	if !keyPattern.MatchString(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, key), nil
+}
+
+// Delete удаляет файл. Отсутствие файла ошибкой не считается.
+func (s *Store) Delete(key string) error {
+	path, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -2,10 +2,12 @@ package database
 
 import (
 	"Reminders/internal/envs"
+	"Reminders/internal/models"
 	"fmt"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // / Объявление переменной DB, хранящей ссылку на экземпляр базы данных
@@ -26,3 +28,57 @@ func InitDatabase() error {
 		return nil
 	}
 }
+
+// / Создание и обновление таблиц всех моделей
+func Migrate() error {
+	if err := DB.AutoMigrate(&models.User{}, &models.TelegramLink{}); err != nil {
+		return err
+	}
+	// Записи, созданные до появления пользователей, получают владельцев до добавления внешних ключей
+	if err := BackfillUsers("reminders", "calendar_feeds"); err != nil {
+		return fmt.Errorf("backfill users: %w", err)
+	}
+	if err := DB.AutoMigrate(&models.EscalationPolicy{}, &models.Event{}, &models.ReminderList{}, &models.Tag{}, &models.ReminderEvent{}, &models.Webhook{}, &models.IdempotencyKey{}); err != nil {
+		return err
+	}
+	// Ключи идемпотентности были уникальны глобально, теперь - в пределах клиента
+	if DB.Migrator().HasIndex(&models.IdempotencyKey{}, "idx_idempotency_keys_key") {
+		if err := DB.Migrator().DropIndex(&models.IdempotencyKey{}, "idx_idempotency_keys_key"); err != nil {
+			return err
+		}
+	}
+	return DB.AutoMigrate(&models.Reminder{}, &models.CalendarFeed{}, &models.TelegramLinkToken{}, &models.APIKey{}, &models.ReminderHistory{}, &models.ReminderEscalation{}, &models.ReminderRecipient{}, &models.ReminderDelivery{}, &models.WebhookDelivery{}, &models.SchedulerState{})
+}
+
+// / Создание пользователей для user_id, на которые ссылаются записи до появления таблицы users,
+// / чтобы можно было добавить внешние ключи
+func BackfillUsers(tables ...string) error {
+	for _, table := range tables {
+		if !DB.Migrator().HasTable(table) {
+			continue
+		}
+		err := DB.Exec(`INSERT INTO users (id, display_name, default_channel, quiet_hours, created_at, updated_at)
+			SELECT DISTINCT t.user_id, 'User ' || t.user_id, 'telegram', '[]', NOW(), NOW()
+			FROM ` + table + ` t
+			WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = t.user_id)`).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Сдвиг последовательности за вставленные вручную идентификаторы; в SQLite последовательностей нет
+	if DB.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return DB.Exec(`SELECT setval(pg_get_serial_sequence('users', 'id'), COALESCE((SELECT MAX(id) FROM users), 0) + 1, false)`).Error
+}
+
+// / Блокировка выбранных строк до конца транзакции FOR UPDATE SKIP LOCKED: строки, которые
+// / уже заблокировала другая транзакция, пропускаются. В SQLite такой блокировки нет,
+// / там транзакции на запись и так идут по одной
+func SkipLocked(tx *gorm.DB) *gorm.DB {
+	if tx.Dialector.Name() != "postgres" {
+		return tx
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+}
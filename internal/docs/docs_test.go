@@ -0,0 +1,50 @@
+package docs_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/swaggo/swag"
+	"github.com/swaggo/swag/gen"
+)
+
+// Спецификация в internal/docs должна совпадать с той, что swag init -g cmd/main.go -o internal/docs
+// собирает из аннотаций обработчиков. Если тест упал, перегенерируйте спецификацию.
+func TestSpecIsUpToDate(t *testing.T) {
+	out := t.TempDir()
+	err := gen.New().Build(&gen.Config{
+		SearchDir:          "../..",
+		MainAPIFile:        "cmd/main.go",
+		PropNamingStrategy: swag.CamelCase,
+		OutputDir:          out,
+		PackageName:        "docs",
+		OutputTypes:        []string{"go", "json", "yaml"},
+		ParseDepth:         100,
+		ParseGoList:        true,
+		LeftTemplateDelim:  "{{",
+		RightTemplateDelim: "}}",
+		CollectionFormat:   "csv",
+		Debugger:           log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("generate spec: %v", err)
+	}
+
+	for _, name := range []string{"docs.go", "swagger.json", "swagger.yaml"} {
+		want, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("internal/docs/%s is stale, regenerate it with: swag init -g cmd/main.go -o internal/docs", name)
+		}
+	}
+}
@@ -15,6 +15,18 @@ type Envs struct {
 	POSTGRES_PORT     string
 	POSTGRES_NAME     string
 	POSTGRES_HOST     string
+	PUBLIC_URL        string
+	BLOB_DIR          string
+	BOT_USERNAME      string
+	// Получение обновлений ботом: polling (по умолчанию) или webhook
+	BOT_MODE           string
+	BOT_WEBHOOK_URL    string
+	BOT_WEBHOOK_SECRET string
+	BOT_WEBHOOK_ADDR   string
+	// Адрес Bot API, пусто - api.telegram.org
+	TELEGRAM_API_URL string
+	// Токен администратора для выпуска ключей API, пусто - ключи выпускаются только по ключу пользователя
+	ADMIN_TOKEN string
 }
 
 // / Инициализация значений ENV
@@ -27,6 +39,24 @@ func LoadEnvs() error {
 	ServerEnvs.POSTGRES_PORT = os.Getenv("DB_PORT")
 	ServerEnvs.POSTGRES_NAME = os.Getenv("DB_NAME")
 	ServerEnvs.POSTGRES_HOST = os.Getenv("DB_HOST")
+	ServerEnvs.PUBLIC_URL = os.Getenv("PUBLIC_URL")
+	ServerEnvs.BLOB_DIR = os.Getenv("BLOB_DIR")
+	ServerEnvs.BOT_USERNAME = os.Getenv("BOT_USERNAME")
+	ServerEnvs.BOT_MODE = os.Getenv("BOT_MODE")
+	ServerEnvs.BOT_WEBHOOK_URL = os.Getenv("BOT_WEBHOOK_URL")
+	ServerEnvs.BOT_WEBHOOK_SECRET = os.Getenv("BOT_WEBHOOK_SECRET")
+	ServerEnvs.BOT_WEBHOOK_ADDR = os.Getenv("BOT_WEBHOOK_ADDR")
+	ServerEnvs.TELEGRAM_API_URL = os.Getenv("TELEGRAM_API_URL")
+	ServerEnvs.ADMIN_TOKEN = os.Getenv("ADMIN_TOKEN")
+	if ServerEnvs.BOT_MODE == "" {
+		ServerEnvs.BOT_MODE = "polling"
+	}
+	if ServerEnvs.BOT_WEBHOOK_ADDR == "" {
+		ServerEnvs.BOT_WEBHOOK_ADDR = ":8081"
+	}
+	if ServerEnvs.BLOB_DIR == "" {
+		ServerEnvs.BLOB_DIR = "blobs"
+	}
 
 	return nil
 }
@@ -0,0 +1,140 @@
+package escalation
+
+import (
+	"Reminders/internal/anchors"
+	"Reminders/internal/models"
+	"Reminders/internal/webhooks"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Источники подтверждения
+const (
+	SourceAPI      = "api"
+	SourceTelegram = "telegram"
+)
+
+// ErrNotSent возвращается при подтверждении напоминания, которое ещё ни разу не отправлялось.
+var ErrNotSent = errors.New("reminder has not been sent yet")
+
+// Schedule планирует шаги эскалации отправленного напоминания. Незавершённые шаги
+// предыдущего повторения отменяются: их заменяет новая отправка.
+func Schedule(tx *gorm.DB, r models.Reminder, sentAt time.Time) error {
+	if err := cancelPending(tx, r.ID); err != nil {
+		return err
+	}
+	if r.Priority != models.PriorityHigh || r.EscalationPolicyID == nil {
+		return nil
+	}
+
+	var policy models.EscalationPolicy
+	err := tx.First(&policy, *r.EscalationPolicyID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for i, step := range policy.Steps {
+		escalation := models.ReminderEscalation{
+			ReminderID:    r.ID,
+			Step:          i,
+			Action:        step.Action,
+			ChatID:        step.ChatID,
+			ContactUserID: step.ContactUserID,
+			DueAt:         sentAt.Add(time.Duration(step.AfterMinutes) * time.Minute),
+			Status:        models.EscalationPending,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+		if err := tx.Create(&escalation).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Acknowledge подтверждает последнюю отправку напоминания, отменяет оставшиеся шаги эскалации
+// и запускает напоминания, ждущие этого подтверждения. Повторное подтверждение ничего не меняет.
+func Acknowledge(tx *gorm.DB, reminderID int, source string) (models.Reminder, error) {
+	var reminder models.Reminder
+	if err := tx.First(&reminder, reminderID).Error; err != nil {
+		return reminder, err
+	}
+	if reminder.SentCount == 0 {
+		return reminder, ErrNotSent
+	}
+	if reminder.AckedAt != nil {
+		return reminder, nil
+	}
+
+	now := time.Now()
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&reminder).Update("acked_at", now).Error; err != nil {
+			return err
+		}
+		if err := cancelPending(tx, reminder.ID); err != nil {
+			return err
+		}
+		err := tx.Create(&models.ReminderHistory{
+			ReminderID: reminder.ID,
+			Action:     models.HistoryAcknowledged,
+			Reason:     source,
+			SendAt:     reminder.SendAt,
+			CreatedAt:  now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if _, err := anchors.OnAcknowledged(tx, reminder, now); err != nil {
+			return err
+		}
+		return webhooks.Publish(tx, models.EventReminderAcknowledged, reminder)
+	})
+	return reminder, err
+}
+
+// Due возвращает шаги эскалации, время которых наступило, вместе с напоминаниями и их получателями.
+func Due(db *gorm.DB, now time.Time) ([]models.ReminderEscalation, error) {
+	var due []models.ReminderEscalation
+	err := db.Preload("Reminder.User.TelegramLinks").Preload("Reminder.Recipients.User.TelegramLinks").
+		Where("status = ? AND due_at <= ?", models.EscalationPending, now).
+		Order("due_at, id").
+		Find(&due).Error
+	return due, err
+}
+
+// Complete помечает шаг эскалации выполненным и записывает его в историю напоминания.
+func Complete(tx *gorm.DB, e models.ReminderEscalation) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&e).Updates(map[string]interface{}{"status": models.EscalationDone, "updated_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+		history := models.ReminderHistory{
+			ReminderID: e.ReminderID,
+			Action:     models.HistoryEscalated,
+			Reason:     e.Action,
+			CreatedAt:  time.Now(),
+		}
+		if e.Reminder != nil {
+			history.SendAt = e.Reminder.SendAt
+		}
+		return tx.Create(&history).Error
+	})
+}
+
+// Cancel отменяет шаг эскалации, который больше не нужно выполнять.
+func Cancel(tx *gorm.DB, e models.ReminderEscalation) error {
+	return tx.Model(&e).Updates(map[string]interface{}{"status": models.EscalationCancelled, "updated_at": time.Now()}).Error
+}
+
+// cancelPending отменяет все незавершённые шаги эскалации напоминания.
+func cancelPending(tx *gorm.DB, reminderID int) error {
+	return tx.Model(&models.ReminderEscalation{}).
+		Where("reminder_id = ? AND status = ?", reminderID, models.EscalationPending).
+		Updates(map[string]interface{}{"status": models.EscalationCancelled, "updated_at": time.Now()}).Error
+}
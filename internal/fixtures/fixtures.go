@@ -0,0 +1,146 @@
+// Package fixtures загружает в базу заранее подготовленные данные из JSON: пользователей,
+// списки, метки, события, политики эскалации и напоминания. Идентификаторы можно задать явно,
+// чтобы на записи ссылались другие записи файла и запросы из коллекции test_api.
+package fixtures
+
+import (
+	"Reminders/internal/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Set - содержимое файла с данными. Записи создаются в порядке полей, чтобы внешние
+// ключи ссылались на уже созданные строки.
+type Set struct {
+	Users              []models.User             `json:"users"`
+	Lists              []models.ReminderList     `json:"lists"`
+	Tags               []models.Tag              `json:"tags"`
+	Events             []models.Event            `json:"events"`
+	EscalationPolicies []models.EscalationPolicy `json:"escalation_policies"`
+	Reminders          []models.Reminder         `json:"reminders"`
+	Webhooks           []models.Webhook          `json:"webhooks"`
+}
+
+// Таблицы с последовательностями идентификаторов, которые сдвигаются после вставки явных id
+var sequenceTables = []string{
+	"users", "telegram_links", "reminder_lists", "tags", "events",
+	"escalation_policies", "reminders", "reminder_recipients", "webhooks",
+}
+
+// Decode читает данные из JSON. Неизвестные поля считаются ошибкой, чтобы опечатка
+// в файле не превращалась молча в пустое значение.
+func Decode(r io.Reader) (Set, error) {
+	var set Set
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&set); err != nil {
+		return set, fmt.Errorf("invalid fixtures: %w", err)
+	}
+	return set, nil
+}
+
+// Load создаёт записи набора в одной транзакции. Пустые created_at и updated_at
+// заполняются текущим временем.
+func Load(db *gorm.DB, set *Set) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i := range set.Users {
+			touch(&set.Users[i].CreatedAt, &set.Users[i].UpdatedAt, now)
+			for j := range set.Users[i].TelegramLinks {
+				link := &set.Users[i].TelegramLinks[j]
+				touch(&link.CreatedAt, &link.UpdatedAt, now)
+			}
+		}
+		for i := range set.Lists {
+			touch(&set.Lists[i].CreatedAt, &set.Lists[i].UpdatedAt, now)
+		}
+		for i := range set.Tags {
+			touch(&set.Tags[i].CreatedAt, &set.Tags[i].UpdatedAt, now)
+		}
+		for i := range set.Events {
+			touch(&set.Events[i].CreatedAt, &set.Events[i].UpdatedAt, now)
+		}
+		for i := range set.EscalationPolicies {
+			touch(&set.EscalationPolicies[i].CreatedAt, &set.EscalationPolicies[i].UpdatedAt, now)
+		}
+		for i := range set.Reminders {
+			touch(&set.Reminders[i].CreatedAt, &set.Reminders[i].UpdatedAt, now)
+			for j := range set.Reminders[i].Recipients {
+				if set.Reminders[i].Recipients[j].CreatedAt.IsZero() {
+					set.Reminders[i].Recipients[j].CreatedAt = now
+				}
+			}
+		}
+		for i := range set.Webhooks {
+			touch(&set.Webhooks[i].CreatedAt, &set.Webhooks[i].UpdatedAt, now)
+		}
+
+		steps := []struct {
+			name    string
+			records interface{}
+			count   int
+		}{
+			{"users", &set.Users, len(set.Users)},
+			{"lists", &set.Lists, len(set.Lists)},
+			{"tags", &set.Tags, len(set.Tags)},
+			{"events", &set.Events, len(set.Events)},
+			{"escalation_policies", &set.EscalationPolicies, len(set.EscalationPolicies)},
+			{"reminders", &set.Reminders, len(set.Reminders)},
+			{"webhooks", &set.Webhooks, len(set.Webhooks)},
+		}
+		for _, step := range steps {
+			if step.count == 0 {
+				continue
+			}
+			if err := tx.Create(step.records).Error; err != nil {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+		}
+		return resetSequences(tx)
+	})
+}
+
+// resetSequences сдвигает последовательности за максимальный id, иначе следующая запись,
+// созданная через API, получила бы id, уже занятый загруженными данными.
+func resetSequences(tx *gorm.DB) error {
+	// В SQLite следующий id и так берётся после максимального
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	for _, table := range sequenceTables {
+		query := fmt.Sprintf(
+			"SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE((SELECT MAX(id) FROM %[1]s), 0) + 1, false)",
+			table)
+		if err := tx.Exec(query).Error; err != nil {
+			return fmt.Errorf("%s sequence: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// touch заполняет пустые отметки времени.
+func touch(createdAt, updatedAt *time.Time, now time.Time) {
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt.IsZero() {
+		*updatedAt = *createdAt
+	}
+}
+
+// Count возвращает число записей каждого вида в наборе.
+func (s Set) Count() map[string]int {
+	return map[string]int{
+		"users":               len(s.Users),
+		"lists":               len(s.Lists),
+		"tags":                len(s.Tags),
+		"events":              len(s.Events),
+		"escalation_policies": len(s.EscalationPolicies),
+		"reminders":           len(s.Reminders),
+		"webhooks":            len(s.Webhooks),
+	}
+}
@@ -0,0 +1,62 @@
+package fixtures_test
+
+import (
+	"Reminders/internal/fixtures"
+	"Reminders/internal/models"
+	"Reminders/internal/testdb"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Данные коллекции test_api должны загружаться в пустую базу, а следующая запись
+// получать id после загруженных.
+func TestLoadCollectionFixtures(t *testing.T) {
+	db := testdb.Open(t)
+	file, err := os.Open("../../test_api/fixtures.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	set, err := fixtures.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fixtures.Load(db, &set); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	counts := map[string]interface{}{
+		"users":               &models.User{},
+		"lists":               &models.ReminderList{},
+		"tags":                &models.Tag{},
+		"events":              &models.Event{},
+		"escalation_policies": &models.EscalationPolicy{},
+		"reminders":           &models.Reminder{},
+		"webhooks":            &models.Webhook{},
+	}
+	for name, want := range set.Count() {
+		var got int64
+		if err := db.Model(counts[name]).Count(&got).Error; err != nil {
+			t.Fatal(err)
+		}
+		if int(got) != want {
+			t.Errorf("%s: %d rows, want %d", name, got, want)
+		}
+	}
+
+	user := models.User{DisplayName: "new"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create after load: %v", err)
+	}
+	if user.ID <= len(set.Users) {
+		t.Errorf("new user id %d collides with fixtures", user.ID)
+	}
+}
+
+func TestDecodeRejectsUnknownFields(t *testing.T) {
+	_, err := fixtures.Decode(strings.NewReader(`{"users": [{"id": 1, "name": "typo"}]}`))
+	if err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
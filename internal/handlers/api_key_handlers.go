@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/envs"
+	"Reminders/internal/models"
+	"Reminders/internal/tokens"
+	"crypto/subtle"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Ключ контекста запроса с идентификатором пользователя, которому принадлежит API-ключ
+	authUserKey = "auth_user_id"
+	// Сколько символов ключа сохраняется открыто, чтобы его можно было узнать в списке
+	apiKeyPrefixLength = 8
+	// Как часто обновляется время последнего использования ключа
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyRequest - выпуск ключа API
+type APIKeyRequest struct {
+	Name string `json:"name" example:"Дашборд"`
+}
+
+// APIKeyResponse - выпущенный ключ API. Ключ показывается только в этом ответе
+type APIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key" example:"3f2a9c1b..."`
+}
+
+// GetAPIKeysHandler godoc
+// @Summary Список ключей API
+// @Description Получить ключи API пользователя. Сами ключи не возвращаются, только их первые символы. Нужен токен администратора ADMIN_TOKEN или ключ API этого пользователя в заголовке Authorization: Bearer
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "Bearer <ADMIN_TOKEN или API key>"
+// @Param id path int true "User ID"
+// @Success 200 {object} APIKeysResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/api-keys [get]
+func GetAPIKeysHandler(ctx *gin.Context) {
+	start := time.Now()
+	user, ok := findUser(ctx, start)
+	if !ok {
+		return
+	}
+
+	keys := []models.APIKey{}
+	if err := database.DB.Where("user_id = ?", user.ID).Order("id").Find(&keys).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch API keys", zap.Int("user_id", user.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("API keys fetched successfully", zap.Int("user_id", user.ID), zap.Int("row_count", len(keys)))
+	ctx.JSON(http.StatusOK, APIKeysResponse{APIKeys: keys})
+}
+
+// CreateAPIKeyHandler godoc
+// @Summary Выпустить ключ API
+// @Description Выпустить ключ API пользователя. Ключ передаётся в заголовке Authorization: Bearer <key> и возвращается только в этом ответе. Нужен токен администратора ADMIN_TOKEN или ключ API этого пользователя
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <ADMIN_TOKEN или API key>"
+// @Param id path int true "User ID"
+// @Param key body APIKeyRequest false "API key"
+// @Success 201 {object} APIKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/api-keys [post]
+func CreateAPIKeyHandler(ctx *gin.Context) {
+	start := time.Now()
+	var req APIKeyRequest
+
+	// Тело запроса необязательно
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if len([]rune(req.Name)) > maxNameLength {
+		logRequestDetails(ctx, start).Info("API key name is too long")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Name is too long"})
+		return
+	}
+
+	user, ok := findUser(ctx, start)
+	if !ok {
+		return
+	}
+
+	token, err := tokens.New()
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to generate API key", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	key := models.APIKey{
+		UserID:    user.ID,
+		Name:      req.Name,
+		Prefix:    token[:apiKeyPrefixLength],
+		TokenHash: tokens.Hash(token),
+		CreatedAt: time.Now(),
+	}
+	if err := database.DB.Create(&key).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to create API key", zap.Int("user_id", user.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("API key created", zap.Int("user_id", user.ID), zap.Int("key_id", key.ID))
+	ctx.JSON(http.StatusCreated, APIKeyResponse{APIKey: key, Key: token})
+}
+
+// DeleteAPIKeyHandler godoc
+// @Summary Отозвать ключ API
+// @Description Нужен токен администратора ADMIN_TOKEN или ключ API этого пользователя в заголовке Authorization: Bearer
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "Bearer <ADMIN_TOKEN или API key>"
+// @Param id path int true "User ID"
+// @Param key_id path int true "API key ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/api-keys/{key_id} [delete]
+func DeleteAPIKeyHandler(ctx *gin.Context) {
+	start := time.Now()
+	userID, keyID := ctx.Param("id"), ctx.Param("key_id")
+
+	result := database.DB.Where("id = ? AND user_id = ?", keyID, userID).Delete(&models.APIKey{})
+	if result.Error != nil {
+		logRequestDetails(ctx, start).Error("Failed to delete API key", zap.String("key_id", keyID), zap.Error(result.Error))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete API key"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		logRequestDetails(ctx, start).Info("No API key found with the given ID", zap.String("key_id", keyID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No API key found with the given ID"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("API key deleted", zap.String("user_id", userID), zap.String("key_id", keyID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "API key deleted successfully"})
+}
+
+// RequireAPIKey пропускает запрос только с действующим ключом API и запоминает владельца ключа.
+// Ключ передаётся в заголовке Authorization: Bearer <key>. Браузерный EventSource
+// не умеет задавать заголовки, поэтому ключ принимается и в параметре access_token.
+func RequireAPIKey(ctx *gin.Context) {
+	start := time.Now()
+	token := bearerToken(ctx)
+	if token == "" {
+		token = ctx.Query("access_token")
+	}
+	userID, ok := apiKeyOwner(ctx, start, token)
+	if !ok {
+		return
+	}
+
+	ctx.Set(authUserKey, userID)
+	ctx.Next()
+}
+
+// RequireKeyIssuer пропускает к ключам API пользователя :id запрос с токеном администратора
+// ADMIN_TOKEN или с действующим ключом API этого же пользователя.
+func RequireKeyIssuer(ctx *gin.Context) {
+	start := time.Now()
+	token := bearerToken(ctx)
+	admin := envs.ServerEnvs.ADMIN_TOKEN
+	if token != "" && admin != "" && subtle.ConstantTimeCompare([]byte(token), []byte(admin)) == 1 {
+		ctx.Next()
+		return
+	}
+
+	userID, ok := apiKeyOwner(ctx, start, token)
+	if !ok {
+		return
+	}
+	if strconv.Itoa(userID) != ctx.Param("id") {
+		logRequestDetails(ctx, start).Info("API key belongs to another user", zap.Int("auth_user_id", userID), zap.String("user_id", ctx.Param("id")))
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key belongs to another user"})
+		return
+	}
+
+	ctx.Set(authUserKey, userID)
+	ctx.Next()
+}
+
+// bearerToken возвращает токен из заголовка Authorization: Bearer <token>.
+func bearerToken(ctx *gin.Context) string {
+	return strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+}
+
+// apiKeyOwner находит пользователя, которому принадлежит ключ API token, и отмечает
+// использование ключа. Если ключа нет, он недействителен или его владелец архивирован,
+// запрос прерывается с ответом 401.
+func apiKeyOwner(ctx *gin.Context, start time.Time, token string) (int, bool) {
+	if token == "" {
+		logRequestDetails(ctx, start).Info("Missing API key")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key is required"})
+		return 0, false
+	}
+
+	var key models.APIKey
+	err := database.DB.Joins("JOIN users ON users.id = api_keys.user_id AND users.deleted_at IS NULL").
+		Where("api_keys.token_hash = ?", tokens.Hash(token)).
+		First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logRequestDetails(ctx, start).Info("Invalid API key")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return 0, false
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to check API key", zap.Error(err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check API key"})
+		return 0, false
+	}
+
+	// Время использования обновляется не чаще раза в минуту, чтобы не писать в базу на каждый запрос
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := database.DB.Model(&key).Update("last_used_at", now).Error; err != nil {
+			logRequestDetails(ctx, start).Warn("Failed to update API key usage", zap.Int("key_id", key.ID), zap.Error(err))
+		}
+	}
+	return key.UserID, true
+}
+
+// authUserID возвращает пользователя, которому принадлежит ключ API запроса.
+func authUserID(ctx *gin.Context) int {
+	return ctx.GetInt(authUserKey)
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"Reminders/internal/blobstore"
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Максимальный размер загружаемого вложения (лимит Telegram для фотографий - 10 МБ)
+var maxAttachmentSize = map[string]int64{
+	models.AttachmentPhoto:    10 << 20,
+	models.AttachmentDocument: 50 << 20,
+	models.AttachmentVoice:    50 << 20,
+}
+
+// UploadAttachmentHandler godoc
+// @Summary Загрузить вложение
+// @Description Загрузить фото, документ или голосовое сообщение к неотправленному напоминанию. Текст напоминания станет подписью
+// @Tags reminders
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Reminder ID"
+// @Param type formData string true "photo, document или voice"
+// @Param file formData file true "Attachment file"
+// @Success 200 {object} ReminderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /reminders/{id}/attachment [post]
+func UploadAttachmentHandler(ctx *gin.Context) {
+	start := time.Now()
+	reminderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Invalid reminder ID", zap.String("reminder_id", ctx.Param("id")))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return
+	}
+
+	attachmentType := ctx.PostForm("type")
+	limit, ok := maxAttachmentSize[attachmentType]
+	if !ok {
+		logRequestDetails(ctx, start).Info("Unknown attachment type", zap.String("attachment_type", attachmentType))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Type must be one of photo, document, voice"})
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		logRequestDetails(ctx, start).Info("No attachment file", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Form field file is required"})
+		return
+	}
+	if fileHeader.Size > limit {
+		logRequestDetails(ctx, start).Info("Attachment is too large", zap.Int64("size", fileHeader.Size))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be at most %d bytes", attachmentType, limit)})
+		return
+	}
+
+	reminder, err := findPendingReminder(database.DB, reminderID)
+	if err != nil {
+		respondReminderError(ctx, start, reminderID, err)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to read attachment", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read attachment"})
+		return
+	}
+	defer file.Close()
+
+	key, err := blobstore.Files.Put(file, fileHeader.Filename)
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to store attachment", zap.Int("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store attachment"})
+		return
+	}
+
+	staleKey := reminder.AttachmentKey
+	reminder.AttachmentType = attachmentType
+	reminder.AttachmentFileID = ""
+	reminder.AttachmentKey = key
+
+	// Текст напоминания становится подписью, на которую у Telegram свой лимит длины
+	if err := validateOptions(reminder); err != nil {
+		removeAttachment(key)
+		logRequestDetails(ctx, start).Info("Invalid reminder options", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reminder.UpdatedAt = time.Now()
+	if err := database.DB.Save(&reminder).Error; err != nil {
+		removeAttachment(key)
+		logRequestDetails(ctx, start).Error("Failed to update reminder", zap.Int("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reminder"})
+		return
+	}
+	removeAttachment(staleKey)
+
+	logRequestDetails(ctx, start).Info("Attachment uploaded", zap.Int("reminder_id", reminderID), zap.String("attachment_type", attachmentType), zap.Int64("size", fileHeader.Size))
+	ctx.JSON(http.StatusOK, ReminderResponse{Message: "Attachment uploaded successfully", Reminder: reminder})
+}
+
+// DeleteAttachmentHandler godoc
+// @Summary Удалить вложение
+// @Description Удалить вложение неотправленного напоминания
+// @Tags reminders
+// @Produce json
+// @Param id path int true "Reminder ID"
+// @Success 200 {object} ReminderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /reminders/{id}/attachment [delete]
+func DeleteAttachmentHandler(ctx *gin.Context) {
+	start := time.Now()
+	reminderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Invalid reminder ID", zap.String("reminder_id", ctx.Param("id")))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return
+	}
+
+	reminder, err := findPendingReminder(database.DB, reminderID)
+	if err != nil {
+		respondReminderError(ctx, start, reminderID, err)
+		return
+	}
+	if reminder.AttachmentType == "" {
+		logRequestDetails(ctx, start).Info("Reminder has no attachment", zap.Int("reminder_id", reminderID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Reminder has no attachment"})
+		return
+	}
+
+	staleKey := reminder.AttachmentKey
+	reminder.AttachmentType = ""
+	reminder.AttachmentFileID = ""
+	reminder.AttachmentKey = ""
+	if err := validateRequired(reminder); err != nil {
+		logRequestDetails(ctx, start).Info("Cannot remove the only content of a reminder", zap.Int("reminder_id", reminderID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Reminder without attachment needs a message"})
+		return
+	}
+
+	reminder.UpdatedAt = time.Now()
+	if err := database.DB.Save(&reminder).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to update reminder", zap.Int("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reminder"})
+		return
+	}
+	removeAttachment(staleKey)
+
+	logRequestDetails(ctx, start).Info("Attachment deleted", zap.Int("reminder_id", reminderID))
+	ctx.JSON(http.StatusOK, ReminderResponse{Message: "Attachment deleted successfully", Reminder: reminder})
+}
+
+// respondReminderError отвечает клиенту на ошибку поиска изменяемого напоминания.
+func respondReminderError(ctx *gin.Context, start time.Time, reminderID int, err error) {
+	switch {
+	case errors.Is(err, errReminderNotFound):
+		logRequestDetails(ctx, start).Info("No reminder found with the given ID", zap.Int("reminder_id", reminderID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No reminder found with the given ID"})
+	case errors.Is(err, errReminderAlreadySent):
+		logRequestDetails(ctx, start).Info("Reminder has already been sent", zap.Int("reminder_id", reminderID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Reminder has already been sent"})
+	case errors.Is(err, errReminderArchived):
+		logRequestDetails(ctx, start).Info("Reminder is archived", zap.Int("reminder_id", reminderID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Reminder is archived"})
+	default:
+		logRequestDetails(ctx, start).Error("Failed to find reminder", zap.Int("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find reminder"})
+	}
+}
@@ -0,0 +1,28 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAttachments(t *testing.T) {
+	photo, photoHeader := multipartBody(t, "photo.jpg", "\xff\xd8\xff\xe0 not really a jpeg", map[string]string{"type": "photo"})
+	unknown, unknownHeader := multipartBody(t, "song.mp3", "ID3", map[string]string{"type": "audio"})
+	runCases(t, []apiCase{
+		{name: "upload", method: http.MethodPost, path: "/reminders/1/attachment", status: http.StatusOK,
+			body: photo, header: photoHeader},
+		{name: "upload_unknown_type", method: http.MethodPost, path: "/reminders/1/attachment", status: http.StatusBadRequest,
+			body: unknown, header: unknownHeader},
+		{name: "upload_already_sent", method: http.MethodPost, path: "/reminders/4/attachment", status: http.StatusBadRequest,
+			body: photo, header: photoHeader},
+		{name: "upload_not_found", method: http.MethodPost, path: "/reminders/99/attachment", status: http.StatusNotFound,
+			body: photo, header: photoHeader},
+		{name: "delete", method: http.MethodDelete, path: "/reminders/1/attachment", status: http.StatusOK,
+			setup: func(t *testing.T, router http.Handler) {
+				if rec := do(router, http.MethodPost, "/reminders/1/attachment", photo, photoHeader); rec.Code != http.StatusOK {
+					t.Fatalf("upload attachment: %d %s", rec.Code, rec.Body)
+				}
+			}},
+		{name: "delete_without_attachment", method: http.MethodDelete, path: "/reminders/1/attachment", status: http.StatusNotFound},
+	})
+}
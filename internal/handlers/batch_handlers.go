@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+// Максимальное количество элементов в одном пакетном запросе
+const maxBatchSize = 100
+
+// Режимы выполнения пакетных операций
+const (
+	// BatchModeAtomic - все элементы применяются в одной транзакции, при любой ошибке всё откатывается
+	BatchModeAtomic = "atomic"
+	// BatchModeBestEffort - успешные элементы сохраняются, ошибочные пропускаются
+	BatchModeBestEffort = "best_effort"
+)
+
+// Статусы элементов в ответе пакетного запроса
+const (
+	BatchStatusCreated    = "created"
+	BatchStatusUpdated    = "updated"
+	BatchStatusDeleted    = "deleted"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back"
+)
+
+var errBatchFailed = errors.New("batch failed")
+
+// BatchCreateRequest - тело запроса пакетного создания напоминаний
+type BatchCreateRequest struct {
+	Mode  string            `json:"mode" example:"atomic"`
+	Items []models.Reminder `json:"items"`
+}
+
+// BatchUpdateRequest - тело запроса пакетного обновления напоминаний, id каждого элемента обязателен
+type BatchUpdateRequest struct {
+	Mode  string            `json:"mode" example:"best_effort"`
+	Items []models.Reminder `json:"items"`
+}
+
+// BatchDeleteRequest - тело запроса пакетного удаления напоминаний
+type BatchDeleteRequest struct {
+	Mode string `json:"mode" example:"atomic"`
+	IDs  []int  `json:"ids"`
+}
+
+// BatchItemResult - результат обработки одного элемента пакета
+type BatchItemResult struct {
+	Index    int              `json:"index"`
+	ID       int              `json:"id,omitempty"`
+	Status   string           `json:"status"`
+	Error    string           `json:"error,omitempty"`
+	Reminder *models.Reminder `json:"reminder,omitempty"`
+}
+
+// BatchResponse - ответ пакетного запроса
+type BatchResponse struct {
+	Mode      string            `json:"mode"`
+	Committed bool              `json:"committed"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// BatchActionHandler направляет запросы вида POST /reminders:<action> в соответствующий обработчик.
+// Gin не поддерживает двоеточие внутри статического сегмента, поэтому действие приходит параметром.
+func BatchActionHandler(ctx *gin.Context) {
+	switch ctx.Param("action") {
+	case ":batchCreate":
+		BatchCreateHandler(ctx)
+	case ":batchUpdate":
+		BatchUpdateHandler(ctx)
+	case ":batchDelete":
+		BatchDeleteHandler(ctx)
+	default:
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Unknown action"})
+	}
+}
+
+// BatchCreateHandler godoc
+// @Summary Пакетное создание напоминаний
+// @Description Создать несколько напоминаний в одной транзакции. Режим atomic откатывает весь пакет при любой ошибке, best_effort сохраняет успешные элементы
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param batch body BatchCreateRequest true "Reminders to create"
+// @Success 201 {object} BatchResponse
+// @Success 207 {object} BatchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} BatchResponse
+// @Router /reminders:batchCreate [post]
+func BatchCreateHandler(ctx *gin.Context) {
+	start := time.Now()
+	var req BatchCreateRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if !checkBatchRequest(ctx, start, &req.Mode, len(req.Items)) {
+		return
+	}
+
+	resp := runBatch(req.Mode, len(req.Items), func(tx *gorm.DB, i int) BatchItemResult {
+		reminder := req.Items[i]
+		if err := createReminder(tx, &reminder); err != nil {
+			return BatchItemResult{Index: i, Status: BatchStatusFailed, Error: err.Error()}
+		}
+		return BatchItemResult{Index: i, ID: reminder.ID, Status: BatchStatusCreated, Reminder: &reminder}
+	})
+
+	writeBatchResponse(ctx, start, http.StatusCreated, resp)
+}
+
+// BatchUpdateHandler godoc
+// @Summary Пакетное обновление напоминаний
+// @Description Обновить несколько неотправленных напоминаний в одной транзакции
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param batch body BatchUpdateRequest true "Reminders to update"
+// @Success 200 {object} BatchResponse
+// @Success 207 {object} BatchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} BatchResponse
+// @Router /reminders:batchUpdate [post]
+func BatchUpdateHandler(ctx *gin.Context) {
+	start := time.Now()
+	var req BatchUpdateRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if !checkBatchRequest(ctx, start, &req.Mode, len(req.Items)) {
+		return
+	}
+
+	staleKeys := make([]string, len(req.Items))
+	resp := runBatch(req.Mode, len(req.Items), func(tx *gorm.DB, i int) BatchItemResult {
+		item := req.Items[i]
+		reminder, staleKey, err := updateReminder(tx, item.ID, item)
+		if err != nil {
+			return BatchItemResult{Index: i, ID: item.ID, Status: BatchStatusFailed, Error: err.Error()}
+		}
+		staleKeys[i] = staleKey
+		return BatchItemResult{Index: i, ID: reminder.ID, Status: BatchStatusUpdated, Reminder: &reminder}
+	})
+	removeCommittedAttachments(resp, staleKeys)
+
+	writeBatchResponse(ctx, start, http.StatusOK, resp)
+}
+
+// BatchDeleteHandler godoc
+// @Summary Пакетное удаление напоминаний
+// @Description Удалить несколько неотправленных напоминаний в одной транзакции
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param batch body BatchDeleteRequest true "Reminder IDs to delete"
+// @Success 200 {object} BatchResponse
+// @Success 207 {object} BatchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} BatchResponse
+// @Router /reminders:batchDelete [post]
+func BatchDeleteHandler(ctx *gin.Context) {
+	start := time.Now()
+	var req BatchDeleteRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if !checkBatchRequest(ctx, start, &req.Mode, len(req.IDs)) {
+		return
+	}
+
+	staleKeys := make([]string, len(req.IDs))
+	resp := runBatch(req.Mode, len(req.IDs), func(tx *gorm.DB, i int) BatchItemResult {
+		id := req.IDs[i]
+		staleKey, err := deleteReminder(tx, id)
+		if err != nil {
+			return BatchItemResult{Index: i, ID: id, Status: BatchStatusFailed, Error: err.Error()}
+		}
+		staleKeys[i] = staleKey
+		return BatchItemResult{Index: i, ID: id, Status: BatchStatusDeleted}
+	})
+	removeCommittedAttachments(resp, staleKeys)
+
+	writeBatchResponse(ctx, start, http.StatusOK, resp)
+}
+
+// checkBatchRequest проверяет режим и размер пакета, при ошибке отвечает клиенту 400.
+func checkBatchRequest(ctx *gin.Context, start time.Time, mode *string, size int) bool {
+	if *mode == "" {
+		*mode = BatchModeAtomic
+	}
+	if *mode != BatchModeAtomic && *mode != BatchModeBestEffort {
+		logRequestDetails(ctx, start).Info("Unknown batch mode", zap.String("mode", *mode))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Mode must be either atomic or best_effort"})
+		return false
+	}
+	if size == 0 {
+		logRequestDetails(ctx, start).Info("Empty batch")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Batch must contain at least one item"})
+		return false
+	}
+	if size > maxBatchSize {
+		logRequestDetails(ctx, start).Info("Batch is too large", zap.Int("size", size))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Batch must contain at most %d items", maxBatchSize)})
+		return false
+	}
+	return true
+}
+
+// runBatch выполняет элементы пакета в одной транзакции, изолируя каждый элемент точкой сохранения.
+func runBatch(mode string, size int, apply func(tx *gorm.DB, i int) BatchItemResult) BatchResponse {
+	resp := BatchResponse{Mode: mode, Results: make([]BatchItemResult, 0, size)}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < size; i++ {
+			savePoint := fmt.Sprintf("batch_item_%d", i)
+			if err := tx.SavePoint(savePoint).Error; err != nil {
+				return err
+			}
+
+			result := apply(tx, i)
+			if result.Status == BatchStatusFailed {
+				if err := tx.RollbackTo(savePoint).Error; err != nil {
+					return err
+				}
+				resp.Failed++
+			} else {
+				resp.Succeeded++
+			}
+			resp.Results = append(resp.Results, result)
+		}
+
+		if mode == BatchModeAtomic && resp.Failed > 0 {
+			return errBatchFailed
+		}
+		return nil
+	})
+
+	if err != nil {
+		// Транзакция откатилась - ни один элемент не сохранён
+		for i := range resp.Results {
+			if resp.Results[i].Status != BatchStatusFailed {
+				resp.Results[i].Status = BatchStatusRolledBack
+				resp.Results[i].Reminder = nil
+			}
+		}
+		if !errors.Is(err, errBatchFailed) {
+			for i := len(resp.Results); i < size; i++ {
+				resp.Results = append(resp.Results, BatchItemResult{Index: i, Status: BatchStatusFailed, Error: err.Error()})
+			}
+		}
+		resp.Succeeded = 0
+		resp.Failed = 0
+		for _, result := range resp.Results {
+			if result.Status == BatchStatusFailed {
+				resp.Failed++
+			}
+		}
+		return resp
+	}
+
+	resp.Committed = true
+	return resp
+}
+
+// removeCommittedAttachments удаляет файлы вложений элементов, изменения которых зафиксированы.
+func removeCommittedAttachments(resp BatchResponse, staleKeys []string) {
+	if !resp.Committed {
+		return
+	}
+	for _, result := range resp.Results {
+		if result.Status != BatchStatusFailed {
+			removeAttachment(staleKeys[result.Index])
+		}
+	}
+}
+
+// writeBatchResponse логирует результат пакета и выбирает код ответа.
+func writeBatchResponse(ctx *gin.Context, start time.Time, okStatus int, resp BatchResponse) {
+	log := logRequestDetails(ctx, start).With(
+		zap.String("mode", resp.Mode),
+		zap.Int("succeeded", resp.Succeeded),
+		zap.Int("failed", resp.Failed),
+	)
+
+	switch {
+	case !resp.Committed:
+		log.Info("Batch rolled back")
+		ctx.JSON(http.StatusUnprocessableEntity, resp)
+	case resp.Failed > 0:
+		log.Info("Batch partially applied")
+		ctx.JSON(http.StatusMultiStatus, resp)
+	default:
+		log.Info("Batch applied successfully")
+		ctx.JSON(okStatus, resp)
+	}
+}
@@ -0,0 +1,29 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "create", method: http.MethodPost, path: "/reminders:batchCreate", status: http.StatusCreated,
+			body: `{"mode": "atomic", "items": [{"user_id": 2, "message": "One", "send_at": "2030-02-01T10:00:00Z"}, {"user_id": 2, "message": "Two", "send_at": "2030-02-02T10:00:00Z"}]}`},
+		{name: "create_atomic_invalid", method: http.MethodPost, path: "/reminders:batchCreate", status: http.StatusUnprocessableEntity,
+			body: `{"mode": "atomic", "items": [{"user_id": 2, "message": "One", "send_at": "2030-02-01T10:00:00Z"}, {"user_id": 2, "send_at": "2030-02-02T10:00:00Z"}]}`},
+		{name: "create_best_effort", method: http.MethodPost, path: "/reminders:batchCreate", status: http.StatusMultiStatus,
+			body: `{"mode": "best_effort", "items": [{"user_id": 2, "message": "One", "send_at": "2030-02-01T10:00:00Z"}, {"user_id": 99, "message": "Two", "send_at": "2030-02-02T10:00:00Z"}]}`},
+		{name: "create_bad_json", method: http.MethodPost, path: "/reminders:batchCreate", status: http.StatusBadRequest,
+			body: `{"items": {}}`},
+		{name: "update", method: http.MethodPost, path: "/reminders:batchUpdate", status: http.StatusOK,
+			body: `{"items": [{"id": 1, "user_id": 1, "message": "Отчёт", "send_at": "2030-01-20T09:00:00Z"}]}`},
+		{name: "update_already_sent", method: http.MethodPost, path: "/reminders:batchUpdate", status: http.StatusUnprocessableEntity,
+			body: `{"items": [{"id": 4, "user_id": 1, "message": "Счёт", "send_at": "2030-01-20T09:00:00Z"}]}`},
+		{name: "delete", method: http.MethodPost, path: "/reminders:batchDelete", status: http.StatusOK,
+			body: `{"ids": [1, 3]}`},
+		{name: "delete_best_effort", method: http.MethodPost, path: "/reminders:batchDelete", status: http.StatusMultiStatus,
+			body: `{"mode": "best_effort", "ids": [1, 4, 99]}`},
+		{name: "unknown_action", method: http.MethodPost, path: "/reminders:batchMove", status: http.StatusNotFound,
+			body: `{"ids": [1]}`},
+	})
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/escalation"
+	"Reminders/internal/models"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errInvalidPolicy = errors.New("invalid escalation policy")
+
+// GetEscalationPoliciesHandler godoc
+// @Summary Список политик эскалации
+// @Description Получить политики эскалации всех пользователей или одного пользователя
+// @Tags escalation
+// @Produce json
+// @Param user_id query int false "User ID"
+// @Success 200 {object} EscalationPoliciesResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /escalation-policies [get]
+func GetEscalationPoliciesHandler(ctx *gin.Context) {
+	start := time.Now()
+	query := database.DB.Order("id")
+	if userID := ctx.Query("user_id"); userID != "" {
+		if _, err := strconv.Atoi(userID); err != nil {
+			logRequestDetails(ctx, start).Info("Invalid user ID", zap.String("user_id", userID))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+
+	policies := []models.EscalationPolicy{}
+	if err := query.Find(&policies).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch escalation policies", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch escalation policies"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Escalation policies fetched successfully", zap.Int("row_count", len(policies)))
+	ctx.JSON(http.StatusOK, EscalationPoliciesResponse{EscalationPolicies: policies})
+}
+
+// GetEscalationPolicyHandler godoc
+// @Summary Получить политику эскалации
+// @Tags escalation
+// @Produce json
+// @Param id path int true "Policy ID"
+// @Success 200 {object} EscalationPolicyResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /escalation-policies/{id} [get]
+func GetEscalationPolicyHandler(ctx *gin.Context) {
+	start := time.Now()
+	policy, ok := findEscalationPolicy(ctx, start)
+	if !ok {
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Escalation policy fetched successfully", zap.Int("policy_id", policy.ID))
+	ctx.JSON(http.StatusOK, EscalationPolicyResponse{EscalationPolicy: policy})
+}
+
+// CreateEscalationPolicyHandler godoc
+// @Summary Создать политику эскалации
+// @Description Создать политику эскалации для важных напоминаний. Шаги выполняются через after_minutes минут после отправки, пока напоминание не подтверждено: resend повторяет напоминание, chat отправляет его в дополнительный чат, contact - запасному контакту
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param policy body models.EscalationPolicy true "Escalation policy"
+// @Success 201 {object} EscalationPolicyResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /escalation-policies [post]
+func CreateEscalationPolicyHandler(ctx *gin.Context) {
+	start := time.Now()
+	var policy models.EscalationPolicy
+
+	if err := ctx.ShouldBindJSON(&policy); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if !checkEscalationPolicy(ctx, start, policy) {
+		return
+	}
+
+	policy.ID = 0
+	policy.CreatedAt = time.Now()
+	policy.UpdatedAt = time.Now()
+	if err := database.DB.Create(&policy).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to create escalation policy", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create escalation policy"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Escalation policy created successfully", zap.Int("policy_id", policy.ID))
+	ctx.JSON(http.StatusCreated, EscalationPolicyResponse{Message: "Escalation policy created successfully", EscalationPolicy: policy})
+}
+
+// UpdateEscalationPolicyHandler godoc
+// @Summary Обновить политику эскалации
+// @Description Обновить политику эскалации. Уже запланированные шаги отправленных напоминаний не меняются
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param id path int true "Policy ID"
+// @Param policy body models.EscalationPolicy true "Escalation policy"
+// @Success 200 {object} EscalationPolicyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /escalation-policies/{id} [put]
+func UpdateEscalationPolicyHandler(ctx *gin.Context) {
+	start := time.Now()
+	var upd models.EscalationPolicy
+
+	if err := ctx.ShouldBindJSON(&upd); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	policy, ok := findEscalationPolicy(ctx, start)
+	if !ok {
+		return
+	}
+	// Владелец политики не меняется, иначе на неё ссылались бы напоминания другого пользователя
+	upd.UserID = policy.UserID
+	if !checkEscalationPolicy(ctx, start, upd) {
+		return
+	}
+
+	policy.Name = upd.Name
+	policy.Steps = upd.Steps
+	policy.UpdatedAt = time.Now()
+	if err := database.DB.Save(&policy).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to update escalation policy", zap.Int("policy_id", policy.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update escalation policy"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Escalation policy updated successfully", zap.Int("policy_id", policy.ID))
+	ctx.JSON(http.StatusOK, EscalationPolicyResponse{Message: "Escalation policy updated successfully", EscalationPolicy: policy})
+}
+
+// DeleteEscalationPolicyHandler godoc
+// @Summary Удалить политику эскалации
+// @Description Удалить политику эскалации. Напоминания с этой политикой больше не эскалируются
+// @Tags escalation
+// @Produce json
+// @Param id path int true "Policy ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /escalation-policies/{id} [delete]
+func DeleteEscalationPolicyHandler(ctx *gin.Context) {
+	start := time.Now()
+	policy, ok := findEscalationPolicy(ctx, start)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&policy).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to delete escalation policy", zap.Int("policy_id", policy.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete escalation policy"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Escalation policy deleted successfully", zap.Int("policy_id", policy.ID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "Escalation policy deleted successfully"})
+}
+
+// AckReminderHandler godoc
+// @Summary Подтвердить напоминание
+// @Description Подтвердить последнюю отправку напоминания и отменить оставшиеся шаги эскалации. В Telegram то же делает кнопка под сообщением
+// @Tags reminders
+// @Produce json
+// @Param id path int true "Reminder ID"
+// @Success 200 {object} ReminderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /reminders/{id}/ack [post]
+func AckReminderHandler(ctx *gin.Context) {
+	start := time.Now()
+	reminderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Invalid reminder ID", zap.String("reminder_id", ctx.Param("id")))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return
+	}
+
+	reminder, err := escalation.Acknowledge(database.DB, reminderID, escalation.SourceAPI)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		logRequestDetails(ctx, start).Info("No reminder found with the given ID", zap.Int("reminder_id", reminderID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No reminder found with the given ID"})
+		return
+	case errors.Is(err, escalation.ErrNotSent):
+		logRequestDetails(ctx, start).Info("Reminder has not been sent yet", zap.Int("reminder_id", reminderID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Reminder has not been sent yet"})
+		return
+	case err != nil:
+		logRequestDetails(ctx, start).Error("Failed to acknowledge reminder", zap.Int("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge reminder"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Reminder acknowledged", zap.Int("reminder_id", reminderID))
+	ctx.JSON(http.StatusOK, ReminderResponse{Message: "Reminder acknowledged successfully", Reminder: reminder})
+}
+
+// findEscalationPolicy ищет политику из параметра id, иначе отвечает клиенту.
+func findEscalationPolicy(ctx *gin.Context, start time.Time) (models.EscalationPolicy, bool) {
+	policyID := ctx.Param("id")
+	var policy models.EscalationPolicy
+
+	err := database.DB.First(&policy, "id = ?", policyID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logRequestDetails(ctx, start).Info("No escalation policy found with the given ID", zap.String("policy_id", policyID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No escalation policy found with the given ID"})
+		return policy, false
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to find escalation policy", zap.String("policy_id", policyID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find escalation policy"})
+		return policy, false
+	}
+	return policy, true
+}
+
+// checkEscalationPolicy проверяет политику, её владельца и запасные контакты, иначе отвечает клиенту.
+func checkEscalationPolicy(ctx *gin.Context, start time.Time, policy models.EscalationPolicy) bool {
+	err := validateEscalationPolicy(policy)
+	if err == nil {
+		err = checkUser(database.DB, policy.UserID)
+		for _, step := range policy.Steps {
+			if err == nil && step.Action == models.EscalateContact {
+				err = checkUser(database.DB, step.ContactUserID)
+			}
+		}
+	}
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errUserNotFound), errors.Is(err, errInvalidPolicy):
+		logRequestDetails(ctx, start).Info("Invalid escalation policy", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		logRequestDetails(ctx, start).Error("Failed to check escalation policy", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check escalation policy"})
+	}
+	return false
+}
+
+// validateEscalationPolicy проверяет обязательные поля и шаги политики.
+func validateEscalationPolicy(policy models.EscalationPolicy) error {
+	if policy.UserID <= 0 {
+		return fmt.Errorf("%w: user_id is required", errInvalidPolicy)
+	}
+	if policy.Name == "" {
+		return fmt.Errorf("%w: name is required", errInvalidPolicy)
+	}
+	if err := policy.Steps.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidPolicy, err)
+	}
+	return nil
+}
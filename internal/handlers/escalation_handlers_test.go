@@ -0,0 +1,29 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEscalationPolicies(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "list", method: http.MethodGet, path: "/escalation-policies?user_id=1", status: http.StatusOK},
+		{name: "get", method: http.MethodGet, path: "/escalation-policies/1", status: http.StatusOK},
+		{name: "get_not_found", method: http.MethodGet, path: "/escalation-policies/99", status: http.StatusNotFound},
+		{name: "create", method: http.MethodPost, path: "/escalation-policies", status: http.StatusCreated,
+			body: `{"user_id": 1, "name": "Важное", "steps": [{"after_minutes": 10, "action": "resend"}, {"after_minutes": 30, "action": "contact", "contact_user_id": 2}]}`},
+		{name: "create_invalid_step", method: http.MethodPost, path: "/escalation-policies", status: http.StatusBadRequest,
+			body: `{"user_id": 1, "name": "Важное", "steps": [{"after_minutes": 10, "action": "call"}]}`},
+		{name: "update", method: http.MethodPut, path: "/escalation-policies/1", status: http.StatusOK,
+			body: `{"user_id": 1, "name": "Лекарства", "steps": [{"after_minutes": 5, "action": "resend"}]}`},
+		{name: "update_not_found", method: http.MethodPut, path: "/escalation-policies/99", status: http.StatusNotFound,
+			body: `{"user_id": 1, "name": "x", "steps": [{"after_minutes": 5, "action": "resend"}]}`},
+		{name: "delete", method: http.MethodDelete, path: "/escalation-policies/1", status: http.StatusOK},
+		{name: "delete_not_found", method: http.MethodDelete, path: "/escalation-policies/99", status: http.StatusNotFound},
+
+		{name: "ack", method: http.MethodPost, path: "/reminders/4/ack", status: http.StatusOK},
+		{name: "ack_not_sent", method: http.MethodPost, path: "/reminders/1/ack", status: http.StatusBadRequest},
+		{name: "ack_not_found", method: http.MethodPost, path: "/reminders/99/ack", status: http.StatusNotFound},
+		{name: "ack_invalid_id", method: http.MethodPost, path: "/reminders/x/ack", status: http.StatusBadRequest},
+	})
+}
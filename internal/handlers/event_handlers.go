@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"Reminders/internal/anchors"
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errInvalidEvent = errors.New("invalid event")
+
+// GetEventsHandler godoc
+// @Summary Список событий
+// @Description Получить события всех пользователей или одного пользователя
+// @Tags events
+// @Produce json
+// @Param user_id query int false "User ID"
+// @Success 200 {object} EventsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /events [get]
+func GetEventsHandler(ctx *gin.Context) {
+	start := time.Now()
+	query := database.DB.Order("starts_at, id")
+	if userID := ctx.Query("user_id"); userID != "" {
+		if _, err := strconv.Atoi(userID); err != nil {
+			logRequestDetails(ctx, start).Info("Invalid user ID", zap.String("user_id", userID))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+
+	events := []models.Event{}
+	if err := query.Find(&events).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch events", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Events fetched successfully", zap.Int("row_count", len(events)))
+	ctx.JSON(http.StatusOK, EventsResponse{Events: events})
+}
+
+// GetEventHandler godoc
+// @Summary Получить событие
+// @Tags events
+// @Produce json
+// @Param id path int true "Event ID"
+// @Success 200 {object} EventResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /events/{id} [get]
+func GetEventHandler(ctx *gin.Context) {
+	start := time.Now()
+	event, ok := findEvent(ctx, start)
+	if !ok {
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Event fetched successfully", zap.Int("event_id", event.ID))
+	ctx.JSON(http.StatusOK, EventResponse{Event: event})
+}
+
+// CreateEventHandler godoc
+// @Summary Создать событие
+// @Description Создать событие, относительно которого задаются напоминания: anchor_event_id и anchor_offset_minutes (отрицательное смещение - до события)
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param event body models.Event true "Event"
+// @Success 201 {object} EventResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /events [post]
+func CreateEventHandler(ctx *gin.Context) {
+	start := time.Now()
+	var event models.Event
+
+	if err := ctx.ShouldBindJSON(&event); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if !checkEvent(ctx, start, event) {
+		return
+	}
+
+	event.ID = 0
+	event.CreatedAt = time.Now()
+	event.UpdatedAt = time.Now()
+	if err := database.DB.Create(&event).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to create event", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Event created successfully", zap.Int("event_id", event.ID))
+	ctx.JSON(http.StatusCreated, EventResponse{Message: "Event created successfully", Event: event})
+}
+
+// UpdateEventHandler godoc
+// @Summary Обновить событие
+// @Description Обновить событие. Неотправленные напоминания, привязанные к событию, переносятся вместе с ним
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param id path int true "Event ID"
+// @Param event body models.Event true "Event"
+// @Success 200 {object} UpdateEventResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /events/{id} [put]
+func UpdateEventHandler(ctx *gin.Context) {
+	start := time.Now()
+	var upd models.Event
+
+	if err := ctx.ShouldBindJSON(&upd); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	event, ok := findEvent(ctx, start)
+	if !ok {
+		return
+	}
+	// Владелец события не меняется, иначе к нему оказались бы привязаны чужие напоминания
+	upd.UserID = event.UserID
+	if !checkEvent(ctx, start, upd) {
+		return
+	}
+
+	event.Name = upd.Name
+	event.StartsAt = upd.StartsAt
+	event.UpdatedAt = time.Now()
+
+	var rescheduled int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&event).Error; err != nil {
+			return err
+		}
+		var err error
+		rescheduled, err = anchors.RescheduleEvent(tx, event.ID)
+		return err
+	})
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to update event", zap.Int("event_id", event.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Event updated successfully", zap.Int("event_id", event.ID), zap.Int("rescheduled", rescheduled))
+	ctx.JSON(http.StatusOK, UpdateEventResponse{Message: "Event updated successfully", Event: event, Rescheduled: rescheduled})
+}
+
+// DeleteEventHandler godoc
+// @Summary Удалить событие
+// @Description Удалить событие. Привязанные к нему напоминания остаются со своим последним временем отправки
+// @Tags events
+// @Produce json
+// @Param id path int true "Event ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /events/{id} [delete]
+func DeleteEventHandler(ctx *gin.Context) {
+	start := time.Now()
+	event, ok := findEvent(ctx, start)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&event).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to delete event", zap.Int("event_id", event.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Event deleted successfully", zap.Int("event_id", event.ID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "Event deleted successfully"})
+}
+
+// findEvent ищет событие из параметра id, иначе отвечает клиенту.
+func findEvent(ctx *gin.Context, start time.Time) (models.Event, bool) {
+	eventID := ctx.Param("id")
+	var event models.Event
+
+	err := database.DB.First(&event, "id = ?", eventID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logRequestDetails(ctx, start).Info("No event found with the given ID", zap.String("event_id", eventID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No event found with the given ID"})
+		return event, false
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to find event", zap.String("event_id", eventID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find event"})
+		return event, false
+	}
+	return event, true
+}
+
+// checkEvent проверяет обязательные поля события и его владельца, иначе отвечает клиенту.
+func checkEvent(ctx *gin.Context, start time.Time, event models.Event) bool {
+	err := validateEvent(event)
+	if err == nil {
+		err = checkUser(database.DB, event.UserID)
+	}
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errUserNotFound), errors.Is(err, errInvalidEvent):
+		logRequestDetails(ctx, start).Info("Invalid event", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		logRequestDetails(ctx, start).Error("Failed to check event", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check event"})
+	}
+	return false
+}
+
+// validateEvent проверяет обязательные поля события.
+func validateEvent(event models.Event) error {
+	if event.UserID <= 0 {
+		return fmt.Errorf("%w: user_id is required", errInvalidEvent)
+	}
+	if event.Name == "" {
+		return fmt.Errorf("%w: name is required", errInvalidEvent)
+	}
+	if event.StartsAt.IsZero() {
+		return fmt.Errorf("%w: starts_at is required", errInvalidEvent)
+	}
+	return nil
+}
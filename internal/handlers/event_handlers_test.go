@@ -0,0 +1,24 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "list", method: http.MethodGet, path: "/events?user_id=1", status: http.StatusOK},
+		{name: "get", method: http.MethodGet, path: "/events/1", status: http.StatusOK},
+		{name: "get_not_found", method: http.MethodGet, path: "/events/99", status: http.StatusNotFound},
+		{name: "create", method: http.MethodPost, path: "/events", status: http.StatusCreated,
+			body: `{"user_id": 2, "name": "Dentist", "starts_at": "2030-03-01T08:00:00Z"}`},
+		{name: "create_bad_json", method: http.MethodPost, path: "/events", status: http.StatusBadRequest,
+			body: `{"starts_at": "tomorrow"}`},
+		{name: "update_moves_reminders", method: http.MethodPut, path: "/events/1", status: http.StatusOK,
+			body: `{"name": "Созвон с командой", "starts_at": "2030-01-16T12:00:00Z"}`},
+		{name: "update_not_found", method: http.MethodPut, path: "/events/99", status: http.StatusNotFound,
+			body: `{"name": "x", "starts_at": "2030-01-16T12:00:00Z"}`},
+		{name: "delete", method: http.MethodDelete, path: "/events/1", status: http.StatusOK},
+		{name: "delete_not_found", method: http.MethodDelete, path: "/events/99", status: http.StatusNotFound},
+	})
+}
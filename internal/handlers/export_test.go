@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+)
+
+// SetWebhookClient заменяет клиент повторной доставки вебхуков и возвращает функцию,
+// восстанавливающую прежний. Тесты доставляют вебхуки на локальный httptest.Server,
+// с которым рабочий клиент соединяться не станет.
+func SetWebhookClient(c *http.Client) (restore func()) {
+	prev := webhookClient
+	webhookClient = c
+	return func() { webhookClient = prev }
+}
+
+// SetStreamPollInterval меняет период проверки журнала потоком изменений и возвращает
+// функцию, восстанавливающую прежний.
+func SetStreamPollInterval(d time.Duration) (restore func()) {
+	prev := streamPollInterval
+	streamPollInterval = d
+	return func() { streamPollInterval = prev }
+}
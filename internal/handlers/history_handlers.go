@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DeliveriesResponse - доставка одного повторения напоминания по получателям
+type DeliveriesResponse struct {
+	Occurrence int                       `json:"occurrence"`
+	Status     string                    `json:"status,omitempty" example:"partial"`
+	Sent       int                       `json:"sent"`
+	Failed     int                       `json:"failed"`
+	Deliveries []models.ReminderDelivery `json:"deliveries"`
+}
+
+// GetReminderHistoryHandler godoc
+// @Summary История напоминания
+// @Description Получить историю доставки напоминания: переносы из-за тихих часов и режима «не беспокоить», отправки
+// @Tags reminders
+// @Produce json
+// @Param id path int true "Reminder ID"
+// @Success 200 {object} HistoryResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /reminders/{id}/history [get]
+func GetReminderHistoryHandler(ctx *gin.Context) {
+	start := time.Now()
+	reminderID := ctx.Param("id")
+
+	var reminder models.Reminder
+	err := database.DB.Select("id").First(&reminder, "id = ?", reminderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logRequestDetails(ctx, start).Info("No reminder found with the given ID", zap.String("reminder_id", reminderID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No reminder found with the given ID"})
+		return
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to find reminder", zap.String("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find reminder"})
+		return
+	}
+
+	history := []models.ReminderHistory{}
+	if err := database.DB.Where("reminder_id = ?", reminder.ID).Order("created_at, id").Find(&history).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch reminder history", zap.String("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminder history"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Reminder history fetched successfully", zap.String("reminder_id", reminderID), zap.Int("row_count", len(history)))
+	ctx.JSON(http.StatusOK, HistoryResponse{History: history})
+}
+
+// GetReminderDeliveriesHandler godoc
+// @Summary Доставка напоминания по получателям
+// @Description Получить результаты доставки повторения напоминания в каждый чат и общее состояние: sent, partial или failed. По умолчанию - последнее отправленное повторение
+// @Tags reminders
+// @Produce json
+// @Param id path int true "Reminder ID"
+// @Param occurrence query int false "Номер повторения, начиная с 1"
+// @Success 200 {object} DeliveriesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /reminders/{id}/deliveries [get]
+func GetReminderDeliveriesHandler(ctx *gin.Context) {
+	start := time.Now()
+	reminderID := ctx.Param("id")
+
+	var reminder models.Reminder
+	err := database.DB.Select("id", "sent_count").First(&reminder, "id = ?", reminderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logRequestDetails(ctx, start).Info("No reminder found with the given ID", zap.String("reminder_id", reminderID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No reminder found with the given ID"})
+		return
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to find reminder", zap.String("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find reminder"})
+		return
+	}
+
+	resp := DeliveriesResponse{Occurrence: reminder.SentCount, Deliveries: []models.ReminderDelivery{}}
+	if v := ctx.Query("occurrence"); v != "" {
+		if resp.Occurrence, err = strconv.Atoi(v); err != nil || resp.Occurrence <= 0 {
+			logRequestDetails(ctx, start).Info("Invalid occurrence", zap.String("occurrence", v))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid occurrence"})
+			return
+		}
+	}
+
+	err = database.DB.Where("reminder_id = ? AND occurrence = ?", reminder.ID, resp.Occurrence).
+		Order("id").
+		Find(&resp.Deliveries).Error
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch reminder deliveries", zap.String("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminder deliveries"})
+		return
+	}
+
+	for _, d := range resp.Deliveries {
+		if d.Status == models.DeliverySent {
+			resp.Sent++
+		} else {
+			resp.Failed++
+		}
+	}
+	if len(resp.Deliveries) > 0 {
+		resp.Status = models.DeliveryStatus(resp.Sent, resp.Failed)
+	}
+
+	logRequestDetails(ctx, start).Info("Reminder deliveries fetched successfully", zap.String("reminder_id", reminderID), zap.Int("occurrence", resp.Occurrence), zap.Int("row_count", len(resp.Deliveries)))
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,39 @@
+package handlers_test
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// addDeliveryHistory записывает отложенную и выполненную отправку напоминания 4.
+func addDeliveryHistory(t *testing.T, _ http.Handler) {
+	sendAt := time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC)
+	deferred := sendAt.Add(time.Hour)
+	history := []models.ReminderHistory{
+		{ReminderID: 4, Action: "deferred", Reason: "quiet_hours", SendAt: sendAt, DeferredUntil: &deferred, CreatedAt: sendAt},
+		{ReminderID: 4, Action: "sent", SendAt: deferred, CreatedAt: deferred},
+	}
+	userID := 1
+	deliveries := []models.ReminderDelivery{
+		{ReminderID: 4, UserID: &userID, ChatID: 100000001, Occurrence: 1, Status: "sent", CreatedAt: deferred},
+	}
+	if err := database.DB.Create(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := database.DB.Create(&deliveries).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "history", method: http.MethodGet, path: "/reminders/4/history", status: http.StatusOK, setup: addDeliveryHistory},
+		{name: "history_empty", method: http.MethodGet, path: "/reminders/1/history", status: http.StatusOK},
+		{name: "history_not_found", method: http.MethodGet, path: "/reminders/99/history", status: http.StatusNotFound},
+		{name: "deliveries", method: http.MethodGet, path: "/reminders/4/deliveries", status: http.StatusOK, setup: addDeliveryHistory},
+		{name: "deliveries_not_found", method: http.MethodGet, path: "/reminders/99/deliveries", status: http.StatusNotFound},
+	})
+}
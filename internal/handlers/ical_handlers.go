@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/envs"
+	"Reminders/internal/ical"
+	"Reminders/internal/models"
+	"Reminders/internal/recurrence"
+	"Reminders/internal/tokens"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Максимальный размер импортируемого файла .ics
+const maxCalendarSize = 1 << 20
+
+// CalendarFeedResponse - ответ с секретной ссылкой на календарь
+type CalendarFeedResponse struct {
+	URL       string    `json:"url" example:"https://reminders.example.com/calendar/3f2a...9c.ics"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportCalendarHandler godoc
+// @Summary Экспорт напоминаний в iCalendar
+// @Description Выгрузить неотправленные напоминания пользователя в формате .ics (VEVENT с VALARM)
+// @Tags calendar
+// @Produce text/calendar
+// @Param id path int true "User ID"
+// @Success 200 {string} string "iCalendar file"
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /users/{id}/reminders.ics [get]
+func ExportCalendarHandler(ctx *gin.Context) {
+	start := time.Now()
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Invalid user ID", zap.String("user_id", ctx.Param("id")))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	writeUserCalendar(ctx, start, userID, true)
+}
+
+// ImportCalendarHandler godoc
+// @Summary Импорт напоминаний из iCalendar
+// @Description Создать напоминания из событий файла .ics. Время срабатывания берётся из VALARM, иначе из DTSTART; RRULE становится правилом повторения, SUMMARY и DESCRIPTION - текстом сообщения
+// @Tags calendar
+// @Accept text/calendar
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "User ID"
+// @Param mode query string false "atomic или best_effort (по умолчанию best_effort)"
+// @Param time_zone query string false "Часовой пояс для времени без TZID"
+// @Param file formData file false "iCalendar file"
+// @Success 201 {object} BatchResponse
+// @Success 207 {object} BatchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} BatchResponse
+// @Router /users/{id}/reminders.ics [post]
+func ImportCalendarHandler(ctx *gin.Context) {
+	start := time.Now()
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Invalid user ID", zap.String("user_id", ctx.Param("id")))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	defaultLoc := time.UTC
+	if tz := ctx.Query("time_zone"); tz != "" {
+		if defaultLoc, err = time.LoadLocation(tz); err != nil {
+			logRequestDetails(ctx, start).Info("Unknown time zone", zap.String("time_zone", tz))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown time_zone"})
+			return
+		}
+	}
+
+	body, err := readCalendarBody(ctx)
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Failed to read calendar", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := ical.Decode(bytes.NewReader(body), defaultLoc)
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Invalid calendar", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid calendar: " + err.Error()})
+		return
+	}
+
+	mode := ctx.DefaultQuery("mode", BatchModeBestEffort)
+	if !checkBatchRequest(ctx, start, &mode, len(events)) {
+		return
+	}
+
+	now := time.Now()
+	resp := runBatch(mode, len(events), func(tx *gorm.DB, i int) BatchItemResult {
+		reminder, err := reminderFromEvent(events[i], userID, now)
+		if err == nil {
+			err = createReminder(tx, &reminder)
+		}
+		if err != nil {
+			return BatchItemResult{Index: i, Status: BatchStatusFailed, Error: fmt.Sprintf("event %q: %v", events[i].UID, err)}
+		}
+		return BatchItemResult{Index: i, ID: reminder.ID, Status: BatchStatusCreated, Reminder: &reminder}
+	})
+
+	writeBatchResponse(ctx, start, http.StatusCreated, resp)
+}
+
+// CreateCalendarFeedHandler godoc
+// @Summary Создать ссылку на календарь
+// @Description Выпустить секретную ссылку на календарь пользователя только для чтения. Предыдущая ссылка перестаёт работать
+// @Tags calendar
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 201 {object} CalendarFeedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /users/{id}/calendar-feed [post]
+func CreateCalendarFeedHandler(ctx *gin.Context) {
+	start := time.Now()
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Invalid user ID", zap.String("user_id", ctx.Param("id")))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if _, ok := findUser(ctx, start); !ok {
+		return
+	}
+
+	token, err := tokens.New()
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to generate feed token", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed"})
+		return
+	}
+
+	feed := models.CalendarFeed{UserID: userID, TokenHash: tokens.Hash(token), CreatedAt: time.Now()}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.CalendarFeed{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&feed).Error
+	})
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to create calendar feed", zap.Int("user_id", userID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Calendar feed created", zap.Int("user_id", userID))
+	ctx.JSON(http.StatusCreated, CalendarFeedResponse{
+		URL:       publicURL(ctx) + "/calendar/" + token + ".ics",
+		CreatedAt: feed.CreatedAt,
+	})
+}
+
+// DeleteCalendarFeedHandler godoc
+// @Summary Отозвать ссылку на календарь
+// @Description Удалить секретную ссылку на календарь пользователя
+// @Tags calendar
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/calendar-feed [delete]
+func DeleteCalendarFeedHandler(ctx *gin.Context) {
+	start := time.Now()
+	userID := ctx.Param("id")
+
+	result := database.DB.Where("user_id = ?", userID).Delete(&models.CalendarFeed{})
+	if result.Error != nil {
+		logRequestDetails(ctx, start).Error("Failed to delete calendar feed", zap.String("user_id", userID), zap.Error(result.Error))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete calendar feed"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		logRequestDetails(ctx, start).Info("No calendar feed found", zap.String("user_id", userID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No calendar feed found for the given user_id"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Calendar feed deleted", zap.String("user_id", userID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "Calendar feed deleted successfully"})
+}
+
+// CalendarFeedHandler godoc
+// @Summary Подписка на календарь
+// @Description Календарь неотправленных напоминаний по секретной ссылке, для подписки из календарных приложений
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "Feed token"
+// @Success 200 {string} string "iCalendar file"
+// @Failure 404 {object} ErrorResponse
+// @Router /calendar/{token} [get]
+func CalendarFeedHandler(ctx *gin.Context) {
+	start := time.Now()
+	token := strings.TrimSuffix(ctx.Param("token"), ".ics")
+
+	var feed models.CalendarFeed
+	if err := database.DB.Where("token_hash = ?", tokens.Hash(token)).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logRequestDetails(ctx, start).Info("Unknown calendar feed token")
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "Calendar feed not found"})
+			return
+		}
+		logRequestDetails(ctx, start).Error("Failed to find calendar feed", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find calendar feed"})
+		return
+	}
+
+	writeUserCalendar(ctx, start, feed.UserID, false)
+}
+
+// writeUserCalendar отдаёт неотправленные напоминания пользователя в формате iCalendar.
+func writeUserCalendar(ctx *gin.Context, start time.Time, userID int, attachment bool) {
+	var reminders []models.Reminder
+	// Напоминания, ждущие своего якоря, ещё не имеют времени отправки
+	err := database.DB.Preload("User").
+		Where("user_id = ? AND is_sent = ? AND archived_at IS NULL AND waiting_anchor = ?", userID, false, false).
+		Order("send_at").
+		Find(&reminders).Error
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch reminders", zap.Int("user_id", userID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminders"})
+		return
+	}
+
+	cal := ical.Calendar{
+		ProdID: "-//Reminders//Telegram Reminder Service//RU",
+		Name:   fmt.Sprintf("Reminders of user %d", userID),
+		Events: make([]ical.Event, 0, len(reminders)),
+	}
+	for _, r := range reminders {
+		cal.Events = append(cal.Events, eventFromReminder(r))
+	}
+
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, cal); err != nil {
+		logRequestDetails(ctx, start).Error("Failed to encode calendar", zap.Int("user_id", userID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode calendar"})
+		return
+	}
+
+	if attachment {
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="reminders-%d.ics"`, userID))
+	}
+	logRequestDetails(ctx, start).Info("Calendar exported", zap.Int("user_id", userID), zap.Int("event_count", len(cal.Events)))
+	ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
+
+// eventFromReminder превращает напоминание в событие, срабатывающее в момент отправки.
+// Время пишется в поясе расписания, поэтому User должен быть загружен. COUNT правила
+// уменьшается на число отправленных повторений: событие начинается со следующего.
+func eventFromReminder(r models.Reminder) ical.Event {
+	summary, _, _ := strings.Cut(r.Message, "\n")
+	loc := r.ScheduleLocation()
+	event := ical.Event{
+		UID:      fmt.Sprintf("reminder-%d@reminders", r.ID),
+		Summary:  summary,
+		Start:    r.SendAt.In(loc),
+		RRule:    r.Recurrence,
+		HasAlarm: true,
+	}
+	if loc != time.UTC {
+		event.TimeZone = loc.String()
+	}
+	if summary != r.Message {
+		event.Description = r.Message
+	}
+	if rule, err := recurrence.Parse(r.Recurrence); err == nil && rule.Count > 0 {
+		rule.Count -= r.SentCount
+		if rule.Count < 1 {
+			rule.Count = 1
+		}
+		event.RRule = rule.String()
+	}
+	return event
+}
+
+// reminderFromEvent превращает событие календаря в напоминание. Для повторяющихся событий
+// из прошлого выбирается ближайшее будущее повторение.
+func reminderFromEvent(e ical.Event, userID int, now time.Time) (models.Reminder, error) {
+	message := e.Summary
+	if e.Description != "" && e.Description != e.Summary {
+		if message != "" {
+			message += "\n\n"
+		}
+		message += e.Description
+	}
+
+	reminder := models.Reminder{
+		UserID:     userID,
+		Message:    message,
+		SendAt:     e.AlarmTime(),
+		TimeZone:   e.TimeZone,
+		Recurrence: e.RRule,
+	}
+	if !reminder.SendAt.After(now) {
+		if reminder.Recurrence == "" {
+			return reminder, errors.New("event is in the past")
+		}
+		rule, err := recurrence.Parse(reminder.Recurrence)
+		if err != nil {
+			return reminder, fmt.Errorf("invalid recurrence: %w", err)
+		}
+		for !reminder.SendAt.After(now) {
+			next, ok := rule.Next(reminder.SendAt, reminder.SentCount+1)
+			if !ok {
+				return reminder, errors.New("all occurrences are in the past")
+			}
+			reminder.SendAt = next
+			// Пропущенные повторения учитываются в COUNT
+			reminder.SentCount++
+		}
+	}
+	return reminder, nil
+}
+
+// readCalendarBody читает файл .ics из поля file формы или из тела запроса.
+func readCalendarBody(ctx *gin.Context) ([]byte, error) {
+	var reader io.Reader = ctx.Request.Body
+	if strings.HasPrefix(ctx.ContentType(), "multipart/form-data") {
+		fileHeader, err := ctx.FormFile("file")
+		if err != nil {
+			return nil, errors.New("form field file is required")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxCalendarSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxCalendarSize {
+		return nil, fmt.Errorf("calendar must be at most %d bytes", maxCalendarSize)
+	}
+	return body, nil
+}
+
+// publicURL возвращает внешний адрес сервиса для ссылок в ответах.
+func publicURL(ctx *gin.Context) string {
+	if envs.ServerEnvs.PUBLIC_URL != "" {
+		return strings.TrimRight(envs.ServerEnvs.PUBLIC_URL, "/")
+	}
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := ctx.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + ctx.Request.Host
+}
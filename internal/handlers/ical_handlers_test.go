@@ -0,0 +1,41 @@
+package handlers_test
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"net/http"
+	"testing"
+)
+
+const calendar = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n" +
+	"BEGIN:VEVENT\r\nUID:standup@example.com\r\nDTSTART;TZID=Europe/Moscow:20300114T100000\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE\r\nSUMMARY:Стендап\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:dentist@example.com\r\nDTSTART:20300201T080000Z\r\nSUMMARY:Dentist\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+// sendTwoOfFive ограничивает напоминание 3 пятью повторениями, два из которых уже отправлены.
+func sendTwoOfFive(t *testing.T, _ http.Handler) {
+	err := database.DB.Model(&models.Reminder{}).Where("id = ?", 3).
+		Updates(map[string]interface{}{"recurrence": "FREQ=WEEKLY;BYDAY=FR;COUNT=5", "sent_count": 2}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "export", method: http.MethodGet, path: "/users/1/reminders.ics", status: http.StatusOK},
+		{name: "export_daylight_saving_count", method: http.MethodGet, path: "/users/2/reminders.ics", status: http.StatusOK,
+			setup: sendTwoOfFive},
+		{name: "export_invalid_user", method: http.MethodGet, path: "/users/x/reminders.ics", status: http.StatusBadRequest},
+		{name: "import", method: http.MethodPost, path: "/users/2/reminders.ics", status: http.StatusCreated,
+			body: calendar, header: map[string]string{"Content-Type": "text/calendar"}},
+		{name: "import_invalid", method: http.MethodPost, path: "/users/2/reminders.ics", status: http.StatusBadRequest,
+			body: "BEGIN:VEVENT\r\n", header: map[string]string{"Content-Type": "text/calendar"}},
+		{name: "import_unknown_time_zone", method: http.MethodPost, path: "/users/2/reminders.ics?time_zone=Mars/Olympus", status: http.StatusBadRequest,
+			body: calendar, header: map[string]string{"Content-Type": "text/calendar"}},
+		{name: "feed_create", method: http.MethodPost, path: "/users/1/calendar-feed", status: http.StatusCreated},
+		{name: "feed_delete_not_found", method: http.MethodDelete, path: "/users/1/calendar-feed", status: http.StatusNotFound},
+		{name: "feed_unknown_token", method: http.MethodGet, path: "/calendar/unknown.ics", status: http.StatusNotFound},
+	})
+}
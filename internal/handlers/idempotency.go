@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/tokens"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// IdempotencyHeader - заголовок, по которому повтор POST-запроса узнаётся и не выполняется дважды
+	IdempotencyHeader = "Idempotency-Key"
+	// Заголовок ответа, отданного из сохранённого
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	// Сколько хранится ответ на запрос с ключом
+	idempotencyTTL = 24 * time.Hour
+	// Максимальная длина ключа
+	maxIdempotencyKeyLength = 255
+	// Максимальный размер тела запроса с ключом: тело читается в память целиком, чтобы сравнить
+	// его с первым запросом. Хватает на самое большое вложение с заголовками multipart
+	maxIdempotentBodySize = 64 << 20
+)
+
+// idempotencyRecorder передаёт ответ клиенту и одновременно копирует его тело для сохранения.
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency сохраняет ответы на POST-запросы с заголовком Idempotency-Key. Клиент, не дождавшийся
+// ответа, повторяет запрос с тем же ключом и получает сохранённый ответ вместо второго выполнения.
+// Ключи разных клиентов не пересекаются: ключ относится к ключу API или токену из заголовка
+// Authorization. Ключ с другим методом, путём или телом отклоняется с 422, ключ выполняющегося
+// запроса - с 409. Ответы 5xx не сохраняются, как и ответы запросов, обработчик которых
+// запаниковал: запрос с тем же ключом выполнится заново.
+func Idempotency(ctx *gin.Context) {
+	key := ctx.GetHeader(IdempotencyHeader)
+	if key == "" || ctx.Request.Method != http.MethodPost {
+		ctx.Next()
+		return
+	}
+	start := time.Now()
+	if len(key) > maxIdempotencyKeyLength {
+		logRequestDetails(ctx, start).Info("Idempotency key is too long", zap.Int("length", len(key)))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxIdempotentBodySize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		logRequestDetails(ctx, start).Info("Request body with idempotency key is too large", zap.Int64("limit", tooLarge.Limit))
+		ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+		return
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Failed to read request body", zap.Error(err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	sum := sha256.New()
+	io.WriteString(sum, ctx.Request.Method+" "+ctx.Request.URL.RequestURI()+"\n")
+	sum.Write(body)
+	fingerprint := hex.EncodeToString(sum.Sum(nil))
+
+	// Истёкшие ключи удаляются здесь же, отдельной задачи очистки нет
+	if err := database.DB.Where("created_at < ?", start.Add(-idempotencyTTL)).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		logRequestDetails(ctx, start).Warn("Failed to delete expired idempotency keys", zap.Error(err))
+	}
+
+	scope := idempotencyScope(ctx)
+	record := models.IdempotencyKey{Scope: scope, Key: key, Fingerprint: fingerprint, CreatedAt: start}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		logRequestDetails(ctx, start).Error("Failed to save idempotency key", zap.Error(result.Error))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to save idempotency key"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		replayIdempotent(ctx, start, scope, key, fingerprint)
+		return
+	}
+
+	recorder := &idempotencyRecorder{ResponseWriter: ctx.Writer}
+	ctx.Writer = recorder
+	// Иначе ключ остался бы занятым и повторы получали бы 409 до истечения срока
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			releaseIdempotencyKey(ctx, start, record)
+			panic(recovered)
+		}
+	}()
+	ctx.Next()
+
+	status := recorder.Status()
+	if status >= http.StatusInternalServerError {
+		releaseIdempotencyKey(ctx, start, record)
+		return
+	}
+	err = database.DB.Model(&record).Updates(map[string]interface{}{
+		"status_code":  status,
+		"content_type": recorder.Header().Get("Content-Type"),
+		"body":         recorder.body.Bytes(),
+	}).Error
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to save idempotent response", zap.Error(err))
+	}
+}
+
+// idempotencyScope возвращает владельца ключа идемпотентности: хеш токена из заголовка
+// Authorization или пустую строку для запросов без него.
+func idempotencyScope(ctx *gin.Context) string {
+	if token := bearerToken(ctx); token != "" {
+		return tokens.Hash(token)
+	}
+	return ""
+}
+
+// releaseIdempotencyKey удаляет ключ запроса, ответ на который не сохраняется.
+func releaseIdempotencyKey(ctx *gin.Context, start time.Time, record models.IdempotencyKey) {
+	if err := database.DB.Delete(&record).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to release idempotency key", zap.Error(err))
+	}
+}
+
+// replayIdempotent отвечает на повтор запроса с уже использованным ключом.
+func replayIdempotent(ctx *gin.Context, start time.Time, scope, key, fingerprint string) {
+	var saved models.IdempotencyKey
+	if err := database.DB.Where("scope = ? AND key = ?", scope, key).First(&saved).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to find idempotency key", zap.Error(err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to find idempotency key"})
+		return
+	}
+
+	switch {
+	case saved.Fingerprint != fingerprint:
+		logRequestDetails(ctx, start).Info("Idempotency key reused for another request")
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key is already used for another request"})
+	case saved.StatusCode == 0:
+		logRequestDetails(ctx, start).Info("Request with idempotency key is in progress")
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is in progress"})
+	default:
+		logRequestDetails(ctx, start).Info("Idempotent response replayed", zap.Int("status", saved.StatusCode))
+		ctx.Header(idempotentReplayedHeader, "true")
+		ctx.Data(saved.StatusCode, saved.ContentType, saved.Body)
+		ctx.Abort()
+	}
+}
@@ -0,0 +1,110 @@
+package handlers_test
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const createReminderBody = `{"user_id": 1, "message": "Позвонить маме", "send_at": "2030-01-15T09:00:00Z"}`
+
+func countReminders(t *testing.T) int64 {
+	t.Helper()
+	var n int64
+	if err := database.DB.Model(&models.Reminder{}).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	router := newRouter(t)
+	before := countReminders(t)
+	header := map[string]string{"Idempotency-Key": "create-1"}
+
+	first := do(router, http.MethodPost, "/reminders", createReminderBody, header)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first request: %d %s", first.Code, first.Body)
+	}
+	second := do(router, http.MethodPost, "/reminders", createReminderBody, header)
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("replay: %d %s, want the first response", second.Code, second.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replayed response without Idempotent-Replayed")
+	}
+	if n := countReminders(t) - before; n != 1 {
+		t.Errorf("%d reminders created, want 1", n)
+	}
+
+	other := do(router, http.MethodPost, "/reminders", strings.Replace(createReminderBody, "маме", "папе", 1), header)
+	if other.Code != http.StatusUnprocessableEntity {
+		t.Errorf("key reused with another body: %d %s, want 422", other.Code, other.Body)
+	}
+}
+
+// Одинаковые ключи разных клиентов не мешают друг другу.
+func TestIdempotencyKeysAreScopedPerClient(t *testing.T) {
+	router := newRouter(t)
+	before := countReminders(t)
+	for _, token := range []string{"", "client-a", "client-b"} {
+		header := map[string]string{"Idempotency-Key": "same-key"}
+		if token != "" {
+			header["Authorization"] = "Bearer " + token
+		}
+		body := strings.Replace(createReminderBody, "маме", "маме "+token, 1)
+		if rec := do(router, http.MethodPost, "/reminders", body, header); rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("client %q: %d %s, want a new reminder", token, rec.Code, rec.Body)
+		}
+	}
+	if n := countReminders(t) - before; n != 3 {
+		t.Errorf("%d reminders created, want 3", n)
+	}
+}
+
+func TestIdempotencyRejectsLargeBody(t *testing.T) {
+	router := newRouter(t)
+	body := `{"message": "` + strings.Repeat("x", 64<<20) + `"}`
+	rec := do(router, http.MethodPost, "/reminders", body, map[string]string{"Idempotency-Key": "large"})
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status %d, want 413", rec.Code)
+	}
+}
+
+// Ключ запроса, обработчик которого запаниковал, освобождается, и повтор выполняется заново.
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	router := newRouter(t)
+	calls := 0
+	router.POST("/panic", func(ctx *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		ctx.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+	header := map[string]string{"Idempotency-Key": "panic-1"}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic was swallowed by the idempotency middleware")
+			}
+		}()
+		do(router, http.MethodPost, "/panic", "{}", header)
+	}()
+	var keys int64
+	if err := database.DB.Model(&models.IdempotencyKey{}).Count(&keys).Error; err != nil {
+		t.Fatal(err)
+	}
+	if keys != 0 {
+		t.Errorf("%d idempotency keys left after a panic, want 0", keys)
+	}
+
+	if rec := do(router, http.MethodPost, "/panic", "{}", header); rec.Code != http.StatusOK || calls != 2 {
+		t.Errorf("retry after a panic: %d %s (calls %d), want a new run", rec.Code, rec.Body, calls)
+	}
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/pausing"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	errInvalidList = errors.New("invalid list")
+	errListExists  = errors.New("list with this name already exists")
+)
+
+// GetListsHandler godoc
+// @Summary Список списков напоминаний
+// @Description Получить списки напоминаний всех пользователей или одного пользователя
+// @Tags lists
+// @Produce json
+// @Param user_id query int false "User ID"
+// @Success 200 {object} ListsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /lists [get]
+func GetListsHandler(ctx *gin.Context) {
+	start := time.Now()
+	query := database.DB.Order("name, id")
+	if userID := ctx.Query("user_id"); userID != "" {
+		if _, err := strconv.Atoi(userID); err != nil {
+			logRequestDetails(ctx, start).Info("Invalid user ID", zap.String("user_id", userID))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+
+	lists := []models.ReminderList{}
+	if err := query.Find(&lists).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch lists", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lists"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Lists fetched successfully", zap.Int("row_count", len(lists)))
+	ctx.JSON(http.StatusOK, ListsResponse{Lists: lists})
+}
+
+// GetListHandler godoc
+// @Summary Получить список напоминаний
+// @Description Получить список. Напоминания списка возвращает GET /reminders?list_id={id}
+// @Tags lists
+// @Produce json
+// @Param id path int true "List ID"
+// @Success 200 {object} ListResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id} [get]
+func GetListHandler(ctx *gin.Context) {
+	start := time.Now()
+	list, ok := findList(ctx, start)
+	if !ok {
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("List fetched successfully", zap.Int("list_id", list.ID))
+	ctx.JSON(http.StatusOK, ListResponse{List: list})
+}
+
+// CreateListHandler godoc
+// @Summary Создать список напоминаний
+// @Description Создать именованный список, например «Работа» или «Здоровье». Напоминание попадает в список через list_id
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Param list body models.ReminderList true "List"
+// @Success 201 {object} ListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /lists [post]
+func CreateListHandler(ctx *gin.Context) {
+	start := time.Now()
+	var list models.ReminderList
+
+	if err := ctx.ShouldBindJSON(&list); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	list.ID = 0
+	list.Name = strings.TrimSpace(list.Name)
+	if !checkList(ctx, start, list) {
+		return
+	}
+
+	list.CreatedAt = time.Now()
+	list.UpdatedAt = time.Now()
+	if err := database.DB.Create(&list).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to create list", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create list"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("List created successfully", zap.Int("list_id", list.ID))
+	ctx.JSON(http.StatusCreated, ListResponse{Message: "List created successfully", List: list})
+}
+
+// UpdateListHandler godoc
+// @Summary Переименовать список напоминаний
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Param id path int true "List ID"
+// @Param list body models.ReminderList true "List"
+// @Success 200 {object} ListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /lists/{id} [put]
+func UpdateListHandler(ctx *gin.Context) {
+	start := time.Now()
+	var upd models.ReminderList
+
+	if err := ctx.ShouldBindJSON(&upd); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	list, ok := findList(ctx, start)
+	if !ok {
+		return
+	}
+	// Владелец списка не меняется, иначе в нём оказались бы чужие напоминания
+	upd.ID = list.ID
+	upd.UserID = list.UserID
+	upd.Name = strings.TrimSpace(upd.Name)
+	if !checkList(ctx, start, upd) {
+		return
+	}
+
+	list.Name = upd.Name
+	list.UpdatedAt = time.Now()
+	if err := database.DB.Save(&list).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to update list", zap.Int("list_id", list.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update list"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("List updated successfully", zap.Int("list_id", list.ID))
+	ctx.JSON(http.StatusOK, ListResponse{Message: "List updated successfully", List: list})
+}
+
+// DeleteListHandler godoc
+// @Summary Удалить список напоминаний
+// @Description Удалить список. Напоминания списка остаются без списка
+// @Tags lists
+// @Produce json
+// @Param id path int true "List ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id} [delete]
+func DeleteListHandler(ctx *gin.Context) {
+	start := time.Now()
+	list, ok := findList(ctx, start)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&list).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to delete list", zap.Int("list_id", list.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete list"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("List deleted successfully", zap.Int("list_id", list.ID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "List deleted successfully"})
+}
+
+// PauseListHandler godoc
+// @Summary Приостановить список напоминаний
+// @Description Приостановить все неотправленные напоминания списка до явного возобновления или до until
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Param id path int true "List ID"
+// @Param pause body PauseRequest false "Pause"
+// @Success 200 {object} UpdatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/pause [post]
+func PauseListHandler(ctx *gin.Context) {
+	start := time.Now()
+	req, ok := bindPauseRequest(ctx, start)
+	if !ok {
+		return
+	}
+	list, ok := findList(ctx, start)
+	if !ok {
+		return
+	}
+
+	var count int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		count, err = pausing.PauseReminders(tx, pausing.ByList(list.ID), req.Until, req.ResumeMode, pausing.ScopeList, start)
+		return err
+	})
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to pause list", zap.Int("list_id", list.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pause list"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("List paused successfully", zap.Int("list_id", list.ID), zap.Int("row_count", count))
+	ctx.JSON(http.StatusOK, UpdatedResponse{Message: "List paused successfully", Updated: count})
+}
+
+// ResumeListHandler godoc
+// @Summary Возобновить список напоминаний
+// @Description Возобновить приостановленные напоминания списка. Параметр missed действует так же, как при возобновлении напоминания
+// @Tags lists
+// @Produce json
+// @Param id path int true "List ID"
+// @Param missed query string false "skip or catch_up"
+// @Success 200 {object} UpdatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/resume [post]
+func ResumeListHandler(ctx *gin.Context) {
+	start := time.Now()
+	mode, ok := resumeMode(ctx, start)
+	if !ok {
+		return
+	}
+	list, ok := findList(ctx, start)
+	if !ok {
+		return
+	}
+
+	var count int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		count, err = pausing.ResumeReminders(tx, pausing.ByList(list.ID), mode, start)
+		return err
+	})
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to resume list", zap.Int("list_id", list.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resume list"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("List resumed successfully", zap.Int("list_id", list.ID), zap.Int("row_count", count))
+	ctx.JSON(http.StatusOK, UpdatedResponse{Message: "List resumed successfully", Updated: count})
+}
+
+// findList ищет список из параметра id, иначе отвечает клиенту.
+func findList(ctx *gin.Context, start time.Time) (models.ReminderList, bool) {
+	listID := ctx.Param("id")
+	var list models.ReminderList
+
+	err := database.DB.First(&list, "id = ?", listID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logRequestDetails(ctx, start).Info("No list found with the given ID", zap.String("list_id", listID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No list found with the given ID"})
+		return list, false
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to find list", zap.String("list_id", listID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find list"})
+		return list, false
+	}
+	return list, true
+}
+
+// checkList проверяет название и владельца списка, иначе отвечает клиенту.
+func checkList(ctx *gin.Context, start time.Time, list models.ReminderList) bool {
+	err := validateName(list.UserID, list.Name, errInvalidList)
+	if err == nil {
+		err = checkUser(database.DB, list.UserID)
+	}
+	if err == nil {
+		err = checkNameFree(&models.ReminderList{}, list.ID, list.UserID, list.Name, errListExists)
+	}
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errListExists):
+		logRequestDetails(ctx, start).Info("List already exists", zap.Error(err))
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, errUserNotFound), errors.Is(err, errInvalidList):
+		logRequestDetails(ctx, start).Info("Invalid list", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		logRequestDetails(ctx, start).Error("Failed to check list", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check list"})
+	}
+	return false
+}
+
+// validateName проверяет владельца и название списка или метки.
+func validateName(userID int, name string, errInvalid error) error {
+	if userID <= 0 {
+		return fmt.Errorf("%w: user_id is required", errInvalid)
+	}
+	if name == "" {
+		return fmt.Errorf("%w: name is required", errInvalid)
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return fmt.Errorf("%w: name must be at most %d characters", errInvalid, maxNameLength)
+	}
+	return nil
+}
+
+// checkNameFree проверяет, что у пользователя нет другого списка или метки с таким названием.
+func checkNameFree(model interface{}, id, userID int, name string, errExists error) error {
+	var count int64
+	err := database.DB.Model(model).Where("user_id = ? AND name = ? AND id <> ?", userID, name, id).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %q", errExists, name)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestLists(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "list", method: http.MethodGet, path: "/lists?user_id=1", status: http.StatusOK},
+		{name: "get", method: http.MethodGet, path: "/lists/1", status: http.StatusOK},
+		{name: "get_not_found", method: http.MethodGet, path: "/lists/99", status: http.StatusNotFound},
+		{name: "create", method: http.MethodPost, path: "/lists", status: http.StatusCreated,
+			body: `{"user_id": 2, "name": "Health"}`},
+		{name: "create_duplicate", method: http.MethodPost, path: "/lists", status: http.StatusConflict,
+			body: `{"user_id": 1, "name": "Работа"}`},
+		{name: "create_bad_json", method: http.MethodPost, path: "/lists", status: http.StatusBadRequest,
+			body: `{"name": 1}`},
+		{name: "create_unknown_user", method: http.MethodPost, path: "/lists", status: http.StatusBadRequest,
+			body: `{"user_id": 99, "name": "Health"}`},
+		{name: "update", method: http.MethodPut, path: "/lists/1", status: http.StatusOK,
+			body: `{"name": "Офис"}`},
+		{name: "update_not_found", method: http.MethodPut, path: "/lists/99", status: http.StatusNotFound,
+			body: `{"name": "Офис"}`},
+		{name: "delete", method: http.MethodDelete, path: "/lists/1", status: http.StatusOK},
+		{name: "delete_not_found", method: http.MethodDelete, path: "/lists/99", status: http.StatusNotFound},
+		{name: "pause", method: http.MethodPost, path: "/lists/1/pause", status: http.StatusOK,
+			body: `{"until": "2030-01-20T00:00:00Z", "resume_mode": "skip"}`},
+		{name: "pause_not_found", method: http.MethodPost, path: "/lists/99/pause", status: http.StatusNotFound},
+		{name: "resume", method: http.MethodPost, path: "/lists/1/resume?missed=skip", status: http.StatusOK},
+		{name: "resume_invalid_mode", method: http.MethodPost, path: "/lists/1/resume?missed=later", status: http.StatusBadRequest},
+	})
+}
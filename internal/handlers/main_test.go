@@ -0,0 +1,238 @@
+package handlers_test
+
+import (
+	"Reminders/internal/blobstore"
+	"Reminders/internal/envs"
+	"Reminders/internal/fixtures"
+	"Reminders/internal/handlers"
+	"Reminders/internal/server"
+	"Reminders/internal/testdb"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Токен администратора для запросов к ключам API
+const testAdminToken = "test-admin-token"
+
+// Заголовок с токеном администратора
+var adminAuth = map[string]string{"Authorization": "Bearer " + testAdminToken}
+
+// go test ./internal/handlers -update перезаписывает эталонные ответы в testdata/golden
+var update = flag.Bool("update", false, "rewrite golden responses in testdata/golden")
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	handlers.SetLogger(zap.NewNop())
+	envs.ServerEnvs.BOT_USERNAME = "reminders_test_bot"
+	envs.ServerEnvs.PUBLIC_URL = "http://reminders.test"
+	envs.ServerEnvs.ADMIN_TOKEN = testAdminToken
+	os.Exit(m.Run())
+}
+
+// newRouter поднимает маршруты сервера на пустой базе с данными из testdata/fixtures.json.
+func newRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	db := testdb.Open(t)
+
+	file, err := os.Open("testdata/fixtures.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	set, err := fixtures.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fixtures.Load(db, &set); err != nil {
+		t.Fatalf("load fixtures: %v", err)
+	}
+	if err := blobstore.InitBlobStore(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	return server.InitRotes(gin.New())
+}
+
+// apiCase - запрос к API и ожидаемый код ответа. Тело ответа сравнивается
+// с эталоном testdata/golden/<тест>/<name>.
+type apiCase struct {
+	name   string
+	method string
+	path   string
+	body   string
+	header map[string]string
+	status int
+	// setup готовит данные перед запросом: напрямую в базе или запросами к router
+	setup func(t *testing.T, router http.Handler)
+}
+
+// runCases выполняет каждый запрос на свежей базе с фикстурами.
+func runCases(t *testing.T, cases []apiCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			router := newRouter(t)
+			if c.setup != nil {
+				c.setup(t, router)
+			}
+			rec := do(router, c.method, c.path, c.body, c.header)
+			if rec.Code != c.status {
+				t.Errorf("%s %s: status %d, want %d; body: %s", c.method, c.path, rec.Code, c.status, rec.Body)
+			}
+			checkGolden(t, rec)
+		})
+	}
+}
+
+// do выполняет запрос к маршрутизатору. Тело с «{» или «[» в начале отправляется как JSON.
+func do(router http.Handler, method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if strings.HasPrefix(body, "{") || strings.HasPrefix(body, "[") {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeJSON разбирает тело ответа в v.
+func decodeJSON(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode response %s: %v", rec.Body, err)
+	}
+}
+
+// checkGolden сравнивает тело ответа с эталоном теста. JSON сравнивается после normalize,
+// остальные ответы - построчно после маскирования меток времени.
+func checkGolden(t *testing.T, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	var got []byte
+	ext := ".txt"
+	if strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		ext = ".json"
+		got = normalizeJSON(t, rec.Body.Bytes())
+	} else {
+		got = normalizeText(rec.Body.Bytes())
+	}
+
+	path := filepath.Join("testdata", "golden", t.Name()+ext)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("response differs from %s\n--- got:\n%s\n--- want:\n%s", path, got, want)
+	}
+}
+
+// Поля, значения которых меняются от запуска к запуску: текущее время и случайные секреты
+var volatileKeys = map[string]bool{
+	"created_at": true, "updated_at": true, "paused_at": true, "archived_at": true,
+	"acked_at": true, "expires_at": true, "last_used_at": true, "delivered_at": true,
+	"next_attempt_at": true, "deactivated_at": true,
+	"key": true, "prefix": true, "secret": true,
+}
+
+// Случайные токены внутри строк, например в ссылках привязки и подписки на календарь
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9_-]{24,}`)
+
+// Адреса локальных httptest.Server со случайным портом
+var localAddrPattern = regexp.MustCompile(`127\.0\.0\.1:\d+`)
+
+// normalizeJSON заменяет изменчивые поля заглушками, приводит время к UTC
+// и форматирует JSON с отступами.
+func normalizeJSON(t *testing.T, body []byte) []byte {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("invalid JSON response: %v\n%s", err, body)
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(normalizeValue("", v)); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func normalizeValue(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = normalizeValue(k, item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeValue(key, item)
+		}
+		return v
+	case string:
+		if volatileKeys[key] && v != "" {
+			return "<" + key + ">"
+		}
+		// Postgres и SQLite возвращают время в разных поясах
+		if ts, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return ts.UTC().Format(time.RFC3339Nano)
+		}
+		v = localAddrPattern.ReplaceAllString(v, "127.0.0.1:<port>")
+		return tokenPattern.ReplaceAllString(v, "<token>")
+	}
+	return v
+}
+
+// Строки iCalendar и CSV с текущим временем
+var volatileText = regexp.MustCompile(`(?m)^(DTSTAMP|LAST-MODIFIED|CREATED):.*$`)
+
+func normalizeText(body []byte) []byte {
+	body = bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n"))
+	return volatileText.ReplaceAll(body, []byte("$1:<now>"))
+}
+
+// multipartBody собирает тело multipart/form-data с файлом file и полями fields.
+func multipartBody(t *testing.T, filename, content string, fields map[string]string) (string, map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String(), map[string]string{"Content-Type": w.FormDataContentType()}
+}
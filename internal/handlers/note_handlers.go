@@ -1,14 +1,23 @@
 package handlers
 
 import (
+	"Reminders/internal/anchors"
 	"Reminders/internal/database"
 	"Reminders/internal/models"
+	"Reminders/internal/webhooks"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 	"net/http"
+	"strconv"
 	"time"
 )
 
+// Максимальный размер страницы списка напоминаний
+const maxRemindersLimit = 500
+
 // Глобальная переменная логгера
 var logger *zap.Logger
 
@@ -33,18 +42,33 @@ func logRequestDetails(ctx *gin.Context, start time.Time) *zap.Logger {
 // @Tags reminders
 // @Accept json
 // @Produce json
-// @Param user_id path int true "User ID"
-// @Success 200 {array} models.Reminder
+// @Param id path int true "User ID"
+// @Param tag query string false "Tag name"
+// @Param list_id query int false "List ID"
+// @Param limit query int false "Размер страницы, не больше 500. Без него возвращаются все напоминания"
+// @Param after_id query int false "Продолжить после напоминания с этим id (next_after_id предыдущей страницы)"
+// @Success 200 {object} RemindersResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
-// @Router /reminders/{user_id} [get]
+// @Router /reminders/{id} [get]
 func GetMessageByUserIDHandler(ctx *gin.Context) {
 	start := time.Now()
-	userID := ctx.Param("user_id")
+	// gin требует одно имя параметра для /reminders/:id и /reminders/:id/history
+	userID := ctx.Param("id")
 
 	var reminders []models.Reminder
 
+	query, ok := filterReminders(ctx, start, database.DB.Preload("Recipients").Preload("Tags"))
+	if !ok {
+		return
+	}
+	query, limit, ok := paginateReminders(ctx, start, query)
+	if !ok {
+		return
+	}
+
 	// Поиск напоминаний по user_id
-	result := database.DB.Where("user_id = ?", userID).Find(&reminders)
+	result := query.Where("user_id = ?", userID).Find(&reminders)
 	if result.Error != nil {
 		logRequestDetails(ctx, start).Error("Failed to fetch reminders", zap.String("user_id", userID), zap.Error(result.Error))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminders"})
@@ -58,25 +82,41 @@ func GetMessageByUserIDHandler(ctx *gin.Context) {
 		return
 	}
 
+	reminders, next := nextPage(reminders, limit)
 	logRequestDetails(ctx, start).Info("Reminders fetched successfully", zap.String("user_id", userID), zap.Int64("row_count", result.RowsAffected))
-	ctx.JSON(http.StatusOK, gin.H{"reminders": reminders})
+	ctx.JSON(http.StatusOK, RemindersResponse{Reminders: reminders, NextAfterID: next})
 }
 
 // GetAllMessagesHandler godoc
 // @Summary Получение всех напоминаний
-// @Description Получить список всех напоминаний
+// @Description Получить список всех напоминаний, при необходимости только с меткой tag или из списка list_id
 // @Tags reminders
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.Reminder
+// @Param tag query string false "Tag name"
+// @Param list_id query int false "List ID"
+// @Param limit query int false "Размер страницы, не больше 500. Без него возвращаются все напоминания"
+// @Param after_id query int false "Продолжить после напоминания с этим id (next_after_id предыдущей страницы)"
+// @Success 200 {object} RemindersResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /reminders [get]
 func GetAllMessagesHandler(ctx *gin.Context) {
 	start := time.Now()
 	var reminders []models.Reminder
 
+	query, ok := filterReminders(ctx, start, database.DB.Preload("Recipients").Preload("Tags"))
+	if !ok {
+		return
+	}
+	query, limit, ok := paginateReminders(ctx, start, query)
+	if !ok {
+		return
+	}
+
 	// Получение всех напоминаний
-	result := database.DB.Find(&reminders)
+	result := query.Find(&reminders)
 	if result.Error != nil {
 		logRequestDetails(ctx, start).Error("Failed to fetch reminders", zap.Error(result.Error))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminders"})
@@ -90,8 +130,61 @@ func GetAllMessagesHandler(ctx *gin.Context) {
 		return
 	}
 
+	reminders, next := nextPage(reminders, limit)
 	logRequestDetails(ctx, start).Info("All reminders fetched successfully", zap.Int64("row_count", result.RowsAffected))
-	ctx.JSON(http.StatusOK, gin.H{"reminders": reminders})
+	ctx.JSON(http.StatusOK, RemindersResponse{Reminders: reminders, NextAfterID: next})
+}
+
+// filterReminders ограничивает запрос напоминаниями с меткой tag и из списка list_id,
+// если они заданы, иначе отвечает клиенту 400.
+func filterReminders(ctx *gin.Context, start time.Time, query *gorm.DB) (*gorm.DB, bool) {
+	if tag := ctx.Query("tag"); tag != "" {
+		query = query.Where("id IN (?)", database.DB.Table("reminder_tags").
+			Select("reminder_tags.reminder_id").
+			Joins("JOIN tags ON tags.id = reminder_tags.tag_id").
+			Where("tags.name = ?", tag))
+	}
+	if listID := ctx.Query("list_id"); listID != "" {
+		if _, err := strconv.Atoi(listID); err != nil {
+			logRequestDetails(ctx, start).Info("Invalid list ID", zap.String("list_id", listID))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid list ID"})
+			return query, false
+		}
+		query = query.Where("list_id = ?", listID)
+	}
+	return query, true
+}
+
+// paginateReminders ограничивает запрос страницей из limit напоминаний после after_id, если limit задан,
+// иначе отвечает клиенту 400. Запрашивается на одно напоминание больше, чтобы узнать, есть ли следующая страница.
+func paginateReminders(ctx *gin.Context, start time.Time, query *gorm.DB) (*gorm.DB, int, bool) {
+	limitParam := ctx.Query("limit")
+	if limitParam == "" {
+		return query, 0, true
+	}
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit <= 0 || limit > maxRemindersLimit {
+		logRequestDetails(ctx, start).Info("Invalid limit", zap.String("limit", limitParam))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Limit must be between 1 and %d", maxRemindersLimit)})
+		return query, 0, false
+	}
+	afterID, err := strconv.Atoi(ctx.DefaultQuery("after_id", "0"))
+	if err != nil || afterID < 0 {
+		logRequestDetails(ctx, start).Info("Invalid after_id", zap.String("after_id", ctx.Query("after_id")))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid after_id"})
+		return query, 0, false
+	}
+	return query.Where("id > ?", afterID).Order("id").Limit(limit + 1), limit, true
+}
+
+// nextPage отбрасывает лишнее напоминание страницы и возвращает after_id следующей страницы,
+// либо 0, если страница последняя или пагинация не запрошена.
+func nextPage(reminders []models.Reminder, limit int) ([]models.Reminder, int) {
+	if limit == 0 || len(reminders) <= limit {
+		return reminders, 0
+	}
+	reminders = reminders[:limit]
+	return reminders, reminders[limit-1].ID
 }
 
 // DeleteMessageHandler godoc
@@ -108,10 +201,15 @@ func GetAllMessagesHandler(ctx *gin.Context) {
 func DeleteMessageHandler(ctx *gin.Context) {
 	start := time.Now()
 	reminderID := ctx.Param("id")
+	if _, err := strconv.Atoi(reminderID); err != nil {
+		logRequestDetails(ctx, start).Info("Invalid reminder ID", zap.String("reminder_id", reminderID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return
+	}
 
 	// Поиск напоминания по ID
 	var reminder models.Reminder
-	result := database.DB.First(&reminder, reminderID)
+	result := database.DB.Limit(1).Find(&reminder, "id = ?", reminderID)
 	if result.Error != nil {
 		logRequestDetails(ctx, start).Error("Failed to find reminder", zap.String("reminder_id", reminderID), zap.Error(result.Error))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find reminder"})
@@ -132,13 +230,22 @@ func DeleteMessageHandler(ctx *gin.Context) {
 		return
 	}
 
-	// Удаление напоминания по ID
-	deleteResult := database.DB.Delete(&reminder)
-	if deleteResult.Error != nil {
-		logRequestDetails(ctx, start).Error("Failed to delete reminder", zap.String("reminder_id", reminderID), zap.Error(deleteResult.Error))
+	// Удаление напоминания по ID вместе с отвязкой зависимых напоминаний
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := anchors.Detach(tx, reminder.ID); err != nil {
+			return err
+		}
+		if err := webhooks.Publish(tx, models.EventReminderDeleted, reminder); err != nil {
+			return err
+		}
+		return tx.Delete(&reminder).Error
+	})
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to delete reminder", zap.String("reminder_id", reminderID), zap.Error(err))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reminder"})
 		return
 	}
+	removeAttachment(reminder.AttachmentKey)
 
 	logRequestDetails(ctx, start).Info("Reminder deleted successfully", zap.String("reminder_id", reminderID))
 	ctx.JSON(http.StatusOK, gin.H{"message": "Reminder deleted successfully"})
@@ -152,13 +259,18 @@ func DeleteMessageHandler(ctx *gin.Context) {
 // @Produce json
 // @Param id path int true "Reminder ID"
 // @Param reminder body models.Reminder true "Updated reminder object"
-// @Success 200 {object} models.Reminder
+// @Success 200 {object} ReminderResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /reminders/{id} [put]
 func UpdateMessageHandler(ctx *gin.Context) {
 	start := time.Now()
-	reminderID := ctx.Param("id")
+	reminderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Invalid reminder ID", zap.String("reminder_id", ctx.Param("id")))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return
+	}
 	var updatedReminder models.Reminder
 
 	// Разбираем JSON из тела запроса
@@ -168,45 +280,45 @@ func UpdateMessageHandler(ctx *gin.Context) {
 		return
 	}
 
-	// Поиск напоминания по ID
-	var existingReminder models.Reminder
-	result := database.DB.First(&existingReminder, reminderID)
-	if result.Error != nil {
-		logRequestDetails(ctx, start).Error("Failed to find reminder", zap.String("reminder_id", reminderID), zap.Error(result.Error))
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find reminder"})
+	// updateReminder проверяет поля и ссылки и сохраняет напоминание в той же транзакции,
+	// что и пакетное обновление
+	var reminder models.Reminder
+	var staleKey string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		reminder, staleKey, err = updateReminder(tx, reminderID, updatedReminder)
+		return err
+	})
+	var invalid invalidReminderError
+	switch {
+	case err == nil:
+	case errors.As(err, &invalid):
+		logRequestDetails(ctx, start).Info("Invalid reminder", zap.Int("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
-	}
-
-	// Проверка, если напоминание не найдено
-	if result.RowsAffected == 0 {
-		logRequestDetails(ctx, start).Info("No reminder found with the given ID", zap.String("reminder_id", reminderID))
+	case errors.Is(err, errReminderNotFound):
+		logRequestDetails(ctx, start).Info("No reminder found with the given ID", zap.Int("reminder_id", reminderID))
 		ctx.JSON(http.StatusNotFound, gin.H{"message": "No reminder found with the given ID"})
 		return
-	}
-
-	// Проверка, если напоминание уже отправлено
-	if existingReminder.IsSent {
-		logRequestDetails(ctx, start).Info("Cannot update reminder that has already been sent", zap.String("reminder_id", reminderID))
+	case errors.Is(err, errReminderAlreadySent):
+		logRequestDetails(ctx, start).Info("Cannot update reminder that has already been sent", zap.Int("reminder_id", reminderID))
 		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Cannot update reminder that has already been sent"})
 		return
-	}
-
-	// Обновление полей напоминания
-	existingReminder.UserID = updatedReminder.UserID
-	existingReminder.Message = updatedReminder.Message
-	existingReminder.SendAt = updatedReminder.SendAt
-	existingReminder.UpdatedAt = time.Now()
-
-	// Сохранение обновленного напоминания в базе данных
-	saveResult := database.DB.Save(&existingReminder)
-	if saveResult.Error != nil {
-		logRequestDetails(ctx, start).Error("Failed to update reminder", zap.String("reminder_id", reminderID), zap.Error(saveResult.Error))
+	case errors.Is(err, errReminderArchived):
+		logRequestDetails(ctx, start).Info("Cannot update archived reminder", zap.Int("reminder_id", reminderID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Cannot update archived reminder"})
+		return
+	case respondReferenceError(ctx, start, &reminder, err):
+		return
+	default:
+		logRequestDetails(ctx, start).Error("Failed to update reminder", zap.Int("reminder_id", reminderID), zap.Error(err))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reminder"})
 		return
 	}
+	removeAttachment(staleKey)
 
-	logRequestDetails(ctx, start).Info("Reminder updated successfully", zap.String("reminder_id", reminderID), zap.Any("updated_reminder", existingReminder))
-	ctx.JSON(http.StatusOK, gin.H{"message": "Reminder updated successfully", "reminder": existingReminder})
+	logRequestDetails(ctx, start).Info("Reminder updated successfully", zap.Int("reminder_id", reminderID), zap.Any("updated_reminder", reminder))
+	ctx.JSON(http.StatusOK, ReminderResponse{Message: "Reminder updated successfully", Reminder: reminder})
 }
 
 // CreateMessageHandler godoc
@@ -216,7 +328,7 @@ func UpdateMessageHandler(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param reminder body models.Reminder true "Reminder object"
-// @Success 200 {object} models.Reminder
+// @Success 201 {object} ReminderResponse
 // @Failure 400 {object} ErrorResponse
 // @Router /reminders [post]
 func CreateMessageHandler(ctx *gin.Context) {
@@ -230,27 +342,55 @@ func CreateMessageHandler(ctx *gin.Context) {
 		return
 	}
 
-	// Устанавливаем значения по умолчанию
-	newReminder.CreatedAt = time.Now()
-	newReminder.UpdatedAt = time.Now()
-	newReminder.IsSent = false
+	// Файлы вложений загружаются отдельным запросом
+	newReminder.AttachmentKey = ""
 
-	// Сохраняем напоминание в базе данных
-	result := database.DB.Create(&newReminder)
-	if result.Error != nil {
-		logRequestDetails(ctx, start).Error("Failed to create reminder", zap.Error(result.Error))
+	// Обязательные поля, часовой пояс, правило повторения, шаблон, разметка и вложение
+	if err := validateReminder(newReminder); err != nil {
+		logRequestDetails(ctx, start).Info("Invalid reminder", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// createReminder сбрасывает служебные поля и проверяет ссылки напоминания
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return createReminder(tx, &newReminder)
+	})
+	if err != nil {
+		if respondReferenceError(ctx, start, &newReminder, err) {
+			return
+		}
+		logRequestDetails(ctx, start).Error("Failed to create reminder", zap.Error(err))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reminder"})
 		return
 	}
 
 	logRequestDetails(ctx, start).Info("Reminder created successfully", zap.Any("new_reminder", newReminder))
-	ctx.JSON(http.StatusCreated, gin.H{"message": "Reminder created successfully", "reminder": newReminder})
+	ctx.JSON(http.StatusCreated, ReminderResponse{Message: "Reminder created successfully", Reminder: newReminder})
 }
 
-type ErrorResponse struct {
-	Message string `json:"message"`
-}
-
-type SuccessResponse struct {
-	Message string `json:"message"`
+// respondReferenceError отвечает клиенту 400, если err - ошибка ссылки напоминания
+// на владельца, список, метку, политику эскалации или якорь. Остальные ошибки
+// остаются вызывающему.
+func respondReferenceError(ctx *gin.Context, start time.Time, r *models.Reminder, err error) bool {
+	switch {
+	case errors.Is(err, errUserNotFound):
+		logRequestDetails(ctx, start).Info("User not found", zap.Int("user_id", r.UserID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+	case errors.Is(err, errPolicyNotFound):
+		logRequestDetails(ctx, start).Info("Escalation policy not found", zap.Int("user_id", r.UserID), zap.Intp("escalation_policy_id", r.EscalationPolicyID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Escalation policy not found"})
+	case errors.Is(err, errListNotFound):
+		logRequestDetails(ctx, start).Info("List not found", zap.Int("user_id", r.UserID), zap.Intp("list_id", r.ListID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "List not found"})
+	case errors.Is(err, errTagNotFound):
+		logRequestDetails(ctx, start).Info("Tag not found", zap.Int("user_id", r.UserID), zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, anchors.ErrInvalidAnchor):
+		logRequestDetails(ctx, start).Info("Invalid reminder anchor", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
 }
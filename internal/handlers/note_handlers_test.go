@@ -0,0 +1,63 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestReminders(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "list", method: http.MethodGet, path: "/reminders", status: http.StatusOK},
+		{name: "list_by_tag", method: http.MethodGet, path: "/reminders?tag=work", status: http.StatusOK},
+		{name: "list_by_list", method: http.MethodGet, path: "/reminders?list_id=1", status: http.StatusOK},
+		{name: "list_invalid_list_id", method: http.MethodGet, path: "/reminders?list_id=x", status: http.StatusBadRequest},
+		{name: "list_page", method: http.MethodGet, path: "/reminders?limit=2", status: http.StatusOK},
+		{name: "list_next_page", method: http.MethodGet, path: "/reminders?limit=2&after_id=2", status: http.StatusOK},
+		{name: "list_invalid_limit", method: http.MethodGet, path: "/reminders?limit=501", status: http.StatusBadRequest},
+		{name: "list_invalid_after_id", method: http.MethodGet, path: "/reminders?limit=1&after_id=-1", status: http.StatusBadRequest},
+		{name: "list_empty", method: http.MethodGet, path: "/reminders?tag=missing", status: http.StatusNotFound},
+		{name: "by_user", method: http.MethodGet, path: "/reminders/2", status: http.StatusOK},
+		{name: "by_user_not_found", method: http.MethodGet, path: "/reminders/99", status: http.StatusNotFound},
+
+		{name: "create", method: http.MethodPost, path: "/reminders", status: http.StatusCreated,
+			body: `{"user_id": 2, "message": "Call mom", "send_at": "2030-02-01T10:00:00Z", "tags": [{"name": "family"}]}`},
+		{name: "create_resets_state", method: http.MethodPost, path: "/reminders", status: http.StatusCreated,
+			body: `{"user_id": 2, "message": "Call mom", "send_at": "2030-02-01T10:00:00Z", "is_sent": true, "sent_count": 3,
+				"paused_at": "2030-01-01T00:00:00Z", "paused_until": "2030-03-01T00:00:00Z", "resume_mode": "catch_up",
+				"archived_at": "2030-01-01T00:00:00Z", "waiting_anchor": true, "delivery_status": "failed"}`},
+		{name: "create_missing_message", method: http.MethodPost, path: "/reminders", status: http.StatusBadRequest,
+			body: `{"user_id": 2, "send_at": "2030-02-01T10:00:00Z"}`},
+		{name: "create_missing_send_at", method: http.MethodPost, path: "/reminders", status: http.StatusBadRequest,
+			body: `{"user_id": 2, "message": "Call mom"}`},
+		{name: "create_bad_json", method: http.MethodPost, path: "/reminders", status: http.StatusBadRequest,
+			body: `{"user_id": `},
+		{name: "create_unknown_user", method: http.MethodPost, path: "/reminders", status: http.StatusBadRequest,
+			body: `{"user_id": 99, "message": "Call mom", "send_at": "2030-02-01T10:00:00Z"}`},
+		{name: "create_invalid_recurrence", method: http.MethodPost, path: "/reminders", status: http.StatusBadRequest,
+			body: `{"user_id": 2, "message": "Call mom", "send_at": "2030-02-01T10:00:00Z", "recurrence": "FREQ=SOMETIMES"}`},
+		{name: "create_foreign_list", method: http.MethodPost, path: "/reminders", status: http.StatusBadRequest,
+			body: `{"user_id": 2, "message": "Call mom", "send_at": "2030-02-01T10:00:00Z", "list_id": 1}`},
+
+		{name: "update", method: http.MethodPut, path: "/reminders/1", status: http.StatusOK,
+			body: `{"user_id": 1, "message": "Подготовить отчёт к пятнице", "send_at": "2030-01-17T09:00:00Z", "list_id": 1}`},
+		{name: "update_bad_json", method: http.MethodPut, path: "/reminders/1", status: http.StatusBadRequest,
+			body: `not json`},
+		{name: "update_not_found", method: http.MethodPut, path: "/reminders/99", status: http.StatusNotFound,
+			body: `{"user_id": 1, "message": "x", "send_at": "2030-01-17T09:00:00Z"}`},
+		{name: "update_invalid_id", method: http.MethodPut, path: "/reminders/x", status: http.StatusBadRequest,
+			body: `{"user_id": 1, "message": "x", "send_at": "2030-01-17T09:00:00Z"}`},
+		{name: "update_empty_message", method: http.MethodPut, path: "/reminders/1", status: http.StatusBadRequest,
+			body: `{"user_id": 1, "message": "", "send_at": "2030-01-17T09:00:00Z"}`},
+		{name: "update_missing_send_at", method: http.MethodPut, path: "/reminders/1", status: http.StatusBadRequest,
+			body: `{"user_id": 1, "message": "x"}`},
+		{name: "update_unknown_user", method: http.MethodPut, path: "/reminders/1", status: http.StatusBadRequest,
+			body: `{"user_id": 99, "message": "x", "send_at": "2030-01-17T09:00:00Z"}`},
+		{name: "update_already_sent", method: http.MethodPut, path: "/reminders/4", status: http.StatusBadRequest,
+			body: `{"user_id": 1, "message": "x", "send_at": "2030-01-17T09:00:00Z"}`},
+
+		{name: "delete", method: http.MethodDelete, path: "/reminders/1", status: http.StatusOK},
+		{name: "delete_not_found", method: http.MethodDelete, path: "/reminders/99", status: http.StatusNotFound},
+		{name: "delete_invalid_id", method: http.MethodDelete, path: "/reminders/1%20OR%201=1", status: http.StatusBadRequest},
+		{name: "delete_already_sent", method: http.MethodDelete, path: "/reminders/4", status: http.StatusBadRequest},
+	})
+}
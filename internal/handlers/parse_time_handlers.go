@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"Reminders/internal/timeparse"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// Максимальная длина разбираемой фразы
+const maxParseTimeText = 200
+
+// ParseTimeRequest - фраза со временем напоминания
+type ParseTimeRequest struct {
+	Text     string `json:"text" binding:"required" example:"every weekday at 8:30"`
+	TimeZone string `json:"time_zone" example:"Europe/Moscow"`
+	// Now - момент, относительно которого разбирается фраза, по умолчанию текущее время
+	Now *time.Time `json:"now,omitempty"`
+}
+
+// ParseTimeHandler godoc
+// @Summary Разобрать время на естественном языке
+// @Description Перевести фразу на английском или русском в время отправки и правило повторения: «in 15 minutes», «tomorrow at 9», «every weekday at 8:30», «next Monday», «on the 1st of every month», «через час», «завтра в 9 утра», «по будням в 8:30». Время считается в часовом поясе time_zone (по умолчанию UTC); если указан только день, напоминание ставится на 9:00
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param phrase body ParseTimeRequest true "Phrase"
+// @Success 200 {object} ParseTimeResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /parse-time [post]
+func ParseTimeHandler(ctx *gin.Context) {
+	start := time.Now()
+	var req ParseTimeRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if len([]rune(req.Text)) > maxParseTimeText {
+		logRequestDetails(ctx, start).Info("Phrase is too long")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Text is too long"})
+		return
+	}
+
+	loc := time.UTC
+	if req.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(req.TimeZone); err != nil {
+			logRequestDetails(ctx, start).Info("Unknown time zone", zap.String("time_zone", req.TimeZone))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown time_zone"})
+			return
+		}
+	}
+	now := start
+	if req.Now != nil {
+		now = *req.Now
+	}
+
+	res, err := timeparse.Parse(req.Text, now.In(loc))
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Failed to parse time", zap.String("text", req.Text), zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Time parsed", zap.Time("send_at", res.At), zap.String("recurrence", res.Recurrence))
+	ctx.JSON(http.StatusOK, ParseTimeResponse{SendAt: res.At, TimeZone: loc.String(), Recurrence: res.Recurrence})
+}
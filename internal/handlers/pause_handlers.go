@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/pausing"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// PauseRequest - параметры паузы. Без until пауза длится до явного возобновления.
+type PauseRequest struct {
+	Until *time.Time `json:"until,omitempty"`
+	// Что сделать с пропущенными повторениями, когда пауза закончится сама: skip или catch_up
+	ResumeMode string `json:"resume_mode,omitempty" example:"skip"`
+}
+
+// PauseReminderHandler godoc
+// @Summary Приостановить напоминание
+// @Description Приостановить неотправленное напоминание до явного возобновления или до until
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param id path int true "Reminder ID"
+// @Param pause body PauseRequest false "Pause"
+// @Success 200 {object} ReminderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /reminders/{id}/pause [post]
+func PauseReminderHandler(ctx *gin.Context) {
+	start := time.Now()
+	req, ok := bindPauseRequest(ctx, start)
+	if !ok {
+		return
+	}
+	reminder, ok := findReminderToPause(ctx, start)
+	if !ok {
+		return
+	}
+	if reminder.Paused() {
+		logRequestDetails(ctx, start).Info("Reminder is already paused", zap.Int("reminder_id", reminder.ID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Reminder is already paused"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := pausing.PauseReminders(tx, pausing.ByID(reminder.ID), req.Until, req.ResumeMode, pausing.ScopeReminder, start)
+		if err != nil {
+			return err
+		}
+		return tx.First(&reminder, reminder.ID).Error
+	})
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to pause reminder", zap.Int("reminder_id", reminder.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pause reminder"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Reminder paused", zap.Int("reminder_id", reminder.ID), zap.Timep("paused_until", req.Until))
+	ctx.JSON(http.StatusOK, ReminderResponse{Message: "Reminder paused successfully", Reminder: reminder})
+}
+
+// ResumeReminderHandler godoc
+// @Summary Возобновить напоминание
+// @Description Возобновить приостановленное напоминание. Для повторяющихся напоминаний missed=skip пропускает повторения, прошедшие во время паузы, missed=catch_up отправляет их по очереди. По умолчанию используется режим, заданный при паузе, иначе skip. Разовое напоминание, время которого прошло, отправляется сразу
+// @Tags reminders
+// @Produce json
+// @Param id path int true "Reminder ID"
+// @Param missed query string false "skip or catch_up"
+// @Success 200 {object} ReminderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /reminders/{id}/resume [post]
+func ResumeReminderHandler(ctx *gin.Context) {
+	start := time.Now()
+	mode, ok := resumeMode(ctx, start)
+	if !ok {
+		return
+	}
+	reminder, ok := findReminderToPause(ctx, start)
+	if !ok {
+		return
+	}
+	if !reminder.Paused() {
+		logRequestDetails(ctx, start).Info("Reminder is not paused", zap.Int("reminder_id", reminder.ID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Reminder is not paused"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := pausing.ResumeReminders(tx, pausing.ByID(reminder.ID), mode, start); err != nil {
+			return err
+		}
+		return tx.First(&reminder, reminder.ID).Error
+	})
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to resume reminder", zap.Int("reminder_id", reminder.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resume reminder"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Reminder resumed", zap.Int("reminder_id", reminder.ID), zap.String("missed", mode))
+	ctx.JSON(http.StatusOK, ReminderResponse{Message: "Reminder resumed successfully", Reminder: reminder})
+}
+
+// PauseUserHandler godoc
+// @Summary Приостановить доставку пользователю
+// @Description Приостановить все напоминания пользователя, в том числе созданные во время паузы, до явного возобновления или до until. Пользователю как получателю чужих напоминаний они тоже не доставляются
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param pause body PauseRequest false "Pause"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/pause [post]
+func PauseUserHandler(ctx *gin.Context) {
+	start := time.Now()
+	req, ok := bindPauseRequest(ctx, start)
+	if !ok {
+		return
+	}
+	user, ok := findUser(ctx, start)
+	if !ok {
+		return
+	}
+
+	if err := pausing.PauseUser(database.DB, &user, req.Until, req.ResumeMode, start); err != nil {
+		logRequestDetails(ctx, start).Error("Failed to pause user", zap.Int("user_id", user.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pause user"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("User paused", zap.Int("user_id", user.ID), zap.Timep("paused_until", req.Until))
+	ctx.JSON(http.StatusOK, UserResponse{Message: "User paused successfully", User: user})
+}
+
+// ResumeUserHandler godoc
+// @Summary Возобновить доставку пользователю
+// @Description Возобновить доставку напоминаний пользователю. Параметр missed действует так же, как при возобновлении напоминания. Напоминания, приостановленные отдельно, остаются на паузе
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param missed query string false "skip or catch_up"
+// @Success 200 {object} ResumeUserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/resume [post]
+func ResumeUserHandler(ctx *gin.Context) {
+	start := time.Now()
+	mode, ok := resumeMode(ctx, start)
+	if !ok {
+		return
+	}
+	user, ok := findUser(ctx, start)
+	if !ok {
+		return
+	}
+	if !user.Paused() {
+		logRequestDetails(ctx, start).Info("User is not paused", zap.Int("user_id", user.ID))
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "User is not paused"})
+		return
+	}
+
+	var missed int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		missed, err = pausing.ResumeUser(tx, &user, mode, start)
+		return err
+	})
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to resume user", zap.Int("user_id", user.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resume user"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("User resumed", zap.Int("user_id", user.ID), zap.String("missed", mode), zap.Int("overdue", missed))
+	ctx.JSON(http.StatusOK, ResumeUserResponse{Message: "User resumed successfully", User: user, Overdue: missed})
+}
+
+// bindPauseRequest разбирает необязательное тело запроса паузы, иначе отвечает клиенту 400.
+func bindPauseRequest(ctx *gin.Context, start time.Time) (PauseRequest, bool) {
+	var req PauseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return req, false
+	}
+	if err := pausing.Validate(req.Until, req.ResumeMode, start); err != nil {
+		logRequestDetails(ctx, start).Info("Invalid pause", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+// resumeMode возвращает режим возобновления из параметра missed, иначе отвечает клиенту 400.
+func resumeMode(ctx *gin.Context, start time.Time) (string, bool) {
+	mode := ctx.Query("missed")
+	if err := pausing.ValidateMode(mode); err != nil {
+		logRequestDetails(ctx, start).Info("Invalid resume mode", zap.String("missed", mode))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return mode, false
+	}
+	return mode, true
+}
+
+// findReminderToPause ищет неотправленное напоминание из параметра id, иначе отвечает клиенту.
+func findReminderToPause(ctx *gin.Context, start time.Time) (models.Reminder, bool) {
+	reminderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Invalid reminder ID", zap.String("reminder_id", ctx.Param("id")))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return models.Reminder{}, false
+	}
+
+	reminder, err := findPendingReminder(database.DB, reminderID)
+	switch {
+	case errors.Is(err, errReminderNotFound):
+		logRequestDetails(ctx, start).Info("No reminder found with the given ID", zap.Int("reminder_id", reminderID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No reminder found with the given ID"})
+		return reminder, false
+	case errors.Is(err, errReminderAlreadySent), errors.Is(err, errReminderArchived):
+		logRequestDetails(ctx, start).Info("Reminder cannot be paused", zap.Int("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return reminder, false
+	case err != nil:
+		logRequestDetails(ctx, start).Error("Failed to find reminder", zap.Int("reminder_id", reminderID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find reminder"})
+		return reminder, false
+	}
+	return reminder, true
+}
@@ -0,0 +1,35 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPause(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "pause", method: http.MethodPost, path: "/reminders/3/pause", status: http.StatusOK,
+			body: `{"until": "2030-02-01T00:00:00Z", "resume_mode": "catch_up"}`},
+		{name: "pause_invalid_mode", method: http.MethodPost, path: "/reminders/3/pause", status: http.StatusBadRequest,
+			body: `{"resume_mode": "later"}`},
+		{name: "pause_already_sent", method: http.MethodPost, path: "/reminders/4/pause", status: http.StatusBadRequest},
+		{name: "pause_not_found", method: http.MethodPost, path: "/reminders/99/pause", status: http.StatusNotFound},
+		{name: "resume_not_paused", method: http.MethodPost, path: "/reminders/3/resume", status: http.StatusBadRequest},
+	})
+}
+
+func TestParseTime(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "weekdays", method: http.MethodPost, path: "/parse-time", status: http.StatusOK,
+			body: `{"text": "every weekday at 8:30", "time_zone": "Europe/Moscow", "now": "2026-10-19T14:20:00+03:00"}`},
+		{name: "tomorrow_ru", method: http.MethodPost, path: "/parse-time", status: http.StatusOK,
+			body: `{"text": "завтра в 9 вечера", "time_zone": "Europe/Moscow", "now": "2026-10-19T14:20:00+03:00"}`},
+		{name: "past", method: http.MethodPost, path: "/parse-time", status: http.StatusBadRequest,
+			body: `{"text": "2020-01-01 10:00", "now": "2026-10-19T14:20:00Z"}`},
+		{name: "unrecognized", method: http.MethodPost, path: "/parse-time", status: http.StatusBadRequest,
+			body: `{"text": "when pigs fly"}`},
+		{name: "unknown_time_zone", method: http.MethodPost, path: "/parse-time", status: http.StatusBadRequest,
+			body: `{"text": "tomorrow", "time_zone": "Mars/Olympus"}`},
+		{name: "missing_text", method: http.MethodPost, path: "/parse-time", status: http.StatusBadRequest,
+			body: `{}`},
+	})
+}
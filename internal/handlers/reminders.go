@@ -0,0 +1,482 @@
+package handlers
+
+import (
+	"Reminders/internal/anchors"
+	"Reminders/internal/blobstore"
+	"Reminders/internal/models"
+	"Reminders/internal/recurrence"
+	"Reminders/internal/templating"
+	"Reminders/internal/tgformat"
+	"Reminders/internal/webhooks"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Максимальная длина подписи к вложению в Telegram
+	maxCaptionLength = 1024
+	// Максимальное количество получателей одного напоминания
+	maxRecipients = 50
+	// Максимальное количество меток одного напоминания
+	maxTags = 20
+	// Максимальная длина названия метки или списка
+	maxNameLength = 64
+)
+
+var (
+	errReminderNotFound    = errors.New("reminder not found")
+	errReminderAlreadySent = errors.New("reminder has already been sent")
+	errReminderArchived    = errors.New("reminder is archived")
+	errUserNotFound        = errors.New("user not found")
+	errPolicyNotFound      = errors.New("escalation policy not found")
+	errListNotFound        = errors.New("list not found")
+	errTagNotFound         = errors.New("tag not found")
+)
+
+// invalidReminderError - ошибка в полях напоминания, текст которой отдаётся клиенту с кодом 400.
+type invalidReminderError struct{ error }
+
+func (e invalidReminderError) Unwrap() error { return e.error }
+
+// validateReminder проверяет обязательные поля и настройки напоминания.
+func validateReminder(r models.Reminder) error {
+	if err := validateRequired(r); err != nil {
+		return err
+	}
+	return validateOptions(r)
+}
+
+// validateRequired проверяет обязательные поля напоминания.
+func validateRequired(r models.Reminder) error {
+	if r.UserID <= 0 {
+		return errors.New("user_id is required")
+	}
+	if r.Message == "" && r.AttachmentType == "" {
+		return errors.New("message is required")
+	}
+	if r.SendAt.IsZero() && !r.Anchored() {
+		return errors.New("send_at is required")
+	}
+	return nil
+}
+
+// validateOptions проверяет часовой пояс, правило повторения, шаблон, разметку и вложение.
+func validateOptions(r models.Reminder) error {
+	if r.TimeZone != "" {
+		if _, err := time.LoadLocation(r.TimeZone); err != nil {
+			return fmt.Errorf("unknown time_zone %q", r.TimeZone)
+		}
+	}
+	if r.Recurrence != "" {
+		if _, err := recurrence.Parse(r.Recurrence); err != nil {
+			return fmt.Errorf("invalid recurrence: %w", err)
+		}
+	}
+	if err := validateAttachment(r); err != nil {
+		return err
+	}
+	if err := validateRecipients(r.Recipients); err != nil {
+		return err
+	}
+	if err := validateTags(r.Tags); err != nil {
+		return err
+	}
+	if err := anchors.Validate(r); err != nil {
+		return err
+	}
+	if err := r.MisfireSettings.Validate(); err != nil {
+		return err
+	}
+	if !models.ValidPriority(r.Priority) {
+		return fmt.Errorf("priority must be one of %s, %s, %s", models.PriorityLow, models.PriorityNormal, models.PriorityHigh)
+	}
+	if !tgformat.ValidMode(r.ParseMode) {
+		return fmt.Errorf("parse_mode must be one of %s, %s", tgformat.ModeMarkdownV2, tgformat.ModeHTML)
+	}
+
+	// Разметка шаблона проверяется на результате с пробными данными
+	text := r.Message
+	if r.IsTemplate {
+		var err error
+		text, err = templating.Validate(r.Message, func(s string) string { return tgformat.Escape(r.ParseMode, s) })
+		if err != nil {
+			return fmt.Errorf("invalid message template: %w", err)
+		}
+	}
+	if err := tgformat.Validate(r.ParseMode, text); err != nil {
+		return fmt.Errorf("invalid %s markup: %w", r.ParseMode, err)
+	}
+	if r.AttachmentType != "" && utf8.RuneCountInString(text) > maxCaptionLength {
+		return fmt.Errorf("message must be at most %d characters when an attachment is set", maxCaptionLength)
+	}
+	return nil
+}
+
+// validateAttachment проверяет согласованность полей вложения.
+func validateAttachment(r models.Reminder) error {
+	switch r.AttachmentType {
+	case "":
+		if r.AttachmentFileID != "" || r.AttachmentKey != "" {
+			return errors.New("attachment_type is required for an attachment")
+		}
+		return nil
+	case models.AttachmentPhoto, models.AttachmentDocument, models.AttachmentVoice:
+	default:
+		return fmt.Errorf("attachment_type must be one of %s, %s, %s",
+			models.AttachmentPhoto, models.AttachmentDocument, models.AttachmentVoice)
+	}
+	if r.AttachmentFileID == "" && r.AttachmentKey == "" {
+		return errors.New("attachment_file_id is required, or upload the file to /reminders/{id}/attachment")
+	}
+	return nil
+}
+
+// validateRecipients проверяет получателей напоминания.
+func validateRecipients(recipients []models.ReminderRecipient) error {
+	if len(recipients) > maxRecipients {
+		return fmt.Errorf("at most %d recipients are allowed", maxRecipients)
+	}
+
+	users := make(map[int]bool)
+	chats := make(map[int64]bool)
+	for i, rec := range recipients {
+		switch rec.Kind {
+		case models.RecipientUser:
+			if rec.UserID == nil || *rec.UserID <= 0 {
+				return fmt.Errorf("recipients[%d]: user_id is required for %s", i, rec.Kind)
+			}
+			if users[*rec.UserID] {
+				return fmt.Errorf("recipients[%d]: duplicate user_id %d", i, *rec.UserID)
+			}
+			users[*rec.UserID] = true
+		case models.RecipientChat:
+			if rec.ChatID == 0 {
+				return fmt.Errorf("recipients[%d]: chat_id is required for %s", i, rec.Kind)
+			}
+			if chats[rec.ChatID] {
+				return fmt.Errorf("recipients[%d]: duplicate chat_id %d", i, rec.ChatID)
+			}
+			chats[rec.ChatID] = true
+		default:
+			return fmt.Errorf("recipients[%d]: kind must be one of %s, %s", i, models.RecipientUser, models.RecipientChat)
+		}
+	}
+	return nil
+}
+
+// validateTags проверяет метки напоминания. Метка задаётся идентификатором или названием.
+func validateTags(tags []models.Tag) error {
+	if len(tags) > maxTags {
+		return fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+	for i, tag := range tags {
+		if tag.ID <= 0 && strings.TrimSpace(tag.Name) == "" {
+			return fmt.Errorf("tags[%d]: id or name is required", i)
+		}
+		if utf8.RuneCountInString(tag.Name) > maxNameLength {
+			return fmt.Errorf("tags[%d]: name must be at most %d characters", i, maxNameLength)
+		}
+	}
+	return nil
+}
+
+// applyChanges переносит изменяемые поля из upd в r. Загруженный файл остаётся
+// вложением, пока клиент не заменит его ссылкой attachment_file_id; ключ заменённого
+// файла возвращается, чтобы удалить его после сохранения.
+func applyChanges(r *models.Reminder, upd models.Reminder) (staleKey string) {
+	r.UserID = upd.UserID
+	r.Message = upd.Message
+	r.IsTemplate = upd.IsTemplate
+	r.ParseMode = upd.ParseMode
+	r.SendAt = upd.SendAt
+	r.TimeZone = upd.TimeZone
+	r.Recurrence = upd.Recurrence
+	r.Urgent = upd.Urgent
+	r.Priority = upd.Priority
+	r.MisfireSettings = upd.MisfireSettings
+	r.EscalationPolicyID = upd.EscalationPolicyID
+	r.Recipients = upd.Recipients
+	r.ListID = upd.ListID
+	r.Tags = upd.Tags
+	r.AnchorEventID = upd.AnchorEventID
+	r.AnchorReminderID = upd.AnchorReminderID
+	r.AnchorOn = upd.AnchorOn
+	r.AnchorOffset = upd.AnchorOffset
+	// Отложенная доставка пересчитывается по новым данным
+	r.DeferredUntil = nil
+
+	if r.AttachmentKey == "" || upd.AttachmentFileID != "" {
+		staleKey = r.AttachmentKey
+		r.AttachmentKey = ""
+		r.AttachmentType = upd.AttachmentType
+		r.AttachmentFileID = upd.AttachmentFileID
+	}
+	r.UpdatedAt = time.Now()
+	return staleKey
+}
+
+// removeAttachment удаляет загруженный файл вложения, ошибки только логируются.
+func removeAttachment(key string) {
+	if key == "" || blobstore.Files == nil {
+		return
+	}
+	if err := blobstore.Files.Delete(key); err != nil {
+		logger.Error("Failed to delete attachment file", zap.String("attachment_key", key), zap.Error(err))
+	}
+}
+
+// checkUser проверяет, что пользователь существует и не архивирован.
+func checkUser(tx *gorm.DB, userID int) error {
+	var count int64
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %d", errUserNotFound, userID)
+	}
+	return nil
+}
+
+// checkReferences проверяет владельца напоминания, получателей-пользователей, список, метки,
+// политику эскалации и якорь, которые должны принадлежать владельцу, и вычисляет время отправки по якорю.
+func checkReferences(tx *gorm.DB, r *models.Reminder) error {
+	if err := checkUser(tx, r.UserID); err != nil {
+		return err
+	}
+	for _, rec := range r.Recipients {
+		if rec.Kind != models.RecipientUser {
+			continue
+		}
+		if err := checkUser(tx, *rec.UserID); err != nil {
+			return err
+		}
+	}
+	if err := checkReminderList(tx, r.UserID, r.ListID); err != nil {
+		return err
+	}
+	if err := resolveTags(tx, r); err != nil {
+		return err
+	}
+	if err := anchors.Resolve(tx, r); err != nil {
+		return err
+	}
+	if r.EscalationPolicyID == nil {
+		return nil
+	}
+
+	var count int64
+	err := tx.Model(&models.EscalationPolicy{}).
+		Where("id = ? AND user_id = ?", *r.EscalationPolicyID, r.UserID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %d", errPolicyNotFound, *r.EscalationPolicyID)
+	}
+	return nil
+}
+
+// checkReminderList проверяет, что список напоминания принадлежит его владельцу.
+func checkReminderList(tx *gorm.DB, userID int, listID *int) error {
+	if listID == nil {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.ReminderList{}).Where("id = ? AND user_id = ?", *listID, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %d", errListNotFound, *listID)
+	}
+	return nil
+}
+
+// resolveTags заменяет метки напоминания метками его владельца. Метки, заданные названием,
+// ищутся по нему; новые названия остаются без идентификатора и создаются при сохранении.
+func resolveTags(tx *gorm.DB, r *models.Reminder) error {
+	tags := make([]models.Tag, 0, len(r.Tags))
+	seen := make(map[string]bool)
+	for _, tag := range r.Tags {
+		name := strings.TrimSpace(tag.Name)
+		var found models.Tag
+		var err error
+		if tag.ID > 0 {
+			err = tx.Where("id = ? AND user_id = ?", tag.ID, r.UserID).First(&found).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %d", errTagNotFound, tag.ID)
+			}
+		} else {
+			err = tx.Where("user_id = ? AND name = ?", r.UserID, name).First(&found).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				found, err = models.Tag{UserID: r.UserID, Name: name}, nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if seen[found.Name] {
+			continue
+		}
+		seen[found.Name] = true
+		tags = append(tags, found)
+	}
+	r.Tags = tags
+	return nil
+}
+
+// createReminder проверяет и сохраняет новое напоминание. Файлы загружаются
+// отдельно, поэтому attachment_key из запроса не принимается; состояние отправки,
+// паузы и архивации из запроса тоже сбрасывается.
+func createReminder(tx *gorm.DB, r *models.Reminder) error {
+	r.AttachmentKey = ""
+	if err := validateReminder(*r); err != nil {
+		return err
+	}
+	if err := checkReferences(tx, r); err != nil {
+		return err
+	}
+
+	r.ID = 0
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = time.Now()
+	r.IsSent = false
+	r.SentCount = 0
+	r.DeferredUntil = nil
+	r.AckedAt = nil
+	r.DeliveryStatus = ""
+	r.DeliveryError = ""
+	r.PausedAt = nil
+	r.PausedUntil = nil
+	r.ResumeMode = ""
+	r.ArchivedAt = nil
+
+	if err := saveReminder(tx, r); err != nil {
+		return err
+	}
+	return webhooks.Publish(tx, models.EventReminderCreated, *r)
+}
+
+// saveReminder сохраняет напоминание и заменяет его метки и список получателей.
+func saveReminder(tx *gorm.DB, r *models.Reminder) error {
+	if err := tx.Omit("Recipients", "Tags").Save(r).Error; err != nil {
+		return err
+	}
+	if err := saveTags(tx, r); err != nil {
+		return err
+	}
+	if err := tx.Where("reminder_id = ?", r.ID).Delete(&models.ReminderRecipient{}).Error; err != nil {
+		return err
+	}
+	// Напоминания, отсчитываемые от этого, переносятся вместе с ним
+	if _, err := anchors.RescheduleReminder(tx, *r); err != nil {
+		return err
+	}
+	if len(r.Recipients) == 0 {
+		return nil
+	}
+
+	for i := range r.Recipients {
+		rec := &r.Recipients[i]
+		rec.ID = 0
+		rec.ReminderID = r.ID
+		rec.CreatedAt = time.Now()
+		rec.InactiveReason = ""
+		rec.DeactivatedAt = nil
+		if rec.Kind == models.RecipientUser {
+			rec.ChatID = 0
+		} else {
+			rec.UserID = nil
+		}
+	}
+	return tx.Create(&r.Recipients).Error
+}
+
+// saveTags создаёт новые метки и заменяет ими метки напоминания.
+func saveTags(tx *gorm.DB, r *models.Reminder) error {
+	for i := range r.Tags {
+		tag := &r.Tags[i]
+		if tag.ID != 0 {
+			continue
+		}
+		tag.CreatedAt = time.Now()
+		tag.UpdatedAt = time.Now()
+		if err := tx.Create(tag).Error; err != nil {
+			return err
+		}
+	}
+	// Replace с пустым списком только удаляет старые связи
+	tags := r.Tags
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+	return tx.Model(r).Association("Tags").Replace(tags)
+}
+
+// findPendingReminder ищет напоминание, которое ещё можно изменять.
+func findPendingReminder(tx *gorm.DB, id int) (models.Reminder, error) {
+	var reminder models.Reminder
+	if err := tx.First(&reminder, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return reminder, errReminderNotFound
+		}
+		return reminder, err
+	}
+	if reminder.IsSent {
+		return reminder, errReminderAlreadySent
+	}
+	if reminder.ArchivedAt != nil {
+		return reminder, errReminderArchived
+	}
+	return reminder, nil
+}
+
+// updateReminder обновляет неотправленное напоминание.
+// Возвращает ключ заменённого файла вложения, который нужно удалить после фиксации транзакции.
+func updateReminder(tx *gorm.DB, id int, upd models.Reminder) (models.Reminder, string, error) {
+	if id <= 0 {
+		return models.Reminder{}, "", invalidReminderError{errors.New("id is required")}
+	}
+	if err := validateRequired(upd); err != nil {
+		return models.Reminder{}, "", invalidReminderError{err}
+	}
+
+	reminder, err := findPendingReminder(tx, id)
+	if err != nil {
+		return reminder, "", err
+	}
+
+	staleKey := applyChanges(&reminder, upd)
+	if err := validateOptions(reminder); err != nil {
+		return reminder, "", invalidReminderError{err}
+	}
+	if err := checkReferences(tx, &reminder); err != nil {
+		return reminder, "", err
+	}
+	if err := saveReminder(tx, &reminder); err != nil {
+		return reminder, "", err
+	}
+	return reminder, staleKey, webhooks.Publish(tx, models.EventReminderUpdated, reminder)
+}
+
+// deleteReminder удаляет неотправленное напоминание. Возвращает ключ файла вложения,
+// который нужно удалить после фиксации транзакции.
+func deleteReminder(tx *gorm.DB, id int) (string, error) {
+	reminder, err := findPendingReminder(tx, id)
+	if err != nil {
+		return "", err
+	}
+	if err := anchors.Detach(tx, reminder.ID); err != nil {
+		return "", err
+	}
+	if err := webhooks.Publish(tx, models.EventReminderDeleted, reminder); err != nil {
+		return "", err
+	}
+	return reminder.AttachmentKey, tx.Delete(&reminder).Error
+}
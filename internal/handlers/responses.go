@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"Reminders/internal/models"
+	"time"
+)
+
+// Схемы ответов API. Обработчики отвечают этими типами, и по ним же swag строит
+// спецификацию в internal/docs, поэтому документация не расходится с ответами.
+
+// ErrorResponse - ответ с ошибкой. Ошибки проверки запроса приходят в error,
+// отсутствующие объекты и недопустимые состояния - в message.
+type ErrorResponse struct {
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// SuccessResponse - ответ без данных.
+type SuccessResponse struct {
+	Message string `json:"message"`
+}
+
+// ReminderResponse - ответ с одним напоминанием.
+type ReminderResponse struct {
+	Message  string          `json:"message,omitempty"`
+	Reminder models.Reminder `json:"reminder"`
+}
+
+// RemindersResponse - список напоминаний. NextAfterID заполнен, если запрошена
+// страница (limit) и за ней есть ещё напоминания.
+type RemindersResponse struct {
+	Reminders   []models.Reminder `json:"reminders"`
+	NextAfterID int               `json:"next_after_id,omitempty"`
+}
+
+// HistoryResponse - история доставки напоминания.
+type HistoryResponse struct {
+	History []models.ReminderHistory `json:"history"`
+}
+
+// UserResponse - ответ с одним пользователем.
+type UserResponse struct {
+	Message string      `json:"message,omitempty"`
+	User    models.User `json:"user"`
+}
+
+// UsersResponse - список пользователей.
+type UsersResponse struct {
+	Users []models.User `json:"users"`
+}
+
+// ResumeUserResponse - ответ на снятие паузы пользователя. Overdue - сколько
+// напоминаний за время паузы просрочено и обработано по режиму missed.
+type ResumeUserResponse struct {
+	Message string      `json:"message"`
+	User    models.User `json:"user"`
+	Overdue int         `json:"overdue"`
+}
+
+// APIKeysResponse - ключи API пользователя без самих ключей.
+type APIKeysResponse struct {
+	APIKeys []models.APIKey `json:"api_keys"`
+}
+
+// ListResponse - ответ с одним списком напоминаний.
+type ListResponse struct {
+	Message string              `json:"message,omitempty"`
+	List    models.ReminderList `json:"list"`
+}
+
+// ListsResponse - списки напоминаний.
+type ListsResponse struct {
+	Lists []models.ReminderList `json:"lists"`
+}
+
+// UpdatedResponse - ответ на массовое изменение. Updated - количество изменённых напоминаний.
+type UpdatedResponse struct {
+	Message string `json:"message"`
+	Updated int    `json:"updated"`
+}
+
+// TagResponse - ответ с одной меткой.
+type TagResponse struct {
+	Message string     `json:"message,omitempty"`
+	Tag     models.Tag `json:"tag"`
+}
+
+// TagsResponse - список меток.
+type TagsResponse struct {
+	Tags []models.Tag `json:"tags"`
+}
+
+// EventResponse - ответ с одним событием.
+type EventResponse struct {
+	Message string       `json:"message,omitempty"`
+	Event   models.Event `json:"event"`
+}
+
+// UpdateEventResponse - ответ на изменение события. Rescheduled - сколько
+// привязанных напоминаний перенесено вместе с событием.
+type UpdateEventResponse struct {
+	Message     string       `json:"message"`
+	Event       models.Event `json:"event"`
+	Rescheduled int          `json:"rescheduled"`
+}
+
+// EventsResponse - список событий.
+type EventsResponse struct {
+	Events []models.Event `json:"events"`
+}
+
+// EscalationPolicyResponse - ответ с одной политикой эскалации.
+type EscalationPolicyResponse struct {
+	Message          string                  `json:"message,omitempty"`
+	EscalationPolicy models.EscalationPolicy `json:"escalation_policy"`
+}
+
+// EscalationPoliciesResponse - список политик эскалации.
+type EscalationPoliciesResponse struct {
+	EscalationPolicies []models.EscalationPolicy `json:"escalation_policies"`
+}
+
+// WebhookResponse - ответ с одним вебхуком. Секрет заполнен только при создании.
+type WebhookResponse struct {
+	Message string         `json:"message,omitempty"`
+	Webhook models.Webhook `json:"webhook"`
+}
+
+// WebhooksResponse - список вебхуков без секретов.
+type WebhooksResponse struct {
+	Webhooks []models.Webhook `json:"webhooks"`
+}
+
+// WebhookDeliveryResponse - результат повторной доставки вебхука.
+type WebhookDeliveryResponse struct {
+	Message  string                 `json:"message"`
+	Delivery models.WebhookDelivery `json:"delivery"`
+}
+
+// WebhookDeliveriesResponse - журнал доставок вебхука.
+type WebhookDeliveriesResponse struct {
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+}
+
+// ParseTimeResponse - разобранное выражение времени. Поля совпадают с полями напоминания,
+// их можно передать в POST /reminders как есть.
+type ParseTimeResponse struct {
+	SendAt     time.Time `json:"send_at"`
+	TimeZone   string    `json:"time_zone" example:"Europe/Moscow"`
+	Recurrence string    `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"`
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/webhooks"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Как часто поток проверяет журнал событий. События пишут и API, и бот,
+// поэтому общий для них источник - база, а не память процесса
+var streamPollInterval = 2 * time.Second
+
+const (
+	// Как часто отправляется комментарий, чтобы прокси не закрывали простаивающее соединение
+	streamHeartbeat = 30 * time.Second
+	// Сколько событий читается из журнала за один запрос
+	streamBatchSize = 500
+	// Транзакция с меньшим номером события может завершиться позже транзакции с большим,
+	// и такое событие появится в журнале, когда поток уже прошёл его номер. Поэтому
+	// события за последние streamCommitLag перечитываются, а отправленные запоминаются
+	streamCommitLag = 30 * time.Second
+)
+
+// StreamRemindersHandler godoc
+// @Summary Поток изменений напоминаний
+// @Description Server-Sent Events с событиями напоминаний владельца ключа API: reminder.created, reminder.updated, reminder.deleted, reminder.sent, reminder.failed, reminder.acknowledged. Поле id события - номер в журнале; после переподключения с заголовком Last-Event-ID (или параметром last_event_id) поток продолжается со следующего события. Без него передаются только новые события. Событие транзакции, завершившейся позже, может прийти после событий с большим id. Журнал хранится 30 дней
+// @Tags reminders
+// @Produce text/event-stream
+// @Param Authorization header string false "Bearer <API key>"
+// @Param access_token query string false "API key, если нельзя передать заголовок"
+// @Param Last-Event-ID header int false "Последнее полученное событие"
+// @Param last_event_id query int false "Последнее полученное событие"
+// @Success 200 {object} webhooks.Payload
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /reminders/stream [get]
+func StreamRemindersHandler(ctx *gin.Context) {
+	start := time.Now()
+	userID := authUserID(ctx)
+
+	lastID, ok := streamStart(ctx, start, userID)
+	if !ok {
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	// nginx иначе буферизует ответ и события приходят пачками
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+	logRequestDetails(ctx, start).Info("Reminder stream opened", zap.Int("user_id", userID), zap.Int("last_event_id", lastID))
+
+	poll := time.NewTicker(streamPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	// События окна запаздывающих транзакций, которые уже есть в журнале, считаются полученными
+	sent, err := recentEventIDs(userID, lastID, time.Now().Add(-streamCommitLag))
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch reminder events", zap.Int("user_id", userID), zap.Error(err))
+		return
+	}
+
+	for {
+		since := time.Now().Add(-streamCommitLag)
+		var late, events []models.ReminderEvent
+		err := database.DB.Where("user_id = ? AND id <= ? AND created_at >= ?", userID, lastID, since).
+			Order("id").
+			Find(&late).Error
+		if err == nil {
+			err = database.DB.Where("user_id = ? AND id > ?", userID, lastID).
+				Order("id").
+				Limit(streamBatchSize).
+				Find(&events).Error
+		}
+		if err != nil {
+			logRequestDetails(ctx, start).Error("Failed to fetch reminder events", zap.Int("user_id", userID), zap.Error(err))
+			return
+		}
+
+		written := 0
+		for _, event := range append(late, events...) {
+			if _, ok := sent[event.ID]; ok {
+				continue
+			}
+			ctx.Render(-1, sse.Event{
+				Id:    strconv.Itoa(event.ID),
+				Event: event.Type,
+				Data:  webhooks.NewPayload(event),
+			})
+			sent[event.ID] = event.CreatedAt
+			written++
+			if event.ID > lastID {
+				lastID = event.ID
+			}
+		}
+		if written > 0 {
+			ctx.Writer.Flush()
+		}
+		for id, createdAt := range sent {
+			if createdAt.Before(since) {
+				delete(sent, id)
+			}
+		}
+		// Отставший клиент догоняет журнал без пауз
+		if len(events) == streamBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Request.Context().Done():
+			logRequestDetails(ctx, start).Info("Reminder stream closed", zap.Int("user_id", userID), zap.Int("last_event_id", lastID))
+			return
+		case <-heartbeat.C:
+			if _, err := ctx.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		case <-poll.C:
+		}
+	}
+}
+
+// recentEventIDs возвращает события пользователя не позже lastID, записанные после since,
+// с временем их создания.
+func recentEventIDs(userID, lastID int, since time.Time) (map[int]time.Time, error) {
+	var events []models.ReminderEvent
+	err := database.DB.Select("id", "created_at").
+		Where("user_id = ? AND id <= ? AND created_at >= ?", userID, lastID, since).
+		Find(&events).Error
+	ids := make(map[int]time.Time, len(events))
+	for _, event := range events {
+		ids[event.ID] = event.CreatedAt
+	}
+	return ids, err
+}
+
+// streamStart возвращает номер события, после которого начинается поток: из Last-Event-ID,
+// иначе последнее событие пользователя в журнале. При ошибке отвечает клиенту.
+func streamStart(ctx *gin.Context, start time.Time, userID int) (int, bool) {
+	value := ctx.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = ctx.Query("last_event_id")
+	}
+	if value != "" {
+		lastID, err := strconv.Atoi(value)
+		if err != nil || lastID < 0 {
+			logRequestDetails(ctx, start).Info("Invalid Last-Event-ID", zap.String("last_event_id", value))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return 0, false
+		}
+		return lastID, true
+	}
+
+	var lastID int
+	err := database.DB.Model(&models.ReminderEvent{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&lastID).Error
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to find last reminder event", zap.Int("user_id", userID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open reminder stream"})
+		return 0, false
+	}
+	return lastID, true
+}
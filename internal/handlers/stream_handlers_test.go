@@ -0,0 +1,94 @@
+package handlers_test
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/handlers"
+	"Reminders/internal/models"
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addStreamEvent записывает событие пользователя 1 с номером id.
+func addStreamEvent(t *testing.T, id int, at time.Time) {
+	t.Helper()
+	event := models.ReminderEvent{ID: id, UserID: 1, ReminderID: 1, Type: models.EventReminderUpdated, Data: []byte(`{"id": 1}`), CreatedAt: at}
+	if err := database.DB.Create(&event).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Событие транзакции, завершившейся после события с большим номером, всё равно
+// приходит в поток, а уже полученные события не повторяются.
+func TestStreamDeliversLateEvents(t *testing.T) {
+	t.Cleanup(handlers.SetStreamPollInterval(50 * time.Millisecond))
+	router := newRouter(t)
+	rec := do(router, http.MethodPost, "/users/1/api-keys", `{"name": "stream"}`, adminAuth)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("issue key: %d %s", rec.Code, rec.Body)
+	}
+	var issued handlers.APIKeyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &issued); err != nil {
+		t.Fatal(err)
+	}
+
+	// Событие 10 клиент получил до переподключения
+	addStreamEvent(t, 10, time.Now())
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	reqCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, srv.URL+"/reminders/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+issued.Key)
+	req.Header.Set("Last-Event-ID", "10")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("stream: status %d", resp.StatusCode)
+	}
+
+	ids := make(chan string)
+	go func() {
+		defer close(ids)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if id, ok := strings.CutPrefix(scanner.Text(), "id:"); ok {
+				ids <- strings.TrimSpace(id)
+			}
+		}
+	}()
+	next := func() string {
+		select {
+		case id := <-ids:
+			return id
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event in the stream")
+			return ""
+		}
+	}
+
+	addStreamEvent(t, 20, time.Now())
+	if id := next(); id != "20" {
+		t.Fatalf("first event %s, want 20", id)
+	}
+	// Событие 15 записано раньше, но стало видно только после события 20
+	addStreamEvent(t, 15, time.Now().Add(-time.Second))
+	if id := next(); id != "15" {
+		t.Fatalf("late event %s, want 15", id)
+	}
+	addStreamEvent(t, 30, time.Now())
+	if id := next(); id != "30" {
+		t.Fatalf("event after the late one %s, want 30 without repeats", id)
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errInvalidTag = errors.New("invalid tag")
+	errTagExists  = errors.New("tag with this name already exists")
+)
+
+// GetTagsHandler godoc
+// @Summary Список меток
+// @Description Получить метки всех пользователей или одного пользователя
+// @Tags tags
+// @Produce json
+// @Param user_id query int false "User ID"
+// @Success 200 {object} TagsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /tags [get]
+func GetTagsHandler(ctx *gin.Context) {
+	start := time.Now()
+	query := database.DB.Order("name, id")
+	if userID := ctx.Query("user_id"); userID != "" {
+		if _, err := strconv.Atoi(userID); err != nil {
+			logRequestDetails(ctx, start).Info("Invalid user ID", zap.String("user_id", userID))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+
+	tags := []models.Tag{}
+	if err := query.Find(&tags).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch tags", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Tags fetched successfully", zap.Int("row_count", len(tags)))
+	ctx.JSON(http.StatusOK, TagsResponse{Tags: tags})
+}
+
+// GetTagHandler godoc
+// @Summary Получить метку
+// @Description Получить метку. Напоминания с меткой возвращает GET /reminders?tag={name}
+// @Tags tags
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Success 200 {object} TagResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /tags/{id} [get]
+func GetTagHandler(ctx *gin.Context) {
+	start := time.Now()
+	tag, ok := findTag(ctx, start)
+	if !ok {
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Tag fetched successfully", zap.Int("tag_id", tag.ID))
+	ctx.JSON(http.StatusOK, TagResponse{Tag: tag})
+}
+
+// CreateTagHandler godoc
+// @Summary Создать метку
+// @Description Создать метку. Метки также создаются автоматически, если в tags напоминания указано новое название
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param tag body models.Tag true "Tag"
+// @Success 201 {object} TagResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /tags [post]
+func CreateTagHandler(ctx *gin.Context) {
+	start := time.Now()
+	var tag models.Tag
+
+	if err := ctx.ShouldBindJSON(&tag); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	tag.ID = 0
+	tag.Name = strings.TrimSpace(tag.Name)
+	if !checkTag(ctx, start, tag) {
+		return
+	}
+
+	tag.CreatedAt = time.Now()
+	tag.UpdatedAt = time.Now()
+	if err := database.DB.Create(&tag).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to create tag", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Tag created successfully", zap.Int("tag_id", tag.ID))
+	ctx.JSON(http.StatusCreated, TagResponse{Message: "Tag created successfully", Tag: tag})
+}
+
+// UpdateTagHandler godoc
+// @Summary Переименовать метку
+// @Description Переименовать метку. Новое название видно у всех напоминаний с этой меткой
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Param tag body models.Tag true "Tag"
+// @Success 200 {object} TagResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /tags/{id} [put]
+func UpdateTagHandler(ctx *gin.Context) {
+	start := time.Now()
+	var upd models.Tag
+
+	if err := ctx.ShouldBindJSON(&upd); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	tag, ok := findTag(ctx, start)
+	if !ok {
+		return
+	}
+	// Владелец метки не меняется, иначе ею оказались бы помечены чужие напоминания
+	upd.ID = tag.ID
+	upd.UserID = tag.UserID
+	upd.Name = strings.TrimSpace(upd.Name)
+	if !checkTag(ctx, start, upd) {
+		return
+	}
+
+	tag.Name = upd.Name
+	tag.UpdatedAt = time.Now()
+	if err := database.DB.Save(&tag).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to update tag", zap.Int("tag_id", tag.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Tag updated successfully", zap.Int("tag_id", tag.ID))
+	ctx.JSON(http.StatusOK, TagResponse{Message: "Tag updated successfully", Tag: tag})
+}
+
+// DeleteTagHandler godoc
+// @Summary Удалить метку
+// @Description Удалить метку и снять её со всех напоминаний
+// @Tags tags
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /tags/{id} [delete]
+func DeleteTagHandler(ctx *gin.Context) {
+	start := time.Now()
+	tag, ok := findTag(ctx, start)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&tag).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to delete tag", zap.Int("tag_id", tag.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Tag deleted successfully", zap.Int("tag_id", tag.ID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
+
+// findTag ищет метку из параметра id, иначе отвечает клиенту.
+func findTag(ctx *gin.Context, start time.Time) (models.Tag, bool) {
+	tagID := ctx.Param("id")
+	var tag models.Tag
+
+	err := database.DB.First(&tag, "id = ?", tagID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logRequestDetails(ctx, start).Info("No tag found with the given ID", zap.String("tag_id", tagID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No tag found with the given ID"})
+		return tag, false
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to find tag", zap.String("tag_id", tagID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find tag"})
+		return tag, false
+	}
+	return tag, true
+}
+
+// checkTag проверяет название и владельца метки, иначе отвечает клиенту.
+func checkTag(ctx *gin.Context, start time.Time, tag models.Tag) bool {
+	err := validateName(tag.UserID, tag.Name, errInvalidTag)
+	if err == nil {
+		err = checkUser(database.DB, tag.UserID)
+	}
+	if err == nil {
+		err = checkNameFree(&models.Tag{}, tag.ID, tag.UserID, tag.Name, errTagExists)
+	}
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errTagExists):
+		logRequestDetails(ctx, start).Info("Tag already exists", zap.Error(err))
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, errUserNotFound), errors.Is(err, errInvalidTag):
+		logRequestDetails(ctx, start).Info("Invalid tag", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		logRequestDetails(ctx, start).Error("Failed to check tag", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check tag"})
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "list", method: http.MethodGet, path: "/tags?user_id=1", status: http.StatusOK},
+		{name: "get", method: http.MethodGet, path: "/tags/1", status: http.StatusOK},
+		{name: "get_not_found", method: http.MethodGet, path: "/tags/99", status: http.StatusNotFound},
+		{name: "create", method: http.MethodPost, path: "/tags", status: http.StatusCreated,
+			body: `{"user_id": 2, "name": "family"}`},
+		{name: "create_duplicate", method: http.MethodPost, path: "/tags", status: http.StatusConflict,
+			body: `{"user_id": 1, "name": "work"}`},
+		{name: "create_empty_name", method: http.MethodPost, path: "/tags", status: http.StatusBadRequest,
+			body: `{"user_id": 1, "name": ""}`},
+		{name: "update", method: http.MethodPut, path: "/tags/1", status: http.StatusOK,
+			body: `{"name": "office"}`},
+		{name: "update_not_found", method: http.MethodPut, path: "/tags/99", status: http.StatusNotFound,
+			body: `{"name": "office"}`},
+		{name: "delete", method: http.MethodDelete, path: "/tags/1", status: http.StatusOK},
+		{name: "delete_not_found", method: http.MethodDelete, path: "/tags/99", status: http.StatusNotFound},
+	})
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/envs"
+	"Reminders/internal/models"
+	"Reminders/internal/tokens"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Время жизни токена привязки чата
+const telegramLinkTTL = 15 * time.Minute
+
+// TelegramLinkResponse - ссылка для привязки чата Telegram
+type TelegramLinkResponse struct {
+	URL       string    `json:"url" example:"https://t.me/reminders_bot?start=3f2a..."`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateTelegramLinkHandler godoc
+// @Summary Создать ссылку для привязки Telegram
+// @Description Выпустить одноразовую ссылку t.me/<bot>?start=<token>. Пользователь открывает её в Telegram, и бот привязывает его чат
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 201 {object} TelegramLinkResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /users/{id}/telegram-link [post]
+func CreateTelegramLinkHandler(ctx *gin.Context) {
+	start := time.Now()
+	botUsername := envs.ServerEnvs.BOT_USERNAME
+	if botUsername == "" {
+		logRequestDetails(ctx, start).Error("BOT_USERNAME is not configured")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Telegram bot is not configured"})
+		return
+	}
+
+	user, ok := findUser(ctx, start)
+	if !ok {
+		return
+	}
+
+	token, err := tokens.New()
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to generate link token", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create Telegram link"})
+		return
+	}
+
+	linkToken := models.TelegramLinkToken{
+		UserID:    user.ID,
+		TokenHash: tokens.Hash(token),
+		ExpiresAt: time.Now().Add(telegramLinkTTL),
+		CreatedAt: time.Now(),
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Заодно удаляются просроченные токены всех пользователей
+		if err := tx.Where("expires_at <= ?", time.Now()).Delete(&models.TelegramLinkToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&linkToken).Error
+	})
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to create Telegram link", zap.Int("user_id", user.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create Telegram link"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Telegram link created", zap.Int("user_id", user.ID), zap.Time("expires_at", linkToken.ExpiresAt))
+	ctx.JSON(http.StatusCreated, TelegramLinkResponse{
+		URL:       "https://t.me/" + botUsername + "?start=" + token,
+		ExpiresAt: linkToken.ExpiresAt,
+	})
+}
+
+// DeleteTelegramLinkHandler godoc
+// @Summary Отвязать чат Telegram
+// @Description Отвязать чат Telegram от пользователя. Из чата это делается командой /unlink
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param chat_id path int true "Telegram chat ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/telegram-link/{chat_id} [delete]
+func DeleteTelegramLinkHandler(ctx *gin.Context) {
+	start := time.Now()
+	userID := ctx.Param("id")
+	chatID, err := strconv.ParseInt(ctx.Param("chat_id"), 10, 64)
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Invalid chat ID", zap.String("chat_id", ctx.Param("chat_id")))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chat ID"})
+		return
+	}
+
+	result := database.DB.Where("user_id = ? AND chat_id = ?", userID, chatID).Delete(&models.TelegramLink{})
+	if result.Error != nil {
+		logRequestDetails(ctx, start).Error("Failed to unlink chat", zap.String("user_id", userID), zap.Int64("chat_id", chatID), zap.Error(result.Error))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink chat"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		logRequestDetails(ctx, start).Info("Chat is not linked to the user", zap.String("user_id", userID), zap.Int64("chat_id", chatID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Chat is not linked to the user"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Chat unlinked", zap.String("user_id", userID), zap.Int64("chat_id", chatID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "Chat unlinked successfully"})
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"Reminders/internal/anchors"
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/transfer"
+	"Reminders/internal/webhooks"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Режимы импорта
+const (
+	// ImportModeCreate - каждая строка создаёт новое напоминание
+	ImportModeCreate = "create"
+	// ImportModeUpsert - строка с external_id обновляет уже загруженное напоминание пользователя
+	ImportModeUpsert = "upsert"
+)
+
+const (
+	// Размер пачки при потоковой выгрузке
+	exportBatchSize = 500
+	// Максимальный размер импортируемого файла
+	maxImportSize = 32 << 20
+	// Максимальное количество ошибок в ответе импорта
+	maxImportErrors = 1000
+)
+
+var errDryRun = errors.New("dry run")
+
+// ImportLineError - ошибка в строке импортируемого файла
+type ImportLineError struct {
+	Line       int    `json:"line"`
+	ExternalID string `json:"external_id,omitempty"`
+	Error      string `json:"error"`
+}
+
+// ImportResponse - итог импорта
+type ImportResponse struct {
+	Format  string            `json:"format"`
+	Mode    string            `json:"mode"`
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Errors  []ImportLineError `json:"errors"`
+}
+
+// ExportRemindersHandler godoc
+// @Summary Выгрузка напоминаний
+// @Description Потоковая выгрузка всех напоминаний или напоминаний одного пользователя в CSV или NDJSON
+// @Tags transfer
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "csv или ndjson (по умолчанию ndjson)"
+// @Param user_id query int false "User ID"
+// @Success 200 {string} string "Export file"
+// @Failure 400 {object} ErrorResponse
+// @Router /reminders/export [get]
+func ExportRemindersHandler(ctx *gin.Context) {
+	start := time.Now()
+	format := ctx.DefaultQuery("format", transfer.FormatNDJSON)
+
+	writer, err := transfer.NewWriter(format, ctx.Writer)
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Unsupported export format", zap.String("format", format))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Format must be either csv or ndjson"})
+		return
+	}
+
+	query := database.DB.Model(&models.Reminder{}).Preload("Recipients").Preload("Tags").Order("id")
+	if userID := ctx.Query("user_id"); userID != "" {
+		if _, err := strconv.Atoi(userID); err != nil {
+			logRequestDetails(ctx, start).Info("Invalid user ID", zap.String("user_id", userID))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+
+	ctx.Header("Content-Type", transfer.ContentType(format))
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="reminders.%s"`, format))
+	ctx.Status(http.StatusOK)
+
+	// Заголовки уже отправлены, поэтому ошибки в середине выгрузки только логируются
+	count := 0
+	var batch []models.Reminder
+	result := query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, r := range batch {
+			if err := writer.Write(r); err != nil {
+				return err
+			}
+		}
+		count += len(batch)
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		ctx.Writer.Flush()
+		return nil
+	})
+	if result.Error != nil {
+		logRequestDetails(ctx, start).Error("Export interrupted", zap.Int("row_count", count), zap.Error(result.Error))
+		return
+	}
+	if err := writer.Flush(); err != nil {
+		logRequestDetails(ctx, start).Error("Export interrupted", zap.Int("row_count", count), zap.Error(err))
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Reminders exported", zap.String("format", format), zap.Int("row_count", count))
+}
+
+// ImportRemindersHandler godoc
+// @Summary Загрузка напоминаний
+// @Description Загрузить напоминания из CSV или NDJSON. Каждая строка проверяется отдельно, ошибки возвращаются с номером строки. В режиме upsert строки с external_id обновляют ранее загруженные напоминания
+// @Tags transfer
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Accept multipart/form-data
+// @Produce json
+// @Param format query string false "csv или ndjson (по умолчанию ndjson)"
+// @Param mode query string false "create или upsert (по умолчанию create)"
+// @Param dry_run query bool false "Только проверить файл, ничего не сохраняя"
+// @Param file formData file false "Import file"
+// @Success 200 {object} ImportResponse
+// @Success 207 {object} ImportResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /reminders/import [post]
+func ImportRemindersHandler(ctx *gin.Context) {
+	start := time.Now()
+	resp := ImportResponse{
+		Format: ctx.DefaultQuery("format", transfer.FormatNDJSON),
+		Mode:   ctx.DefaultQuery("mode", ImportModeCreate),
+		Errors: []ImportLineError{},
+	}
+	resp.DryRun, _ = strconv.ParseBool(ctx.Query("dry_run"))
+
+	if resp.Mode != ImportModeCreate && resp.Mode != ImportModeUpsert {
+		logRequestDetails(ctx, start).Info("Unknown import mode", zap.String("mode", resp.Mode))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Mode must be either create or upsert"})
+		return
+	}
+
+	body, err := importBody(ctx)
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Failed to read import file", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	reader, err := transfer.NewReader(resp.Format, body)
+	if err != nil {
+		logRequestDetails(ctx, start).Info("Unsupported import format", zap.String("format", resp.Format))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Format must be either csv or ndjson"})
+		return
+	}
+
+	addError := func(line int, r models.Reminder, err error) {
+		resp.Failed++
+		if len(resp.Errors) >= maxImportErrors {
+			return
+		}
+		lineErr := ImportLineError{Line: line, Error: err.Error()}
+		if r.ExternalID != nil {
+			lineErr.ExternalID = *r.ExternalID
+		}
+		resp.Errors = append(resp.Errors, lineErr)
+	}
+
+	var (
+		fileErr   error
+		staleKeys []string
+	)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for {
+			row, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				fileErr = err
+				return err
+			}
+
+			resp.Total++
+			if row.Err != nil {
+				addError(row.Line, row.Reminder, row.Err)
+				continue
+			}
+
+			savePoint := fmt.Sprintf("import_line_%d", row.Line)
+			if err := tx.SavePoint(savePoint).Error; err != nil {
+				return err
+			}
+			status, staleKey, err := importReminder(tx, row.Reminder, resp.Mode == ImportModeUpsert)
+			if err != nil {
+				if err := tx.RollbackTo(savePoint).Error; err != nil {
+					return err
+				}
+				addError(row.Line, row.Reminder, err)
+				continue
+			}
+			if staleKey != "" {
+				staleKeys = append(staleKeys, staleKey)
+			}
+			if status == BatchStatusCreated {
+				resp.Created++
+			} else {
+				resp.Updated++
+			}
+		}
+
+		// При пробном запуске транзакция откатывается, но ограничения базы уже проверены
+		if resp.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	switch {
+	case fileErr != nil:
+		logRequestDetails(ctx, start).Info("Invalid import file", zap.Error(fileErr))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import file: " + fileErr.Error()})
+		return
+	case err != nil && !errors.Is(err, errDryRun):
+		logRequestDetails(ctx, start).Error("Failed to import reminders", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import reminders"})
+		return
+	}
+	if !resp.DryRun {
+		for _, key := range staleKeys {
+			removeAttachment(key)
+		}
+	}
+
+	log := logRequestDetails(ctx, start).With(
+		zap.String("format", resp.Format),
+		zap.String("mode", resp.Mode),
+		zap.Bool("dry_run", resp.DryRun),
+		zap.Int("total", resp.Total),
+		zap.Int("created", resp.Created),
+		zap.Int("updated", resp.Updated),
+		zap.Int("failed", resp.Failed),
+	)
+	if resp.Failed > 0 {
+		log.Info("Reminders partially imported")
+		ctx.JSON(http.StatusMultiStatus, resp)
+		return
+	}
+	log.Info("Reminders imported successfully")
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// importReminder сохраняет строку импорта. Состояние отправки переносится как есть,
+// чтобы восстановленные из копии напоминания не отправлялись повторно.
+// Вторым значением возвращается ключ заменённого файла вложения.
+func importReminder(tx *gorm.DB, r models.Reminder, upsert bool) (string, string, error) {
+	if r.SentCount < 0 {
+		return "", "", errors.New("sent_count must not be negative")
+	}
+	if r.ExternalID != nil && *r.ExternalID == "" {
+		r.ExternalID = nil
+	}
+	// Загруженные файлы не входят в выгрузку, переносятся только ссылки на file_id
+	r.AttachmentKey = ""
+	if r.AttachmentFileID == "" {
+		r.AttachmentType = ""
+	}
+	if err := validateReminder(r); err != nil {
+		return "", "", err
+	}
+	// Идентификаторы выдаёт база, чтобы не конфликтовать с уже существующими записями.
+	// Метки из выгрузки другой базы находятся или создаются по имени
+	r.ID = 0
+	for i := range r.Tags {
+		r.Tags[i].ID = 0
+	}
+	if err := checkReferences(tx, &r); err != nil {
+		return "", "", err
+	}
+
+	if r.ExternalID != nil {
+		var existing models.Reminder
+		err := tx.Where("user_id = ? AND external_id = ?", r.UserID, *r.ExternalID).First(&existing).Error
+		switch {
+		case err == nil && !upsert:
+			return "", "", fmt.Errorf("external_id %q already exists for user %d", *r.ExternalID, r.UserID)
+		case err == nil:
+			staleKey := applyChanges(&existing, r)
+			existing.SentCount = r.SentCount
+			existing.IsSent = r.IsSent
+			// Якорь проверяется ещё раз, теперь с идентификатором обновляемого напоминания
+			if err := anchors.Resolve(tx, &existing); err != nil {
+				return "", "", err
+			}
+			if err := saveReminder(tx, &existing); err != nil {
+				return "", "", err
+			}
+			return BatchStatusUpdated, staleKey, webhooks.Publish(tx, models.EventReminderUpdated, existing)
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return "", "", err
+		}
+	}
+
+	r.DeferredUntil = nil
+	r.AckedAt = nil
+	r.DeliveryStatus = ""
+	r.DeliveryError = ""
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = time.Now()
+	if err := saveReminder(tx, &r); err != nil {
+		return "", "", err
+	}
+	return BatchStatusCreated, "", webhooks.Publish(tx, models.EventReminderCreated, r)
+}
+
+// importBody возвращает импортируемый файл из поля file формы или тело запроса.
+func importBody(ctx *gin.Context) (io.ReadCloser, error) {
+	if strings.HasPrefix(ctx.ContentType(), "multipart/form-data") {
+		fileHeader, err := ctx.FormFile("file")
+		if err != nil {
+			return nil, errors.New("form field file is required")
+		}
+		if fileHeader.Size > maxImportSize {
+			return nil, fmt.Errorf("file must be at most %d bytes", maxImportSize)
+		}
+		return fileHeader.Open()
+	}
+	return http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize), nil
+}
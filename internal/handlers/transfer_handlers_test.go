@@ -0,0 +1,98 @@
+package handlers_test
+
+import (
+	"Reminders/internal/handlers"
+	"Reminders/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransfer(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "export_ndjson", method: http.MethodGet, path: "/reminders/export?user_id=1", status: http.StatusOK},
+		{name: "export_csv", method: http.MethodGet, path: "/reminders/export?user_id=2&format=csv", status: http.StatusOK},
+		{name: "export_unknown_format", method: http.MethodGet, path: "/reminders/export?format=xml", status: http.StatusBadRequest},
+		{name: "import", method: http.MethodPost, path: "/reminders/import", status: http.StatusMultiStatus,
+			body: `{"external_id": "a1", "user_id": 2, "message": "Imported", "send_at": "2030-03-01T10:00:00Z"}` + "\n" +
+				`{"user_id": 2, "send_at": "2030-03-01T10:00:00Z"}` + "\n"},
+		{name: "import_dry_run", method: http.MethodPost, path: "/reminders/import?dry_run=true", status: http.StatusOK,
+			body: `{"user_id": 2, "message": "Imported", "send_at": "2030-03-01T10:00:00Z"}` + "\n"},
+		{name: "import_csv", method: http.MethodPost, path: "/reminders/import?format=csv", status: http.StatusOK,
+			body: "user_id,message,send_at\n2,From CSV,2030-03-02T10:00:00Z\n"},
+		{name: "import_unknown_mode", method: http.MethodPost, path: "/reminders/import?mode=merge", status: http.StatusBadRequest},
+	})
+}
+
+// Выгрузка одной базы загружается в другую с повторением, метками, получателями и
+// часовым поясом, а повторная загрузка того же файла не создаёт дубликатов по external_id.
+func TestTransferRoundTrip(t *testing.T) {
+	source := newRouter(t)
+	created := do(source, http.MethodPost, "/reminders", `{"external_id": "rt-1", "user_id": 2, "message": "Water the plants",
+		"send_at": "2030-02-04T09:00:00+01:00", "time_zone": "Europe/Berlin", "recurrence": "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO",
+		"tags": [{"name": "garden"}, {"name": "home"}],
+		"recipients": [{"kind": "user", "user_id": 1}, {"kind": "chat", "chat_id": -1001234567890}]}`, nil)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", created.Code, created.Body)
+	}
+	export := do(source, http.MethodGet, "/reminders/export?user_id=2", "", nil)
+	if export.Code != http.StatusOK {
+		t.Fatalf("export: %d %s", export.Code, export.Body)
+	}
+	file := export.Body.String()
+
+	target := newRouter(t)
+	first := decodeImport(t, do(target, http.MethodPost, "/reminders/import", file, nil))
+	if first.Failed != 0 || first.Created != strings.Count(file, "\n") {
+		t.Fatalf("import: %+v", first)
+	}
+
+	var list struct {
+		Reminders []models.Reminder `json:"reminders"`
+	}
+	decodeJSON(t, do(target, http.MethodGet, "/reminders/2", "", nil), &list)
+	var r *models.Reminder
+	for i := range list.Reminders {
+		if id := list.Reminders[i].ExternalID; id != nil && *id == "rt-1" {
+			r = &list.Reminders[i]
+		}
+	}
+	if r == nil {
+		t.Fatalf("imported reminder rt-1 not found in %+v", list.Reminders)
+	}
+	if r.Recurrence != "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO" || r.TimeZone != "Europe/Berlin" ||
+		!r.SendAt.Equal(time.Date(2030, 2, 4, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("imported recurrence %q, time_zone %q, send_at %s", r.Recurrence, r.TimeZone, r.SendAt)
+	}
+	var tags []string
+	for _, tag := range r.Tags {
+		tags = append(tags, tag.Name)
+	}
+	sort.Strings(tags)
+	if strings.Join(tags, ",") != "garden,home" {
+		t.Errorf("imported tags %v", tags)
+	}
+	if len(r.Recipients) != 2 || r.Recipients[0].UserID == nil || *r.Recipients[0].UserID != 1 || r.Recipients[1].ChatID != -1001234567890 {
+		t.Errorf("imported recipients %+v", r.Recipients)
+	}
+
+	again := decodeImport(t, do(target, http.MethodPost, "/reminders/import", file, nil))
+	if len(again.Errors) != 1 || again.Errors[0].ExternalID != "rt-1" || !strings.Contains(again.Errors[0].Error, "already exists") {
+		t.Errorf("second import: %+v, want rt-1 rejected as a duplicate", again)
+	}
+
+	malformed := do(target, http.MethodPost, "/reminders/import?format=csv", "user_id,message\n2,No send_at column\n", nil)
+	if malformed.Code != http.StatusBadRequest {
+		t.Errorf("import without send_at column: %d %s", malformed.Code, malformed.Body)
+	}
+}
+
+func decodeImport(t *testing.T, rec *httptest.ResponseRecorder) handlers.ImportResponse {
+	t.Helper()
+	var resp handlers.ImportResponse
+	decodeJSON(t, rec, &resp)
+	return resp
+}
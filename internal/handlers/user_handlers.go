@@ -0,0 +1,454 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Политики удаления пользователя
+const (
+	// DeletePolicyArchive - пользователь скрывается, его неотправленные напоминания архивируются
+	DeletePolicyArchive = "archive"
+	// DeletePolicyCascade - пользователь удаляется вместе со всеми напоминаниями
+	DeletePolicyCascade = "cascade"
+)
+
+var (
+	errChatAlreadyLinked = errors.New("chat is already linked to another user")
+	localePattern        = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+)
+
+// UserRequest - тело запроса создания и обновления пользователя
+type UserRequest struct {
+	DisplayName    string            `json:"display_name" example:"Иван"`
+	TimeZone       string            `json:"time_zone" example:"Europe/Moscow"`
+	Locale         string            `json:"locale" example:"ru"`
+	DefaultChannel string            `json:"default_channel" example:"telegram"`
+	QuietHours     models.QuietHours `json:"quiet_hours"`
+	// Без поля привязанные чаты не меняются, пустой список отвязывает все
+	TelegramChatIDs *[]int64              `json:"telegram_chat_ids,omitempty"`
+	Digest          models.DigestSettings `json:"digest"`
+	// Политика по умолчанию для напоминаний пользователя, которые пропущены, пока бот не работал
+	models.MisfireSettings
+}
+
+// CreateUserHandler godoc
+// @Summary Создать пользователя
+// @Description Создать пользователя с профилем и привязанными чатами Telegram
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body UserRequest true "User profile"
+// @Success 201 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users [post]
+func CreateUserHandler(ctx *gin.Context) {
+	start := time.Now()
+	var req UserRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if err := validateUserRequest(&req); err != nil {
+		logRequestDetails(ctx, start).Info("Invalid user", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := models.User{CreatedAt: time.Now()}
+	applyUserRequest(&user, req)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("TelegramLinks").Create(&user).Error; err != nil {
+			return err
+		}
+		var chatIDs []int64
+		if req.TelegramChatIDs != nil {
+			chatIDs = *req.TelegramChatIDs
+		}
+		return syncTelegramLinks(tx, &user, chatIDs)
+	})
+	if !respondUserSaveError(ctx, start, err) {
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("User created successfully", zap.Int("user_id", user.ID))
+	ctx.JSON(http.StatusCreated, UserResponse{Message: "User created successfully", User: user})
+}
+
+// GetUsersHandler godoc
+// @Summary Список пользователей
+// @Description Получить всех пользователей, кроме архивированных
+// @Tags users
+// @Produce json
+// @Success 200 {object} UsersResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /users [get]
+func GetUsersHandler(ctx *gin.Context) {
+	start := time.Now()
+	var users []models.User
+
+	if err := database.DB.Preload("TelegramLinks").Order("id").Find(&users).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch users", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Users fetched successfully", zap.Int("row_count", len(users)))
+	ctx.JSON(http.StatusOK, UsersResponse{Users: users})
+}
+
+// GetUserHandler godoc
+// @Summary Получить пользователя
+// @Description Получить профиль пользователя по идентификатору
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id} [get]
+func GetUserHandler(ctx *gin.Context) {
+	start := time.Now()
+	user, ok := findUser(ctx, start)
+	if !ok {
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("User fetched successfully", zap.Int("user_id", user.ID))
+	ctx.JSON(http.StatusOK, UserResponse{User: user})
+}
+
+// UpdateUserHandler godoc
+// @Summary Обновить пользователя
+// @Description Обновить профиль пользователя. Список telegram_chat_ids заменяет привязанные чаты, без него чаты не меняются
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param user body UserRequest true "User profile"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/{id} [put]
+func UpdateUserHandler(ctx *gin.Context) {
+	start := time.Now()
+	var req UserRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if err := validateUserRequest(&req); err != nil {
+		logRequestDetails(ctx, start).Info("Invalid user", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := findUser(ctx, start)
+	if !ok {
+		return
+	}
+	applyUserRequest(&user, req)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("TelegramLinks").Save(&user).Error; err != nil {
+			return err
+		}
+		// Тихие часы или часовой пояс могли измениться, отложенные напоминания проверяются заново
+		err := tx.Model(&models.Reminder{}).
+			Where("user_id = ? AND is_sent = ? AND deferred_until IS NOT NULL", user.ID, false).
+			Update("deferred_until", nil).Error
+		if err != nil || req.TelegramChatIDs == nil {
+			return err
+		}
+		return syncTelegramLinks(tx, &user, *req.TelegramChatIDs)
+	})
+	if !respondUserSaveError(ctx, start, err) {
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("User updated successfully", zap.Int("user_id", user.ID))
+	ctx.JSON(http.StatusOK, UserResponse{Message: "User updated successfully", User: user})
+}
+
+// DeleteUserHandler godoc
+// @Summary Удалить пользователя
+// @Description Удалить пользователя. Политика archive (по умолчанию) скрывает пользователя, архивирует его неотправленные напоминания и отвязывает чаты Telegram, cascade удаляет пользователя вместе с напоминаниями
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param policy query string false "archive или cascade"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id} [delete]
+func DeleteUserHandler(ctx *gin.Context) {
+	start := time.Now()
+	policy := ctx.DefaultQuery("policy", DeletePolicyArchive)
+	if policy != DeletePolicyArchive && policy != DeletePolicyCascade {
+		logRequestDetails(ctx, start).Info("Unknown delete policy", zap.String("policy", policy))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Policy must be either archive or cascade"})
+		return
+	}
+
+	user, ok := findUser(ctx, start)
+	if !ok {
+		return
+	}
+
+	var attachmentKeys []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if policy == DeletePolicyArchive {
+			err := tx.Model(&models.Reminder{}).
+				Where("user_id = ? AND is_sent = ? AND archived_at IS NULL", user.ID, false).
+				Update("archived_at", time.Now()).Error
+			if err != nil {
+				return err
+			}
+			// Чаты отвязываются, иначе уникальный chat_id не даст привязать их к другому пользователю
+			if err := tx.Where("user_id = ?", user.ID).Delete(&models.TelegramLink{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("user_id = ?", user.ID).Delete(&models.TelegramLinkToken{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&user).Error
+		}
+
+		// Внешние ключи удаляют напоминания, чаты и ссылки на календарь, файлы вложений удаляются вручную
+		err := tx.Model(&models.Reminder{}).
+			Where("user_id = ? AND attachment_key <> ''", user.ID).
+			Pluck("attachment_key", &attachmentKeys).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&user).Error
+	})
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to delete user", zap.Int("user_id", user.ID), zap.String("policy", policy), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+	for _, key := range attachmentKeys {
+		removeAttachment(key)
+	}
+
+	logRequestDetails(ctx, start).Info("User deleted successfully", zap.Int("user_id", user.ID), zap.String("policy", policy))
+	ctx.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+// DndRequest - тело запроса включения режима «не беспокоить»
+type DndRequest struct {
+	Until time.Time `json:"until" binding:"required"`
+}
+
+// SetDndHandler godoc
+// @Summary Включить режим «не беспокоить»
+// @Description Отложить доставку напоминаний пользователя до указанного времени. Срочные напоминания доставляются сразу
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param dnd body DndRequest true "DND end time"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/dnd [put]
+func SetDndHandler(ctx *gin.Context) {
+	start := time.Now()
+	var req DndRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if !req.Until.After(time.Now()) {
+		logRequestDetails(ctx, start).Info("DND end is in the past", zap.Time("until", req.Until))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "until must be in the future"})
+		return
+	}
+
+	user, ok := findUser(ctx, start)
+	if !ok {
+		return
+	}
+	setDnd(ctx, start, user, &req.Until)
+}
+
+// ClearDndHandler godoc
+// @Summary Выключить режим «не беспокоить»
+// @Description Выключить режим «не беспокоить». Отложенные напоминания будут доставлены при следующей проверке
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/dnd [delete]
+func ClearDndHandler(ctx *gin.Context) {
+	start := time.Now()
+	user, ok := findUser(ctx, start)
+	if !ok {
+		return
+	}
+	setDnd(ctx, start, user, nil)
+}
+
+// setDnd сохраняет окончание режима «не беспокоить». Отложенные из-за него напоминания
+// переносятся обратно, чтобы бот проверил их заново.
+func setDnd(ctx *gin.Context, start time.Time, user models.User, until *time.Time) {
+	user.DndUntil = until
+	user.UpdatedAt = time.Now()
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&user).Updates(map[string]interface{}{"dnd_until": until, "updated_at": user.UpdatedAt}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Reminder{}).
+			Where("user_id = ? AND is_sent = ? AND deferred_until IS NOT NULL", user.ID, false).
+			Update("deferred_until", nil).Error
+	})
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to update do-not-disturb", zap.Int("user_id", user.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update do-not-disturb"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Do-not-disturb updated", zap.Int("user_id", user.ID), zap.Timep("dnd_until", until))
+	ctx.JSON(http.StatusOK, UserResponse{Message: "Do-not-disturb updated successfully", User: user})
+}
+
+// findUser ищет пользователя из параметра id вместе с привязанными чатами, иначе отвечает клиенту.
+func findUser(ctx *gin.Context, start time.Time) (models.User, bool) {
+	userID := ctx.Param("id")
+	var user models.User
+
+	err := database.DB.Preload("TelegramLinks").First(&user, "id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logRequestDetails(ctx, start).Info("No user found with the given ID", zap.String("user_id", userID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No user found with the given ID"})
+		return user, false
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to find user", zap.String("user_id", userID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find user"})
+		return user, false
+	}
+	return user, true
+}
+
+// validateUserRequest проверяет профиль и подставляет значения по умолчанию.
+func validateUserRequest(req *UserRequest) error {
+	if req.DisplayName == "" {
+		return errors.New("display_name is required")
+	}
+	if req.TimeZone != "" {
+		if _, err := time.LoadLocation(req.TimeZone); err != nil {
+			return fmt.Errorf("unknown time_zone %q", req.TimeZone)
+		}
+	}
+	if req.Locale != "" && !localePattern.MatchString(req.Locale) {
+		return fmt.Errorf("locale %q must look like ru or en-US", req.Locale)
+	}
+	if req.DefaultChannel == "" {
+		req.DefaultChannel = models.ChannelTelegram
+	}
+	if req.DefaultChannel != models.ChannelTelegram {
+		return fmt.Errorf("default_channel must be %s", models.ChannelTelegram)
+	}
+	if req.QuietHours == nil {
+		req.QuietHours = models.QuietHours{}
+	}
+	if err := req.QuietHours.Validate(); err != nil {
+		return err
+	}
+	if err := req.MisfireSettings.Validate(); err != nil {
+		return err
+	}
+	return req.Digest.Validate()
+}
+
+func applyUserRequest(user *models.User, req UserRequest) {
+	user.DisplayName = req.DisplayName
+	user.TimeZone = req.TimeZone
+	user.Locale = req.Locale
+	user.DefaultChannel = req.DefaultChannel
+	user.QuietHours = req.QuietHours
+	user.MisfireSettings = req.MisfireSettings
+	user.Digest = req.Digest
+	user.UpdatedAt = time.Now()
+}
+
+// syncTelegramLinks приводит привязанные чаты пользователя к списку chatIDs,
+// сохраняя существующие привязки с их состоянием.
+func syncTelegramLinks(tx *gorm.DB, user *models.User, chatIDs []int64) error {
+	keep := make(map[int64]bool, len(chatIDs))
+	for _, chatID := range chatIDs {
+		keep[chatID] = true
+	}
+
+	links := make([]models.TelegramLink, 0, len(chatIDs))
+	for _, link := range user.TelegramLinks {
+		if !keep[link.ChatID] {
+			if err := tx.Delete(&link).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		delete(keep, link.ChatID)
+		links = append(links, link)
+	}
+
+	for _, chatID := range chatIDs {
+		if !keep[chatID] {
+			continue
+		}
+		delete(keep, chatID)
+
+		var count int64
+		if err := tx.Model(&models.TelegramLink{}).Where("chat_id = ?", chatID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: %d", errChatAlreadyLinked, chatID)
+		}
+
+		link := models.TelegramLink{UserID: user.ID, ChatID: chatID, Active: true, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+		links = append(links, link)
+	}
+
+	user.TelegramLinks = links
+	return nil
+}
+
+// respondUserSaveError отвечает клиенту на ошибку сохранения пользователя. Возвращает true, если ошибки нет.
+func respondUserSaveError(ctx *gin.Context, start time.Time, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errChatAlreadyLinked):
+		logRequestDetails(ctx, start).Info("Chat is already linked", zap.Error(err))
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		logRequestDetails(ctx, start).Error("Failed to save user", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save user"})
+	}
+	return false
+}
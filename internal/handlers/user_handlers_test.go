@@ -0,0 +1,151 @@
+package handlers_test
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/handlers"
+	"Reminders/internal/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// pauseUser ставит пользователя 1 на паузу без срока.
+func pauseUser(t *testing.T, _ http.Handler) {
+	at := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	if err := database.DB.Model(&models.User{}).Where("id = ?", 1).Update("paused_at", at).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUsers(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "list", method: http.MethodGet, path: "/users", status: http.StatusOK},
+		{name: "get", method: http.MethodGet, path: "/users/1", status: http.StatusOK},
+		{name: "get_not_found", method: http.MethodGet, path: "/users/99", status: http.StatusNotFound},
+		{name: "create", method: http.MethodPost, path: "/users", status: http.StatusCreated,
+			body: `{"display_name": "Пётр", "time_zone": "Asia/Yekaterinburg", "locale": "ru", "quiet_hours": [{"start": "23:00", "end": "07:00"}]}`},
+		{name: "create_unknown_time_zone", method: http.MethodPost, path: "/users", status: http.StatusBadRequest,
+			body: `{"display_name": "Пётр", "time_zone": "Mars/Olympus"}`},
+		{name: "create_bad_json", method: http.MethodPost, path: "/users", status: http.StatusBadRequest,
+			body: `{"display_name": `},
+		{name: "update", method: http.MethodPut, path: "/users/2", status: http.StatusOK,
+			body: `{"display_name": "Anna K.", "time_zone": "Europe/Paris", "locale": "fr", "default_channel": "telegram"}`},
+		{name: "update_replace_telegram_chats", method: http.MethodPut, path: "/users/2", status: http.StatusOK,
+			body: `{"display_name": "Anna", "time_zone": "Europe/Berlin", "telegram_chat_ids": [100000003]}`},
+		{name: "update_clear_telegram_chats", method: http.MethodPut, path: "/users/2", status: http.StatusOK,
+			body: `{"display_name": "Anna", "time_zone": "Europe/Berlin", "telegram_chat_ids": []}`},
+		{name: "update_not_found", method: http.MethodPut, path: "/users/99", status: http.StatusNotFound,
+			body: `{"display_name": "x"}`},
+		{name: "delete_archive", method: http.MethodDelete, path: "/users/1", status: http.StatusOK},
+		{name: "delete_cascade", method: http.MethodDelete, path: "/users/2?policy=cascade", status: http.StatusOK},
+		{name: "delete_unknown_policy", method: http.MethodDelete, path: "/users/2?policy=forget", status: http.StatusBadRequest},
+		{name: "delete_not_found", method: http.MethodDelete, path: "/users/99", status: http.StatusNotFound},
+
+		{name: "dnd", method: http.MethodPut, path: "/users/1/dnd", status: http.StatusOK,
+			body: `{"until": "2030-01-14T12:00:00Z"}`},
+		{name: "dnd_missing_until", method: http.MethodPut, path: "/users/1/dnd", status: http.StatusBadRequest,
+			body: `{}`},
+		{name: "dnd_clear", method: http.MethodDelete, path: "/users/1/dnd", status: http.StatusOK},
+		{name: "pause", method: http.MethodPost, path: "/users/1/pause", status: http.StatusOK,
+			body: `{"until": "2030-01-20T00:00:00Z"}`},
+		{name: "pause_not_found", method: http.MethodPost, path: "/users/99/pause", status: http.StatusNotFound},
+		{name: "resume", method: http.MethodPost, path: "/users/1/resume", status: http.StatusOK, setup: pauseUser},
+		{name: "resume_not_paused", method: http.MethodPost, path: "/users/1/resume", status: http.StatusBadRequest},
+
+		{name: "telegram_link", method: http.MethodPost, path: "/users/1/telegram-link", status: http.StatusCreated},
+		{name: "telegram_link_not_found", method: http.MethodPost, path: "/users/99/telegram-link", status: http.StatusNotFound},
+		{name: "telegram_unlink", method: http.MethodDelete, path: "/users/1/telegram-link/100000001", status: http.StatusOK},
+		{name: "telegram_unlink_not_found", method: http.MethodDelete, path: "/users/1/telegram-link/100000002", status: http.StatusNotFound},
+	})
+}
+
+func TestAPIKeys(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "create", method: http.MethodPost, path: "/users/1/api-keys", status: http.StatusCreated,
+			body: `{"name": "Дашборд"}`, header: adminAuth},
+		{name: "create_user_not_found", method: http.MethodPost, path: "/users/99/api-keys", status: http.StatusNotFound,
+			body: `{"name": "Дашборд"}`, header: adminAuth},
+		{name: "create_unauthenticated", method: http.MethodPost, path: "/users/1/api-keys", status: http.StatusUnauthorized,
+			body: `{"name": "Дашборд"}`},
+		{name: "create_wrong_token", method: http.MethodPost, path: "/users/1/api-keys", status: http.StatusUnauthorized,
+			body: `{"name": "Дашборд"}`, header: map[string]string{"Authorization": "Bearer wrong"}},
+		{name: "list", method: http.MethodGet, path: "/users/1/api-keys", status: http.StatusOK, header: adminAuth},
+		{name: "list_unauthenticated", method: http.MethodGet, path: "/users/1/api-keys", status: http.StatusUnauthorized},
+		{name: "delete_not_found", method: http.MethodDelete, path: "/users/1/api-keys/99", status: http.StatusNotFound, header: adminAuth},
+		{name: "delete_unauthenticated", method: http.MethodDelete, path: "/users/1/api-keys/99", status: http.StatusUnauthorized},
+	})
+}
+
+// Ключом API пользователь управляет только своими ключами.
+func TestAPIKeysWithUserKey(t *testing.T) {
+	router := newRouter(t)
+	rec := do(router, http.MethodPost, "/users/1/api-keys", `{"name": "cli"}`, adminAuth)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("issue key: %d %s", rec.Code, rec.Body)
+	}
+	var issued handlers.APIKeyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &issued); err != nil {
+		t.Fatal(err)
+	}
+	auth := map[string]string{"Authorization": "Bearer " + issued.Key}
+
+	if rec := do(router, http.MethodPost, "/users/1/api-keys", `{"name": "second"}`, auth); rec.Code != http.StatusCreated {
+		t.Errorf("own key: %d %s, want 201", rec.Code, rec.Body)
+	}
+	if rec := do(router, http.MethodGet, "/users/2/api-keys", "", auth); rec.Code != http.StatusForbidden {
+		t.Errorf("another user's keys: %d %s, want 403", rec.Code, rec.Body)
+	}
+	if rec := do(router, http.MethodPost, "/users/2/api-keys", `{"name": "stolen"}`, auth); rec.Code != http.StatusForbidden {
+		t.Errorf("key for another user: %d %s, want 403", rec.Code, rec.Body)
+	}
+}
+
+// Ключи архивированного пользователя перестают действовать.
+func TestArchivedUserAPIKeyRejected(t *testing.T) {
+	router := newRouter(t)
+	rec := do(router, http.MethodPost, "/users/1/api-keys", `{"name": "cli"}`, adminAuth)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("issue key: %d %s", rec.Code, rec.Body)
+	}
+	var issued handlers.APIKeyResponse
+	decodeJSON(t, rec, &issued)
+	auth := map[string]string{"Authorization": "Bearer " + issued.Key}
+
+	if rec := do(router, http.MethodDelete, "/users/1", "", adminAuth); rec.Code != http.StatusOK {
+		t.Fatalf("delete user: %d %s", rec.Code, rec.Body)
+	}
+	if rec := do(router, http.MethodGet, "/users/1/api-keys", "", auth); rec.Code != http.StatusUnauthorized {
+		t.Errorf("keys of an archived user: %d %s, want 401", rec.Code, rec.Body)
+	}
+	// С действующим ключом поток не закрывается сам, поэтому запрос ограничен по времени
+	reqCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream := httptest.NewRecorder()
+	router.ServeHTTP(stream, httptest.NewRequest(http.MethodGet, "/reminders/stream?access_token="+issued.Key, nil).WithContext(reqCtx))
+	if stream.Code != http.StatusUnauthorized {
+		t.Errorf("stream with a key of an archived user: %d, want 401", stream.Code)
+	}
+}
+
+// Чат архивированного пользователя можно привязать к другому пользователю.
+func TestArchivedUserChatCanBeRelinked(t *testing.T) {
+	router := newRouter(t)
+	if rec := do(router, http.MethodDelete, "/users/1", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("delete user: %d %s", rec.Code, rec.Body)
+	}
+
+	var links int64
+	if err := database.DB.Model(&models.TelegramLink{}).Where("user_id = ?", 1).Count(&links).Error; err != nil {
+		t.Fatal(err)
+	}
+	if links != 0 {
+		t.Errorf("archived user still has %d linked chats", links)
+	}
+	link := models.TelegramLink{UserID: 2, ChatID: 100000001, Active: true}
+	if err := database.DB.Create(&link).Error; err != nil {
+		t.Errorf("relink chat of archived user: %v", err)
+	}
+}
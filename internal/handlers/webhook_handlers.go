@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"Reminders/internal/tokens"
+	"Reminders/internal/webhooks"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Сколько доставок возвращается в журнале по умолчанию и максимум
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+	// Сколько ждать ответа получателя при повторной доставке из API
+	redeliverTimeout = 10 * time.Second
+)
+
+var errInvalidWebhook = errors.New("invalid webhook")
+
+// webhookClient выполняет повторные доставки, запрошенные через API.
+var webhookClient = webhooks.NewClient(redeliverTimeout)
+
+// WebhookRequest - создание или изменение подписки. Пустой secret при создании
+// генерируется сервером, при изменении оставляет прежний; active по умолчанию true.
+type WebhookRequest struct {
+	UserID int               `json:"user_id"`
+	URL    string            `json:"url" example:"https://example.com/hooks/reminders"`
+	Secret string            `json:"secret,omitempty"`
+	Events models.StringList `json:"events" example:"reminder.sent,reminder.failed"`
+	Active *bool             `json:"active,omitempty"`
+}
+
+// GetWebhooksHandler godoc
+// @Summary Список вебхуков
+// @Description Получить подписки всех пользователей или одного пользователя. Секрет подписки не возвращается
+// @Tags webhooks
+// @Produce json
+// @Param user_id query int false "User ID"
+// @Success 200 {object} WebhooksResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /webhooks [get]
+func GetWebhooksHandler(ctx *gin.Context) {
+	start := time.Now()
+	query := database.DB.Order("id")
+	if userID := ctx.Query("user_id"); userID != "" {
+		if _, err := strconv.Atoi(userID); err != nil {
+			logRequestDetails(ctx, start).Info("Invalid user ID", zap.String("user_id", userID))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+
+	hooks := []models.Webhook{}
+	if err := query.Find(&hooks).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch webhooks", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+
+	logRequestDetails(ctx, start).Info("Webhooks fetched successfully", zap.Int("row_count", len(hooks)))
+	ctx.JSON(http.StatusOK, WebhooksResponse{Webhooks: hooks})
+}
+
+// GetWebhookHandler godoc
+// @Summary Получить вебхук
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} WebhookResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id} [get]
+func GetWebhookHandler(ctx *gin.Context) {
+	start := time.Now()
+	hook, ok := findWebhook(ctx, start)
+	if !ok {
+		return
+	}
+	hook.Secret = ""
+
+	logRequestDetails(ctx, start).Info("Webhook fetched successfully", zap.Int("webhook_id", hook.ID))
+	ctx.JSON(http.StatusOK, WebhookResponse{Webhook: hook})
+}
+
+// CreateWebhookHandler godoc
+// @Summary Создать вебхук
+// @Description Подписать URL на события напоминаний пользователя: reminder.created, reminder.updated, reminder.deleted, reminder.sent, reminder.failed, reminder.acknowledged. Тело запроса подписывается: X-Reminders-Signature = sha256=HMAC-SHA256(secret, "<X-Reminders-Timestamp>.<тело>"). Секрет возвращается только в ответе на создание
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body WebhookRequest true "Webhook"
+// @Success 201 {object} WebhookResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /webhooks [post]
+func CreateWebhookHandler(ctx *gin.Context) {
+	start := time.Now()
+	var req WebhookRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	hook := models.Webhook{
+		UserID:    req.UserID,
+		URL:       req.URL,
+		Secret:    req.Secret,
+		Events:    req.Events,
+		Active:    req.Active == nil || *req.Active,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if !checkWebhook(ctx, start, hook) {
+		return
+	}
+
+	if hook.Secret == "" {
+		secret, err := tokens.New()
+		if err != nil {
+			logRequestDetails(ctx, start).Error("Failed to generate webhook secret", zap.Error(err))
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+			return
+		}
+		hook.Secret = secret
+	}
+	if err := database.DB.Create(&hook).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to create webhook", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Webhook created successfully", zap.Int("webhook_id", hook.ID))
+	ctx.JSON(http.StatusCreated, WebhookResponse{Message: "Webhook created successfully", Webhook: hook})
+}
+
+// UpdateWebhookHandler godoc
+// @Summary Обновить вебхук
+// @Description Изменить адрес, события, секрет или отключить подписку. Доставки в отключённую подписку не выполняются
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param webhook body WebhookRequest true "Webhook"
+// @Success 200 {object} WebhookResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id} [put]
+func UpdateWebhookHandler(ctx *gin.Context) {
+	start := time.Now()
+	var req WebhookRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logRequestDetails(ctx, start).Error("Invalid request data", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	hook, ok := findWebhook(ctx, start)
+	if !ok {
+		return
+	}
+	// Владелец подписки не меняется, иначе ей доставлялись бы события другого пользователя
+	hook.URL = req.URL
+	hook.Events = req.Events
+	if req.Secret != "" {
+		hook.Secret = req.Secret
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	if !checkWebhook(ctx, start, hook) {
+		return
+	}
+
+	hook.UpdatedAt = time.Now()
+	if err := database.DB.Save(&hook).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to update webhook", zap.Int("webhook_id", hook.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+	hook.Secret = ""
+
+	logRequestDetails(ctx, start).Info("Webhook updated successfully", zap.Int("webhook_id", hook.ID))
+	ctx.JSON(http.StatusOK, WebhookResponse{Message: "Webhook updated successfully", Webhook: hook})
+}
+
+// DeleteWebhookHandler godoc
+// @Summary Удалить вебхук
+// @Description Удалить подписку вместе с журналом её доставок
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id} [delete]
+func DeleteWebhookHandler(ctx *gin.Context) {
+	start := time.Now()
+	hook, ok := findWebhook(ctx, start)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&hook).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to delete webhook", zap.Int("webhook_id", hook.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Webhook deleted successfully", zap.Int("webhook_id", hook.ID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetWebhookDeliveriesHandler godoc
+// @Summary Журнал доставок вебхука
+// @Description Получить доставки подписки, начиная с последних: состояние, число попыток, код ответа и время следующей попытки
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param status query string false "pending, delivered или failed"
+// @Param limit query int false "Количество доставок (по умолчанию 50, максимум 500)"
+// @Success 200 {object} WebhookDeliveriesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id}/deliveries [get]
+func GetWebhookDeliveriesHandler(ctx *gin.Context) {
+	start := time.Now()
+	hook, ok := findWebhook(ctx, start)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Where("webhook_id = ?", hook.ID).Order("id DESC")
+	switch status := ctx.Query("status"); status {
+	case "":
+	case models.WebhookPending, models.WebhookDelivered, models.WebhookFailed:
+		query = query.Where("status = ?", status)
+	default:
+		logRequestDetails(ctx, start).Info("Invalid delivery status", zap.String("status", status))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Status must be one of pending, delivered, failed"})
+		return
+	}
+	limit := defaultDeliveriesLimit
+	if v := ctx.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxDeliveriesLimit {
+			logRequestDetails(ctx, start).Info("Invalid limit", zap.String("limit", v))
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Limit must be between 1 and %d", maxDeliveriesLimit)})
+			return
+		}
+		limit = n
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	if err := query.Limit(limit).Find(&deliveries).Error; err != nil {
+		logRequestDetails(ctx, start).Error("Failed to fetch webhook deliveries", zap.Int("webhook_id", hook.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook deliveries"})
+		return
+	}
+
+	logRequestDetails(ctx, start).Info("Webhook deliveries fetched successfully", zap.Int("webhook_id", hook.ID), zap.Int("row_count", len(deliveries)))
+	ctx.JSON(http.StatusOK, WebhookDeliveriesResponse{Deliveries: deliveries})
+}
+
+// RedeliverWebhookHandler godoc
+// @Summary Повторить доставку вебхука
+// @Description Отправить событие доставки ещё раз. Создаётся новая доставка, первая попытка выполняется сразу; при неудаче она повторяется по обычному расписанию
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 200 {object} WebhookDeliveryResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func RedeliverWebhookHandler(ctx *gin.Context) {
+	start := time.Now()
+	hook, ok := findWebhook(ctx, start)
+	if !ok {
+		return
+	}
+	if !hook.Active {
+		logRequestDetails(ctx, start).Info("Webhook is disabled", zap.Int("webhook_id", hook.ID))
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Webhook is disabled"})
+		return
+	}
+
+	deliveryID := ctx.Param("delivery_id")
+	var original models.WebhookDelivery
+	err := database.DB.Where("id = ? AND webhook_id = ?", deliveryID, hook.ID).First(&original).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logRequestDetails(ctx, start).Info("No delivery found with the given ID", zap.String("delivery_id", deliveryID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No delivery found with the given ID"})
+		return
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to find delivery", zap.String("delivery_id", deliveryID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find delivery"})
+		return
+	}
+
+	delivery, err := webhooks.Enqueue(database.DB, hook.ID, original.EventID, time.Now())
+	if err == nil {
+		err = database.DB.Preload("Event").First(&delivery, delivery.ID).Error
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to create delivery", zap.Int("webhook_id", hook.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeliver webhook"})
+		return
+	}
+	delivery.Webhook = &hook
+
+	dispatcher := webhooks.Dispatcher{DB: database.DB, Client: webhookClient}
+	if err := dispatcher.Deliver(ctx.Request.Context(), &delivery); err != nil {
+		logRequestDetails(ctx, start).Error("Failed to save delivery", zap.Int("delivery_id", delivery.ID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeliver webhook"})
+		return
+	}
+	delivery.Webhook, delivery.Event = nil, nil
+
+	logRequestDetails(ctx, start).Info("Webhook redelivered", zap.Int("delivery_id", delivery.ID), zap.String("status", delivery.Status), zap.Int("response_code", delivery.ResponseCode))
+	ctx.JSON(http.StatusOK, WebhookDeliveryResponse{Message: "Webhook redelivered", Delivery: delivery})
+}
+
+// findWebhook ищет подписку из параметра id, иначе отвечает клиенту.
+func findWebhook(ctx *gin.Context, start time.Time) (models.Webhook, bool) {
+	webhookID := ctx.Param("id")
+	var hook models.Webhook
+
+	err := database.DB.First(&hook, "id = ?", webhookID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logRequestDetails(ctx, start).Info("No webhook found with the given ID", zap.String("webhook_id", webhookID))
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "No webhook found with the given ID"})
+		return hook, false
+	}
+	if err != nil {
+		logRequestDetails(ctx, start).Error("Failed to find webhook", zap.String("webhook_id", webhookID), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find webhook"})
+		return hook, false
+	}
+	return hook, true
+}
+
+// checkWebhook проверяет адрес, события и владельца подписки, иначе отвечает клиенту.
+func checkWebhook(ctx *gin.Context, start time.Time, hook models.Webhook) bool {
+	err := validateWebhook(hook)
+	if err == nil {
+		err = checkUser(database.DB, hook.UserID)
+	}
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errUserNotFound), errors.Is(err, errInvalidWebhook):
+		logRequestDetails(ctx, start).Info("Invalid webhook", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		logRequestDetails(ctx, start).Error("Failed to check webhook", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check webhook"})
+	}
+	return false
+}
+
+// validateWebhook проверяет обязательные поля подписки.
+func validateWebhook(hook models.Webhook) error {
+	if hook.UserID <= 0 {
+		return fmt.Errorf("%w: user_id is required", errInvalidWebhook)
+	}
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", errInvalidWebhook)
+	}
+	// Имена проверяет клиент при соединении, здесь отклоняются очевидные случаи
+	host := u.Hostname()
+	if ip := net.ParseIP(host); (ip != nil && !webhooks.PublicIP(ip)) || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: url must not point to a loopback or private address", errInvalidWebhook)
+	}
+	if len(hook.Events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", errInvalidWebhook)
+	}
+	for _, event := range hook.Events {
+		if !models.ValidEventType(event) {
+			return fmt.Errorf("%w: event must be one of %s", errInvalidWebhook, strings.Join(models.EventTypes, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package handlers_test
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/handlers"
+	"Reminders/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// addWebhookDelivery записывает неудачную доставку события вебхуку 1.
+func addWebhookDelivery(t *testing.T, _ http.Handler) {
+	at := time.Date(2030, 1, 14, 9, 0, 5, 0, time.UTC)
+	event := models.ReminderEvent{UserID: 1, ReminderID: 1, Type: models.EventReminderSent, Data: []byte(`{"id": 1}`), CreatedAt: at}
+	if err := database.DB.Create(&event).Error; err != nil {
+		t.Fatal(err)
+	}
+	delivery := models.WebhookDelivery{WebhookID: 1, EventID: event.ID, Status: models.WebhookFailed, Attempts: 8,
+		ResponseCode: 500, Error: "unexpected status 500", CreatedAt: at, UpdatedAt: at}
+	if err := database.DB.Create(&delivery).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// webhookReceiver направляет вебхук 1 на локальный сервер, который отвечает status.
+// Если trusted, повторная доставка идёт клиентом сервера, иначе - рабочим клиентом,
+// который в локальную сеть не ходит.
+func webhookReceiver(status int, trusted bool) func(t *testing.T, router http.Handler) {
+	return func(t *testing.T, router http.Handler) {
+		addWebhookDelivery(t, router)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(srv.Close)
+		if trusted {
+			t.Cleanup(handlers.SetWebhookClient(srv.Client()))
+		}
+		if err := database.DB.Model(&models.Webhook{}).Where("id = ?", 1).Update("url", srv.URL+"/hook").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	runCases(t, []apiCase{
+		{name: "list", method: http.MethodGet, path: "/webhooks?user_id=1", status: http.StatusOK},
+		{name: "get", method: http.MethodGet, path: "/webhooks/1", status: http.StatusOK},
+		{name: "get_not_found", method: http.MethodGet, path: "/webhooks/99", status: http.StatusNotFound},
+		{name: "create", method: http.MethodPost, path: "/webhooks", status: http.StatusCreated,
+			body: `{"user_id": 2, "url": "https://example.org/hook", "events": ["reminder.created", "reminder.deleted"]}`},
+		{name: "create_invalid_url", method: http.MethodPost, path: "/webhooks", status: http.StatusBadRequest,
+			body: `{"user_id": 2, "url": "ftp://example.org/hook", "events": ["reminder.created"]}`},
+		{name: "create_loopback_url", method: http.MethodPost, path: "/webhooks", status: http.StatusBadRequest,
+			body: `{"user_id": 2, "url": "http://127.0.0.1:8080/hook", "events": ["reminder.created"]}`},
+		{name: "create_private_url", method: http.MethodPost, path: "/webhooks", status: http.StatusBadRequest,
+			body: `{"user_id": 2, "url": "http://10.0.0.5/hook", "events": ["reminder.created"]}`},
+		{name: "create_localhost_url", method: http.MethodPost, path: "/webhooks", status: http.StatusBadRequest,
+			body: `{"user_id": 2, "url": "http://localhost/hook", "events": ["reminder.created"]}`},
+		{name: "create_unknown_event", method: http.MethodPost, path: "/webhooks", status: http.StatusBadRequest,
+			body: `{"user_id": 2, "url": "https://example.org/hook", "events": ["reminder.exploded"]}`},
+		{name: "update", method: http.MethodPut, path: "/webhooks/1", status: http.StatusOK,
+			body: `{"url": "https://example.com/hooks/v2", "events": ["reminder.sent", "reminder.failed"], "active": false}`},
+		{name: "delete", method: http.MethodDelete, path: "/webhooks/1", status: http.StatusOK},
+		{name: "deliveries", method: http.MethodGet, path: "/webhooks/1/deliveries", status: http.StatusOK, setup: addWebhookDelivery},
+		{name: "deliveries_invalid_status", method: http.MethodGet, path: "/webhooks/1/deliveries?status=lost", status: http.StatusBadRequest},
+		{name: "redeliver", method: http.MethodPost, path: "/webhooks/1/deliveries/1/redeliver", status: http.StatusOK,
+			setup: webhookReceiver(http.StatusNoContent, true)},
+		{name: "redeliver_failed", method: http.MethodPost, path: "/webhooks/1/deliveries/1/redeliver", status: http.StatusOK,
+			setup: webhookReceiver(http.StatusServiceUnavailable, true)},
+		{name: "redeliver_private_address", method: http.MethodPost, path: "/webhooks/1/deliveries/1/redeliver", status: http.StatusOK,
+			setup: webhookReceiver(http.StatusNoContent, false)},
+		{name: "redeliver_not_found", method: http.MethodPost, path: "/webhooks/1/deliveries/99/redeliver", status: http.StatusNotFound},
+	})
+}
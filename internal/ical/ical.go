@@ -0,0 +1,353 @@
+// Package ical кодирует и разбирает календари в формате iCalendar (RFC 5545)
+// в объёме, нужном для обмена напоминаниями: VEVENT с DTSTART, RRULE и VALARM.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateTimeLayout    = "20060102T150405"
+	utcDateTimeLayout = "20060102T150405Z"
+	dateLayout        = "20060102"
+
+	// Максимальная длина строки без учёта CRLF по RFC 5545
+	maxLineLength = 75
+)
+
+// Calendar - календарь с набором событий.
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Event - событие календаря. Start хранится в часовом поясе события,
+// TimeZone пуст для времени в UTC. При записи событие с неизвестным поясом
+// записывается в UTC.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	TimeZone    string
+	RRule       string
+	// AlarmOffset - смещение напоминания VALARM относительно начала события
+	AlarmOffset time.Duration
+	HasAlarm    bool
+}
+
+// AlarmTime возвращает момент срабатывания напоминания события.
+func (e Event) AlarmTime() time.Time {
+	return e.Start.Add(e.AlarmOffset)
+}
+
+// Encode записывает календарь в w.
+func Encode(w io.Writer, cal Calendar) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		writeFolded(bw, s)
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:" + cal.ProdID)
+	line("CALSCALE:GREGORIAN")
+	if cal.Name != "" {
+		line("X-WR-CALNAME:" + escapeText(cal.Name))
+	}
+
+	// Для каждого TZID календарь должен содержать его описание VTIMEZONE
+	zones, locations, years := eventZones(cal.Events)
+	for _, name := range zones {
+		writeTimeZone(line, name, locations[name], years[name])
+	}
+
+	stamp := time.Now().UTC().Format(utcDateTimeLayout)
+	for _, e := range cal.Events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp)
+		if loc := locations[e.TimeZone]; loc != nil {
+			line("DTSTART;TZID=" + e.TimeZone + ":" + e.Start.In(loc).Format(dateTimeLayout))
+		} else {
+			line("DTSTART:" + e.Start.UTC().Format(utcDateTimeLayout))
+		}
+		if e.RRule != "" {
+			line("RRULE:" + e.RRule)
+		}
+		line("SUMMARY:" + escapeText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escapeText(e.Description))
+		}
+		if e.HasAlarm {
+			line("BEGIN:VALARM")
+			line("ACTION:DISPLAY")
+			line("TRIGGER:" + formatDuration(e.AlarmOffset))
+			line("DESCRIPTION:" + escapeText(e.Summary))
+			line("END:VALARM")
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+// Decode разбирает календарь и возвращает его события. Время без TZID считается
+// локальным временем в defaultLoc.
+func Decode(r io.Reader, defaultLoc *time.Location) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events    []Event
+		current   *Event
+		inAlarm   bool
+		sawHeader bool
+	)
+	for n, raw := range lines {
+		name, params, value, err := parseLine(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCALENDAR"):
+			sawHeader = true
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current = &Event{}
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: unexpected END:VEVENT", n+1)
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", n+1, current.UID)
+			}
+			events = append(events, *current)
+			current = nil
+		case name == "BEGIN" && strings.EqualFold(value, "VALARM"):
+			inAlarm = true
+		case name == "END" && strings.EqualFold(value, "VALARM"):
+			inAlarm = false
+		case current == nil:
+			// Свойства календаря и других компонентов не используются
+		case inAlarm:
+			if name == "TRIGGER" && !current.HasAlarm && params["VALUE"] != "DATE-TIME" && params["RELATED"] != "END" {
+				offset, err := parseDuration(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid TRIGGER: %w", n+1, err)
+				}
+				current.AlarmOffset = offset
+				current.HasAlarm = true
+			}
+		default:
+			switch name {
+			case "UID":
+				current.UID = value
+			case "SUMMARY":
+				current.Summary = unescapeText(value)
+			case "DESCRIPTION":
+				current.Description = unescapeText(value)
+			case "RRULE":
+				current.RRule = value
+			case "DTSTART":
+				current.Start, current.TimeZone, err = parseDateTime(value, params, defaultLoc)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid DTSTART: %w", n+1, err)
+				}
+			}
+		}
+	}
+
+	if !sawHeader {
+		return nil, errors.New("not an iCalendar file")
+	}
+	if current != nil {
+		return nil, errors.New("unterminated VEVENT")
+	}
+	return events, nil
+}
+
+// unfold читает строки и склеивает продолжения, начинающиеся с пробела или табуляции.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		if (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += text[1:]
+			continue
+		}
+		lines = append(lines, text)
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine разбирает строку вида NAME;PARAM=VALUE:VALUE.
+func parseLine(line string) (string, map[string]string, string, error) {
+	colon := -1
+	inQuotes := false
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		}
+		if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", fmt.Errorf("malformed content line %q", line)
+	}
+
+	head, value := line[:colon], line[colon+1:]
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, value, nil
+}
+
+func parseDateTime(value string, params map[string]string, defaultLoc *time.Location) (time.Time, string, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, defaultLoc)
+		return t, "", err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcDateTimeLayout, value)
+		return t, "", err
+	}
+	if tzid := params["TZID"]; tzid != "" {
+		loc, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("unknown TZID %q", tzid)
+		}
+		t, err := time.ParseInLocation(dateTimeLayout, value, loc)
+		return t, tzid, err
+	}
+	t, err := time.ParseInLocation(dateTimeLayout, value, defaultLoc)
+	return t, "", err
+}
+
+// parseDuration разбирает длительность вида -P1DT2H30M.
+func parseDuration(value string) (time.Duration, error) {
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign = -1
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") {
+		return 0, fmt.Errorf("malformed duration %q", value)
+	}
+
+	var total time.Duration
+	inTime := false
+	num := 0
+	hasNum := false
+	for _, c := range value[1:] {
+		switch {
+		case c >= '0' && c <= '9':
+			num = num*10 + int(c-'0')
+			hasNum = true
+			continue
+		case c == 'T':
+			inTime = true
+			continue
+		}
+		if !hasNum {
+			return 0, fmt.Errorf("malformed duration %q", value)
+		}
+		switch {
+		case c == 'W' && !inTime:
+			total += time.Duration(num) * 7 * 24 * time.Hour
+		case c == 'D' && !inTime:
+			total += time.Duration(num) * 24 * time.Hour
+		case c == 'H' && inTime:
+			total += time.Duration(num) * time.Hour
+		case c == 'M' && inTime:
+			total += time.Duration(num) * time.Minute
+		case c == 'S' && inTime:
+			total += time.Duration(num) * time.Second
+		default:
+			return 0, fmt.Errorf("malformed duration %q", value)
+		}
+		num, hasNum = 0, false
+	}
+	return sign * total, nil
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	s := sign + "PT"
+	if h := d / time.Hour; h > 0 {
+		s += fmt.Sprintf("%dH", h)
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		s += fmt.Sprintf("%dM", m)
+		d -= m * time.Minute
+	}
+	if sec := d / time.Second; sec > 0 {
+		s += fmt.Sprintf("%dS", sec)
+	}
+	return s
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+// writeFolded пишет строку, перенося её по 75 байт без разрыва UTF-8 символов.
+func writeFolded(w *bufio.Writer, s string) {
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// Пробел в начале строки продолжения тоже занимает место
+		limit = maxLineLength - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
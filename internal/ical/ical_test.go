@@ -0,0 +1,76 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeTimeZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := Calendar{
+		ProdID: "-//test//EN",
+		Events: []Event{
+			{UID: "a", Summary: "Winter", Start: time.Date(2030, 1, 18, 17, 0, 0, 0, berlin), TimeZone: "Europe/Berlin"},
+			{UID: "b", Summary: "Summer", Start: time.Date(2030, 7, 5, 17, 0, 0, 0, berlin), TimeZone: "Europe/Berlin"},
+			{UID: "c", Summary: "Unknown zone", Start: time.Date(2030, 7, 5, 17, 0, 0, 0, time.UTC), TimeZone: "Mars/Olympus"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, cal); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VTIMEZONE\r\nTZID:Europe/Berlin\r\n",
+		"BEGIN:DAYLIGHT\r\nDTSTART:19700329T020000\r\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:19701025T030000\r\nRRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\n",
+		"DTSTART;TZID=Europe/Berlin:20300118T170000\r\n",
+		"DTSTART;TZID=Europe/Berlin:20300705T170000\r\n",
+		"DTSTART:20300705T170000Z\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "BEGIN:VTIMEZONE"); n != 1 {
+		t.Errorf("%d VTIMEZONE components, want 1", n)
+	}
+
+	events, err := Decode(strings.NewReader(out), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("decoded %d events, want 3", len(events))
+	}
+	for i, e := range events {
+		if !e.Start.Equal(cal.Events[i].Start) {
+			t.Errorf("event %s: start %s, want %s", e.UID, e.Start, cal.Events[i].Start)
+		}
+	}
+}
+
+func TestEncodeFixedOffsetZone(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = Encode(&buf, Calendar{ProdID: "-//test//EN", Events: []Event{
+		{UID: "a", Summary: "Standup", Start: time.Date(2030, 1, 14, 10, 0, 0, 0, moscow), TimeZone: "Europe/Moscow"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "BEGIN:VTIMEZONE\r\nTZID:Europe/Moscow\r\nBEGIN:STANDARD\r\nDTSTART:19700101T000000\r\n" +
+		"TZOFFSETFROM:+0300\r\nTZOFFSETTO:+0300\r\nTZNAME:MSK\r\nEND:STANDARD\r\nEND:VTIMEZONE\r\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("missing %q in\n%s", want, buf.String())
+	}
+}
@@ -0,0 +1,159 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+)
+
+// Коды дней недели RRULE по порядку time.Weekday
+var weekdayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// transition - смена смещения часового пояса.
+type transition struct {
+	// Момент смены в UTC
+	at time.Time
+	// Смещение от UTC до и после смены, в секундах
+	from, to int
+}
+
+// eventZones загружает часовые пояса событий и для каждого находит самый ранний год
+// события: правила переходов VTIMEZONE берутся из этого года. Пояса, которые
+// не удалось загрузить, пропускаются, такие события записываются в UTC.
+func eventZones(events []Event) ([]string, map[string]*time.Location, map[string]int) {
+	var names []string
+	locations := make(map[string]*time.Location)
+	years := make(map[string]int)
+	for _, e := range events {
+		if e.TimeZone == "" {
+			continue
+		}
+		if _, seen := years[e.TimeZone]; !seen {
+			years[e.TimeZone] = e.Start.Year()
+			if loc, err := time.LoadLocation(e.TimeZone); err == nil {
+				names = append(names, e.TimeZone)
+				locations[e.TimeZone] = loc
+			}
+		}
+		if y := e.Start.Year(); y < years[e.TimeZone] {
+			years[e.TimeZone] = y
+		}
+	}
+	return names, locations, years
+}
+
+// writeTimeZone записывает компонент VTIMEZONE для пояса loc. Если за год было две смены
+// смещения, они записываются ежегодными правилами, иначе - смещение на начало года
+// и разовые смены.
+func writeTimeZone(line func(string), name string, loc *time.Location, year int) {
+	line("BEGIN:VTIMEZONE")
+	line("TZID:" + name)
+	transitions := yearTransitions(loc, year)
+	if len(transitions) == 2 {
+		for _, tr := range transitions {
+			writeObservance(line, loc, tr, true)
+		}
+	} else {
+		startOfYear := time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+		abbr, offset := startOfYear.Zone()
+		line("BEGIN:STANDARD")
+		line("DTSTART:19700101T000000")
+		line("TZOFFSETFROM:" + formatOffset(offset))
+		line("TZOFFSETTO:" + formatOffset(offset))
+		line("TZNAME:" + abbr)
+		line("END:STANDARD")
+		for _, tr := range transitions {
+			writeObservance(line, loc, tr, false)
+		}
+	}
+	line("END:VTIMEZONE")
+}
+
+// writeObservance записывает компонент STANDARD или DAYLIGHT, начинающийся со смены tr.
+// yearly добавляет правило, повторяющее смену каждый год в тот же день недели месяца.
+func writeObservance(line func(string), loc *time.Location, tr transition, yearly bool) {
+	after := tr.at.In(loc)
+	kind := "STANDARD"
+	if after.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	abbr, _ := after.Zone()
+	// DTSTART - местное время до смены
+	wall := tr.at.Add(time.Duration(tr.from) * time.Second).UTC()
+
+	line("BEGIN:" + kind)
+	if yearly {
+		daysInMonth := time.Date(wall.Year(), wall.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		n := (wall.Day()-1)/7 + 1
+		if wall.Day()+7 > daysInMonth {
+			n = -1
+		}
+		// Правило начинается с 1970 года, чтобы покрывать события до года переходов
+		first := nthWeekday(1970, wall.Month(), n, wall.Weekday())
+		first = first.Add(time.Duration(wall.Hour())*time.Hour + time.Duration(wall.Minute())*time.Minute + time.Duration(wall.Second())*time.Second)
+		line("DTSTART:" + first.Format(dateTimeLayout))
+		line(fmt.Sprintf("RRULE:FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", int(wall.Month()), n, weekdayCodes[wall.Weekday()]))
+	} else {
+		line("DTSTART:" + wall.Format(dateTimeLayout))
+	}
+	line("TZOFFSETFROM:" + formatOffset(tr.from))
+	line("TZOFFSETTO:" + formatOffset(tr.to))
+	line("TZNAME:" + abbr)
+	line("END:" + kind)
+}
+
+// nthWeekday возвращает n-й день недели weekday месяца, -1 - последний.
+func nthWeekday(year int, month time.Month, n int, weekday time.Weekday) time.Time {
+	if n < 0 {
+		last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+		return last.AddDate(0, 0, -((int(last.Weekday()) - int(weekday) + 7) % 7))
+	}
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return first.AddDate(0, 0, (int(weekday)-int(first.Weekday())+7)%7+(n-1)*7)
+}
+
+// yearTransitions возвращает смены смещения пояса loc за год. Смены ищутся по дням,
+// а точный момент - двоичным поиском с точностью до секунды.
+func yearTransitions(loc *time.Location, year int) []transition {
+	var result []transition
+	from := time.Date(year, 1, 1, 0, 0, 0, 0, loc).Unix()
+	end := time.Date(year+1, 1, 1, 0, 0, 0, 0, loc).Unix()
+	offsetAt := func(unix int64) int {
+		_, offset := time.Unix(unix, 0).In(loc).Zone()
+		return offset
+	}
+
+	const day = 24 * 60 * 60
+	prev := offsetAt(from)
+	for t := from; t < end; t += day {
+		next := offsetAt(t + day)
+		if next == prev {
+			continue
+		}
+		lo, hi := t, t+day
+		for hi-lo > 1 {
+			mid := (lo + hi) / 2
+			if offsetAt(mid) == prev {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		result = append(result, transition{at: time.Unix(hi, 0).UTC(), from: prev, to: next})
+		prev = next
+	}
+	return result
+}
+
+// formatOffset записывает смещение от UTC в виде +HHMM или +HHMMSS.
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+	if sec := seconds % 60; sec != 0 {
+		s += fmt.Sprintf("%02d", sec)
+	}
+	return s
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// APIKey - ключ доступа пользователя к API. Сам ключ показывается один раз при выпуске,
+// в базе хранится только его хеш.
+type APIKey struct {
+	ID         int        `json:"id" gorm:"primaryKey"`
+	UserID     int        `json:"user_id" gorm:"index"`
+	User       *User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Name       string     `json:"name" example:"Дашборд"`
+	Prefix     string     `json:"prefix" example:"3f2a9c1b"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// CalendarFeed - секретная ссылка на календарь напоминаний пользователя только для чтения.
+// Хранится только хеш токена, сам токен показывается один раз при создании.
+type CalendarFeed struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	UserID    int       `json:"user_id" gorm:"uniqueIndex"`
+	User      *User     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
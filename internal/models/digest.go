@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Режимы доставки сводкой
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DeferDigest - причина переноса: напоминание ждёт ближайшей сводки.
+const DeferDigest = "digest"
+
+// DigestSettings - доставка напоминаний сводкой в часовом поясе пользователя.
+// Напоминания, наступившие между сводками, приходят одним сообщением в At
+// (для weekly - в день Weekday). AgendaAt включает вечерний обзор напоминаний на завтра.
+type DigestSettings struct {
+	Mode     string `json:"mode,omitempty" example:"daily"`
+	At       string `json:"at,omitempty" example:"09:00"`
+	Weekday  string `json:"weekday,omitempty" example:"MO"`
+	AgendaAt string `json:"agenda_at,omitempty" example:"20:00"`
+}
+
+// Enabled сообщает, включена ли доставка сводкой.
+func (d DigestSettings) Enabled() bool {
+	return d.Mode != ""
+}
+
+// Validate проверяет режим, время и день недели сводки.
+func (d DigestSettings) Validate() error {
+	switch d.Mode {
+	case "":
+		if d.At != "" || d.Weekday != "" {
+			return errors.New("digest.mode is required with digest.at")
+		}
+	case DigestDaily, DigestWeekly:
+		if _, err := parseClock(d.At); err != nil {
+			return fmt.Errorf("digest.at: %w", err)
+		}
+		if _, ok := weekdayCodes[d.Weekday]; d.Mode == DigestWeekly && !ok {
+			return fmt.Errorf("digest.weekday is required for %s digest", DigestWeekly)
+		}
+		if d.Mode == DigestDaily && d.Weekday != "" {
+			return fmt.Errorf("digest.weekday is only allowed for %s digest", DigestWeekly)
+		}
+	default:
+		return fmt.Errorf("digest.mode must be one of %s, %s", DigestDaily, DigestWeekly)
+	}
+	if d.AgendaAt != "" {
+		if _, err := parseClock(d.AgendaAt); err != nil {
+			return fmt.Errorf("digest.agenda_at: %w", err)
+		}
+	}
+	return nil
+}
+
+// NextDigest возвращает время ближайшей сводки пользователя после t.
+func (u User) NextDigest(t time.Time) time.Time {
+	loc := u.Location()
+	at, _ := parseClock(u.Digest.At)
+	local := t.In(loc)
+	for offset := 0; offset <= 7; offset++ {
+		slot := time.Date(local.Year(), local.Month(), local.Day()+offset, at/60, at%60, 0, 0, loc)
+		if slot.After(t) && u.digestDay(slot.Weekday()) {
+			return slot
+		}
+	}
+	return t
+}
+
+// IsDigestSlot сообщает, приходится ли t ровно на время сводки пользователя.
+func (u User) IsDigestSlot(t time.Time) bool {
+	at, err := parseClock(u.Digest.At)
+	if err != nil {
+		return false
+	}
+	local := t.In(u.Location())
+	return local.Hour()*60+local.Minute() == at && local.Second() == 0 && local.Nanosecond() == 0 &&
+		u.digestDay(local.Weekday())
+}
+
+// LastAgenda возвращает время последнего вечернего обзора не позже t.
+// Второе значение равно false, если обзор выключен.
+func (u User) LastAgenda(t time.Time) (time.Time, bool) {
+	at, err := parseClock(u.Digest.AgendaAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	loc := u.Location()
+	local := t.In(loc)
+	slot := time.Date(local.Year(), local.Month(), local.Day(), at/60, at%60, 0, 0, loc)
+	if slot.After(t) {
+		slot = time.Date(local.Year(), local.Month(), local.Day()-1, at/60, at%60, 0, 0, loc)
+	}
+	return slot, true
+}
+
+// digestDay сообщает, бывает ли сводка в указанный день недели.
+func (u User) digestDay(day time.Weekday) bool {
+	return u.Digest.Mode != DigestWeekly || weekdayCodes[u.Digest.Weekday] == day
+}
+
+// Value сохраняет настройки сводки в JSON.
+func (d DigestSettings) Value() (driver.Value, error) {
+	data, err := json.Marshal(d)
+	return string(data), err
+}
+
+// Scan читает настройки сводки из JSON.
+func (d *DigestSettings) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*d = DigestSettings{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported digest settings value %T", value)
+	}
+	return json.Unmarshal(data, d)
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Приоритеты напоминаний. Пустой приоритет считается обычным.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// Действия шага эскалации
+const (
+	// EscalateResend - повторить напоминание в чаты пользователя
+	EscalateResend = "resend"
+	// EscalateChat - отправить напоминание в дополнительный чат, например в общую группу
+	EscalateChat = "chat"
+	// EscalateContact - отправить напоминание запасному контакту, другому пользователю сервиса
+	EscalateContact = "contact"
+)
+
+// Состояния запланированного шага эскалации
+const (
+	EscalationPending   = "pending"
+	EscalationDone      = "done"
+	EscalationCancelled = "cancelled"
+)
+
+// EscalationPolicy - порядок действий, если важное напоминание не подтверждено.
+type EscalationPolicy struct {
+	ID        int             `json:"id" gorm:"primaryKey"`
+	UserID    int             `json:"user_id" gorm:"index"`
+	User      *User           `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Name      string          `json:"name" example:"Лекарства"`
+	Steps     EscalationSteps `json:"steps" gorm:"type:jsonb"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// EscalationStep - шаг эскалации. AfterMinutes отсчитывается от отправки напоминания.
+type EscalationStep struct {
+	AfterMinutes  int    `json:"after_minutes" example:"15"`
+	Action        string `json:"action" example:"resend"`
+	ChatID        int64  `json:"chat_id,omitempty"`
+	ContactUserID int    `json:"contact_user_id,omitempty"`
+}
+
+// EscalationSteps - шаги эскалации, хранятся в JSON.
+type EscalationSteps []EscalationStep
+
+// ReminderEscalation - запланированный шаг эскалации отправленного напоминания.
+// Шаг копируется из политики при отправке, поэтому изменение политики не влияет
+// на уже запущенные эскалации.
+type ReminderEscalation struct {
+	ID            int       `json:"id" gorm:"primaryKey"`
+	ReminderID    int       `json:"reminder_id" gorm:"index"`
+	Reminder      *Reminder `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Step          int       `json:"step"`
+	Action        string    `json:"action"`
+	ChatID        int64     `json:"chat_id,omitempty"`
+	ContactUserID int       `json:"contact_user_id,omitempty"`
+	DueAt         time.Time `json:"due_at" gorm:"index"`
+	Status        string    `json:"status" gorm:"index"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ValidPriority сообщает, поддерживается ли приоритет.
+func ValidPriority(priority string) bool {
+	switch priority {
+	case "", PriorityLow, PriorityNormal, PriorityHigh:
+		return true
+	}
+	return false
+}
+
+// Validate проверяет шаги эскалации.
+func (s EscalationSteps) Validate() error {
+	if len(s) == 0 {
+		return errors.New("at least one step is required")
+	}
+	prev := 0
+	for i, step := range s {
+		if step.AfterMinutes <= 0 {
+			return fmt.Errorf("steps[%d]: after_minutes must be positive", i)
+		}
+		if step.AfterMinutes < prev {
+			return fmt.Errorf("steps[%d]: after_minutes must not decrease", i)
+		}
+		prev = step.AfterMinutes
+
+		switch step.Action {
+		case EscalateResend:
+		case EscalateChat:
+			if step.ChatID == 0 {
+				return fmt.Errorf("steps[%d]: chat_id is required for %s", i, step.Action)
+			}
+		case EscalateContact:
+			if step.ContactUserID <= 0 {
+				return fmt.Errorf("steps[%d]: contact_user_id is required for %s", i, step.Action)
+			}
+		default:
+			return fmt.Errorf("steps[%d]: action must be one of %s, %s, %s", i, EscalateResend, EscalateChat, EscalateContact)
+		}
+	}
+	return nil
+}
+
+// Value сохраняет шаги в JSON.
+func (s EscalationSteps) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(s)
+	return string(data), err
+}
+
+// Scan читает шаги из JSON.
+func (s *EscalationSteps) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported escalation steps value %T", value)
+	}
+	return json.Unmarshal(data, s)
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// Event - событие, относительно которого задаются напоминания
+// (например, «за 30 минут до встречи»).
+type Event struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	UserID    int       `json:"user_id" gorm:"index"`
+	User      *User     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Name      string    `json:"name" example:"Планёрка"`
+	StartsAt  time.Time `json:"starts_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
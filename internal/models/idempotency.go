@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// IdempotencyKey - ответ на POST-запрос с заголовком Idempotency-Key. Повтор запроса с тем же
+// ключом получает сохранённый ответ и не выполняется заново. Пока первый запрос не завершён,
+// StatusCode равен нулю.
+type IdempotencyKey struct {
+	ID int `gorm:"primaryKey"`
+	// Чей это ключ: хеш ключа API или токена из заголовка Authorization, пусто - запрос без него.
+	// Одинаковые ключи разных клиентов не пересекаются
+	Scope       string `gorm:"size:64;uniqueIndex:idx_idempotency_keys_scope_key"`
+	Key         string `gorm:"uniqueIndex:idx_idempotency_keys_scope_key"`
+	Fingerprint string `gorm:"size:64"`
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time `gorm:"index"`
+}
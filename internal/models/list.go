@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// ReminderList - именованный список напоминаний пользователя, например «Работа» или «Здоровье».
+// Напоминание входит не больше чем в один список.
+type ReminderList struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	UserID    int       `json:"user_id" gorm:"uniqueIndex:idx_reminder_lists_user_name"`
+	User      *User     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Name      string    `json:"name" gorm:"uniqueIndex:idx_reminder_lists_user_name" example:"Здоровье"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Tag - метка напоминания. У напоминания может быть несколько меток.
+type Tag struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	UserID    int       `json:"user_id" gorm:"uniqueIndex:idx_tags_user_name"`
+	User      *User     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Name      string    `json:"name" gorm:"uniqueIndex:idx_tags_user_name" example:"work"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
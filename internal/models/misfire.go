@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Что делать с напоминаниями, время которых прошло, пока бот не работал
+const (
+	// MisfireSendAll - отправить каждое пропущенное повторение
+	MisfireSendAll = "send_all"
+	// MisfireLatest - отправить только последнее пропущенное повторение
+	MisfireLatest = "latest"
+	// MisfireSkipOlder - не отправлять, если опоздание больше порога
+	MisfireSkipOlder = "skip_older"
+	// MisfireDigest - прислать одно сообщение со списком пропущенных напоминаний
+	MisfireDigest = "digest"
+)
+
+// MisfireSettings - политика пропущенных напоминаний. Пустая политика у напоминания
+// означает политику пользователя, у пользователя - MisfireSendAll.
+type MisfireSettings struct {
+	Policy           string `json:"misfire_policy,omitempty" example:"skip_older"`
+	ThresholdMinutes int    `json:"misfire_threshold_minutes,omitempty" example:"120"`
+}
+
+// Validate проверяет политику и порог для MisfireSkipOlder.
+func (m MisfireSettings) Validate() error {
+	switch m.Policy {
+	case "", MisfireSendAll, MisfireLatest, MisfireDigest:
+		if m.ThresholdMinutes != 0 {
+			return fmt.Errorf("misfire_threshold_minutes is only allowed with %s", MisfireSkipOlder)
+		}
+	case MisfireSkipOlder:
+		if m.ThresholdMinutes <= 0 {
+			return errors.New("misfire_threshold_minutes must be positive")
+		}
+	default:
+		return fmt.Errorf("misfire_policy must be one of %s, %s, %s, %s",
+			MisfireSendAll, MisfireLatest, MisfireSkipOlder, MisfireDigest)
+	}
+	return nil
+}
+
+// Misfire возвращает действующую политику пропущенных напоминаний и порог опоздания.
+// Для политики пользователя User должен быть загружен.
+func (r Reminder) Misfire() (string, time.Duration) {
+	settings := r.MisfireSettings
+	if settings.Policy == "" && r.User != nil {
+		settings = r.User.MisfireSettings
+	}
+	if settings.Policy == "" {
+		return MisfireSendAll, 0
+	}
+	return settings.Policy, time.Duration(settings.ThresholdMinutes) * time.Minute
+}
+
+// SchedulerState - состояние планировщика бота, единственная строка. По времени последней
+// проверки напоминаний бот после перезапуска узнаёт, сколько он не работал.
+type SchedulerState struct {
+	ID         int `gorm:"primaryKey"`
+	LastTickAt time.Time
+}
@@ -5,11 +5,93 @@ import (
 )
 
 type Reminder struct {
-	ID        int       `json:"id" gorm:"primaryKey"`
-	UserID    int       `json:"user_id"`
-	Message   string    `json:"message"`
-	SendAt    time.Time `json:"send_at"`
-	IsSent    bool      `json:"is_sent"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 int                 `json:"id" gorm:"primaryKey"`
+	ExternalID         *string             `json:"external_id,omitempty" gorm:"uniqueIndex:idx_reminders_user_external_id"`
+	UserID             int                 `json:"user_id" gorm:"uniqueIndex:idx_reminders_user_external_id"`
+	User               *User               `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Recipients         []ReminderRecipient `json:"recipients,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	ListID             *int                `json:"list_id,omitempty" gorm:"index"`
+	List               *ReminderList       `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	Tags               []Tag               `json:"tags,omitempty" gorm:"many2many:reminder_tags;constraint:OnDelete:CASCADE"`
+	Message            string              `json:"message"`
+	IsTemplate         bool                `json:"is_template"`
+	ParseMode          string              `json:"parse_mode,omitempty" example:"HTML"`
+	AttachmentType     string              `json:"attachment_type,omitempty" example:"photo"`
+	AttachmentFileID   string              `json:"attachment_file_id,omitempty"`
+	AttachmentKey      string              `json:"attachment_key,omitempty"`
+	SendAt             time.Time           `json:"send_at"`
+	AnchorEventID      *int                `json:"anchor_event_id,omitempty"`
+	AnchorEvent        *Event              `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	AnchorReminderID   *int                `json:"anchor_reminder_id,omitempty"`
+	AnchorReminder     *Reminder           `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	AnchorOn           string              `json:"anchor_on,omitempty" example:"acknowledged"`
+	AnchorOffset       int                 `json:"anchor_offset_minutes,omitempty" example:"-30"`
+	WaitingAnchor      bool                `json:"waiting_anchor,omitempty"`
+	TimeZone           string              `json:"time_zone,omitempty" example:"Europe/Moscow"`
+	Recurrence         string              `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO,WE,FR"`
+	SentCount          int                 `json:"sent_count"`
+	IsSent             bool                `json:"is_sent"`
+	Urgent             bool                `json:"urgent"`
+	Priority           string              `json:"priority,omitempty" example:"high"`
+	EscalationPolicyID *int                `json:"escalation_policy_id,omitempty"`
+	EscalationPolicy   *EscalationPolicy   `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	AckedAt            *time.Time          `json:"acked_at,omitempty"`
+	DeliveryStatus     string              `json:"delivery_status,omitempty" example:"partial"`
+	DeliveryError      string              `json:"delivery_error,omitempty" example:"bot_blocked"`
+	DeferredUntil      *time.Time          `json:"deferred_until,omitempty"`
+	PausedAt           *time.Time          `json:"paused_at,omitempty"`
+	PausedUntil        *time.Time          `json:"paused_until,omitempty"`
+	ResumeMode         string              `json:"resume_mode,omitempty" example:"catch_up"`
+	MisfireSettings    `gorm:"embedded"`
+	ArchivedAt         *time.Time `json:"archived_at,omitempty"`
+	// До какого времени напоминание взял на отправку экземпляр бота
+	ClaimedUntil *time.Time `json:"-" gorm:"index"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Типы вложений. Файл вложения либо загружен в хранилище (AttachmentKey),
+// либо уже есть в Telegram (AttachmentFileID).
+const (
+	AttachmentPhoto    = "photo"
+	AttachmentDocument = "document"
+	AttachmentVoice    = "voice"
+)
+
+// Моменты напоминания-якоря, от которых отсчитывается зависимое напоминание
+const (
+	AnchorScheduled    = "scheduled"
+	AnchorSent         = "sent"
+	AnchorAcknowledged = "acknowledged"
+)
+
+// Anchored сообщает, задано ли время отправки относительно события или другого напоминания.
+func (r Reminder) Anchored() bool {
+	return r.AnchorEventID != nil || r.AnchorReminderID != nil
+}
+
+// Paused сообщает, приостановлено ли напоминание.
+func (r Reminder) Paused() bool {
+	return r.PausedAt != nil
+}
+
+// Location возвращает часовой пояс напоминания, по умолчанию UTC.
+func (r Reminder) Location() *time.Location {
+	if r.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ScheduleLocation возвращает часовой пояс, в котором считается расписание: пояс напоминания,
+// а если он не задан - пояс пользователя. Для пояса пользователя User должен быть загружен.
+func (r Reminder) ScheduleLocation() *time.Location {
+	if r.TimeZone == "" && r.User != nil {
+		return r.User.Location()
+	}
+	return r.Location()
 }
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// Виды получателей напоминания
+const (
+	// RecipientUser - пользователь сервиса, напоминание уходит во все его привязанные чаты
+	RecipientUser = "user"
+	// RecipientChat - группа или канал Telegram, куда добавлен бот
+	RecipientChat = "chat"
+)
+
+// Состояния доставки
+const (
+	DeliverySent    = "sent"
+	DeliveryPartial = "partial"
+	DeliveryFailed  = "failed"
+	// Напоминание опоздало и пропущено по политике пропущенных напоминаний
+	DeliverySkipped = "skipped"
+)
+
+// ReminderRecipient - дополнительный получатель напоминания. Если у напоминания нет
+// получателей, оно отправляется в чаты его владельца. Группа или канал, куда писать больше
+// нельзя, отключается с причиной в InactiveReason и снова включается, когда из чата приходит
+// сообщение боту или получателей напоминания сохраняют заново.
+type ReminderRecipient struct {
+	ID             int        `json:"id" gorm:"primaryKey"`
+	ReminderID     int        `json:"-" gorm:"index"`
+	Reminder       *Reminder  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Kind           string     `json:"kind" example:"chat"`
+	UserID         *int       `json:"user_id,omitempty"`
+	User           *User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	ChatID         int64      `json:"chat_id,omitempty" example:"-1001234567890"`
+	InactiveReason string     `json:"inactive_reason,omitempty" example:"chat_not_found"`
+	DeactivatedAt  *time.Time `json:"deactivated_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ReminderDelivery - результат доставки одного повторения напоминания в один чат.
+type ReminderDelivery struct {
+	ID          int       `json:"id" gorm:"primaryKey"`
+	ReminderID  int       `json:"reminder_id" gorm:"index"`
+	Reminder    *Reminder `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	RecipientID *int      `json:"recipient_id,omitempty"`
+	UserID      *int      `json:"user_id,omitempty"`
+	ChatID      int64     `json:"chat_id"`
+	Occurrence  int       `json:"occurrence"`
+	Status      string    `json:"status" example:"sent"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DeliveryStatus возвращает общее состояние доставки по числу успешных и неудачных отправок.
+func DeliveryStatus(sent, failed int) string {
+	switch {
+	case failed == 0:
+		return DeliverySent
+	case sent == 0:
+		return DeliveryFailed
+	}
+	return DeliveryPartial
+}
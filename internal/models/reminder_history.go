@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// Действия в истории напоминания
+const (
+	HistoryDeferred = "deferred"
+	HistorySent     = "sent"
+	// Шаг эскалации выполнен, в Reason - действие шага
+	HistoryEscalated = "escalated"
+	// Получатель подтвердил напоминание, в Reason - откуда пришло подтверждение
+	HistoryAcknowledged = "acknowledged"
+	// Время отправки пересчитано после переноса якоря
+	HistoryRescheduled = "rescheduled"
+	// Напоминание приостановлено, в Reason - что приостановлено: напоминание, список или пользователь
+	HistoryPaused = "paused"
+	// Напоминание возобновлено, в Reason - что сделано с пропущенными повторениями
+	HistoryResumed = "resumed"
+	// Опоздавшее напоминание не отправлено, в Reason - политика пропущенных напоминаний
+	HistorySkipped = "skipped"
+	// Напоминание некуда доставить, в Reason - почему отключены чаты получателя
+	HistoryFailed = "failed"
+)
+
+// ReminderHistory - запись в истории доставки напоминания.
+type ReminderHistory struct {
+	ID            int        `json:"id" gorm:"primaryKey"`
+	ReminderID    int        `json:"reminder_id" gorm:"index"`
+	Reminder      *Reminder  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Action        string     `json:"action" example:"deferred"`
+	Reason        string     `json:"reason,omitempty" example:"quiet_hours"`
+	SendAt        time.Time  `json:"send_at"`
+	DeferredUntil *time.Time `json:"deferred_until,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -0,0 +1,257 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Каналы доставки
+const (
+	ChannelTelegram = "telegram"
+)
+
+// Причины переноса доставки
+const (
+	DeferQuietHours = "quiet_hours"
+	DeferDND        = "dnd"
+)
+
+// Дни недели в формате RRULE
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// User - получатель напоминаний. Удаление мягкое: архивированный пользователь
+// остаётся в базе вместе с историей своих напоминаний.
+type User struct {
+	ID              int        `json:"id" gorm:"primaryKey"`
+	DisplayName     string     `json:"display_name" example:"Иван"`
+	TimeZone        string     `json:"time_zone,omitempty" example:"Europe/Moscow"`
+	Locale          string     `json:"locale,omitempty" example:"ru"`
+	DefaultChannel  string     `json:"default_channel" example:"telegram"`
+	QuietHours      QuietHours `json:"quiet_hours" gorm:"type:jsonb"`
+	DndUntil        *time.Time `json:"dnd_until,omitempty"`
+	PausedAt        *time.Time `json:"paused_at,omitempty"`
+	PausedUntil     *time.Time `json:"paused_until,omitempty"`
+	ResumeMode      string     `json:"resume_mode,omitempty" example:"skip"`
+	MisfireSettings `gorm:"embedded;embeddedPrefix:misfire_"`
+	Digest          DigestSettings `json:"digest" gorm:"type:jsonb"`
+	AgendaSentAt    *time.Time     `json:"-"`
+	TelegramLinks   []TelegramLink `json:"telegram_links" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// Причины отключения чата Telegram после ошибки, которая не пройдёт при повторе
+const (
+	InactiveBotBlocked      = "bot_blocked"
+	InactiveBotKicked       = "bot_kicked"
+	InactiveUserDeactivated = "user_deactivated"
+	InactiveChatNotFound    = "chat_not_found"
+	InactiveForbidden       = "forbidden"
+)
+
+// TelegramLink - чат Telegram, привязанный к пользователю. Если Telegram сообщает, что писать
+// в чат больше нельзя, чат отключается с причиной в InactiveReason и снова включается,
+// когда пользователь пишет боту.
+type TelegramLink struct {
+	ID             int        `json:"id" gorm:"primaryKey"`
+	UserID         int        `json:"user_id" gorm:"index"`
+	User           *User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	ChatID         int64      `json:"chat_id" gorm:"uniqueIndex"`
+	Active         bool       `json:"active"`
+	InactiveReason string     `json:"inactive_reason,omitempty" example:"bot_blocked"`
+	DeactivatedAt  *time.Time `json:"deactivated_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TelegramLinkToken - одноразовый токен для привязки чата через ссылку t.me/<bot>?start=<token>.
+// Хранится только хеш токена.
+type TelegramLinkToken struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	UserID    int       `json:"user_id" gorm:"index"`
+	User      *User     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QuietWindow - интервал тишины в часовом поясе пользователя. Пустой список дней
+// означает каждый день. Интервал может переходить через полночь (22:00-07:00),
+// тогда дни недели относятся к его началу.
+type QuietWindow struct {
+	Weekdays []string `json:"weekdays,omitempty" example:"MO,TU,WE,TH,FR"`
+	Start    string   `json:"start" example:"22:00"`
+	End      string   `json:"end" example:"07:00"`
+}
+
+// QuietHours - набор интервалов тишины, хранится в JSON.
+type QuietHours []QuietWindow
+
+// Location возвращает часовой пояс пользователя, по умолчанию UTC.
+func (u User) Location() *time.Location {
+	if u.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Paused сообщает, приостановлена ли доставка пользователю.
+func (u User) Paused() bool {
+	return u.PausedAt != nil
+}
+
+// ActiveChatIDs возвращает привязанные чаты, в которые можно отправлять сообщения.
+func (u User) ActiveChatIDs() []int64 {
+	var ids []int64
+	for _, link := range u.TelegramLinks {
+		if link.Active {
+			ids = append(ids, link.ChatID)
+		}
+	}
+	return ids
+}
+
+// DeferUntil возвращает время, до которого нужно отложить доставку в момент t, и причину переноса.
+// Если доставка не откладывается, возвращается t и пустая причина. Интервалы тишины, идущие
+// друг за другом, и режим «не беспокоить» складываются.
+func (u User) DeferUntil(t time.Time) (time.Time, string) {
+	loc := u.Location()
+	until, reason := t, ""
+
+	// Каждый интервал короче суток, поэтому за неделю цепочка гарантированно заканчивается
+	for i := 0; i < 2*len(weekdayCodes); i++ {
+		changed := false
+		if u.DndUntil != nil && until.Before(*u.DndUntil) {
+			until, reason, changed = *u.DndUntil, DeferDND, true
+		}
+		if end, ok := u.QuietHours.end(until.In(loc)); ok {
+			until, reason, changed = end, DeferQuietHours, true
+		}
+		if !changed {
+			break
+		}
+	}
+	return until, reason
+}
+
+// end возвращает конец интервала тишины, в который попадает t. Учитываются интервалы,
+// начавшиеся в день t и накануне, если они переходят через полночь.
+func (q QuietHours) end(t time.Time) (time.Time, bool) {
+	var latest time.Time
+	found := false
+
+	for _, w := range q {
+		start, err := parseClock(w.Start)
+		if err != nil {
+			continue
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			continue
+		}
+
+		for _, offset := range []int{0, -1} {
+			day := time.Date(t.Year(), t.Month(), t.Day()+offset, 0, 0, 0, 0, t.Location())
+			if !w.appliesTo(day.Weekday()) {
+				continue
+			}
+			from := time.Date(day.Year(), day.Month(), day.Day(), start/60, start%60, 0, 0, t.Location())
+			to := time.Date(day.Year(), day.Month(), day.Day(), end/60, end%60, 0, 0, t.Location())
+			if end <= start {
+				to = time.Date(day.Year(), day.Month(), day.Day()+1, end/60, end%60, 0, 0, t.Location())
+			}
+			if !t.Before(from) && t.Before(to) && to.After(latest) {
+				latest, found = to, true
+			}
+		}
+	}
+	return latest, found
+}
+
+// appliesTo сообщает, начинается ли интервал в указанный день недели.
+func (w QuietWindow) appliesTo(day time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, code := range w.Weekdays {
+		if weekdayCodes[code] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate проверяет дни недели и время интервалов.
+func (q QuietHours) Validate() error {
+	for i, w := range q {
+		for _, day := range w.Weekdays {
+			if _, ok := weekdayCodes[day]; !ok {
+				return fmt.Errorf("quiet_hours[%d]: unknown weekday %q", i, day)
+			}
+		}
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return fmt.Errorf("quiet_hours[%d]: invalid start: %w", i, err)
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return fmt.Errorf("quiet_hours[%d]: invalid end: %w", i, err)
+		}
+		if start == end {
+			return fmt.Errorf("quiet_hours[%d]: start and end must differ", i)
+		}
+	}
+	return nil
+}
+
+// Value сохраняет интервалы в JSON.
+func (q QuietHours) Value() (driver.Value, error) {
+	if q == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(q)
+	return string(data), err
+}
+
+// Scan читает интервалы из JSON.
+func (q *QuietHours) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*q = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported quiet hours value %T", value)
+	}
+	return json.Unmarshal(data, q)
+}
+
+// parseClock разбирает время ЧЧ:ММ и возвращает число минут от полуночи.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.New("expected HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Типы событий жизненного цикла напоминания
+const (
+	EventReminderCreated      = "reminder.created"
+	EventReminderUpdated      = "reminder.updated"
+	EventReminderDeleted      = "reminder.deleted"
+	EventReminderSent         = "reminder.sent"
+	EventReminderFailed       = "reminder.failed"
+	EventReminderAcknowledged = "reminder.acknowledged"
+)
+
+// EventTypes - все типы событий, на которые можно подписаться.
+var EventTypes = []string{
+	EventReminderCreated, EventReminderUpdated, EventReminderDeleted,
+	EventReminderSent, EventReminderFailed, EventReminderAcknowledged,
+}
+
+// Состояния доставки вебхука
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+)
+
+// ReminderEvent - событие жизненного цикла напоминания. Data - снимок напоминания
+// в момент события. Журнал событий служит очередью вебхуков и позволяет продолжить
+// поток изменений с последнего полученного события. Внешнего ключа на напоминание нет,
+// чтобы события удалённых напоминаний сохранялись.
+type ReminderEvent struct {
+	ID         int             `json:"id" gorm:"primaryKey"`
+	UserID     int             `json:"user_id" gorm:"index"`
+	User       *User           `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	ReminderID int             `json:"reminder_id" gorm:"index"`
+	Type       string          `json:"type" example:"reminder.sent"`
+	Data       json.RawMessage `json:"data" gorm:"type:jsonb" swaggertype:"object"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"index"`
+}
+
+// Webhook - подписка внешней системы на события напоминаний пользователя.
+// Тело запроса подписывается HMAC-SHA256 с секретом подписки.
+type Webhook struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"index"`
+	User      *User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	URL       string     `json:"url" example:"https://example.com/hooks/reminders"`
+	Secret    string     `json:"secret,omitempty"`
+	Events    StringList `json:"events" gorm:"type:jsonb" example:"reminder.sent,reminder.failed"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// WebhookDelivery - доставка события подписке. Хранит число попыток и ответ
+// на последнюю из них.
+type WebhookDelivery struct {
+	ID            int            `json:"id" gorm:"primaryKey"`
+	WebhookID     int            `json:"webhook_id" gorm:"index"`
+	Webhook       *Webhook       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	EventID       int            `json:"event_id" gorm:"index"`
+	Event         *ReminderEvent `json:"event,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Status        string         `json:"status" gorm:"index" example:"delivered"`
+	Attempts      int            `json:"attempts"`
+	ResponseCode  int            `json:"response_code,omitempty" example:"200"`
+	Error         string         `json:"error,omitempty"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty" gorm:"index"`
+	DeliveredAt   *time.Time     `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// ValidEventType сообщает, поддерживается ли тип события.
+func ValidEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribed сообщает, подписан ли вебхук на тип события.
+func (w Webhook) Subscribed(eventType string) bool {
+	for _, t := range w.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// StringList - список строк, хранится в JSON.
+type StringList []string
+
+// Value сохраняет список в JSON.
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	return string(data), err
+}
+
+// Scan читает список из JSON.
+func (l *StringList) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported string list value %T", value)
+	}
+	return json.Unmarshal(data, l)
+}
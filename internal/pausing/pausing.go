@@ -0,0 +1,256 @@
+// Package pausing приостанавливает и возобновляет напоминания и пользователей.
+package pausing
+
+import (
+	"Reminders/internal/models"
+	"Reminders/internal/recurrence"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Что делать с повторениями, пропущенными за время паузы
+const (
+	// ResumeSkip - перейти к ближайшему будущему повторению
+	ResumeSkip = "skip"
+	// ResumeCatchUp - отправить пропущенные повторения по очереди
+	ResumeCatchUp = "catch_up"
+)
+
+// Что приостановлено, записывается в Reason истории
+const (
+	ScopeReminder = "reminder"
+	ScopeList     = "list"
+	ScopeUser     = "user"
+)
+
+// ErrInvalidPause возвращается при неверных параметрах паузы.
+var ErrInvalidPause = errors.New("invalid pause")
+
+// Validate проверяет параметры паузы. Пустой режим означает ResumeSkip.
+func Validate(until *time.Time, mode string, now time.Time) error {
+	if until != nil && !until.After(now) {
+		return fmt.Errorf("%w: until must be in the future", ErrInvalidPause)
+	}
+	return ValidateMode(mode)
+}
+
+// ValidateMode проверяет режим возобновления.
+func ValidateMode(mode string) error {
+	switch mode {
+	case "", ResumeSkip, ResumeCatchUp:
+		return nil
+	}
+	return fmt.Errorf("%w: resume mode must be one of %s, %s", ErrInvalidPause, ResumeSkip, ResumeCatchUp)
+}
+
+// PauseReminders приостанавливает неотправленные напоминания, выбранные условием where.
+// Если until задано, напоминания возобновятся сами в режиме mode. Возвращает количество
+// приостановленных напоминаний.
+func PauseReminders(tx *gorm.DB, where func(*gorm.DB) *gorm.DB, until *time.Time, mode, scope string, now time.Time) (int, error) {
+	var reminders []models.Reminder
+	err := tx.Scopes(where).Where("is_sent = ? AND archived_at IS NULL AND paused_at IS NULL", false).Find(&reminders).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, r := range reminders {
+		err := tx.Model(&r).Updates(map[string]interface{}{
+			"paused_at":    now,
+			"paused_until": until,
+			"resume_mode":  mode,
+			"updated_at":   now,
+		}).Error
+		if err != nil {
+			return 0, err
+		}
+		if err := addHistory(tx, r.ID, models.HistoryPaused, scope, r.SendAt, now); err != nil {
+			return 0, err
+		}
+	}
+	return len(reminders), nil
+}
+
+// ResumeReminders возобновляет приостановленные напоминания, выбранные условием where.
+// Пустой mode берёт режим, заданный при паузе. Возвращает количество возобновлённых напоминаний.
+func ResumeReminders(tx *gorm.DB, where func(*gorm.DB) *gorm.DB, mode string, now time.Time) (int, error) {
+	var reminders []models.Reminder
+	err := tx.Scopes(where).Preload("User").Where("is_sent = ? AND archived_at IS NULL AND paused_at IS NOT NULL", false).Find(&reminders).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, r := range reminders {
+		rmode := mode
+		if rmode == "" {
+			rmode = r.ResumeMode
+		}
+		updates := missedUpdates(r, rmode, now)
+		updates["paused_at"] = nil
+		updates["paused_until"] = nil
+		updates["resume_mode"] = ""
+		updates["updated_at"] = now
+		if err := tx.Model(&r).Updates(updates).Error; err != nil {
+			return 0, err
+		}
+		sendAt := r.SendAt
+		if next, ok := updates["send_at"].(time.Time); ok {
+			sendAt = next
+		}
+		if err := addHistory(tx, r.ID, models.HistoryResumed, modeOrDefault(rmode), sendAt, now); err != nil {
+			return 0, err
+		}
+	}
+	return len(reminders), nil
+}
+
+// PauseUser приостанавливает доставку всех напоминаний пользователя, в том числе
+// созданных во время паузы.
+func PauseUser(tx *gorm.DB, user *models.User, until *time.Time, mode string, now time.Time) error {
+	user.PausedAt = &now
+	user.PausedUntil = until
+	user.ResumeMode = mode
+	return tx.Model(user).Updates(map[string]interface{}{
+		"paused_at":    now,
+		"paused_until": until,
+		"resume_mode":  mode,
+		"updated_at":   now,
+	}).Error
+}
+
+// ResumeUser возобновляет доставку пользователю. Пропущенные повторения его напоминаний,
+// не приостановленных отдельно, обрабатываются в режиме mode, пустой mode берёт режим паузы.
+// Возвращает количество напоминаний, у которых пропущены повторения.
+func ResumeUser(tx *gorm.DB, user *models.User, mode string, now time.Time) (int, error) {
+	if mode == "" {
+		mode = user.ResumeMode
+	}
+	err := tx.Model(user).Updates(map[string]interface{}{
+		"paused_at":    nil,
+		"paused_until": nil,
+		"resume_mode":  "",
+		"updated_at":   now,
+	}).Error
+	if err != nil {
+		return 0, err
+	}
+	user.PausedAt, user.PausedUntil, user.ResumeMode = nil, nil, ""
+
+	var reminders []models.Reminder
+	err = tx.Where("user_id = ? AND is_sent = ? AND archived_at IS NULL AND paused_at IS NULL AND send_at <= ?", user.ID, false, now).
+		Find(&reminders).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, r := range reminders {
+		r.User = user
+		updates := missedUpdates(r, mode, now)
+		sendAt := r.SendAt
+		if next, ok := updates["send_at"].(time.Time); ok {
+			sendAt = next
+		}
+		if len(updates) > 0 {
+			updates["updated_at"] = now
+			if err := tx.Model(&r).Updates(updates).Error; err != nil {
+				return 0, err
+			}
+		}
+		if err := addHistory(tx, r.ID, models.HistoryResumed, modeOrDefault(mode), sendAt, now); err != nil {
+			return 0, err
+		}
+	}
+	return len(reminders), nil
+}
+
+// ResumeExpired возобновляет напоминания и пользователей, у которых закончилась пауза.
+func ResumeExpired(db *gorm.DB, now time.Time) (int, error) {
+	count := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		n, err := ResumeReminders(tx, func(db *gorm.DB) *gorm.DB {
+			return db.Where("paused_until <= ?", now)
+		}, "", now)
+		count += n
+		if err != nil {
+			return err
+		}
+
+		var users []models.User
+		if err := tx.Where("paused_at IS NOT NULL AND paused_until <= ?", now).Find(&users).Error; err != nil {
+			return err
+		}
+		for i := range users {
+			if _, err := ResumeUser(tx, &users[i], "", now); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// ByID выбирает напоминание по идентификатору.
+func ByID(id int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", id)
+	}
+}
+
+// ByList выбирает напоминания списка.
+func ByList(listID int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("list_id = ?", listID)
+	}
+}
+
+// SkipMissed возвращает ближайшее повторение напоминания после now. Второе значение
+// равно false, если повторения закончились.
+func SkipMissed(r models.Reminder, now time.Time) (time.Time, bool) {
+	rule, err := recurrence.Parse(r.Recurrence)
+	if err != nil {
+		return r.SendAt, true
+	}
+
+	next, _, ok := rule.After(r.SendAt.In(r.ScheduleLocation()), r.SentCount+1, now)
+	return next, ok
+}
+
+// missedUpdates возвращает изменения напоминания, время которого прошло во время паузы.
+// Разовое напоминание отправляется после возобновления в любом режиме.
+func missedUpdates(r models.Reminder, mode string, now time.Time) map[string]interface{} {
+	updates := map[string]interface{}{}
+	if mode == ResumeCatchUp || r.Recurrence == "" || r.SendAt.After(now) {
+		return updates
+	}
+
+	next, ok := SkipMissed(r, now)
+	if !ok {
+		updates["is_sent"] = true
+		return updates
+	}
+	updates["send_at"] = next
+	updates["deferred_until"] = nil
+	return updates
+}
+
+// modeOrDefault возвращает режим возобновления, по умолчанию ResumeSkip.
+func modeOrDefault(mode string) string {
+	if mode == "" {
+		return ResumeSkip
+	}
+	return mode
+}
+
+// addHistory записывает паузу или возобновление в историю напоминания.
+func addHistory(tx *gorm.DB, reminderID int, action, reason string, sendAt, now time.Time) error {
+	return tx.Create(&models.ReminderHistory{
+		ReminderID: reminderID,
+		Action:     action,
+		Reason:     reason,
+		SendAt:     sendAt,
+		CreatedAt:  now,
+	}).Error
+}
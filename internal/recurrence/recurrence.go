@@ -0,0 +1,442 @@
+// Package recurrence реализует подмножество правил повторения RRULE (RFC 5545),
+// достаточное для повторяющихся напоминаний.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Частоты повторения
+const (
+	Minutely = "MINUTELY"
+	Hourly   = "HOURLY"
+	Daily    = "DAILY"
+	Weekly   = "WEEKLY"
+	Monthly  = "MONTHLY"
+	Yearly   = "YEARLY"
+)
+
+const (
+	// Максимальное число периодов, просматриваемых при поиске следующего повторения
+	maxPeriods = 1000
+	// Максимальное число повторений, перебираемых при поиске пропущенных
+	maxOccurrences = 1000000
+)
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum - день недели с необязательным порядковым номером в месяце (1MO, -1FR).
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule - разобранное правило повторения.
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+}
+
+// Parse разбирает строку RRULE. Префикс "RRULE:" допускается.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("empty recurrence rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(value)
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+			if err == nil && rule.Interval < 1 {
+				err = errors.New("must be positive")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+			if err == nil && rule.Count < 1 {
+				err = errors.New("must be positive")
+			}
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseInts(value, -31, 31)
+		case "BYMONTH":
+			rule.ByMonth, err = parseInts(value, 1, 12)
+		case "WKST":
+			if _, ok := weekdayCodes[strings.ToUpper(value)]; !ok {
+				err = errors.New("unknown weekday")
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+
+	switch rule.Freq {
+	case Minutely, Hourly, Daily, Weekly, Monthly, Yearly:
+	case "":
+		return nil, errors.New("FREQ is required")
+	default:
+		return nil, fmt.Errorf("unsupported FREQ %s", rule.Freq)
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL are mutually exclusive")
+	}
+	for _, wd := range rule.ByDay {
+		if wd.N != 0 && rule.Freq != Monthly {
+			return nil, errors.New("numbered BYDAY is only supported with FREQ=MONTHLY")
+		}
+	}
+	return rule, nil
+}
+
+// String возвращает правило в формате RRULE без префикса.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = wd.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	return strings.Join(parts, ";")
+}
+
+// String возвращает день недели в формате BYDAY.
+func (wd WeekdayNum) String() string {
+	for code, day := range weekdayCodes {
+		if day == wd.Weekday {
+			if wd.N != 0 {
+				return strconv.Itoa(wd.N) + code
+			}
+			return code
+		}
+	}
+	return ""
+}
+
+// Next возвращает повторение, следующее за prev. occurrences - сколько повторений уже
+// состоялось, включая prev; оно нужно для учёта COUNT. Время суток берётся из prev,
+// поэтому prev должен быть в часовом поясе напоминания. Второе значение равно false,
+// если повторения закончились.
+func (r *Rule) Next(prev time.Time, occurrences int) (time.Time, bool) {
+	if r.Count > 0 && occurrences >= r.Count {
+		return time.Time{}, false
+	}
+
+	next, ok := r.next(prev)
+	if !ok {
+		return time.Time{}, false
+	}
+	if !r.Until.IsZero() && next.After(r.Until) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// After возвращает первое повторение позже t, перебирая повторения от prev, и сколько
+// повторений пропущено, включая prev. occurrences - как в Next. Третье значение равно false,
+// если повторения закончились раньше t; тогда возвращается последнее из них.
+func (r *Rule) After(prev time.Time, occurrences int, t time.Time) (time.Time, int, bool) {
+	next, skipped := prev, 0
+	for !next.After(t) && skipped < maxOccurrences {
+		n, ok := r.Next(next, occurrences+skipped)
+		if !ok {
+			return next, skipped, false
+		}
+		next = n
+		skipped++
+	}
+	return next, skipped, true
+}
+
+// Latest возвращает последнее повторение не позже t, перебирая повторения от prev,
+// и сколько более ранних повторений пропущено. prev должен быть не позже t.
+func (r *Rule) Latest(prev time.Time, occurrences int, t time.Time) (time.Time, int) {
+	latest, skipped := prev, 0
+	for skipped < maxOccurrences {
+		n, ok := r.Next(latest, occurrences+skipped)
+		if !ok || n.After(t) {
+			break
+		}
+		latest = n
+		skipped++
+	}
+	return latest, skipped
+}
+
+func (r *Rule) next(prev time.Time) (time.Time, bool) {
+	switch r.Freq {
+	case Minutely:
+		return prev.Add(time.Duration(r.Interval) * time.Minute), true
+	case Hourly:
+		return prev.Add(time.Duration(r.Interval) * time.Hour), true
+	case Daily:
+		for i := 1; i <= maxPeriods; i++ {
+			c := prev.AddDate(0, 0, i*r.Interval)
+			if r.matchesDay(c) {
+				return c, true
+			}
+		}
+	case Weekly:
+		weekStart := startOfWeek(prev)
+		for i := 1; i <= maxPeriods*7; i++ {
+			c := prev.AddDate(0, 0, i)
+			weeks := int(dateOnly(startOfWeek(c)).Sub(dateOnly(weekStart)).Hours()/24+0.5) / 7
+			if weeks%r.Interval != 0 {
+				continue
+			}
+			if len(r.ByDay) == 0 {
+				if c.Weekday() == prev.Weekday() {
+					return c, true
+				}
+			} else if r.matchesDay(c) {
+				return c, true
+			}
+		}
+	case Monthly:
+		for i := 0; i <= maxPeriods; i++ {
+			first := time.Date(prev.Year(), prev.Month()+time.Month(i*r.Interval), 1, prev.Hour(), prev.Minute(), prev.Second(), 0, prev.Location())
+			for _, c := range r.monthCandidates(first, prev.Day()) {
+				if c.After(prev) {
+					return c, true
+				}
+			}
+		}
+	case Yearly:
+		for i := 0; i <= maxPeriods; i++ {
+			year := prev.Year() + i*r.Interval
+			months := r.ByMonth
+			if len(months) == 0 {
+				months = []int{int(prev.Month())}
+			}
+			for _, m := range sortedInts(months) {
+				first := time.Date(year, time.Month(m), 1, prev.Hour(), prev.Minute(), prev.Second(), 0, prev.Location())
+				for _, c := range r.monthCandidates(first, prev.Day()) {
+					if c.After(prev) {
+						return c, true
+					}
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// monthCandidates возвращает подходящие дни месяца, начинающегося с first, по возрастанию.
+func (r *Rule) monthCandidates(first time.Time, defaultDay int) []time.Time {
+	if len(r.ByMonth) > 0 && r.Freq == Monthly && !containsInt(r.ByMonth, int(first.Month())) {
+		return nil
+	}
+
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+	var days []int
+	switch {
+	case len(r.ByMonthDay) > 0:
+		// BYDAY вместе с BYMONTHDAY сужает дни месяца: BYDAY=FR;BYMONTHDAY=13 - только пятницы 13-го
+		for _, d := range r.ByMonthDay {
+			d = resolveMonthDay(d, daysInMonth)
+			if d >= 1 && d <= daysInMonth && !containsInt(days, d) &&
+				(len(r.ByDay) == 0 || r.matchesWeekday(first, d, daysInMonth)) {
+				days = append(days, d)
+			}
+		}
+	case len(r.ByDay) > 0:
+		for d := 1; d <= daysInMonth; d++ {
+			if r.matchesWeekday(first, d, daysInMonth) {
+				days = append(days, d)
+			}
+		}
+	default:
+		// Месяцы без нужного дня (например, 31-го) пропускаются, как требует RFC 5545
+		if defaultDay <= daysInMonth {
+			days = append(days, defaultDay)
+		}
+	}
+
+	days = sortedInts(days)
+	result := make([]time.Time, 0, len(days))
+	for _, d := range days {
+		result = append(result, first.AddDate(0, 0, d-1))
+	}
+	return result
+}
+
+// matchesWeekday сообщает, подходит ли день d месяца, начинающегося с first, под BYDAY
+// с учётом порядкового номера дня недели в месяце.
+func (r *Rule) matchesWeekday(first time.Time, d, daysInMonth int) bool {
+	weekday := first.AddDate(0, 0, d-1).Weekday()
+	for _, wd := range r.ByDay {
+		if weekday != wd.Weekday {
+			continue
+		}
+		if wd.N == 0 ||
+			(wd.N > 0 && (d-1)/7+1 == wd.N) ||
+			(wd.N < 0 && (daysInMonth-d)/7+1 == -wd.N) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesDay(t time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsInt(r.ByMonth, int(t.Month())) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 {
+		daysInMonth := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		matched := false
+		for _, d := range r.ByMonthDay {
+			if resolveMonthDay(d, daysInMonth) == t.Day() {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveMonthDay переводит отрицательный день месяца (-1 - последний) в номер дня.
+func resolveMonthDay(d, daysInMonth int) int {
+	if d < 0 {
+		return daysInMonth + d + 1
+	}
+	return d
+}
+
+// startOfWeek возвращает понедельник недели, в которую попадает t (WKST=MO).
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unknown date format")
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var result []WeekdayNum
+	for _, item := range strings.Split(strings.ToUpper(value), ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+		day, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+		wd := WeekdayNum{Weekday: day}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("invalid weekday %q", item)
+			}
+			wd.N = n
+		}
+		result = append(result, wd)
+	}
+	return result, nil
+}
+
+func parseInts(value string, min, max int) ([]int, error) {
+	var result []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("invalid value %q", item)
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+func joinInts(values []int) string {
+	items := make([]string, len(values))
+	for i, v := range values {
+		items[i] = strconv.Itoa(v)
+	}
+	return strings.Join(items, ",")
+}
+
+func sortedInts(values []int) []int {
+	result := append([]int(nil), values...)
+	sort.Ints(result)
+	return result
+}
+
+func containsInt(values []int, v int) bool {
+	for _, item := range values {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		rule string
+		prev time.Time
+		want []time.Time
+	}{
+		{
+			name: "daily",
+			rule: "FREQ=DAILY;INTERVAL=2",
+			prev: time.Date(2026, 10, 19, 9, 0, 0, 0, moscow),
+			want: []time.Time{time.Date(2026, 10, 21, 9, 0, 0, 0, moscow), time.Date(2026, 10, 23, 9, 0, 0, 0, moscow)},
+		},
+		{
+			name: "daily_last_day_of_month",
+			rule: "FREQ=DAILY;BYMONTHDAY=-1",
+			prev: time.Date(2026, 1, 31, 9, 0, 0, 0, moscow),
+			want: []time.Time{time.Date(2026, 2, 28, 9, 0, 0, 0, moscow), time.Date(2026, 3, 31, 9, 0, 0, 0, moscow)},
+		},
+		{
+			name: "weekly_by_day",
+			rule: "FREQ=WEEKLY;BYDAY=MO,WE",
+			prev: time.Date(2026, 10, 19, 9, 0, 0, 0, moscow),
+			want: []time.Time{time.Date(2026, 10, 21, 9, 0, 0, 0, moscow), time.Date(2026, 10, 26, 9, 0, 0, 0, moscow)},
+		},
+		{
+			name: "biweekly",
+			rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR",
+			prev: time.Date(2026, 10, 23, 18, 0, 0, 0, moscow),
+			want: []time.Time{time.Date(2026, 11, 6, 18, 0, 0, 0, moscow), time.Date(2026, 11, 20, 18, 0, 0, 0, moscow)},
+		},
+		{
+			name: "monthly_last_friday",
+			rule: "FREQ=MONTHLY;BYDAY=-1FR",
+			prev: time.Date(2026, 10, 30, 18, 0, 0, 0, moscow),
+			want: []time.Time{time.Date(2026, 11, 27, 18, 0, 0, 0, moscow), time.Date(2026, 12, 25, 18, 0, 0, 0, moscow)},
+		},
+		{
+			name: "monthly_friday_13th",
+			rule: "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			prev: time.Date(2026, 2, 13, 9, 0, 0, 0, moscow),
+			want: []time.Time{
+				time.Date(2026, 3, 13, 9, 0, 0, 0, moscow),
+				time.Date(2026, 11, 13, 9, 0, 0, 0, moscow),
+				time.Date(2027, 8, 13, 9, 0, 0, 0, moscow),
+			},
+		},
+		{
+			name: "monthly_31st_skips_short_months",
+			rule: "FREQ=MONTHLY",
+			prev: time.Date(2026, 1, 31, 9, 0, 0, 0, moscow),
+			want: []time.Time{time.Date(2026, 3, 31, 9, 0, 0, 0, moscow), time.Date(2026, 5, 31, 9, 0, 0, 0, moscow)},
+		},
+		{
+			name: "yearly_by_month",
+			rule: "FREQ=YEARLY;BYMONTH=3,9;BYMONTHDAY=1",
+			prev: time.Date(2026, 3, 1, 9, 0, 0, 0, moscow),
+			want: []time.Time{time.Date(2026, 9, 1, 9, 0, 0, 0, moscow), time.Date(2027, 3, 1, 9, 0, 0, 0, moscow)},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rule, err := Parse(c.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			prev := c.prev
+			for i, want := range c.want {
+				next, ok := rule.Next(prev, i+1)
+				if !ok {
+					t.Fatalf("occurrence %d: no next occurrence after %s", i+1, prev)
+				}
+				if !next.Equal(want) {
+					t.Fatalf("occurrence %d: got %s, want %s", i+1, next, want)
+				}
+				prev = next
+			}
+		})
+	}
+}
+
+func TestNextCountAndUntil(t *testing.T) {
+	prev := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	rule, err := Parse("FREQ=DAILY;COUNT=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rule.Next(prev, 1); !ok {
+		t.Error("COUNT=2: second occurrence is missing")
+	}
+	if _, ok := rule.Next(prev, 2); ok {
+		t.Error("COUNT=2: third occurrence must not exist")
+	}
+
+	rule, err = Parse("FREQ=DAILY;UNTIL=20261020")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rule.Next(prev, 1); !ok {
+		t.Error("UNTIL: occurrence on the last day is missing")
+	}
+	if _, ok := rule.Next(prev.AddDate(0, 0, 1), 2); ok {
+		t.Error("UNTIL: occurrence after the last day must not exist")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"FREQ=SOMETIMES",
+		"INTERVAL=2",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20261020",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=DAILY;BYSETPOS=1",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q): expected error", s)
+		}
+	}
+}
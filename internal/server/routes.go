@@ -12,16 +12,105 @@ func InitRotes(router *gin.Engine) *gin.Engine {
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Повтор POST-запроса с тем же Idempotency-Key получает сохранённый ответ
+	router.Use(handlers.Idempotency)
+
+	// Выгрузка и загрузка напоминаний в CSV и NDJSON
+	router.GET("/reminders/export", handlers.ExportRemindersHandler)
+	router.POST("/reminders/import", handlers.ImportRemindersHandler)
+
+	// Поток изменений напоминаний владельца ключа API (Server-Sent Events)
+	router.GET("/reminders/stream", handlers.RequireAPIKey, handlers.StreamRemindersHandler)
+
 	// Получение напоминания
-	router.GET("/reminders/:user_id", handlers.GetMessageByUserIDHandler)
+	router.GET("/reminders/:id", handlers.GetMessageByUserIDHandler)
 	// Получение списка всех напоминаний
 	router.GET("/reminders", handlers.GetAllMessagesHandler)
 	// Создание напоминания
 	router.POST("/reminders", handlers.CreateMessageHandler)
+	// Пакетные операции: /reminders:batchCreate, /reminders:batchUpdate, /reminders:batchDelete
+	router.POST("/reminders:action", handlers.BatchActionHandler)
 	// Редактирование напоминания
 	router.PUT("/reminders/:id", handlers.UpdateMessageHandler)
 	// Удаление напоминания
 	router.DELETE("/reminders/:id", handlers.DeleteMessageHandler)
+	// Загрузка и удаление вложения напоминания
+	router.POST("/reminders/:id/attachment", handlers.UploadAttachmentHandler)
+	router.DELETE("/reminders/:id/attachment", handlers.DeleteAttachmentHandler)
+	// История доставки напоминания
+	router.GET("/reminders/:id/history", handlers.GetReminderHistoryHandler)
+	// Доставка напоминания по получателям
+	router.GET("/reminders/:id/deliveries", handlers.GetReminderDeliveriesHandler)
+	// Подтверждение напоминания, отменяет эскалацию
+	router.POST("/reminders/:id/ack", handlers.AckReminderHandler)
+	router.POST("/reminders/:id/pause", handlers.PauseReminderHandler)
+	router.POST("/reminders/:id/resume", handlers.ResumeReminderHandler)
+	// Разбор времени на естественном языке: «tomorrow at 9», «по будням в 8:30»
+	router.POST("/parse-time", handlers.ParseTimeHandler)
+
+	// Списки напоминаний и метки
+	router.GET("/lists", handlers.GetListsHandler)
+	router.POST("/lists", handlers.CreateListHandler)
+	router.GET("/lists/:id", handlers.GetListHandler)
+	router.PUT("/lists/:id", handlers.UpdateListHandler)
+	router.DELETE("/lists/:id", handlers.DeleteListHandler)
+	router.POST("/lists/:id/pause", handlers.PauseListHandler)
+	router.POST("/lists/:id/resume", handlers.ResumeListHandler)
+	router.GET("/tags", handlers.GetTagsHandler)
+	router.POST("/tags", handlers.CreateTagHandler)
+	router.GET("/tags/:id", handlers.GetTagHandler)
+	router.PUT("/tags/:id", handlers.UpdateTagHandler)
+	router.DELETE("/tags/:id", handlers.DeleteTagHandler)
+
+	// События, относительно которых задаются напоминания
+	router.GET("/events", handlers.GetEventsHandler)
+	router.POST("/events", handlers.CreateEventHandler)
+	router.GET("/events/:id", handlers.GetEventHandler)
+	router.PUT("/events/:id", handlers.UpdateEventHandler)
+	router.DELETE("/events/:id", handlers.DeleteEventHandler)
+
+	// Политики эскалации неподтверждённых важных напоминаний
+	router.GET("/escalation-policies", handlers.GetEscalationPoliciesHandler)
+	router.POST("/escalation-policies", handlers.CreateEscalationPolicyHandler)
+	router.GET("/escalation-policies/:id", handlers.GetEscalationPolicyHandler)
+	router.PUT("/escalation-policies/:id", handlers.UpdateEscalationPolicyHandler)
+	router.DELETE("/escalation-policies/:id", handlers.DeleteEscalationPolicyHandler)
+
+	// Вебхуки на события напоминаний и журнал их доставок
+	router.GET("/webhooks", handlers.GetWebhooksHandler)
+	router.POST("/webhooks", handlers.CreateWebhookHandler)
+	router.GET("/webhooks/:id", handlers.GetWebhookHandler)
+	router.PUT("/webhooks/:id", handlers.UpdateWebhookHandler)
+	router.DELETE("/webhooks/:id", handlers.DeleteWebhookHandler)
+	router.GET("/webhooks/:id/deliveries", handlers.GetWebhookDeliveriesHandler)
+	router.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", handlers.RedeliverWebhookHandler)
+
+	// Пользователи и привязанные чаты Telegram
+	router.GET("/users", handlers.GetUsersHandler)
+	router.POST("/users", handlers.CreateUserHandler)
+	router.GET("/users/:id", handlers.GetUserHandler)
+	router.PUT("/users/:id", handlers.UpdateUserHandler)
+	router.DELETE("/users/:id", handlers.DeleteUserHandler)
+	// Режим «не беспокоить» до указанного времени
+	router.PUT("/users/:id/dnd", handlers.SetDndHandler)
+	router.DELETE("/users/:id/dnd", handlers.ClearDndHandler)
+	router.POST("/users/:id/pause", handlers.PauseUserHandler)
+	router.POST("/users/:id/resume", handlers.ResumeUserHandler)
+	// Ключи API пользователя
+	router.GET("/users/:id/api-keys", handlers.RequireKeyIssuer, handlers.GetAPIKeysHandler)
+	router.POST("/users/:id/api-keys", handlers.RequireKeyIssuer, handlers.CreateAPIKeyHandler)
+	router.DELETE("/users/:id/api-keys/:key_id", handlers.RequireKeyIssuer, handlers.DeleteAPIKeyHandler)
+	// Привязка чата Telegram по ссылке t.me/<bot>?start=<token> и отвязка
+	router.POST("/users/:id/telegram-link", handlers.CreateTelegramLinkHandler)
+	router.DELETE("/users/:id/telegram-link/:chat_id", handlers.DeleteTelegramLinkHandler)
+
+	// Экспорт и импорт напоминаний пользователя в формате iCalendar
+	router.GET("/users/:id/reminders.ics", handlers.ExportCalendarHandler)
+	router.POST("/users/:id/reminders.ics", handlers.ImportCalendarHandler)
+	// Секретная ссылка на календарь для подписки
+	router.POST("/users/:id/calendar-feed", handlers.CreateCalendarFeedHandler)
+	router.DELETE("/users/:id/calendar-feed", handlers.DeleteCalendarFeedHandler)
+	router.GET("/calendar/:token", handlers.CalendarFeedHandler)
 
 	return router
 }
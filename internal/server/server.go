@@ -1,9 +1,9 @@
 package server
 
 import (
+	"Reminders/internal/blobstore"
 	"Reminders/internal/database"
 	"Reminders/internal/envs"
-	"Reminders/internal/models"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"log"
@@ -37,7 +37,19 @@ func InitDatabase() error {
 		logger.Fatal("Ошибка подключения к базе данных", zap.Error(err))
 	}
 	logger.Info("Успешное подключение к базе данных")
-	database.DB.AutoMigrate(&models.Reminder{})
+	if err := database.Migrate(); err != nil {
+		logger.Fatal("Ошибка миграции базы данных", zap.Error(err))
+	}
+	return nil
+}
+
+// InitBlobStore инициализирует хранилище загруженных вложений.
+func InitBlobStore() error {
+	dir := envs.ServerEnvs.BLOB_DIR
+	if err := blobstore.InitBlobStore(dir); err != nil {
+		logger.Fatal("Ошибка инициализации хранилища вложений", zap.String("dir", dir), zap.Error(err))
+	}
+	logger.Info("Хранилище вложений готово", zap.String("dir", dir))
 	return nil
 }
 
@@ -52,6 +64,10 @@ func InitServer() {
 	if err := InitDatabase(); err != nil {
 		logger.Fatal("Ошибка при инициализации базы данных", zap.Error(err))
 	}
+
+	if err := InitBlobStore(); err != nil {
+		logger.Fatal("Ошибка при инициализации хранилища вложений", zap.Error(err))
+	}
 }
 
 // StartServer запускает сервер.
@@ -0,0 +1,250 @@
+// Package templating проверяет и выполняет шаблоны текста напоминаний в синтаксисе
+// text/template. Шаблонам доступен только фиксированный набор безопасных функций.
+package templating
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+const (
+	// Максимальная длина результата, совпадает с лимитом сообщения Telegram
+	maxOutputSize = 4096
+	// Формат времени по умолчанию
+	defaultLayout = "02.01.2006 15:04"
+	// Формат даты для countdown и daysUntil
+	dateLayout = "2006-01-02"
+)
+
+// Встроенные функции text/template, которые разрешены в шаблонах. Остальные (call и
+// подобные) запрещены, как и пользовательские функции вне funcs.
+var allowedBuiltins = map[string]bool{
+	"and": true, "or": true, "not": true, "len": true, "index": true,
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+	"print": true, "printf": true, "println": true,
+}
+
+var errOutputTooLong = fmt.Errorf("rendered message exceeds %d bytes", maxOutputSize)
+
+// Data - значения, подставляемые в шаблон при отправке.
+type Data struct {
+	// Name - имя получателя
+	Name string
+	// SendAt - запланированное время отправки
+	SendAt time.Time
+	// Location - часовой пояс получателя
+	Location *time.Location
+	// Occurrence - номер повторения, начиная с 1
+	Occurrence int
+	// Escape экранирует подставляемые значения под режим разметки сообщения
+	Escape func(string) string
+}
+
+// Validate разбирает шаблон, проверяет, что он использует только разрешённые
+// конструкции, и выполняет его на пробных данных. Результат возвращается, чтобы
+// вызывающий код мог проверить разметку.
+func Validate(text string, escape func(string) string) (string, error) {
+	sample := Data{
+		Name:       "Alice",
+		SendAt:     time.Now(),
+		Location:   time.UTC,
+		Occurrence: 1,
+		Escape:     escape,
+	}
+	return Render(text, sample)
+}
+
+// Render выполняет шаблон с данными получателя.
+func Render(text string, data Data) (string, error) {
+	if data.Location == nil {
+		data.Location = time.UTC
+	}
+	if data.Escape == nil {
+		data.Escape = func(s string) string { return s }
+	}
+
+	tmpl, err := template.New("message").Option("missingkey=error").Funcs(funcs(data)).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	if err := checkTree(tmpl.Tree.Root); err != nil {
+		return "", err
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Name() != tmpl.Name() {
+			return "", errors.New("nested template definitions are not allowed")
+		}
+	}
+
+	out := &limitedBuffer{limit: maxOutputSize}
+	if err := tmpl.Execute(out, values(data)); err != nil {
+		if errors.Is(err, errOutputTooLong) {
+			return "", errOutputTooLong
+		}
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// values возвращает данные шаблона в виде карты простых значений, чтобы из шаблона
+// нельзя было вызывать методы Go-объектов.
+func values(data Data) map[string]interface{} {
+	return map[string]interface{}{
+		"Name":       data.Escape(data.Name),
+		"SendAt":     data.Escape(data.SendAt.In(data.Location).Format(defaultLayout)),
+		"Occurrence": data.Occurrence,
+	}
+}
+
+// funcs возвращает набор функций шаблона, привязанный к данным получателя.
+func funcs(data Data) template.FuncMap {
+	sendAt := data.SendAt.In(data.Location)
+	return template.FuncMap{
+		// name - имя получателя
+		"name": func() string {
+			return data.Escape(data.Name)
+		},
+		// sendAt - время отправки в часовом поясе получателя, формат Go необязателен
+		"sendAt": func(layout ...string) (string, error) {
+			if len(layout) > 1 {
+				return "", errors.New("sendAt takes at most one layout")
+			}
+			if len(layout) == 0 {
+				return data.Escape(sendAt.Format(defaultLayout)), nil
+			}
+			return data.Escape(sendAt.Format(layout[0])), nil
+		},
+		// occurrence - номер повторения
+		"occurrence": func() int {
+			return data.Occurrence
+		},
+		// daysUntil - число календарных дней от отправки до даты ГГГГ-ММ-ДД
+		"daysUntil": func(date string) (int, error) {
+			target, err := time.ParseInLocation(dateLayout, date, data.Location)
+			if err != nil {
+				return 0, fmt.Errorf("daysUntil: expected date as %s", dateLayout)
+			}
+			from := time.Date(sendAt.Year(), sendAt.Month(), sendAt.Day(), 0, 0, 0, 0, data.Location)
+			// Сутки при переводе часов длятся 23 или 25 часов, поэтому дни округляются
+			return int(math.Round(target.Sub(from).Hours() / 24)), nil
+		},
+		// countdown - оставшееся до даты ГГГГ-ММ-ДД или ГГГГ-ММ-ДД ЧЧ:ММ время вида "3d 4h 15m"
+		"countdown": func(date string) (string, error) {
+			target, err := time.ParseInLocation(dateLayout+" 15:04", date, data.Location)
+			if err != nil {
+				if target, err = time.ParseInLocation(dateLayout, date, data.Location); err != nil {
+					return "", fmt.Errorf("countdown: expected date as %s or %s 15:04", dateLayout, dateLayout)
+				}
+			}
+			return data.Escape(formatCountdown(target.Sub(sendAt))), nil
+		},
+	}
+}
+
+func formatCountdown(d time.Duration) string {
+	if d <= 0 {
+		return "0m"
+	}
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return strings.Join(parts, " ")
+}
+
+// checkTree обходит дерево шаблона и запрещает циклы, вложенные шаблоны и функции
+// вне разрешённого набора.
+func checkTree(node parse.Node) error {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkTree(child); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return checkTree(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			if err := checkTree(cmd); err != nil {
+				return err
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if err := checkTree(arg); err != nil {
+				return err
+			}
+		}
+	case *parse.IdentifierNode:
+		if !allowedBuiltins[n.Ident] && !isTemplateFunc(n.Ident) {
+			return fmt.Errorf("function %q is not allowed", n.Ident)
+		}
+	case *parse.IfNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		return errors.New("range is not allowed")
+	case *parse.TemplateNode:
+		return errors.New("template calls are not allowed")
+	case *parse.ChainNode:
+		return errors.New("method chains are not allowed")
+	}
+	return nil
+}
+
+func checkBranch(n *parse.BranchNode) error {
+	if err := checkTree(n.Pipe); err != nil {
+		return err
+	}
+	if err := checkTree(n.List); err != nil {
+		return err
+	}
+	return checkTree(n.ElseList)
+}
+
+func isTemplateFunc(name string) bool {
+	_, ok := funcs(Data{Location: time.UTC})[name]
+	return ok
+}
+
+// limitedBuffer - буфер, отказывающийся принимать больше limit байт.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errOutputTooLong
+	}
+	return b.Buffer.Write(p)
+}
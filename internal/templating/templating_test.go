@@ -0,0 +1,165 @@
+package templating
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := Data{
+		Name:       "Anna",
+		SendAt:     time.Date(2030, 3, 30, 11, 0, 0, 0, time.UTC),
+		Location:   berlin,
+		Occurrence: 3,
+	}
+	cases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "Позвонить маме", "Позвонить маме"},
+		{"name", "Привет, {{name}}! {{.Name}}", "Привет, Anna! Anna"},
+		{"send_at_default_layout", "{{sendAt}} / {{.SendAt}}", "30.03.2030 12:00 / 30.03.2030 12:00"},
+		{"send_at_layout", `{{sendAt "Mon 15:04 MST"}}`, "Sat 12:00 CET"},
+		{"occurrence", "#{{occurrence}} {{if gt .Occurrence 2}}again{{else}}first{{end}}", "#3 again"},
+		{"builtins", `{{printf "%03d" occurrence}} {{len "abc"}} {{and true "x"}}`, "003 3 x"},
+		{"with", `{{with name}}[{{.}}]{{end}}`, "[Anna]"},
+		// В ночь на 31 марта 2030 часы переводятся вперёд: до полудня следующего дня 23 часа
+		{"countdown_spring_forward", `{{countdown "2030-03-31 12:00"}}`, "23h"},
+		{"days_until_spring_forward", `{{daysUntil "2030-03-31"}}`, "1"},
+		{"days_until_over_both_changes", `{{daysUntil "2030-12-25"}}`, "270"},
+		{"countdown_over_spring_forward", `{{countdown "2030-04-02"}}`, "2d 11h"},
+		{"countdown_past", `{{countdown "2030-03-01"}}`, "0m"},
+		{"days_until_past", `{{daysUntil "2030-03-28"}}`, "-2"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Render(c.text, data)
+			if err != nil {
+				t.Fatalf("Render(%q): %v", c.text, err)
+			}
+			if got != c.want {
+				t.Errorf("Render(%q) = %q, want %q", c.text, got, c.want)
+			}
+		})
+	}
+}
+
+// В ночь на 27 октября 2030 часы переводятся назад: в сутках 25 часов.
+func TestRenderFallBack(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := Data{SendAt: time.Date(2030, 10, 26, 12, 0, 0, 0, berlin), Location: berlin}
+	got, err := Render(`{{countdown "2030-10-27 12:00"}} {{daysUntil "2030-10-27"}} {{daysUntil "2030-10-28"}}`, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1d 1h 1 2"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderEscapesValues(t *testing.T) {
+	data := Data{
+		Name:   "a_b*c",
+		SendAt: time.Date(2030, 1, 2, 3, 4, 0, 0, time.UTC),
+		Escape: func(s string) string { return strings.NewReplacer("_", `\_`, "*", `\*`, ".", `\.`).Replace(s) },
+	}
+	got, err := Render(`{{name}} {{.Name}} {{sendAt}} {{countdown "2030-01-03"}} *bold*`, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `a\_b\*c a\_b\*c 02\.01\.2030 03:04 20h 56m *bold*`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// Шаблон не может выйти за пределы песочницы: вызывать функции и методы Go,
+// определять и вызывать другие шаблоны и перебирать значения.
+func TestRenderRejects(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		// err - подстрока ошибки
+		err string
+	}{
+		{"call", `{{call .Escape "x"}}`, `function "call" is not allowed`},
+		{"call_in_if", `{{if true}}{{call name}}{{end}}`, `function "call" is not allowed`},
+		{"call_in_else", `{{if false}}x{{else}}{{call name}}{{end}}`, `function "call" is not allowed`},
+		{"call_in_with_pipe", `{{with call name}}x{{end}}`, `function "call" is not allowed`},
+		{"template", `{{template "message"}}`, "template calls are not allowed"},
+		{"define", `{{define "x"}}secret{{end}}hello`, "nested template definitions are not allowed"},
+		{"block", `{{block "x" .}}body{{end}}`, "template calls are not allowed"},
+		{"range", `{{range $i, $c := "abc"}}{{$c}}{{end}}`, "range is not allowed"},
+		{"method_chain", `{{(sendAt).Len}}`, "method chains are not allowed"},
+		{"unknown_func", `{{exec "rm -rf /"}}`, `function "exec" not defined`},
+		{"unknown_func_in_pipe", `{{name | upper}}`, `function "upper" not defined`},
+		{"missing_key", `{{.Password}}`, `map has no entry for key "Password"`},
+		{"method_on_value", `{{.Name.String}}`, "can't evaluate field String"},
+		{"bad_date", `{{daysUntil "tomorrow"}}`, "daysUntil: expected date as 2006-01-02"},
+		{"bad_countdown", `{{countdown "31.12.2030"}}`, "countdown: expected date"},
+		{"send_at_two_layouts", `{{sendAt "15:04" "02.01"}}`, "sendAt takes at most one layout"},
+		{"syntax", `{{name`, "unclosed action"},
+	}
+	data := Data{Name: "Anna", SendAt: time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Render(c.text, data)
+			if err == nil {
+				t.Fatalf("Render(%q) = %q, want an error", c.text, got)
+			}
+			if !strings.Contains(err.Error(), c.err) {
+				t.Errorf("Render(%q): %v, want %q", c.text, err, c.err)
+			}
+		})
+	}
+}
+
+// Результат длиннее лимита сообщения Telegram отклоняется, даже если его собирают
+// из коротких частей.
+func TestRenderOutputLimit(t *testing.T) {
+	data := Data{Name: strings.Repeat("я", 1000), SendAt: time.Now()}
+	cases := []struct {
+		name    string
+		text    string
+		wantLen int
+	}{
+		{"exactly_at_limit", strings.Repeat("x", maxOutputSize), maxOutputSize},
+		{"text_over_limit", strings.Repeat("x", maxOutputSize+1), -1},
+		{"substitution_at_limit", strings.Repeat("x", maxOutputSize-2000) + "{{name}}", maxOutputSize},
+		{"substitution_over_limit", strings.Repeat("x", maxOutputSize-1999) + "{{name}}", -1},
+		{"printf_padding", `{{printf "%5000d" 1}}`, -1},
+		{"repeated_substitutions", strings.Repeat("{{name}}", 3), -1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Render(c.text, data)
+			if c.wantLen < 0 {
+				if !errors.Is(err, errOutputTooLong) {
+					t.Errorf("error %v, want %v", err, errOutputTooLong)
+				}
+				return
+			}
+			if err != nil || len(got) != c.wantLen {
+				t.Errorf("Render = %d bytes, %v; want %d bytes", len(got), err, c.wantLen)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if _, err := Validate("Привет, {{name}}! До отпуска {{daysUntil \"2031-07-01\"}} дней", nil); err != nil {
+		t.Errorf("valid template rejected: %v", err)
+	}
+	if _, err := Validate("{{call name}}", nil); err == nil {
+		t.Error("template with call accepted")
+	}
+}
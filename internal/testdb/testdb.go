@@ -0,0 +1,110 @@
+// Package testdb готовит базу для тестов: Postgres из TEST_DATABASE_URL, если переменная задана,
+// иначе SQLite во временном файле. Каждый тест получает пустую базу с таблицами всех моделей,
+// которая на время теста становится database.DB.
+package testdb
+
+import (
+	"Reminders/internal/database"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Счётчик для имён схем Postgres, уникальных в пределах процесса
+var schemas atomic.Int64
+
+// Open создаёт пустую базу, ставит её в database.DB и возвращает. После теста
+// database.DB возвращается к прежнему значению, а база удаляется.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	var db *gorm.DB
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		db = openPostgres(t, dsn)
+	} else {
+		db = openSQLite(t)
+	}
+
+	prev := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = prev })
+	if err := database.Migrate(); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
+
+// IsPostgres сообщает, идут ли тесты на Postgres. Блокировки строк и параллельные
+// транзакции можно проверить только там.
+func IsPostgres() bool {
+	return os.Getenv("TEST_DATABASE_URL") != ""
+}
+
+func openSQLite(t testing.TB) *gorm.DB {
+	path := filepath.Join(t.TempDir(), "test.db")
+	dsn := path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), config())
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	closeOnCleanup(t, db)
+	return db
+}
+
+// openPostgres создаёт для теста отдельную схему и подключается к ней через search_path.
+func openPostgres(t testing.TB, dsn string) *gorm.DB {
+	admin, err := gorm.Open(postgres.Open(dsn), config())
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	schema := fmt.Sprintf("test_%d_%d_%d", os.Getpid(), time.Now().UnixNano(), schemas.Add(1))
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	db, err := gorm.Open(postgres.Open(withSearchPath(dsn, schema)), config())
+	if err != nil {
+		t.Fatalf("open postgres schema: %v", err)
+	}
+	closeOnCleanup(t, db)
+	return db
+}
+
+// withSearchPath добавляет search_path к строке подключения в виде URL или key=value.
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		return dsn + sep + "search_path=" + schema
+	}
+	return dsn + " search_path=" + schema
+}
+
+func config() *gorm.Config {
+	return &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+}
+
+func closeOnCleanup(t testing.TB, db *gorm.DB) {
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
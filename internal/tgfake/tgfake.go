@@ -0,0 +1,443 @@
+// Package tgfake - поддельный Telegram Bot API для локального запуска бота и интеграционных
+// проверок. Сервер записывает отправленные ботом сообщения, отдаёт подготовленные обновления
+// через getUpdates и умеет отвечать ошибками Telegram, например 429 и 403.
+package tgfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Максимальный размер загружаемого файла
+	maxUpload = 50 << 20
+	// Максимальное время ожидания getUpdates, чтобы проверки не зависали надолго
+	maxPollWait = 10 * time.Second
+)
+
+// BotUser - пользователь бота, которого возвращает getMe.
+var BotUser = tgbotapi.User{ID: 1, IsBot: true, FirstName: "Reminders", UserName: "reminders_bot"}
+
+// Message - сообщение, отправленное ботом.
+type Message struct {
+	MessageID   int       `json:"message_id"`
+	Method      string    `json:"method"`
+	ChatID      int64     `json:"chat_id"`
+	Text        string    `json:"text,omitempty"`
+	Caption     string    `json:"caption,omitempty"`
+	ParseMode   string    `json:"parse_mode,omitempty"`
+	File        string    `json:"file,omitempty"`
+	ReplyMarkup string    `json:"reply_markup,omitempty"`
+	SentAt      time.Time `json:"sent_at"`
+}
+
+// CallbackAnswer - ответ бота на нажатие кнопки.
+type CallbackAnswer struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+}
+
+// Fault - ошибка, которой сервер ответит на подходящий запрос вместо выполнения метода.
+type Fault struct {
+	// Метод, пусто - любой
+	Method string `json:"method,omitempty"`
+	// Чат, 0 - любой
+	ChatID      int64  `json:"chat_id,omitempty"`
+	Code        int    `json:"error_code"`
+	Description string `json:"description"`
+	RetryAfter  int    `json:"retry_after,omitempty"`
+	// Сколько раз сработает ошибка, 0 - пока её не сбросят
+	Times int `json:"times,omitempty"`
+}
+
+// TooManyRequests - ограничение частоты запросов с ожиданием retryAfter секунд.
+func TooManyRequests(retryAfter int) Fault {
+	return Fault{
+		Code:        http.StatusTooManyRequests,
+		Description: fmt.Sprintf("Too Many Requests: retry after %d", retryAfter),
+		RetryAfter:  retryAfter,
+	}
+}
+
+// BotBlocked - пользователь заблокировал бота.
+func BotBlocked(chatID int64) Fault {
+	return Fault{ChatID: chatID, Code: http.StatusForbidden, Description: "Forbidden: bot was blocked by the user"}
+}
+
+// UserDeactivated - аккаунт пользователя удалён.
+func UserDeactivated(chatID int64) Fault {
+	return Fault{ChatID: chatID, Code: http.StatusForbidden, Description: "Forbidden: user is deactivated"}
+}
+
+// ChatNotFound - чат не существует или бот из него удалён.
+func ChatNotFound(chatID int64) Fault {
+	return Fault{ChatID: chatID, Code: http.StatusBadRequest, Description: "Bad Request: chat not found"}
+}
+
+// Server - поддельный Bot API. Принимает запросы вида /bot<token>/<method> с любым токеном
+// и служебные запросы /_fake/... для управления из проверок и вручную.
+type Server struct {
+	mu          sync.Mutex
+	messages    []Message
+	callbacks   []CallbackAnswer
+	updates     []tgbotapi.Update
+	nextUpdate  int
+	nextMessage int
+	faults      []Fault
+	webhook     tgbotapi.WebhookInfo
+	secretToken string
+	// Закрывается при добавлении обновления, чтобы разбудить ждущий getUpdates
+	notify chan struct{}
+}
+
+// New возвращает пустой сервер.
+func New() *Server {
+	return &Server{nextUpdate: 1, nextMessage: 1, notify: make(chan struct{})}
+}
+
+// Endpoint возвращает шаблон адреса API для tgbotapi.NewBotAPIWithAPIEndpoint.
+func Endpoint(baseURL string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/bot%s/%s"
+}
+
+// Messages возвращает сообщения, отправленные ботом.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Callbacks возвращает ответы бота на нажатия кнопок.
+func (s *Server) Callbacks() []CallbackAnswer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]CallbackAnswer(nil), s.callbacks...)
+}
+
+// Webhook возвращает установленный вебхук и его secret_token.
+func (s *Server) Webhook() (tgbotapi.WebhookInfo, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.webhook, s.secretToken
+}
+
+// AddUpdate ставит обновление в очередь getUpdates и возвращает его номер.
+func (s *Server) AddUpdate(update tgbotapi.Update) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update.UpdateID = s.nextUpdate
+	s.nextUpdate++
+	s.updates = append(s.updates, update)
+	close(s.notify)
+	s.notify = make(chan struct{})
+	return update.UpdateID
+}
+
+// SendText ставит в очередь текстовое сообщение пользователя боту, например команду.
+func (s *Server) SendText(chatID int64, text string) int {
+	msg := &tgbotapi.Message{
+		MessageID: int(time.Now().UnixNano() % 1000000),
+		From:      &tgbotapi.User{ID: chatID, FirstName: "User"},
+		Chat:      &tgbotapi.Chat{ID: chatID, Type: "private"},
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		length := len(text)
+		if i := strings.IndexByte(text, ' '); i > 0 {
+			length = i
+		}
+		msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: length}}
+	}
+	return s.AddUpdate(tgbotapi.Update{Message: msg})
+}
+
+// PressButton ставит в очередь нажатие кнопки с данными data под сообщением бота.
+func (s *Server) PressButton(chatID int64, messageID int, data string) int {
+	return s.AddUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      strconv.Itoa(s.nextUpdateID()),
+		From:    &tgbotapi.User{ID: chatID, FirstName: "User"},
+		Message: &tgbotapi.Message{MessageID: messageID, Chat: &tgbotapi.Chat{ID: chatID}},
+		Data:    data,
+	}})
+}
+
+// Fail добавляет ошибку, которой сервер ответит на подходящие запросы.
+func (s *Server) Fail(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, f)
+}
+
+// Reset удаляет записанные сообщения, обновления, ошибки и вебхук.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages, s.callbacks, s.updates, s.faults = nil, nil, nil, nil
+	s.webhook, s.secretToken = tgbotapi.WebhookInfo{}, ""
+}
+
+// ServeHTTP обрабатывает запросы к Bot API и служебные запросы.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/_fake/") {
+		s.serveControl(w, r)
+		return
+	}
+
+	// /bot<token>/<method>
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "bot") || len(parts[0]) == len("bot") {
+		writeError(w, Fault{Code: http.StatusNotFound, Description: "Not Found"})
+		return
+	}
+	method := parts[1]
+
+	if err := r.ParseMultipartForm(maxUpload); err != nil && err != http.ErrNotMultipart {
+		writeError(w, Fault{Code: http.StatusBadRequest, Description: "Bad Request: " + err.Error()})
+		return
+	}
+	if method == "getUpdates" {
+		s.getUpdates(w, r)
+		return
+	}
+
+	chatID, err := chatParam(r)
+	if err != nil {
+		writeError(w, Fault{Code: http.StatusBadRequest, Description: "Bad Request: chat not found"})
+		return
+	}
+	if f, ok := s.fault(method, chatID); ok {
+		writeError(w, f)
+		return
+	}
+
+	switch method {
+	case "getMe":
+		writeResult(w, BotUser)
+	case "sendMessage":
+		writeResult(w, s.record(method, chatID, r, ""))
+	case "sendPhoto", "sendDocument", "sendVoice":
+		field := strings.ToLower(strings.TrimPrefix(method, "send"))
+		writeResult(w, s.record(method, chatID, r, fileParam(r, field)))
+	case "answerCallbackQuery":
+		s.mu.Lock()
+		s.callbacks = append(s.callbacks, CallbackAnswer{
+			CallbackQueryID: r.FormValue("callback_query_id"),
+			Text:            r.FormValue("text"),
+		})
+		s.mu.Unlock()
+		writeResult(w, true)
+	case "setWebhook":
+		s.mu.Lock()
+		s.webhook = tgbotapi.WebhookInfo{URL: r.FormValue("url")}
+		s.secretToken = r.FormValue("secret_token")
+		if allowed := r.FormValue("allowed_updates"); allowed != "" {
+			json.Unmarshal([]byte(allowed), &s.webhook.AllowedUpdates)
+		}
+		s.mu.Unlock()
+		writeResult(w, true)
+	case "deleteWebhook":
+		s.mu.Lock()
+		s.webhook, s.secretToken = tgbotapi.WebhookInfo{}, ""
+		s.mu.Unlock()
+		writeResult(w, true)
+	case "getWebhookInfo":
+		s.mu.Lock()
+		info := s.webhook
+		info.PendingUpdateCount = len(s.updates)
+		s.mu.Unlock()
+		writeResult(w, info)
+	default:
+		writeError(w, Fault{Code: http.StatusNotFound, Description: "Not Found: method not found"})
+	}
+}
+
+// getUpdates отдаёт обновления начиная с offset. Если их нет, ждёт до timeout секунд.
+// Как и в Telegram, запрос с offset подтверждает получение предыдущих обновлений.
+func (s *Server) getUpdates(w http.ResponseWriter, r *http.Request) {
+	if f, ok := s.fault("getUpdates", 0); ok {
+		writeError(w, f)
+		return
+	}
+	offset, _ := strconv.Atoi(r.FormValue("offset"))
+	timeout, _ := strconv.Atoi(r.FormValue("timeout"))
+	wait := time.Duration(timeout) * time.Second
+	if wait > maxPollWait {
+		wait = maxPollWait
+	}
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+
+	for {
+		s.mu.Lock()
+		if offset > 0 {
+			kept := s.updates[:0]
+			for _, u := range s.updates {
+				if u.UpdateID >= offset {
+					kept = append(kept, u)
+				}
+			}
+			s.updates = kept
+		}
+		updates := append([]tgbotapi.Update{}, s.updates...)
+		notify := s.notify
+		s.mu.Unlock()
+
+		if len(updates) > 0 {
+			writeResult(w, updates)
+			return
+		}
+		select {
+		case <-notify:
+		case <-deadline.C:
+			writeResult(w, updates)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// record записывает отправленное сообщение и возвращает его в виде ответа Telegram.
+func (s *Server) record(method string, chatID int64, r *http.Request, file string) tgbotapi.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg := Message{
+		MessageID:   s.nextMessage,
+		Method:      method,
+		ChatID:      chatID,
+		Text:        r.FormValue("text"),
+		Caption:     r.FormValue("caption"),
+		ParseMode:   r.FormValue("parse_mode"),
+		File:        file,
+		ReplyMarkup: r.FormValue("reply_markup"),
+		SentAt:      time.Now(),
+	}
+	s.nextMessage++
+	s.messages = append(s.messages, msg)
+
+	result := tgbotapi.Message{
+		MessageID: msg.MessageID,
+		From:      &BotUser,
+		Chat:      &tgbotapi.Chat{ID: chatID},
+		Date:      int(msg.SentAt.Unix()),
+		Text:      msg.Text,
+		Caption:   msg.Caption,
+	}
+	if method == "sendPhoto" {
+		result.Photo = []tgbotapi.PhotoSize{{FileID: "photo-" + strconv.Itoa(msg.MessageID)}}
+	}
+	return result
+}
+
+// fault возвращает ошибку для запроса, если она задана, и уменьшает её счётчик.
+func (s *Server) fault(method string, chatID int64) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.faults {
+		if (f.Method != "" && f.Method != method) || (f.ChatID != 0 && f.ChatID != chatID) {
+			continue
+		}
+		if f.Times > 0 {
+			s.faults[i].Times--
+			if s.faults[i].Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f, true
+	}
+	return Fault{}, false
+}
+
+// nextUpdateID возвращает номер, который получит следующее обновление.
+func (s *Server) nextUpdateID() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextUpdate
+}
+
+// serveControl обрабатывает служебные запросы:
+// GET /_fake/messages, POST /_fake/updates, POST /_fake/faults, POST /_fake/reset.
+func (s *Server) serveControl(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/_fake/messages":
+		writeJSON(w, http.StatusOK, s.Messages())
+	case r.Method == http.MethodGet && r.URL.Path == "/_fake/callbacks":
+		writeJSON(w, http.StatusOK, s.Callbacks())
+	case r.Method == http.MethodPost && r.URL.Path == "/_fake/updates":
+		var update tgbotapi.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"update_id": s.AddUpdate(update)})
+	case r.Method == http.MethodPost && r.URL.Path == "/_fake/faults":
+		var f Fault
+		if err := json.NewDecoder(r.Body).Decode(&f); err != nil || f.Code == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "error_code is required"})
+			return
+		}
+		s.Fail(f)
+		writeJSON(w, http.StatusOK, f)
+	case r.Method == http.MethodPost && r.URL.Path == "/_fake/reset":
+		s.Reset()
+		writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+}
+
+// chatParam возвращает chat_id запроса, 0 - если его нет.
+func chatParam(r *http.Request) (int64, error) {
+	value := r.FormValue("chat_id")
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// fileParam возвращает file_id или имя загруженного файла из поля field.
+func fileParam(r *http.Request, field string) string {
+	if value := r.FormValue(field); value != "" {
+		return value
+	}
+	if r.MultipartForm != nil {
+		if files := r.MultipartForm.File[field]; len(files) > 0 {
+			return "upload:" + files[0].Filename
+		}
+	}
+	return ""
+}
+
+// writeResult отвечает успешным результатом в формате Bot API.
+func writeResult(w http.ResponseWriter, result interface{}) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		writeError(w, Fault{Code: http.StatusInternalServerError, Description: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, tgbotapi.APIResponse{Ok: true, Result: data})
+}
+
+// writeError отвечает ошибкой в формате Bot API.
+func writeError(w http.ResponseWriter, f Fault) {
+	resp := tgbotapi.APIResponse{Ok: false, ErrorCode: f.Code, Description: f.Description}
+	if f.RetryAfter > 0 {
+		resp.Parameters = &tgbotapi.ResponseParameters{RetryAfter: f.RetryAfter}
+	}
+	writeJSON(w, f.Code, resp)
+}
+
+// writeJSON отвечает JSON с кодом code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,209 @@
+// Package tgformat проверяет и экранирует разметку сообщений Telegram
+// в режимах MarkdownV2 и HTML.
+package tgformat
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Режимы разметки Telegram
+const (
+	ModeMarkdownV2 = "MarkdownV2"
+	ModeHTML       = "HTML"
+)
+
+// Теги, которые Telegram принимает в режиме HTML
+var htmlTags = map[string]bool{
+	"b": true, "strong": true, "i": true, "em": true, "u": true, "ins": true,
+	"s": true, "strike": true, "del": true, "span": true, "tg-spoiler": true,
+	"a": true, "tg-emoji": true, "code": true, "pre": true, "blockquote": true,
+}
+
+// Символы, которые в MarkdownV2 нужно экранировать вне разметки
+const markdownReserved = "_*[]()~`>#+-=|{}.!\\"
+
+var (
+	htmlEscaper     = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	markdownEscaper = buildMarkdownEscaper()
+)
+
+func buildMarkdownEscaper() *strings.Replacer {
+	pairs := make([]string, 0, len(markdownReserved)*2)
+	for _, c := range markdownReserved {
+		pairs = append(pairs, string(c), "\\"+string(c))
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// ValidMode сообщает, поддерживается ли режим разметки. Пустой режим означает обычный текст.
+func ValidMode(mode string) bool {
+	return mode == "" || mode == ModeMarkdownV2 || mode == ModeHTML
+}
+
+// Escape экранирует текст так, чтобы он отображался буквально в указанном режиме.
+func Escape(mode, text string) string {
+	switch mode {
+	case ModeMarkdownV2:
+		return markdownEscaper.Replace(text)
+	case ModeHTML:
+		return htmlEscaper.Replace(text)
+	}
+	return text
+}
+
+// Validate проверяет, что Telegram сможет разобрать разметку текста.
+func Validate(mode, text string) error {
+	switch mode {
+	case "":
+		return nil
+	case ModeMarkdownV2:
+		return validateMarkdownV2(text)
+	case ModeHTML:
+		return validateHTML(text)
+	}
+	return fmt.Errorf("unsupported parse mode %q", mode)
+}
+
+// validateMarkdownV2 проверяет экранирование зарезервированных символов и парность
+// маркеров форматирования.
+func validateMarkdownV2(text string) error {
+	var open []string
+	inLinkText := false
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(text) {
+				return fmt.Errorf("dangling backslash at offset %d", i)
+			}
+			_, size := utf8.DecodeRuneInString(text[i+1:])
+			i += 1 + size
+			continue
+		case strings.HasPrefix(text[i:], "```"):
+			end := strings.Index(text[i+3:], "```")
+			if end < 0 {
+				return fmt.Errorf("unclosed code block at offset %d", i)
+			}
+			i += 3 + end + 3
+			continue
+		case c == '`':
+			end := strings.IndexByte(text[i+1:], '`')
+			if end < 0 {
+				return fmt.Errorf("unclosed inline code at offset %d", i)
+			}
+			i += 1 + end + 1
+			continue
+		case strings.HasPrefix(text[i:], "__"), strings.HasPrefix(text[i:], "||"):
+			open = toggle(open, text[i:i+2])
+			i += 2
+			continue
+		case c == '*' || c == '_' || c == '~':
+			open = toggle(open, string(c))
+		case c == '[':
+			if inLinkText {
+				return fmt.Errorf("nested link at offset %d", i)
+			}
+			inLinkText = true
+		case c == ']':
+			if !inLinkText {
+				return fmt.Errorf("character ']' at offset %d must be escaped", i)
+			}
+			inLinkText = false
+			if !strings.HasPrefix(text[i+1:], "(") {
+				return fmt.Errorf("link at offset %d has no URL", i)
+			}
+			end := strings.IndexByte(text[i+1:], ')')
+			if end < 0 {
+				return fmt.Errorf("unclosed link URL at offset %d", i)
+			}
+			i += 1 + end + 1
+			continue
+		case c == '>' && (i == 0 || text[i-1] == '\n'):
+			// Цитата в начале строки
+		case strings.IndexByte(markdownReserved, c) >= 0:
+			return fmt.Errorf("character '%c' at offset %d must be escaped", c, i)
+		}
+		i++
+	}
+
+	if inLinkText {
+		return fmt.Errorf("unclosed link text")
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("unclosed formatting %q", open[len(open)-1])
+	}
+	return nil
+}
+
+// toggle закрывает маркер, если он открыт последним, иначе открывает его.
+func toggle(open []string, marker string) []string {
+	if len(open) > 0 && open[len(open)-1] == marker {
+		return open[:len(open)-1]
+	}
+	return append(open, marker)
+}
+
+// validateHTML проверяет, что используются только поддерживаемые Telegram теги,
+// теги правильно вложены, а сущности допустимы.
+func validateHTML(text string) error {
+	var open []string
+
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '<':
+			end := strings.IndexByte(text[i:], '>')
+			if end < 0 {
+				return fmt.Errorf("unclosed tag at offset %d", i)
+			}
+			tag := text[i+1 : i+end]
+			closing := strings.HasPrefix(tag, "/")
+			fields := strings.Fields(strings.TrimPrefix(tag, "/"))
+			if len(fields) == 0 {
+				return fmt.Errorf("empty tag at offset %d", i)
+			}
+			name := strings.ToLower(fields[0])
+			if !htmlTags[name] {
+				return fmt.Errorf("unsupported tag <%s> at offset %d", name, i)
+			}
+			if closing {
+				if len(open) == 0 || open[len(open)-1] != name {
+					return fmt.Errorf("unexpected closing tag </%s> at offset %d", name, i)
+				}
+				open = open[:len(open)-1]
+			} else {
+				open = append(open, name)
+			}
+			i += end
+		case '>':
+			return fmt.Errorf("character '>' at offset %d must be escaped as &gt;", i)
+		case '&':
+			end := strings.IndexByte(text[i:], ';')
+			if end < 0 || !validEntity(text[i+1:i+end]) {
+				return fmt.Errorf("invalid entity at offset %d, use &amp; for '&'", i)
+			}
+			i += end
+		}
+	}
+
+	if len(open) > 0 {
+		return fmt.Errorf("unclosed tag <%s>", open[len(open)-1])
+	}
+	return nil
+}
+
+func validEntity(name string) bool {
+	switch name {
+	case "lt", "gt", "amp", "quot":
+		return true
+	}
+	if strings.HasPrefix(name, "#x") || strings.HasPrefix(name, "#X") {
+		return len(name) > 2 && strings.Trim(name[2:], "0123456789abcdefABCDEF") == ""
+	}
+	if strings.HasPrefix(name, "#") {
+		return len(name) > 1 && strings.Trim(name[1:], "0123456789") == ""
+	}
+	return false
+}
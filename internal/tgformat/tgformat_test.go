@@ -0,0 +1,156 @@
+package tgformat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateMarkdownV2(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		// err - подстрока ошибки или пустая строка для правильной разметки
+		err string
+	}{
+		// Смещения в ошибках считаются в байтах
+		{"plain", "Позвонить маме", ""},
+		{"formatting", "*bold* _italic_ __underline__ ~strike~ ||spoiler||", ""},
+		{"nested", "*bold _italic bold_ bold*", ""},
+		{"escaped_reserved", `Цена: 10\.5\! \(скидка\) \#1 a\-b \+ c\=d \{x\} a\|b \>`, ""},
+		{"escaped_unicode", `\я`, ""},
+		{"inline_code", "`a.b(c)*_`", ""},
+		{"code_block", "```go\nfmt.Println(\"*\")\n```", ""},
+		{"link", "[сайт](https://example.com/a_b)", ""},
+		{"quote", ">цитата\n>вторая строка", ""},
+
+		{"unescaped_dot", "Встреча в 10.00", "character '.' at offset 20 must be escaped"},
+		{"unescaped_exclamation", "Привет!", "character '!' at offset 12 must be escaped"},
+		{"unescaped_minus", "a-b", "character '-' at offset 1 must be escaped"},
+		{"unescaped_paren", "(скоро)", "character '(' at offset 0 must be escaped"},
+		{"unescaped_bracket", "a]b", "character ']' at offset 1 must be escaped"},
+		{"quote_inside_line", "a > b", "character '>' at offset 2 must be escaped"},
+		{"dangling_backslash", `a\`, "dangling backslash at offset 1"},
+		{"unbalanced_bold", "*bold", `unclosed formatting "*"`},
+		{"unbalanced_italic", "_italic", `unclosed formatting "_"`},
+		{"unbalanced_underline", "__underline", `unclosed formatting "__"`},
+		{"unbalanced_spoiler", "||spoiler", `unclosed formatting "||"`},
+		{"overlapping", "*bold _italic* still_", "unclosed formatting"},
+		{"unclosed_inline_code", "`code", "unclosed inline code at offset 0"},
+		{"unclosed_code_block", "```\ncode`", "unclosed code block at offset 0"},
+		{"link_without_url", "[text] more", "link at offset 5 has no URL"},
+		{"unclosed_link_url", "[text](https://example.com", "unclosed link URL"},
+		{"unclosed_link_text", "[text", "unclosed link text"},
+		{"nested_link", "[a [b](x)](y)", "nested link at offset 3"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			checkError(t, Validate(ModeMarkdownV2, c.text), c.err)
+		})
+	}
+}
+
+func TestValidateHTML(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		err  string
+	}{
+		{"plain", "Позвонить маме", ""},
+		{"tags", "<b>bold</b> <i>it</i> <u>u</u> <s>s</s> <tg-spoiler>x</tg-spoiler> <code>c</code>", ""},
+		{"nested", "<b>bold <i>both</i></b>", ""},
+		{"attributes", `<a href="https://example.com/?a=1">link</a> <span class="tg-spoiler">x</span>`, ""},
+		{"upper_case", "<B>bold</B>", ""},
+		{"pre_code", `<pre><code class="language-go">x := 1</code></pre>`, ""},
+		{"named_entities", "a &lt; b &gt; c &amp; d &quot;e&quot;", ""},
+		{"numeric_entities", "&#60; &#x3C; &#X3c;", ""},
+
+		{"unknown_tag", "<script>alert(1)</script>", "unsupported tag <script> at offset 0"},
+		{"unknown_closing_tag", "x</div>", "unsupported tag <div> at offset 1"},
+		{"br_tag", "line<br>line", "unsupported tag <br>"},
+		{"crossed_tags", "<b><i>x</b></i>", "unexpected closing tag </b> at offset 7"},
+		{"closing_without_opening", "x</b>", "unexpected closing tag </b>"},
+		{"unclosed_tag", "<b>bold", "unclosed tag <b>"},
+		{"unterminated_tag", "a <b", "unclosed tag at offset 2"},
+		{"empty_tag", "a <> b", "empty tag at offset 2"},
+		{"raw_gt", "a > b", "character '>' at offset 2 must be escaped as &gt;"},
+		{"raw_ampersand", "Tom & Jerry", "invalid entity at offset 4"},
+		{"entity_without_semicolon", "&amp", "invalid entity at offset 0"},
+		{"unsupported_named_entity", "a&nbsp;b", "invalid entity at offset 1"},
+		{"empty_numeric_entity", "&#;", "invalid entity"},
+		{"bad_hex_entity", "&#xZZ;", "invalid entity"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			checkError(t, Validate(ModeHTML, c.text), c.err)
+		})
+	}
+}
+
+func TestValidateMode(t *testing.T) {
+	if err := Validate("", "*не разметка* <b> & _"); err != nil {
+		t.Errorf("plain text rejected: %v", err)
+	}
+	checkError(t, Validate("Markdown", "x"), `unsupported parse mode "Markdown"`)
+	for mode, want := range map[string]bool{"": true, ModeMarkdownV2: true, ModeHTML: true, "Markdown": false, "html": false} {
+		if got := ValidMode(mode); got != want {
+			t.Errorf("ValidMode(%q) = %v, want %v", mode, got, want)
+		}
+	}
+}
+
+// Экранированный текст проходит проверку и отображается буквально.
+func TestEscapeRoundTrip(t *testing.T) {
+	texts := []string{
+		"",
+		"Позвонить маме",
+		markdownReserved,
+		"Встреча в 10.00 (переговорка #3) - не опаздывать!",
+		"*не жирный* _не курсив_ ~x~ ||y|| `z` ```w```",
+		"[не ссылка](https://example.com)",
+		">не цитата\n>>",
+		`C:\Users\anna\`,
+		"<b>не тег</b> & &amp; &lt; a>b",
+		"😀 ёлка",
+	}
+	for _, text := range texts {
+		for _, mode := range []string{ModeMarkdownV2, ModeHTML} {
+			escaped := Escape(mode, text)
+			if err := Validate(mode, escaped); err != nil {
+				t.Errorf("Validate(%s, Escape(%q)) = %v", mode, text, err)
+			}
+			if got := unescape(mode, escaped); got != text {
+				t.Errorf("%s: Escape(%q) = %q, which reads back as %q", mode, text, escaped, got)
+			}
+		}
+	}
+	if got := Escape("", "*x* <b>"); got != "*x* <b>" {
+		t.Errorf("Escape without parse mode changed the text to %q", got)
+	}
+}
+
+// unescape возвращает текст, который Telegram покажет для экранированной строки.
+func unescape(mode, text string) string {
+	if mode == ModeHTML {
+		return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(text)
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			i++
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+func checkError(t *testing.T, err error, want string) {
+	t.Helper()
+	switch {
+	case want == "" && err != nil:
+		t.Errorf("unexpected error: %v", err)
+	case want != "" && err == nil:
+		t.Errorf("no error, want %q", want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Errorf("error %q, want %q", err, want)
+	}
+}
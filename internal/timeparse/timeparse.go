@@ -0,0 +1,853 @@
+// Package timeparse переводит фразы на английском и русском вроде «in 15 minutes»,
+// «завтра в 9», «every weekday at 8:30» или «1-го числа каждого месяца» в момент времени
+// и правило повторения RRULE относительно заданного текущего времени и его часового пояса.
+package timeparse
+
+import (
+	"Reminders/internal/recurrence"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Час, на который ставится напоминание, если указан только день: «завтра», «next Monday»
+const defaultHour = 9
+
+// Максимальный сдвиг от текущего времени, около 100 лет
+const maxOffset = 100 * 366 * 24 * time.Hour
+
+// Средняя длина месяца григорианского календаря, для оценки сдвига в месяцах
+const averageMonth = 2629746 * time.Second
+
+var (
+	// ErrUnrecognized - во фразе нет понятного выражения времени или после него есть лишние слова.
+	ErrUnrecognized = errors.New("unrecognized time expression")
+	// ErrConflict - во фразе несколько несовместимых указаний дня, например «tomorrow on Friday».
+	ErrConflict = errors.New("conflicting time expressions")
+	// ErrPast - фраза указывает на уже прошедшее время.
+	ErrPast = errors.New("time is in the past")
+)
+
+// Result - разобранное выражение времени.
+type Result struct {
+	// At - момент отправки, для повторения - первое повторение после текущего времени.
+	// Часовой пояс - тот же, что у текущего времени.
+	At time.Time
+	// Recurrence - правило повторения RRULE без префикса или пустая строка.
+	Recurrence string
+}
+
+// Parse разбирает фразу целиком относительно now.
+func Parse(text string, now time.Time) (Result, error) {
+	p := newParser(text, now)
+	p.parse()
+	if p.pos < len(p.toks) {
+		if p.pos == 0 {
+			return Result{}, fmt.Errorf("%w %q", ErrUnrecognized, text)
+		}
+		return Result{}, fmt.Errorf("%w: unexpected %q", ErrUnrecognized, text[p.toks[p.pos].start:])
+	}
+	return p.resolve()
+}
+
+// Extract находит выражение времени в начале или в конце текста, например в
+// «завтра в 9 купить молоко» или «call mom in 2 hours», и возвращает его вместе
+// с остальным текстом.
+func Extract(text string, now time.Time) (Result, string, error) {
+	p := newParser(text, now)
+	p.parse()
+	if p.pos > 0 {
+		rest := ""
+		if p.pos < len(p.toks) {
+			rest = strings.TrimLeft(text[p.toks[p.pos].start:], separators)
+		}
+		res, err := p.resolve()
+		return res, rest, err
+	}
+
+	// Самое длинное выражение в конце текста
+	for i := 1; i < len(p.toks); i++ {
+		suffix := newParser(text, now)
+		suffix.toks = suffix.toks[i:]
+		suffix.parse()
+		if suffix.pos == len(suffix.toks) {
+			res, err := suffix.resolve()
+			return res, strings.TrimRight(text[:p.toks[i].start], separators), err
+		}
+	}
+	return Result{}, strings.TrimSpace(text), fmt.Errorf("%w %q", ErrUnrecognized, text)
+}
+
+// Символы между выражением времени и остальным текстом
+const separators = " \t\r\n,;:-–—"
+
+// token - слово фразы в нижнем регистре и его начало в исходном тексте.
+type token struct {
+	text  string
+	start int
+}
+
+func tokenize(s string) []token {
+	var toks []token
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := strings.ReplaceAll(strings.ToLower(s[start:end]), "ё", "е")
+		word = strings.TrimRight(word, ",;!?.")
+		if word != "" {
+			toks = append(toks, token{text: word, start: start})
+		}
+		start = -1
+	}
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			flush(i)
+		} else if start < 0 {
+			start = i
+		}
+	}
+	flush(len(s))
+	return toks
+}
+
+// parser собирает части выражения: относительный сдвиг, день, дату, время суток и
+// повторение. Каждая часть может встретиться один раз, на повторной разбор останавливается.
+type parser struct {
+	toks []token
+	pos  int
+	now  time.Time
+
+	hasOffset bool
+	offset    time.Duration
+	offDays   int
+	offMonths int
+
+	hasDays bool
+	days    int
+
+	hasWeekday bool
+	weekday    time.Weekday
+	next       bool
+
+	hasDate bool
+	hasYear bool
+	date    time.Time
+
+	monthDay int
+
+	hasClock bool
+	hour     int
+	minute   int
+	partHour int
+
+	rule *recurrence.Rule
+}
+
+func newParser(text string, now time.Time) *parser {
+	return &parser{toks: tokenize(text), now: now}
+}
+
+func (p *parser) peek(i int) string {
+	if p.pos+i < len(p.toks) {
+		return p.toks[p.pos+i].text
+	}
+	return ""
+}
+
+// parse разбирает части выражения, пока очередное слово не окажется непонятным.
+func (p *parser) parse() {
+	for p.pos < len(p.toks) {
+		if !(p.recurrence() || p.relative() || p.day() || p.weekdayExpr() ||
+			p.dateExpr() || p.clock() || p.partOfDay()) {
+			return
+		}
+	}
+}
+
+// recurrence разбирает повторение: «every weekday», «каждые 2 часа», «по понедельникам»,
+// «ежедневно», «of every month», «каждое 1-е число».
+func (p *parser) recurrence() bool {
+	if p.rule != nil {
+		return false
+	}
+	w := p.peek(0)
+
+	if freq, ok := adverbFreq[w]; ok {
+		p.rule = &recurrence.Rule{Freq: freq, Interval: 1}
+		p.pos++
+		return true
+	}
+
+	// «on weekdays», «по будням», «в выходные», «on Mondays and Fridays», «по средам»
+	i := 0
+	if w == "on" || w == "по" || w == "в" {
+		i = 1
+	}
+	if days, ok := weekdayGroup[p.peek(i)]; ok {
+		p.setByDay(days, 1)
+		p.pos += i + 1
+		return true
+	}
+	if days, n := p.weekdayList(i, pluralWeekdays); n > 0 {
+		p.setByDay(days, 1)
+		p.pos += i + n
+		return true
+	}
+
+	// «of every month», «of each month»
+	i = 0
+	if w == "of" {
+		i = 1
+	}
+	if !everyWords[p.peek(i)] {
+		return false
+	}
+	i++
+
+	// «every 15th», «каждое 1-е число», «каждое 15 число»
+	if day, ordinal, ok := dayNumber(p.peek(i)); ok && p.monthDay == 0 {
+		n := 0
+		switch next := p.peek(i + 1); {
+		case next == "число" || next == "числа":
+			n = 2
+		case ordinal && !hasUnit(next):
+			n = 1
+		}
+		if n > 0 {
+			p.rule = &recurrence.Rule{Freq: recurrence.Monthly, Interval: 1}
+			p.monthDay = day
+			p.pos += i + n
+			return true
+		}
+	}
+
+	interval := 1
+	if p.peek(i) == "other" {
+		interval = 2
+		i++
+	} else if n, err := strconv.Atoi(p.peek(i)); err == nil && n > 0 {
+		interval = n
+		i++
+	}
+
+	if u, ok := units[p.peek(i)]; ok {
+		p.rule = &recurrence.Rule{Freq: u.freq, Interval: interval}
+		p.pos += i + 1
+		return true
+	}
+	if days, ok := weekdayGroup[p.peek(i)]; ok {
+		p.setByDay(days, interval)
+		p.pos += i + 1
+		return true
+	}
+	if days, ok := dayAdjectives[p.peek(i)]; ok && units[p.peek(i+1)] == dayUnit {
+		p.setByDay(days, interval)
+		p.pos += i + 2
+		return true
+	}
+	if days, n := p.weekdayList(i, weekdays); n > 0 {
+		p.setByDay(days, interval)
+		p.pos += i + n
+		return true
+	}
+	return false
+}
+
+func hasUnit(w string) bool {
+	_, ok := units[w]
+	return ok
+}
+
+// weekdayList разбирает перечисление дней недели «Monday, Wednesday and Friday»,
+// начиная с i-го слова, и возвращает дни и число слов.
+func (p *parser) weekdayList(i int, names map[string]time.Weekday) ([]time.Weekday, int) {
+	var days []time.Weekday
+	n := 0
+	for {
+		day, ok := names[p.peek(i+n)]
+		if !ok {
+			break
+		}
+		days = append(days, day)
+		n++
+		if andWords[p.peek(i+n)] {
+			if _, ok := names[p.peek(i+n+1)]; ok {
+				n++
+			}
+		}
+	}
+	return days, n
+}
+
+func (p *parser) setByDay(days []time.Weekday, interval int) {
+	p.rule = &recurrence.Rule{Freq: recurrence.Weekly, Interval: interval}
+	for _, day := range days {
+		p.rule.ByDay = append(p.rule.ByDay, recurrence.WeekdayNum{Weekday: day})
+	}
+}
+
+// relative разбирает сдвиг от текущего времени: «in 15 minutes», «in 1h 30m», «через час»,
+// «через полчаса», «next week», «на следующей неделе».
+func (p *parser) relative() bool {
+	if p.hasOffset {
+		return false
+	}
+	// «next week», «на следующей неделе», «в следующем месяце»
+	i := 0
+	if p.peek(0) == "на" || p.peek(0) == "в" {
+		i = 1
+	}
+	if nextWords[p.peek(i)] {
+		if u, ok := units[p.peek(i+1)]; ok && u.dur == 0 && u != dayUnit && p.addOffset(u, 1) {
+			p.pos += i + 2
+			return true
+		}
+	}
+
+	if !relativeWords[p.peek(0)] {
+		return false
+	}
+	saved := *p
+	i = 1
+	matched := false
+	for {
+		u, n, words := p.offsetPart(i)
+		if words == 0 {
+			break
+		}
+		// Слишком большой сдвиг не разбирается, фраза остаётся непонятой
+		if !p.addOffset(u, n) {
+			*p = saved
+			return false
+		}
+		matched = true
+		i += words
+		// «in 1 hour and 30 minutes», «через час и 15 минут»
+		if _, _, words := p.offsetPart(i + 1); andWords[p.peek(i)] && words > 0 {
+			i++
+		}
+	}
+	if !matched {
+		return false
+	}
+	p.pos += i
+	return true
+}
+
+// offsetPart разбирает одно слагаемое сдвига, начиная с i-го слова, и возвращает единицу,
+// их количество и число слов.
+func (p *parser) offsetPart(i int) (unit, int, int) {
+	w := p.peek(i)
+	switch {
+	case w == "полчаса":
+		return minuteUnit, 30, 1
+	case w == "half" && (p.peek(i+1) == "an" || p.peek(i+1) == "a") && units[p.peek(i+2)] == hourUnit:
+		return minuteUnit, 30, 3
+	case w == "полтора" && units[p.peek(i+1)] == hourUnit:
+		return minuteUnit, 90, 2
+	}
+
+	if w == "a" || w == "an" || w == "one" {
+		if u, ok := units[p.peek(i+1)]; ok {
+			return u, 1, 2
+		}
+		return unit{}, 0, 0
+	}
+	if n, err := strconv.Atoi(w); err == nil && n > 0 {
+		if u, ok := units[p.peek(i+1)]; ok {
+			return u, n, 2
+		}
+		return unit{}, 0, 0
+	}
+	// «15m», «2h», «15мин»
+	digits := strings.TrimRightFunc(w, func(r rune) bool { return !unicode.IsDigit(r) })
+	if n, err := strconv.Atoi(digits); err == nil && n > 0 && len(digits) < len(w) {
+		if u, ok := units[w[len(digits):]]; ok {
+			return u, n, 1
+		}
+	}
+	// «через час», «через неделю»
+	if u, ok := units[w]; ok {
+		return u, 1, 1
+	}
+	return unit{}, 0, 0
+}
+
+// addOffset добавляет к сдвигу n единиц u. Возвращает false и не меняет сдвиг, если
+// он превысил бы maxOffset.
+func (p *parser) addOffset(u unit, n int) bool {
+	// n проверяется до умножения, чтобы оно не переполнилось
+	size := u.span()
+	if n > int(maxOffset/size) || p.offsetSpan()+time.Duration(n)*size > maxOffset {
+		return false
+	}
+	p.hasOffset = true
+	p.offset += time.Duration(n) * u.dur
+	p.offDays += n * u.days
+	p.offMonths += n * u.months
+	return true
+}
+
+// offsetSpan оценивает длину накопленного сдвига.
+func (p *parser) offsetSpan() time.Duration {
+	return p.offset + time.Duration(p.offDays)*24*time.Hour + time.Duration(p.offMonths)*averageMonth
+}
+
+// day разбирает «today», «tomorrow», «day after tomorrow», «tonight», «сегодня», «завтра»,
+// «послезавтра».
+func (p *parser) day() bool {
+	if p.hasDays {
+		return false
+	}
+	w := p.peek(0)
+	if days, ok := dayWords[w]; ok {
+		p.hasDays, p.days = true, days
+		p.pos++
+		return true
+	}
+	if w == "day" && p.peek(1) == "after" && p.peek(2) == "tomorrow" {
+		p.hasDays, p.days = true, 2
+		p.pos += 3
+		return true
+	}
+	if w == "tonight" {
+		p.hasDays, p.days = true, 0
+		if p.partHour == 0 {
+			p.partHour = partsOfDay["evening"]
+		}
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// weekdayExpr разбирает «Monday», «on Friday», «next Monday», «в среду»,
+// «в следующий понедельник».
+func (p *parser) weekdayExpr() bool {
+	if p.hasWeekday {
+		return false
+	}
+	i := 0
+	if w := p.peek(0); w == "on" || w == "в" || w == "во" {
+		i++
+	}
+	next := false
+	if nextWords[p.peek(i)] {
+		next = true
+		i++
+	} else if p.peek(i) == "this" {
+		i++
+	}
+	day, ok := weekdays[p.peek(i)]
+	if !ok {
+		return false
+	}
+	p.hasWeekday, p.weekday, p.next = true, day, next
+	p.pos += i + 1
+	return true
+}
+
+// dateExpr разбирает дату: «2025-01-02», «02.01.2025», «5 января», «January 5th»,
+// «on the 1st», «15-го числа».
+func (p *parser) dateExpr() bool {
+	if p.hasDate || p.monthDay > 0 {
+		return false
+	}
+	i := 0
+	if p.peek(i) == "on" {
+		i++
+	}
+	if p.peek(i) == "the" {
+		i++
+	}
+	w := p.peek(i)
+	loc := p.now.Location()
+
+	// Дата со временем
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, strings.ToUpper(w), loc); err == nil && !p.hasClock {
+			p.setDate(t, true)
+			p.hasClock, p.hour, p.minute = true, t.Hour(), t.Minute()
+			p.pos += i + 1
+			return true
+		}
+	}
+	for _, layout := range []string{"2006-01-02", "02.01.2006", "2.1.2006"} {
+		if t, err := time.ParseInLocation(layout, w, loc); err == nil {
+			p.setDate(t, true)
+			p.pos += i + 1
+			return true
+		}
+	}
+	for _, layout := range []string{"02.01", "2.1"} {
+		if t, err := time.ParseInLocation(layout, w, loc); err == nil {
+			p.setDate(time.Date(p.dateYear(t.Month(), t.Day()), t.Month(), t.Day(), 0, 0, 0, 0, loc), false)
+			p.pos += i + 1
+			return true
+		}
+	}
+
+	// «5 января», «5th of January», «January 5th»
+	day, ordinal, ok := dayNumber(w)
+	if ok {
+		j := i + 1
+		if p.peek(j) == "of" {
+			j++
+		}
+		if month, ok := months[p.peek(j)]; ok {
+			return p.monthDate(j+1, month, day)
+		}
+	} else if month, ok := months[w]; ok {
+		if day, _, ok := dayNumber(p.peek(i + 1)); ok {
+			return p.monthDate(i+2, month, day)
+		}
+	}
+
+	// «the 1st», «1-го числа», «15 числа»
+	if !ok {
+		return false
+	}
+	switch {
+	case p.peek(i+1) == "числа" || p.peek(i+1) == "число":
+		p.pos += i + 2
+	case ordinal || i > 0 && p.peek(i-1) == "the":
+		p.pos += i + 1
+	default:
+		return false
+	}
+	p.monthDay = day
+	return true
+}
+
+// monthDate задаёт день и месяц, за которыми с i-го слова может следовать год, и
+// переходит за них. Дня, которого нет в месяце, например 30 февраля или 29 февраля
+// невисокосного года, не бывает: дата остаётся неразобранной. 29 февраля без года -
+// ближайшее в високосный год.
+func (p *parser) monthDate(i int, month time.Month, day int) bool {
+	year := p.dateYear(month, day)
+	hasYear := false
+	if y, err := strconv.Atoi(p.peek(i)); err == nil && y >= 1970 && y <= 9999 {
+		year, hasYear = y, true
+		i++
+	}
+	if day > daysIn(year, month) {
+		return false
+	}
+	p.pos += i
+	p.setDate(time.Date(year, month, day, 0, 0, 0, 0, p.now.Location()), hasYear)
+	return true
+}
+
+// dateYear возвращает год для даты без года: текущий или, для 29 февраля, ближайший високосный.
+func (p *parser) dateYear(month time.Month, day int) int {
+	year := p.now.Year()
+	for day <= 29 && daysIn(year, month) < day {
+		year++
+	}
+	return year
+}
+
+// daysIn возвращает число дней в месяце.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func (p *parser) setDate(t time.Time, hasYear bool) {
+	p.hasDate, p.hasYear = true, hasYear
+	p.date = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, p.now.Location())
+}
+
+// dayNumber разбирает день месяца «5», «5th», «1st», «1-го», «1-е». Второе значение
+// сообщает, был ли у числа порядковый суффикс.
+func dayNumber(s string) (int, bool, bool) {
+	trimmed := s
+	for _, suffix := range []string{"st", "nd", "rd", "th", "-го", "-е", "-ое", "-ого", "го"} {
+		if t, ok := strings.CutSuffix(s, suffix); ok {
+			trimmed = t
+			break
+		}
+	}
+	n, err := strconv.Atoi(trimmed)
+	if err != nil || n < 1 || n > 31 {
+		return 0, false, false
+	}
+	return n, trimmed != s, true
+}
+
+// clock разбирает время суток: «at 9», «9:30», «9pm», «at 9 o'clock», «в 9 утра»,
+// «в 21:00», «noon», «в полночь». Число без предлога, двоеточия и уточнения не считается временем.
+func (p *parser) clock() bool {
+	if p.hasClock {
+		return false
+	}
+	i := 0
+	prefixed := false
+	if w := p.peek(0); w == "at" || w == "в" || w == "во" || w == "@" {
+		i, prefixed = 1, true
+	}
+
+	switch p.peek(i) {
+	case "noon", "midday", "полдень":
+		p.setClock(12, 0, i+1)
+		return true
+	case "midnight", "полночь":
+		p.setClock(0, 0, i+1)
+		return true
+	}
+
+	w := p.peek(i)
+	meridiem := ""
+	for _, suffix := range []string{"am", "pm"} {
+		if t, ok := strings.CutSuffix(w, suffix); ok && t != "" {
+			w, meridiem = t, suffix
+			break
+		}
+	}
+	hours, minutes, hasMinutes := strings.Cut(w, ":")
+	hour, err := strconv.Atoi(hours)
+	if err != nil || hour < 0 || hour > 23 {
+		return false
+	}
+	minute := 0
+	if hasMinutes {
+		minute, err = strconv.Atoi(minutes)
+		if err != nil || len(minutes) != 2 || minute > 59 {
+			return false
+		}
+	}
+	// «в 9 часов вечера», «at 9 o'clock», «в 9 часов»
+	n := i + 1
+	if meridiem == "" {
+		j := n
+		if hourWords[p.peek(j)] {
+			j++
+		}
+		if m, ok := meridiems[p.peek(j)]; ok {
+			meridiem, n = m, j+1
+		} else if prefixed {
+			n = j
+		}
+	}
+	if !prefixed && !hasMinutes && meridiem == "" {
+		return false
+	}
+
+	switch meridiem {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return false
+		}
+		if meridiem == "pm" && hour < 12 {
+			hour += 12
+		}
+		if meridiem == "am" && hour == 12 {
+			hour = 0
+		}
+	case "night":
+		// «11 ночи» - 23:00, «2 ночи» - 2:00
+		if hour == 12 {
+			hour = 0
+		} else if hour >= 6 && hour < 12 {
+			hour += 12
+		}
+	}
+	p.setClock(hour, minute, n)
+	return true
+}
+
+func (p *parser) setClock(hour, minute, n int) {
+	p.hasClock, p.hour, p.minute = true, hour, minute
+	p.pos += n
+}
+
+// partOfDay разбирает «in the morning», «this evening», «утром», «вечером».
+func (p *parser) partOfDay() bool {
+	if p.partHour > 0 {
+		return false
+	}
+	i := 0
+	switch {
+	case p.peek(0) == "in" && p.peek(1) == "the":
+		i = 2
+	case p.peek(0) == "at" || p.peek(0) == "this":
+		i = 1
+	}
+	hour, ok := partsOfDay[p.peek(i)]
+	if !ok {
+		return false
+	}
+	p.partHour = hour
+	p.pos += i + 1
+	return true
+}
+
+// resolve вычисляет момент времени и правило повторения из разобранных частей.
+func (p *parser) resolve() (Result, error) {
+	anchors := 0
+	for _, set := range []bool{p.hasOffset, p.hasDays, p.hasWeekday, p.hasDate, p.monthDay > 0} {
+		if set {
+			anchors++
+		}
+	}
+	if anchors > 1 {
+		return Result{}, ErrConflict
+	}
+
+	hour, minute := defaultHour, 0
+	if p.hasClock {
+		hour, minute = p.hour, p.minute
+	} else if p.partHour > 0 {
+		hour = p.partHour
+	}
+
+	if p.rule != nil {
+		at, err := p.resolveRule(hour, minute)
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{At: at, Recurrence: p.rule.String()}, nil
+	}
+
+	now, loc := p.now, p.now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, loc)
+	var at time.Time
+	switch {
+	case p.hasOffset:
+		at = now.AddDate(0, p.offMonths, p.offDays).Add(p.offset)
+		if p.hasClock || p.partHour > 0 {
+			// «через 2 часа в 9» не имеет смысла, «через 2 дня в 9» - имеет
+			if p.offset != 0 {
+				return Result{}, ErrConflict
+			}
+			at = time.Date(at.Year(), at.Month(), at.Day(), hour, minute, 0, 0, loc)
+		} else if p.offset == 0 {
+			// «через неделю» - в то же время суток, с точностью до минуты
+			at = at.Truncate(time.Minute)
+		}
+	case p.hasDays:
+		at = today.AddDate(0, 0, p.days)
+	case p.hasWeekday:
+		days := (int(p.weekday) - int(now.Weekday()) + 7) % 7
+		if days == 0 && (p.next || !today.After(now)) {
+			days = 7
+		}
+		at = today.AddDate(0, 0, days)
+	case p.hasDate:
+		at = time.Date(p.date.Year(), p.date.Month(), p.date.Day(), hour, minute, 0, 0, loc)
+		// Дата без года - ближайшая впереди, 29 февраля - в следующий високосный год
+		for year := at.Year() + 1; !p.hasYear && !at.After(now); year++ {
+			if daysIn(year, at.Month()) >= at.Day() {
+				at = time.Date(year, at.Month(), at.Day(), hour, minute, 0, 0, loc)
+			}
+		}
+	case p.monthDay > 0:
+		at = nextMonthDay(today, p.monthDay, now)
+	case p.hasClock || p.partHour > 0:
+		at = today
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+	default:
+		return Result{}, ErrUnrecognized
+	}
+
+	if !at.After(now) {
+		return Result{}, ErrPast
+	}
+	return Result{At: at}, nil
+}
+
+// resolveRule дополняет правило днём из фразы и возвращает первое повторение после текущего времени.
+func (p *parser) resolveRule(hour, minute int) (time.Time, error) {
+	rule, now, loc := p.rule, p.now, p.now.Location()
+	switch {
+	case p.hasOffset:
+		return time.Time{}, ErrConflict
+	case p.monthDay > 0:
+		if rule.Freq != recurrence.Monthly {
+			return time.Time{}, ErrConflict
+		}
+		rule.ByMonthDay = []int{p.monthDay}
+	case p.hasWeekday:
+		if rule.Freq != recurrence.Weekly || len(rule.ByDay) > 0 {
+			return time.Time{}, ErrConflict
+		}
+		rule.ByDay = []recurrence.WeekdayNum{{Weekday: p.weekday}}
+	}
+
+	// «every 2 hours» без времени суток отсчитывается от текущего момента
+	if (rule.Freq == recurrence.Minutely || rule.Freq == recurrence.Hourly) && !p.hasClock && !p.hasDate && !p.hasDays {
+		step := time.Duration(rule.Interval) * time.Minute
+		if rule.Freq == recurrence.Hourly {
+			step = time.Duration(rule.Interval) * time.Hour
+		}
+		return now.Truncate(time.Minute).Add(step), nil
+	}
+
+	start := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, loc)
+	switch {
+	case p.hasDate:
+		start = time.Date(p.date.Year(), p.date.Month(), p.date.Day(), hour, minute, 0, 0, loc)
+	case p.hasDays:
+		start = start.AddDate(0, 0, p.days)
+	}
+
+	// Серия отсчитывается от start: его неделя или месяц - первые в интервале. Сам start
+	// становится первым повторением, если ещё не прошёл и подходит под дни правила
+	if start.After(now) && onRuleDay(rule, start) {
+		return start, nil
+	}
+	threshold := now
+	if start.After(now) {
+		threshold = start
+	}
+	at, _, ok := rule.After(start, 0, threshold)
+	if !ok {
+		return time.Time{}, ErrPast
+	}
+	return at, nil
+}
+
+// onRuleDay сообщает, приходится ли t на день недели или число месяца из правила.
+func onRuleDay(rule *recurrence.Rule, t time.Time) bool {
+	if len(rule.ByMonthDay) > 0 && !containsDay(rule.ByMonthDay, t.Day()) {
+		return false
+	}
+	if len(rule.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range rule.ByDay {
+		if wd.Weekday == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDay(days []int, day int) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// nextMonthDay возвращает ближайшее после now число day месяца во время суток из today.
+// Месяцы, в которых нет такого числа, пропускаются.
+func nextMonthDay(today time.Time, day int, now time.Time) time.Time {
+	for i := 0; ; i++ {
+		first := time.Date(today.Year(), today.Month()+time.Month(i), 1, today.Hour(), today.Minute(), 0, 0, today.Location())
+		if day > first.AddDate(0, 1, -1).Day() {
+			continue
+		}
+		if at := first.AddDate(0, 0, day-1); at.After(now) {
+			return at
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package timeparse
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Каждая фраза разбирается относительно 15:30 по Москве в разные дни недели: с понедельника
+// 19 октября 2026 по воскресенье 25 октября.
+func TestParse(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, moscow)
+	}
+	const (
+		mon = 19
+		tue = 20
+		thu = 22
+		fri = 23
+		sun = 25
+	)
+	cases := []struct {
+		name    string
+		phrases []string
+		rrule   string
+		// want - ожидаемое время по дню октября, в который разбирается фраза
+		want map[int]time.Time
+	}{
+		{
+			name:    "offset",
+			phrases: []string{"in 15 minutes", "через 15 минут"},
+			want: map[int]time.Time{
+				mon: at(10, mon, 15, 45), tue: at(10, tue, 15, 45), thu: at(10, thu, 15, 45),
+				fri: at(10, fri, 15, 45), sun: at(10, sun, 15, 45),
+			},
+		},
+		{
+			name:    "tomorrow",
+			phrases: []string{"tomorrow at 9", "завтра в 9"},
+			want: map[int]time.Time{
+				mon: at(10, 20, 9, 0), tue: at(10, 21, 9, 0), thu: at(10, 23, 9, 0),
+				fri: at(10, 24, 9, 0), sun: at(10, 26, 9, 0),
+			},
+		},
+		{
+			name:    "next_weekday",
+			phrases: []string{"next Monday", "в следующий понедельник"},
+			want: map[int]time.Time{
+				mon: at(10, 26, 9, 0), tue: at(10, 26, 9, 0), thu: at(10, 26, 9, 0),
+				fri: at(10, 26, 9, 0), sun: at(10, 26, 9, 0),
+			},
+		},
+		{
+			name:    "every_day",
+			phrases: []string{"every day at 18", "каждый день в 18"},
+			rrule:   "FREQ=DAILY",
+			want: map[int]time.Time{
+				mon: at(10, mon, 18, 0), tue: at(10, tue, 18, 0), thu: at(10, thu, 18, 0),
+				fri: at(10, fri, 18, 0), sun: at(10, sun, 18, 0),
+			},
+		},
+		{
+			name:    "every_weekday",
+			phrases: []string{"every weekday at 8:30", "каждый будний день в 8:30", "по будням в 8:30"},
+			rrule:   "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+			want: map[int]time.Time{
+				mon: at(10, 20, 8, 30), tue: at(10, 21, 8, 30), thu: at(10, 23, 8, 30),
+				fri: at(10, 26, 8, 30), sun: at(10, 26, 8, 30),
+			},
+		},
+		{
+			name:    "every_monday",
+			phrases: []string{"every monday at 9", "каждый понедельник в 9"},
+			rrule:   "FREQ=WEEKLY;BYDAY=MO",
+			want: map[int]time.Time{
+				mon: at(10, 26, 9, 0), tue: at(10, 26, 9, 0), thu: at(10, 26, 9, 0),
+				fri: at(10, 26, 9, 0), sun: at(10, 26, 9, 0),
+			},
+		},
+		{
+			// Неделя текущего дня - первая в серии: пятница этой недели, если она ещё
+			// впереди, иначе через две недели
+			name:    "every_2_weeks_on_friday",
+			phrases: []string{"every 2 weeks on friday", "каждые 2 недели в пятницу"},
+			rrule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR",
+			want: map[int]time.Time{
+				mon: at(10, 23, 9, 0), tue: at(10, 23, 9, 0), thu: at(10, 23, 9, 0),
+				fri: at(11, 6, 9, 0), sun: at(11, 6, 9, 0),
+			},
+		},
+		{
+			// В понедельник до 18:00 первое повторение - сегодня
+			name:    "every_2_weeks_on_monday_evening",
+			phrases: []string{"every 2 weeks on monday at 18", "каждые 2 недели в понедельник в 18"},
+			rrule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO",
+			want: map[int]time.Time{
+				mon: at(10, mon, 18, 0), tue: at(11, 2, 18, 0), thu: at(11, 2, 18, 0),
+				fri: at(11, 2, 18, 0), sun: at(11, 2, 18, 0),
+			},
+		},
+		{
+			name:    "first_of_every_month",
+			phrases: []string{"on the 1st of every month", "1-го числа каждого месяца"},
+			rrule:   "FREQ=MONTHLY;BYMONTHDAY=1",
+			want: map[int]time.Time{
+				mon: at(11, 1, 9, 0), tue: at(11, 1, 9, 0), thu: at(11, 1, 9, 0),
+				fri: at(11, 1, 9, 0), sun: at(11, 1, 9, 0),
+			},
+		},
+		{
+			// 19-го до 18:00 первое повторение - сегодня
+			name:    "monthly_today_evening",
+			phrases: []string{"every month on the 19th at 18", "каждый месяц 19-го числа в 18"},
+			rrule:   "FREQ=MONTHLY;BYMONTHDAY=19",
+			want: map[int]time.Time{
+				mon: at(10, 19, 18, 0), tue: at(11, 19, 18, 0), thu: at(11, 19, 18, 0),
+				fri: at(11, 19, 18, 0), sun: at(11, 19, 18, 0),
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for day, want := range c.want {
+				now := at(10, day, 15, 30)
+				for _, phrase := range c.phrases {
+					got, err := Parse(phrase, now)
+					if err != nil {
+						t.Errorf("%s: Parse(%q): %v", now.Weekday(), phrase, err)
+						continue
+					}
+					if !got.At.Equal(want) || got.Recurrence != c.rrule {
+						t.Errorf("%s: Parse(%q) = %s %q, want %s %q", now.Weekday(), phrase,
+							got.At.Format(time.RFC3339), got.Recurrence, want.Format(time.RFC3339), c.rrule)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Даты, которых нет в календаре, не разбираются, а 29 февраля без года приходится на
+// ближайший високосный год.
+func TestParseDates(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 15, 30, 0, 0, moscow)
+	afterLeapDay := time.Date(2028, 3, 1, 12, 0, 0, 0, moscow)
+	date := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, moscow)
+	}
+
+	cases := []struct {
+		name    string
+		phrases []string
+		now     time.Time
+		want    time.Time
+		rrule   string
+	}{
+		{"leap_day", []string{"on 29 february", "29 февраля", "29.02"}, now, date(2028, 2, 29, 9, 0), ""},
+		{"leap_day_passed", []string{"on 29 february", "29 февраля", "29.02"}, afterLeapDay, date(2032, 2, 29, 9, 0), ""},
+		{"leap_day_with_year", []string{"29 february 2028", "29 февраля 2028", "29.02.2028"}, now, date(2028, 2, 29, 9, 0), ""},
+		{"yearly_leap_day", []string{"every year on 29 february", "каждый год 29 февраля"}, now, date(2028, 2, 29, 9, 0), "FREQ=YEARLY"},
+		{"yearly_leap_day_passed", []string{"every year on 29 february"}, afterLeapDay, date(2032, 2, 29, 9, 0), "FREQ=YEARLY"},
+		{"last_day_of_month", []string{"on november 30", "30 ноября"}, now, date(2026, 11, 30, 9, 0), ""},
+		{"offset_at_limit", []string{"in 100 years", "через 100 лет"}, now, date(2126, 10, 19, 15, 30), ""},
+		{"long_offset", []string{"in 36500 days", "in 876000 hours"}, now, now.AddDate(0, 0, 36500), ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for _, phrase := range c.phrases {
+				got, err := Parse(phrase, c.now)
+				if err != nil {
+					t.Errorf("Parse(%q): %v", phrase, err)
+					continue
+				}
+				if !got.At.Equal(c.want) || got.Recurrence != c.rrule {
+					t.Errorf("Parse(%q) = %s %q, want %s %q", phrase,
+						got.At.Format(time.RFC3339), got.Recurrence, c.want.Format(time.RFC3339), c.rrule)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	now := time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC)
+	phrases := []string{
+		// Дня нет в месяце
+		"on february 30",
+		"30 февраля",
+		"31 ноября",
+		"november 31st",
+		"on the 31st of november",
+		"31 апреля 2027",
+		"29 february 2027",
+		"29 февраля 2027",
+		"every year on 30 february",
+		// Сдвиг больше 100 лет или переполняющий time.Duration
+		"in 9999999 hours",
+		"in 99999999999 minutes",
+		"in 1000000000 years",
+		"in 101 years",
+		"in 9223372036854775807 minutes",
+		"через 99999999999 минут",
+		"in 99 years and 2 years",
+		"in 1 hour and 99999999999 minutes",
+	}
+	for _, phrase := range phrases {
+		if got, err := Parse(phrase, now); !errors.Is(err, ErrUnrecognized) {
+			t.Errorf("Parse(%q) = %s, %v; want ErrUnrecognized", phrase, got.At.Format(time.RFC3339), err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package timeparse
+
+import (
+	"Reminders/internal/recurrence"
+	"time"
+)
+
+// unit - единица времени в «через 15 минут» и «каждые 2 часа».
+type unit struct {
+	freq   string
+	dur    time.Duration
+	days   int
+	months int
+}
+
+// span оценивает длину единицы.
+func (u unit) span() time.Duration {
+	return u.dur + time.Duration(u.days)*24*time.Hour + time.Duration(u.months)*averageMonth
+}
+
+var (
+	minuteUnit = unit{freq: recurrence.Minutely, dur: time.Minute}
+	hourUnit   = unit{freq: recurrence.Hourly, dur: time.Hour}
+	dayUnit    = unit{freq: recurrence.Daily, days: 1}
+	weekUnit   = unit{freq: recurrence.Weekly, days: 7}
+	monthUnit  = unit{freq: recurrence.Monthly, months: 1}
+	yearUnit   = unit{freq: recurrence.Yearly, months: 12}
+)
+
+var units = map[string]unit{
+	"minute": minuteUnit, "minutes": minuteUnit, "min": minuteUnit, "mins": minuteUnit, "m": minuteUnit,
+	"минуту": minuteUnit, "минуты": minuteUnit, "минут": minuteUnit, "мин": minuteUnit,
+	"hour": hourUnit, "hours": hourUnit, "hr": hourUnit, "hrs": hourUnit, "h": hourUnit,
+	"час": hourUnit, "часа": hourUnit, "часов": hourUnit, "ч": hourUnit,
+	"day": dayUnit, "days": dayUnit, "d": dayUnit,
+	"день": dayUnit, "дня": dayUnit, "дней": dayUnit,
+	"week": weekUnit, "weeks": weekUnit, "w": weekUnit,
+	"неделю": weekUnit, "недели": weekUnit, "недель": weekUnit, "неделе": weekUnit,
+	"month": monthUnit, "months": monthUnit,
+	"месяц": monthUnit, "месяца": monthUnit, "месяцев": monthUnit, "месяце": monthUnit,
+	"year": yearUnit, "years": yearUnit,
+	"год": yearUnit, "года": yearUnit, "лет": yearUnit, "году": yearUnit,
+}
+
+// Слова, с которых начинается относительное время и повторение
+var (
+	relativeWords = map[string]bool{"in": true, "через": true}
+	everyWords    = map[string]bool{
+		"every": true, "each": true,
+		"каждый": true, "каждую": true, "каждое": true, "каждые": true, "каждого": true, "каждой": true,
+	}
+	// Единственное число: «в понедельник», «every Monday»
+	weekdays = map[string]time.Weekday{
+		"monday": time.Monday, "mon": time.Monday, "понедельник": time.Monday, "пн": time.Monday,
+		"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday, "вторник": time.Tuesday, "вт": time.Tuesday,
+		"wednesday": time.Wednesday, "wed": time.Wednesday, "среда": time.Wednesday, "среду": time.Wednesday, "ср": time.Wednesday,
+		"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "четверг": time.Thursday, "чт": time.Thursday,
+		"friday": time.Friday, "fri": time.Friday, "пятница": time.Friday, "пятницу": time.Friday, "пт": time.Friday,
+		"saturday": time.Saturday, "sat": time.Saturday, "суббота": time.Saturday, "субботу": time.Saturday, "сб": time.Saturday,
+		"sunday": time.Sunday, "sun": time.Sunday, "воскресенье": time.Sunday, "вс": time.Sunday,
+	}
+	// Множественное число: «on Mondays», «по понедельникам»
+	pluralWeekdays = map[string]time.Weekday{
+		"mondays": time.Monday, "понедельникам": time.Monday,
+		"tuesdays": time.Tuesday, "вторникам": time.Tuesday,
+		"wednesdays": time.Wednesday, "средам": time.Wednesday,
+		"thursdays": time.Thursday, "четвергам": time.Thursday,
+		"fridays": time.Friday, "пятницам": time.Friday,
+		"saturdays": time.Saturday, "субботам": time.Saturday,
+		"sundays": time.Sunday, "воскресеньям": time.Sunday,
+	}
+	workdays     = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	weekend      = []time.Weekday{time.Saturday, time.Sunday}
+	weekdayGroup = map[string][]time.Weekday{
+		"weekday": workdays, "weekdays": workdays, "workday": workdays, "workdays": workdays,
+		"будни": workdays, "будням": workdays,
+		"weekend": weekend, "weekends": weekend,
+		"выходные": weekend, "выходным": weekend,
+	}
+	// «каждый будний день», «каждый рабочий день»
+	dayAdjectives = map[string][]time.Weekday{
+		"будний": workdays, "рабочий": workdays,
+		"выходной": weekend,
+	}
+	adverbFreq = map[string]string{
+		"hourly": recurrence.Hourly, "ежечасно": recurrence.Hourly,
+		"daily": recurrence.Daily, "ежедневно": recurrence.Daily,
+		"weekly": recurrence.Weekly, "еженедельно": recurrence.Weekly,
+		"monthly": recurrence.Monthly, "ежемесячно": recurrence.Monthly,
+		"yearly": recurrence.Yearly, "annually": recurrence.Yearly, "ежегодно": recurrence.Yearly,
+	}
+	nextWords = map[string]bool{
+		"next": true, "следующий": true, "следующую": true, "следующее": true, "следующей": true, "следующем": true,
+	}
+	// Смещение в днях от сегодняшнего дня
+	dayWords = map[string]int{
+		"today": 0, "сегодня": 0,
+		"tomorrow": 1, "завтра": 1,
+		"послезавтра": 2,
+	}
+	months = map[string]time.Month{
+		"january": time.January, "jan": time.January, "января": time.January,
+		"february": time.February, "feb": time.February, "февраля": time.February,
+		"march": time.March, "mar": time.March, "марта": time.March,
+		"april": time.April, "apr": time.April, "апреля": time.April,
+		"may": time.May, "мая": time.May,
+		"june": time.June, "jun": time.June, "июня": time.June,
+		"july": time.July, "jul": time.July, "июля": time.July,
+		"august": time.August, "aug": time.August, "августа": time.August,
+		"september": time.September, "sep": time.September, "sept": time.September, "сентября": time.September,
+		"october": time.October, "oct": time.October, "октября": time.October,
+		"november": time.November, "nov": time.November, "ноября": time.November,
+		"december": time.December, "dec": time.December, "декабря": time.December,
+	}
+	// Части суток и их час по умолчанию
+	partsOfDay = map[string]int{
+		"morning": 9, "утром": 9,
+		"afternoon": 13, "днем": 13,
+		"evening": 19, "вечером": 19,
+		"night": 22, "ночью": 22,
+	}
+	// Уточнение после часа: «9 pm», «9 вечера»
+	meridiems = map[string]string{
+		"am": "am", "a.m": "am", "утра": "am",
+		"pm": "pm", "p.m": "pm", "вечера": "pm", "дня": "pm",
+		"ночи": "night",
+	}
+	// Слова, допустимые между числом и уточнением: «в 9 часов вечера», «at 9 o'clock»
+	hourWords = map[string]bool{"час": true, "часа": true, "часов": true, "o'clock": true, "oclock": true}
+	andWords  = map[string]bool{"and": true, "и": true, "&": true}
+)
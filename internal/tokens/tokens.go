@@ -0,0 +1,26 @@
+// Package tokens выпускает случайные секретные токены и хеширует их для хранения в базе.
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Длина токена в байтах до кодирования
+const tokenBytes = 24
+
+// New возвращает новый случайный токен в шестнадцатеричном виде.
+func New() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Hash возвращает SHA-256 токена. В базе хранится только хеш.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
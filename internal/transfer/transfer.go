@@ -0,0 +1,272 @@
+// Package transfer кодирует напоминания в форматы CSV и NDJSON для резервного
+// копирования и переноса между инсталляциями, и разбирает их обратно построчно.
+package transfer
+
+import (
+	"Reminders/internal/models"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Поддерживаемые форматы
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Максимальная длина строки NDJSON
+const maxLineSize = 1 << 20
+
+// Columns - колонки CSV в порядке выгрузки
+var Columns = []string{
+	"id", "external_id", "user_id", "message", "is_template", "send_at", "time_zone",
+	"recurrence", "sent_count", "is_sent", "created_at", "updated_at",
+}
+
+// Обязательные колонки при загрузке CSV
+var requiredColumns = []string{"user_id", "message", "send_at"}
+
+// ContentType возвращает MIME-тип формата.
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Writer построчно записывает напоминания.
+type Writer interface {
+	Write(r models.Reminder) error
+	Flush() error
+}
+
+// NewWriter возвращает Writer для указанного формата.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{w: bufio.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (cw *csvWriter) Write(r models.Reminder) error {
+	if !cw.headerWritten {
+		if err := cw.w.Write(Columns); err != nil {
+			return err
+		}
+		cw.headerWritten = true
+	}
+
+	externalID := ""
+	if r.ExternalID != nil {
+		externalID = *r.ExternalID
+	}
+	return cw.w.Write([]string{
+		strconv.Itoa(r.ID),
+		externalID,
+		strconv.Itoa(r.UserID),
+		r.Message,
+		strconv.FormatBool(r.IsTemplate),
+		r.SendAt.Format(time.RFC3339),
+		r.TimeZone,
+		r.Recurrence,
+		strconv.Itoa(r.SentCount),
+		strconv.FormatBool(r.IsSent),
+		r.CreatedAt.Format(time.RFC3339),
+		r.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (cw *csvWriter) Flush() error {
+	if !cw.headerWritten {
+		if err := cw.w.Write(Columns); err != nil {
+			return err
+		}
+		cw.headerWritten = true
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type ndjsonWriter struct {
+	w *bufio.Writer
+}
+
+func (nw *ndjsonWriter) Write(r models.Reminder) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	nw.w.Write(data)
+	return nw.w.WriteByte('\n')
+}
+
+func (nw *ndjsonWriter) Flush() error {
+	return nw.w.Flush()
+}
+
+// Row - разобранная строка файла. Если Err не nil, строку нужно пропустить.
+type Row struct {
+	Line     int
+	Reminder models.Reminder
+	Err      error
+}
+
+// Reader построчно читает напоминания. В конце файла возвращает io.EOF.
+type Reader interface {
+	Read() (Row, error)
+}
+
+// NewReader возвращает Reader для указанного формата.
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		return &csvReader{r: cr}, nil
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+		return &ndjsonReader{s: scanner}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func (cr *csvReader) Read() (Row, error) {
+	if cr.columns == nil {
+		if err := cr.readHeader(); err != nil {
+			return Row{}, err
+		}
+	}
+
+	record, err := cr.r.Read()
+	if err == io.EOF {
+		return Row{}, io.EOF
+	}
+	line, _ := cr.r.FieldPos(0)
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Row{Line: parseErr.StartLine, Err: parseErr.Err}, nil
+	}
+	if err != nil {
+		return Row{}, err
+	}
+
+	row := Row{Line: line}
+	row.Reminder, row.Err = cr.parseRecord(record)
+	return row, nil
+}
+
+func (cr *csvReader) readHeader() error {
+	header, err := cr.r.Read()
+	if err == io.EOF {
+		return io.EOF
+	}
+	if err != nil {
+		return fmt.Errorf("invalid header: %w", err)
+	}
+
+	cr.columns = make(map[string]int, len(header))
+	for i, name := range header {
+		cr.columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := cr.columns[name]; !ok {
+			return fmt.Errorf("missing required column %q", name)
+		}
+	}
+	return nil
+}
+
+func (cr *csvReader) parseRecord(record []string) (models.Reminder, error) {
+	var r models.Reminder
+	get := func(name string) string {
+		i, ok := cr.columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	var err error
+	if v := get("id"); v != "" {
+		if r.ID, err = strconv.Atoi(v); err != nil {
+			return r, fmt.Errorf("invalid id %q", v)
+		}
+	}
+	if v := get("external_id"); v != "" {
+		r.ExternalID = &v
+	}
+	if r.UserID, err = strconv.Atoi(get("user_id")); err != nil {
+		return r, fmt.Errorf("invalid user_id %q", get("user_id"))
+	}
+	r.Message = get("message")
+	if v := get("is_template"); v != "" {
+		if r.IsTemplate, err = strconv.ParseBool(v); err != nil {
+			return r, fmt.Errorf("invalid is_template %q", v)
+		}
+	}
+	if r.SendAt, err = time.Parse(time.RFC3339, get("send_at")); err != nil {
+		return r, fmt.Errorf("invalid send_at %q, expected RFC 3339", get("send_at"))
+	}
+	r.TimeZone = get("time_zone")
+	r.Recurrence = get("recurrence")
+	if v := get("sent_count"); v != "" {
+		if r.SentCount, err = strconv.Atoi(v); err != nil || r.SentCount < 0 {
+			return r, fmt.Errorf("invalid sent_count %q", v)
+		}
+	}
+	if v := get("is_sent"); v != "" {
+		if r.IsSent, err = strconv.ParseBool(v); err != nil {
+			return r, fmt.Errorf("invalid is_sent %q", v)
+		}
+	}
+	return r, nil
+}
+
+type ndjsonReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func (nr *ndjsonReader) Read() (Row, error) {
+	for nr.s.Scan() {
+		nr.line++
+		data := bytes.TrimSpace(nr.s.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := Row{Line: nr.line}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&row.Reminder); err != nil {
+			row.Err = fmt.Errorf("invalid JSON: %w", err)
+		}
+		return row, nil
+	}
+	if err := nr.s.Err(); err != nil {
+		return Row{}, fmt.Errorf("line %d: %w", nr.line+1, err)
+	}
+	return Row{}, io.EOF
+}
@@ -0,0 +1,259 @@
+package transfer
+
+import (
+	"Reminders/internal/models"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sample возвращает напоминание со всеми переносимыми полями. Время отправки - в
+// часовом поясе напоминания, чтобы проверить, что смещение не теряется.
+func sample(t *testing.T) models.Reminder {
+	t.Helper()
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	externalID := "backup-1"
+	userID := 3
+	return models.Reminder{
+		ID:         7,
+		ExternalID: &externalID,
+		UserID:     2,
+		Message:    "Полить цветы, \"фикус\"\nи кактус",
+		IsTemplate: true,
+		SendAt:     time.Date(2030, 3, 4, 9, 30, 0, 0, moscow),
+		TimeZone:   "Europe/Moscow",
+		Recurrence: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+		SentCount:  2,
+		Tags:       []models.Tag{{ID: 5, UserID: 2, Name: "дом"}, {ID: 6, UserID: 2, Name: "растения"}},
+		Recipients: []models.ReminderRecipient{
+			{ID: 1, Kind: models.RecipientUser, UserID: &userID},
+			{ID: 2, Kind: models.RecipientChat, ChatID: -1001234567890},
+		},
+		CreatedAt: time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2030, 1, 2, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// roundTrip записывает напоминания в формате format и читает их обратно.
+func roundTrip(t *testing.T, format string, reminders ...models.Reminder) []models.Reminder {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range reminders {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readAll(t, format, buf.String())
+	result := make([]models.Reminder, len(rows))
+	for i, row := range rows {
+		if row.Err != nil {
+			t.Fatalf("line %d: %v\n%s", row.Line, row.Err, buf.String())
+		}
+		result[i] = row.Reminder
+	}
+	return result
+}
+
+func readAll(t *testing.T, format, data string) []Row {
+	t.Helper()
+	r, err := NewReader(format, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []Row
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return rows
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+// checkTime сравнивает момент и смещение часового пояса.
+func checkTime(t *testing.T, name string, got, want time.Time) {
+	t.Helper()
+	_, gotOffset := got.Zone()
+	_, wantOffset := want.Zone()
+	if !got.Equal(want) || gotOffset != wantOffset {
+		t.Errorf("%s = %s, want %s", name, got.Format(time.RFC3339), want.Format(time.RFC3339))
+	}
+}
+
+// NDJSON переносит напоминание целиком, с метками и получателями.
+func TestRoundTripNDJSON(t *testing.T) {
+	want := sample(t)
+	got := roundTrip(t, FormatNDJSON, want)
+	if len(got) != 1 {
+		t.Fatalf("read %d reminders, want 1", len(got))
+	}
+	r := got[0]
+
+	checkTime(t, "send_at", r.SendAt, want.SendAt)
+	checkTime(t, "created_at", r.CreatedAt, want.CreatedAt)
+	if r.ExternalID == nil || *r.ExternalID != *want.ExternalID {
+		t.Errorf("external_id = %v, want %q", r.ExternalID, *want.ExternalID)
+	}
+	if r.Message != want.Message || r.TimeZone != want.TimeZone || r.Recurrence != want.Recurrence ||
+		r.IsTemplate != want.IsTemplate || r.SentCount != want.SentCount || r.UserID != want.UserID {
+		t.Errorf("read %+v, want %+v", r, want)
+	}
+	var tags []string
+	for _, tag := range r.Tags {
+		tags = append(tags, tag.Name)
+	}
+	if !reflect.DeepEqual(tags, []string{"дом", "растения"}) {
+		t.Errorf("tags = %v", tags)
+	}
+	if len(r.Recipients) != 2 ||
+		r.Recipients[0].Kind != models.RecipientUser || r.Recipients[0].UserID == nil || *r.Recipients[0].UserID != 3 ||
+		r.Recipients[1].Kind != models.RecipientChat || r.Recipients[1].ChatID != -1001234567890 {
+		t.Errorf("recipients = %+v", r.Recipients)
+	}
+}
+
+// CSV переносит колонки из Columns: метки и получатели в него не входят.
+func TestRoundTripCSV(t *testing.T) {
+	want := sample(t)
+	plain := models.Reminder{UserID: 1, Message: "Без повторения", SendAt: time.Date(2030, 5, 1, 8, 0, 0, 0, time.UTC)}
+	got := roundTrip(t, FormatCSV, want, plain)
+	if len(got) != 2 {
+		t.Fatalf("read %d reminders, want 2", len(got))
+	}
+	r := got[0]
+
+	checkTime(t, "send_at", r.SendAt, want.SendAt)
+	if r.ID != want.ID || r.ExternalID == nil || *r.ExternalID != *want.ExternalID {
+		t.Errorf("id = %d, external_id = %v", r.ID, r.ExternalID)
+	}
+	if r.Message != want.Message || r.TimeZone != want.TimeZone || r.Recurrence != want.Recurrence ||
+		r.IsTemplate != want.IsTemplate || r.SentCount != want.SentCount || r.UserID != want.UserID {
+		t.Errorf("read %+v, want %+v", r, want)
+	}
+	if len(r.Tags) != 0 || len(r.Recipients) != 0 {
+		t.Errorf("csv row with tags %v and recipients %v", r.Tags, r.Recipients)
+	}
+	if p := got[1]; p.ExternalID != nil || p.Recurrence != "" || p.TimeZone != "" || !p.SendAt.Equal(plain.SendAt) {
+		t.Errorf("second row %+v", p)
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Join(Columns, ",") + "\n"; buf.String() != want {
+		t.Errorf("empty export %q, want only the header", buf.String())
+	}
+}
+
+// Ошибочные строки возвращаются с номером строки, а чтение продолжается.
+func TestReadMalformedRows(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		data   string
+		// lines - номера строк, ошибки - подстроки ошибок; пустая ошибка у правильной строки
+		lines  []int
+		errors []string
+	}{
+		{
+			name:   "csv_bad_values",
+			format: FormatCSV,
+			data: "user_id,message,send_at,sent_count,is_sent\n" +
+				"x,a,2030-01-01T09:00:00Z,,\n" +
+				"1,b,2030-01-01 09:00,,\n" +
+				"1,c,2030-01-01T09:00:00Z,-1,\n" +
+				"1,d,2030-01-01T09:00:00Z,,maybe\n" +
+				"1,e,2030-01-01T09:00:00+03:00,0,false\n",
+			lines:  []int{2, 3, 4, 5, 6},
+			errors: []string{`invalid user_id "x"`, "expected RFC 3339", `invalid sent_count "-1"`, `invalid is_sent "maybe"`, ""},
+		},
+		{
+			name:   "csv_bare_quote",
+			format: FormatCSV,
+			data:   "user_id,message,send_at\n1,say \"hi\",2030-01-01T09:00:00Z\n1,ok,2030-01-01T09:00:00Z\n",
+			lines:  []int{2, 3},
+			errors: []string{"bare \"", ""},
+		},
+		{
+			name:   "csv_bom_and_spaces_in_header",
+			format: FormatCSV,
+			data:   "\ufeffuser_id, message ,send_at\n1,ok,2030-01-01T09:00:00Z\n",
+			lines:  []int{2},
+			errors: []string{""},
+		},
+		{
+			name:   "ndjson_bad_lines",
+			format: FormatNDJSON,
+			data: `{"user_id": 1, "message": "a", "send_at": "2030-01-01T09:00:00Z"}` + "\n" +
+				"\n" +
+				`{"user_id": 1, "message": ` + "\n" +
+				`{"user_id": 1, "message": "c", "send_at": "2030-01-01T09:00:00Z", "colour": "red"}` + "\n" +
+				`{"user_id": "1", "message": "d", "send_at": "2030-01-01T09:00:00Z"}` + "\n",
+			lines:  []int{1, 3, 4, 5},
+			errors: []string{"", "invalid JSON", `unknown field "colour"`, "invalid JSON"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rows := readAll(t, c.format, c.data)
+			if len(rows) != len(c.lines) {
+				t.Fatalf("read %d rows, want %d: %+v", len(rows), len(c.lines), rows)
+			}
+			for i, row := range rows {
+				if row.Line != c.lines[i] {
+					t.Errorf("row %d on line %d, want %d", i, row.Line, c.lines[i])
+				}
+				switch {
+				case c.errors[i] == "" && row.Err != nil:
+					t.Errorf("line %d: unexpected error %v", row.Line, row.Err)
+				case c.errors[i] != "" && (row.Err == nil || !strings.Contains(row.Err.Error(), c.errors[i])):
+					t.Errorf("line %d: error %v, want %q", row.Line, row.Err, c.errors[i])
+				}
+			}
+		})
+	}
+}
+
+// Файл без обязательной колонки отклоняется целиком.
+func TestReadMissingColumn(t *testing.T) {
+	r, err := NewReader(FormatCSV, strings.NewReader("user_id,message\n1,a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(); err == nil || !strings.Contains(err.Error(), `missing required column "send_at"`) {
+		t.Errorf("error %v, want the missing send_at column", err)
+	}
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	if _, err := NewReader("xml", strings.NewReader("")); err == nil {
+		t.Error("NewReader accepted xml")
+	}
+	if _, err := NewWriter("xml", io.Discard); err == nil {
+		t.Error("NewWriter accepted xml")
+	}
+}
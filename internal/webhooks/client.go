@@ -0,0 +1,53 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress - адрес вебхука ведёт во внутреннюю сеть.
+var ErrPrivateAddress = errors.New("webhook address is not public")
+
+// Сколько ждать установки соединения с получателем
+const dialTimeout = 5 * time.Second
+
+// Сеть 100.64.0.0/10 для NAT операторов связи, IsPrivate её не включает
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// NewClient возвращает клиент для доставки вебхуков. Клиент соединяется только
+// с публичными адресами: проверяется адрес, в который разрешилось имя, поэтому
+// имя, указывающее на loopback или частную сеть, и перенаправления туда тоже отклоняются.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: dialTimeout, Control: checkDialAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Через прокси проверялся бы адрес прокси, а не получателя
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// PublicIP сообщает, что адрес глобальный: не loopback, не частная, link-local,
+// multicast или неуказанная сеть.
+func PublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() && !sharedAddressSpace.Contains(ip) &&
+		!(ip.To4() != nil && ip.To4()[0] == 0)
+}
+
+// checkDialAddress не даёт соединиться с непубличным адресом.
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !PublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+// Package webhooks публикует события жизненного цикла напоминаний и доставляет их
+// подписанным JSON-запросами на адреса подписок.
+package webhooks
+
+import (
+	"Reminders/internal/database"
+	"Reminders/internal/models"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Заголовки запроса вебхука
+const (
+	HeaderEvent     = "X-Reminders-Event"
+	HeaderDelivery  = "X-Reminders-Delivery"
+	HeaderTimestamp = "X-Reminders-Timestamp"
+	// HeaderSignature - "sha256=" и HMAC-SHA256 строки "<timestamp>.<тело>" в шестнадцатеричном виде
+	HeaderSignature = "X-Reminders-Signature"
+)
+
+const (
+	// Максимальное число попыток доставки
+	MaxAttempts = 8
+	// Задержка перед второй попыткой, дальше она удваивается
+	baseBackoff = 30 * time.Second
+	// Максимальная задержка между попытками
+	maxBackoff = 6 * time.Hour
+	// Сколько доставок выполняется за один проход
+	batchSize = 100
+	// Сколько подписок получают доставки одновременно
+	maxParallel = 8
+	// Сколько байт ответа сохраняется в журнале при ошибке
+	maxErrorBody = 512
+	// На сколько проход откладывает взятые доставки, чтобы их не взял другой экземпляр бота.
+	// Попытка сама назначает следующее время, а если процесс упадёт, доставки вернутся
+	// в очередь по истечении этого срока
+	claimLease = 30 * time.Minute
+)
+
+// ErrDisabled - доставка в отключённую подписку.
+var ErrDisabled = errors.New("webhook is disabled")
+
+// Payload - тело запроса вебхука и события потока изменений.
+type Payload struct {
+	ID         int             `json:"id"`
+	Type       string          `json:"type" example:"reminder.sent"`
+	ReminderID int             `json:"reminder_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Data       json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+// NewPayload возвращает тело запроса для события.
+func NewPayload(event models.ReminderEvent) Payload {
+	return Payload{
+		ID:         event.ID,
+		Type:       event.Type,
+		ReminderID: event.ReminderID,
+		CreatedAt:  event.CreatedAt,
+		Data:       event.Data,
+	}
+}
+
+// Publish записывает событие напоминания и ставит его в очередь доставки всем активным
+// подпискам владельца на этот тип событий. Вызывается в транзакции, изменившей напоминание.
+func Publish(tx *gorm.DB, eventType string, r models.Reminder) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	event := models.ReminderEvent{
+		UserID:     r.UserID,
+		ReminderID: r.ID,
+		Type:       eventType,
+		Data:       data,
+		CreatedAt:  time.Now(),
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return err
+	}
+
+	var hooks []models.Webhook
+	if err := tx.Where("user_id = ? AND active = ?", r.UserID, true).Find(&hooks).Error; err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		if !hook.Subscribed(eventType) {
+			continue
+		}
+		if _, err := Enqueue(tx, hook.ID, event.ID, event.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Enqueue ставит событие в очередь доставки подписке.
+func Enqueue(tx *gorm.DB, webhookID, eventID int, at time.Time) (models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       eventID,
+		Status:        models.WebhookPending,
+		NextAttemptAt: &at,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	return delivery, tx.Create(&delivery).Error
+}
+
+// PruneEvents удаляет из журнала события, записанные раньше before, вместе с их
+// доставками. События с доставками, ожидающими повторной попытки, остаются.
+func PruneEvents(db *gorm.DB, before time.Time) (int64, error) {
+	pending := db.Model(&models.WebhookDelivery{}).Select("event_id").Where("status = ?", models.WebhookPending)
+	result := db.Where("created_at < ? AND id NOT IN (?)", before, pending).Delete(&models.ReminderEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// Sign возвращает подпись тела запроса для заголовка HeaderSignature.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff возвращает задержку перед следующей попыткой после attempts неудачных.
+func Backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+// Dispatcher доставляет события из очереди. Client должен быть из NewClient,
+// чтобы вебхук не мог обратиться во внутреннюю сеть.
+type Dispatcher struct {
+	DB     *gorm.DB
+	Client *http.Client
+}
+
+// DeliverDue выполняет доставки, время попытки которых наступило, и возвращает число
+// попыток. Доставки одной подписки идут по очереди, разные подписки обслуживаются
+// параллельно, не больше maxParallel сразу. После неудачной попытки остальные доставки
+// подписки ждут следующего прохода, чтобы недоступный получатель не задерживал очередь.
+// Несколько экземпляров бота могут вызывать DeliverDue одновременно: доставки берутся
+// через claimDue, и каждую выполняет один экземпляр.
+func (d Dispatcher) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := d.claimDue(now)
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+
+	var order []int
+	byWebhook := make(map[int][]*models.WebhookDelivery)
+	for i := range due {
+		id := due[i].WebhookID
+		if _, ok := byWebhook[id]; !ok {
+			order = append(order, id)
+		}
+		byWebhook[id] = append(byWebhook[id], &due[i])
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		attempted int
+		firstErr  error
+		// Доставки подписок, на которых проход остановился после неудачи
+		skipped []*models.WebhookDelivery
+	)
+	slots := make(chan struct{}, maxParallel)
+	for _, id := range order {
+		deliveries := byWebhook[id]
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			for i, delivery := range deliveries {
+				err := d.Deliver(ctx, delivery)
+				mu.Lock()
+				attempted++
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				if err != nil || delivery.Status == models.WebhookPending {
+					mu.Lock()
+					skipped = append(skipped, deliveries[i+1:]...)
+					mu.Unlock()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Доставки, до которых проход не дошёл, возвращаются в очередь с прежним временем
+	for _, delivery := range skipped {
+		err := d.DB.Model(delivery).Update("next_attempt_at", delivery.NextAttemptAt).Error
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return attempted, firstErr
+}
+
+// claimDue берёт доставки, время попытки которых наступило, и откладывает их на claimLease.
+// Строки, которые в это время берёт другой экземпляр бота, пропускаются. У возвращаемых
+// доставок остаётся прежнее время попытки.
+func (d Dispatcher) claimDue(now time.Time) ([]models.WebhookDelivery, error) {
+	var claimed []models.WebhookDelivery
+	err := d.DB.Transaction(func(tx *gorm.DB) error {
+		err := database.SkipLocked(tx.Select("id", "next_attempt_at")).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookPending, now).
+			Order("next_attempt_at, id").
+			Limit(batchSize).
+			Find(&claimed).Error
+		if err != nil || len(claimed) == 0 {
+			return err
+		}
+		ids := make([]int, len(claimed))
+		for i, delivery := range claimed {
+			ids[i] = delivery.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(claimLease)).Error
+	})
+	if err != nil || len(claimed) == 0 {
+		return nil, err
+	}
+
+	ids := make([]int, len(claimed))
+	for i, delivery := range claimed {
+		ids[i] = delivery.ID
+	}
+	var loaded []models.WebhookDelivery
+	if err := d.DB.Preload("Webhook").Preload("Event").Where("id IN ?", ids).Find(&loaded).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[int]models.WebhookDelivery, len(loaded))
+	for _, delivery := range loaded {
+		byID[delivery.ID] = delivery
+	}
+	due := make([]models.WebhookDelivery, 0, len(claimed))
+	for _, c := range claimed {
+		delivery, ok := byID[c.ID]
+		if !ok {
+			continue
+		}
+		delivery.NextAttemptAt = c.NextAttemptAt
+		due = append(due, delivery)
+	}
+	return due, nil
+}
+
+// Deliver выполняет одну попытку доставки и сохраняет её результат. При неудаче следующая
+// попытка планируется с экспоненциальной задержкой, после MaxAttempts доставка считается
+// неудавшейся. Возвращаемая ошибка относится только к сохранению результата.
+func (d Dispatcher) Deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseCode, delivery.Error = 0, ""
+
+	var code int
+	err := ErrDisabled
+	// Подписку могли отключить после постановки события в очередь
+	if delivery.Webhook == nil || delivery.Webhook.Active {
+		code, err = d.post(ctx, *delivery)
+	}
+	delivery.ResponseCode = code
+	switch {
+	case errors.Is(err, ErrDisabled):
+		delivery.Status = models.WebhookFailed
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = nil
+	case err == nil:
+		delivery.Status = models.WebhookDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= MaxAttempts:
+		delivery.Status = models.WebhookFailed
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(Backoff(delivery.Attempts))
+		delivery.Status = models.WebhookPending
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = &next
+	}
+	delivery.UpdatedAt = now
+
+	return d.DB.Model(delivery).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"response_code":   delivery.ResponseCode,
+		"error":           delivery.Error,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"delivered_at":    delivery.DeliveredAt,
+		"updated_at":      delivery.UpdatedAt,
+	}).Error
+}
+
+// post отправляет событие на адрес подписки. Успешным считается любой ответ 2xx.
+func (d Dispatcher) post(ctx context.Context, delivery models.WebhookDelivery) (int, error) {
+	if delivery.Webhook == nil || delivery.Event == nil {
+		return 0, fmt.Errorf("webhook or event of delivery %d not found", delivery.ID)
+	}
+	body, err := json.Marshal(NewPayload(*delivery.Event))
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Reminders-Webhook/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event.Type)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Webhook.Secret, timestamp, body))
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,395 @@
+package webhooks
+
+import (
+	"Reminders/internal/models"
+	"Reminders/internal/testdb"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// receiver - получатель вебхуков, отвечающий по очереди кодами из statuses
+// (последний код повторяется) и запоминающий запросы.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	rc.requests = append(rc.requests, receivedRequest{header: r.Header.Clone(), body: body})
+	status := rc.statuses[0]
+	if len(rc.statuses) > 1 {
+		rc.statuses = rc.statuses[1:]
+	}
+	rc.mu.Unlock()
+	w.WriteHeader(status)
+	w.Write([]byte("receiver says hi"))
+}
+
+// newWebhook создаёт пользователя и подписку на адрес url.
+func newWebhook(t *testing.T, db *gorm.DB, url string) models.Webhook {
+	t.Helper()
+	user := models.User{DisplayName: "webhook owner"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	hook := models.Webhook{UserID: user.ID, URL: url, Secret: "s3cret", Events: models.StringList{models.EventReminderSent}, Active: true}
+	if err := db.Create(&hook).Error; err != nil {
+		t.Fatal(err)
+	}
+	return hook
+}
+
+// enqueue ставит в очередь подписки событие отправки напоминания reminderID.
+func enqueue(t *testing.T, db *gorm.DB, hook models.Webhook, reminderID int, at time.Time) models.WebhookDelivery {
+	t.Helper()
+	event := models.ReminderEvent{UserID: hook.UserID, ReminderID: reminderID, Type: models.EventReminderSent,
+		Data: json.RawMessage(`{"id":` + strconv.Itoa(reminderID) + `}`), CreatedAt: at}
+	if err := db.Create(&event).Error; err != nil {
+		t.Fatal(err)
+	}
+	delivery, err := Enqueue(db, hook.ID, event.ID, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return delivery
+}
+
+func reload(t *testing.T, db *gorm.DB, id int) models.WebhookDelivery {
+	t.Helper()
+	var delivery models.WebhookDelivery
+	if err := db.First(&delivery, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return delivery
+}
+
+func TestDeliverSignsRequest(t *testing.T) {
+	db := testdb.Open(t)
+	rc := &receiver{statuses: []int{http.StatusNoContent}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	hook := newWebhook(t, db, srv.URL)
+	delivery := enqueue(t, db, hook, 7, time.Now())
+
+	d := Dispatcher{DB: db, Client: srv.Client()}
+	if n, err := d.DeliverDue(context.Background(), time.Now()); err != nil || n != 1 {
+		t.Fatalf("DeliverDue = %d, %v; want 1 attempt", n, err)
+	}
+	if got := reload(t, db, delivery.ID); got.Status != models.WebhookDelivered || got.ResponseCode != http.StatusNoContent {
+		t.Fatalf("delivery %+v, want delivered with 204", got)
+	}
+
+	req := rc.requests[0]
+	if got := req.header.Get(HeaderEvent); got != models.EventReminderSent {
+		t.Errorf("%s = %q", HeaderEvent, got)
+	}
+	if got := req.header.Get(HeaderDelivery); got != strconv.Itoa(delivery.ID) {
+		t.Errorf("%s = %q, want %d", HeaderDelivery, got, delivery.ID)
+	}
+	timestamp, err := strconv.ParseInt(req.header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", HeaderTimestamp, err)
+	}
+	// Получатель проверяет подпись тем же секретом
+	if got, want := req.header.Get(HeaderSignature), Sign("s3cret", timestamp, req.body); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+	if got := Sign("other", timestamp, req.body); got == req.header.Get(HeaderSignature) {
+		t.Error("signature does not depend on the secret")
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.ReminderID != 7 || payload.Type != models.EventReminderSent || string(payload.Data) != `{"id":7}` {
+		t.Errorf("payload %+v", payload)
+	}
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	db := testdb.Open(t)
+	rc := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusOK}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	hook := newWebhook(t, db, srv.URL)
+	delivery := enqueue(t, db, hook, 1, time.Now())
+	d := Dispatcher{DB: db, Client: srv.Client()}
+
+	before := time.Now()
+	if _, err := d.DeliverDue(context.Background(), before); err != nil {
+		t.Fatal(err)
+	}
+	got := reload(t, db, delivery.ID)
+	if got.Status != models.WebhookPending || got.Attempts != 1 || got.ResponseCode != http.StatusInternalServerError {
+		t.Fatalf("after failure: %+v", got)
+	}
+	if got.Error != "unexpected status 500: receiver says hi" {
+		t.Errorf("error = %q", got.Error)
+	}
+	if got.NextAttemptAt == nil || got.NextAttemptAt.Before(before.Add(baseBackoff)) || got.NextAttemptAt.After(time.Now().Add(baseBackoff)) {
+		t.Fatalf("next attempt at %v, want %s after the attempt", got.NextAttemptAt, baseBackoff)
+	}
+
+	// До следующей попытки доставка не повторяется
+	if n, err := d.DeliverDue(context.Background(), time.Now()); err != nil || n != 0 {
+		t.Fatalf("DeliverDue before backoff = %d, %v; want 0", n, err)
+	}
+	if n, err := d.DeliverDue(context.Background(), got.NextAttemptAt.Add(time.Second)); err != nil || n != 1 {
+		t.Fatalf("DeliverDue after backoff = %d, %v; want 1", n, err)
+	}
+	got = reload(t, db, delivery.ID)
+	if got.Status != models.WebhookDelivered || got.Attempts != 2 || got.Error != "" || got.NextAttemptAt != nil {
+		t.Errorf("after retry: %+v", got)
+	}
+}
+
+func TestDeliverGivesUpAfterMaxAttempts(t *testing.T) {
+	db := testdb.Open(t)
+	srv := httptest.NewServer(&receiver{statuses: []int{http.StatusBadGateway}})
+	defer srv.Close()
+	hook := newWebhook(t, db, srv.URL)
+	delivery := enqueue(t, db, hook, 1, time.Now())
+	if err := db.Model(&delivery).Update("attempts", MaxAttempts-1).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	d := Dispatcher{DB: db, Client: srv.Client()}
+	if _, err := d.DeliverDue(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := reload(t, db, delivery.ID); got.Status != models.WebhookFailed || got.Attempts != MaxAttempts || got.NextAttemptAt != nil {
+		t.Errorf("after last attempt: %+v", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		7:  32 * time.Minute,
+		10: 4*time.Hour + 16*time.Minute,
+		11: maxBackoff,
+		50: maxBackoff,
+	}
+	for attempts, want := range cases {
+		if got := Backoff(attempts); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+// Недоступный получатель не задерживает доставки другим подпискам, и после первой
+// неудачи его остальные доставки ждут следующего прохода.
+func TestDeliverDueByWebhook(t *testing.T) {
+	db := testdb.Open(t)
+	fastHit := make(chan struct{})
+	var once sync.Once
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Отвечает только после запроса к другой подписке, то есть если доставки идут параллельно
+		select {
+		case <-fastHit:
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(fastHit) })
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fast.Close()
+
+	down := newWebhook(t, db, slow.URL)
+	up := newWebhook(t, db, fast.URL)
+	at := time.Now().Add(-time.Minute)
+	var downIDs, upIDs []int
+	for i := 0; i < 3; i++ {
+		downIDs = append(downIDs, enqueue(t, db, down, i, at).ID)
+		upIDs = append(upIDs, enqueue(t, db, up, i, at.Add(time.Second)).ID)
+	}
+
+	d := Dispatcher{DB: db, Client: http.DefaultClient}
+	started := time.Now()
+	n, err := d.DeliverDue(context.Background(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed > 4*time.Second {
+		t.Errorf("deliveries to different webhooks ran sequentially (%s)", elapsed)
+	}
+	if n != 4 {
+		t.Errorf("DeliverDue = %d attempts, want 4", n)
+	}
+	for _, id := range upIDs {
+		if got := reload(t, db, id); got.Status != models.WebhookDelivered {
+			t.Errorf("delivery %d to the working webhook: %s", id, got.Status)
+		}
+	}
+	if got := reload(t, db, downIDs[0]); got.Attempts != 1 || got.ResponseCode != http.StatusServiceUnavailable {
+		t.Errorf("first delivery to the failing webhook: %+v", got)
+	}
+	for _, id := range downIDs[1:] {
+		got := reload(t, db, id)
+		if got.Attempts != 0 || got.Status != models.WebhookPending {
+			t.Errorf("delivery %d after a failure must wait for the next pass: %+v", id, got)
+		}
+		// Взятая, но не выполненная доставка возвращается в очередь с прежним временем
+		if got.NextAttemptAt == nil || got.NextAttemptAt.After(at) {
+			t.Errorf("delivery %d next attempt at %v, want %v", id, got.NextAttemptAt, at)
+		}
+	}
+}
+
+// Доставку, которую взял один проход, другой проход не выполняет.
+func TestClaimDueTakesEachDeliveryOnce(t *testing.T) {
+	db := testdb.Open(t)
+	hook := newWebhook(t, db, "https://example.com/hook")
+	now := time.Now()
+	first := enqueue(t, db, hook, 1, now.Add(-time.Minute))
+	second := enqueue(t, db, hook, 2, now.Add(-time.Second))
+	d := Dispatcher{DB: db}
+
+	due, err := d.claimDue(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 2 || due[0].ID != first.ID || due[1].ID != second.ID {
+		t.Fatalf("claimed %+v, want deliveries %d and %d in order", due, first.ID, second.ID)
+	}
+	if due[0].Webhook == nil || due[0].Event == nil {
+		t.Error("claimed delivery without webhook or event")
+	}
+	if due[0].NextAttemptAt == nil || due[0].NextAttemptAt.After(now) {
+		t.Errorf("claimed delivery keeps next attempt at %v, want the original time", due[0].NextAttemptAt)
+	}
+	if got := reload(t, db, first.ID); got.NextAttemptAt == nil || got.NextAttemptAt.Before(now.Add(claimLease-time.Second)) {
+		t.Errorf("claimed delivery postponed to %v, want %s later", got.NextAttemptAt, claimLease)
+	}
+	if again, err := d.claimDue(now); err != nil || len(again) != 0 {
+		t.Errorf("second claim = %d deliveries, %v; want none", len(again), err)
+	}
+	if again, err := d.claimDue(now.Add(claimLease + time.Second)); err != nil || len(again) != 2 {
+		t.Errorf("claim after the lease = %d deliveries, %v; want 2", len(again), err)
+	}
+}
+
+// Пока одна транзакция держит доставку, другая её пропускает, а не ждёт.
+func TestClaimDueSkipsLockedRows(t *testing.T) {
+	if !testdb.IsPostgres() {
+		t.Skip("row locks need TEST_DATABASE_URL")
+	}
+	db := testdb.Open(t)
+	hook := newWebhook(t, db, "https://example.com/hook")
+	now := time.Now()
+	locked := enqueue(t, db, hook, 1, now.Add(-time.Minute))
+	free := enqueue(t, db, hook, 2, now.Add(-time.Second))
+
+	tx := db.Begin()
+	defer tx.Rollback()
+	if err := tx.Exec("SELECT id FROM webhook_deliveries WHERE id = ? FOR UPDATE", locked.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan []models.WebhookDelivery)
+	go func() {
+		due, err := Dispatcher{DB: db}.claimDue(now)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- due
+	}()
+	select {
+	case due := <-done:
+		if len(due) != 1 || due[0].ID != free.ID {
+			t.Errorf("claimed %+v, want only delivery %d", due, free.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("claim waits for the locked row")
+	}
+}
+
+func TestPruneEvents(t *testing.T) {
+	db := testdb.Open(t)
+	hook := newWebhook(t, db, "https://example.com/hook")
+	now := time.Now()
+	old := enqueue(t, db, hook, 1, now.Add(-48*time.Hour))
+	if err := db.Model(&old).Update("status", models.WebhookDelivered).Error; err != nil {
+		t.Fatal(err)
+	}
+	retrying := enqueue(t, db, hook, 2, now.Add(-48*time.Hour))
+	recent := enqueue(t, db, hook, 3, now)
+
+	n, err := PruneEvents(db, now.Add(-24*time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("PruneEvents = %d, %v; want 1", n, err)
+	}
+	var events []models.ReminderEvent
+	if err := db.Order("id").Find(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].ID != retrying.EventID || events[1].ID != recent.EventID {
+		t.Errorf("events left: %+v, want the pending and the recent one", events)
+	}
+	var deliveries int64
+	if err := db.Model(&models.WebhookDelivery{}).Where("id = ?", old.ID).Count(&deliveries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if deliveries != 0 {
+		t.Error("delivery of a pruned event is kept")
+	}
+}
+
+func TestNewClientRejectsPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(&receiver{statuses: []int{http.StatusOK}})
+	defer srv.Close()
+
+	_, err := NewClient(time.Second).Get(srv.URL)
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("request to %s: %v, want %v", srv.URL, err, ErrPrivateAddress)
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::248": true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"fd00::1":              false,
+		"fe80::1":              false,
+		"::ffff:127.0.0.1":     false,
+		"224.0.0.1":            false,
+	}
+	for addr, want := range cases {
+		if got := PublicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("PublicIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
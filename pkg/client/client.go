@@ -0,0 +1,250 @@
+// Package client - клиент API сервиса напоминаний с типизированными методами для каждого
+// маршрута. Запросы принимают context, POST-запросы повторяются с тем же Idempotency-Key,
+// поэтому повтор после обрыва связи не создаёт дубликатов. Ошибки API возвращаются как
+// *APIError и сравниваются с ErrNotFound, ErrBadRequest и другими через errors.Is.
+//
+//	c := client.New("http://localhost:8080", os.Getenv("REMINDERS_API_KEY"))
+//	r, err := c.CreateReminder(ctx, client.Reminder{UserID: 1, Message: "Позвонить", SendAt: at})
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// IdempotencyHeader - заголовок, по которому сервер узнаёт повтор POST-запроса
+	IdempotencyHeader = "Idempotency-Key"
+
+	// Значения по умолчанию для New
+	defaultTimeout      = 30 * time.Second
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 500 * time.Millisecond
+	// Максимальная пауза между повторами
+	maxRetryBackoff = 30 * time.Second
+)
+
+// Client - клиент API. Поля можно менять до первого запроса.
+type Client struct {
+	// BaseURL - адрес сервера без завершающего слеша, например http://localhost:8080
+	BaseURL string
+	// APIKey передаётся в заголовке Authorization: Bearer и нужен для потока изменений
+	// и ключей API, для которых подходит и токен администратора ADMIN_TOKEN
+	APIKey string
+	// HTTPClient выполняет запросы. Его Timeout не действует на поток изменений
+	HTTPClient *http.Client
+	// MaxRetries - сколько раз повторить запрос после сетевой ошибки, 429 или 5xx
+	MaxRetries int
+	// RetryBackoff - пауза перед первым повтором, дальше она удваивается
+	RetryBackoff time.Duration
+}
+
+// New возвращает клиент сервера baseURL. Пустой apiKey допустим для маршрутов без авторизации.
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:      strings.TrimRight(baseURL, "/"),
+		APIKey:       apiKey,
+		HTTPClient:   &http.Client{Timeout: defaultTimeout},
+		MaxRetries:   defaultMaxRetries,
+		RetryBackoff: defaultRetryBackoff,
+	}
+}
+
+// request - запрос к API. Тело хранится целиком, чтобы его можно было отправить повторно.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	contentType string
+	body        []byte
+	accept      string
+}
+
+// jsonRequest готовит запрос с телом в JSON. Нулевое тело не отправляется.
+func jsonRequest(method, path string, query url.Values, in interface{}) (request, error) {
+	req := request{method: method, path: path, query: query, accept: "application/json"}
+	if in == nil {
+		return req, nil
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return req, fmt.Errorf("encode request: %w", err)
+	}
+	req.body, req.contentType = body, "application/json"
+	return req, nil
+}
+
+// doJSON выполняет запрос с телом in и разбирает ответ 2xx в out.
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	req, err := jsonRequest(method, path, query, in)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, out)
+}
+
+// do выполняет запрос и разбирает ответ 2xx в out, если out не nil.
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s %s response: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// send выполняет запрос с повторами и возвращает ответ 2xx. Остальные ответы
+// превращаются в *APIError. Тело ответа закрывает вызывающий.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	return c.sendWith(ctx, c.HTTPClient, req)
+}
+
+func (c *Client) sendWith(ctx context.Context, httpClient *http.Client, req request) (*http.Response, error) {
+	// Ключ один на все попытки, иначе сервер не узнает повтор
+	var idempotencyKey string
+	if req.method == http.MethodPost {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			return nil, err
+		}
+		idempotencyKey = key
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, httpClient, req, idempotencyKey)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			return resp, nil
+		}
+
+		var wait time.Duration
+		if err == nil {
+			err = newAPIError(resp)
+			wait = retryAfter(resp)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= c.MaxRetries || !retryable(err) {
+			return nil, err
+		}
+
+		if wait == 0 {
+			wait = c.backoff(attempt)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt отправляет запрос один раз.
+func (c *Client) attempt(ctx context.Context, httpClient *http.Client, req request, idempotencyKey string) (*http.Response, error) {
+	target := c.BaseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if req.accept != "" {
+		httpReq.Header.Set("Accept", req.accept)
+	}
+	if c.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	if idempotencyKey != "" {
+		httpReq.Header.Set(IdempotencyHeader, idempotencyKey)
+	}
+	return httpClient.Do(httpReq)
+}
+
+// retryable сообщает, имеет ли смысл повторить запрос: сетевые ошибки, 429, 5xx и 409
+// от ещё выполняющегося запроса с тем же Idempotency-Key.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch {
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		return true
+	case apiErr.StatusCode == http.StatusConflict:
+		return strings.Contains(apiErr.Message, IdempotencyHeader)
+	case apiErr.StatusCode == http.StatusNotImplemented:
+		return false
+	}
+	return apiErr.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff возвращает паузу перед повтором attempt: удвоение с разбросом до половины паузы.
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := float64(c.RetryBackoff) * math.Pow(2, float64(attempt))
+	if wait > float64(maxRetryBackoff) {
+		wait = float64(maxRetryBackoff)
+	}
+	return time.Duration(wait/2 + mathrand.Float64()*wait/2)
+}
+
+// retryAfter читает паузу из заголовка Retry-After в секундах.
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	wait := time.Duration(seconds) * time.Second
+	if wait > maxRetryBackoff {
+		wait = maxRetryBackoff
+	}
+	return wait
+}
+
+// newIdempotencyKey возвращает случайный ключ запроса.
+func newIdempotencyKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate idempotency key: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// userQuery возвращает параметр user_id, если пользователь задан.
+func userQuery(userID int) url.Values {
+	if userID <= 0 {
+		return nil
+	}
+	return url.Values{"user_id": {strconv.Itoa(userID)}}
+}
@@ -0,0 +1,255 @@
+package client_test
+
+import (
+	"Reminders/pkg/client"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder запоминает запросы к тестовому серверу и отвечает через respond с номером попытки.
+type recorder struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	respond  func(w http.ResponseWriter, r *http.Request, attempt int)
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	rec.requests = append(rec.requests, r)
+	attempt := len(rec.requests)
+	rec.mu.Unlock()
+	rec.respond(w, r, attempt)
+}
+
+func (rec *recorder) calls() []*http.Request {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]*http.Request(nil), rec.requests...)
+}
+
+// newTestClient поднимает сервер с обработчиком respond и клиент к нему с короткими паузами.
+func newTestClient(t *testing.T, respond func(w http.ResponseWriter, r *http.Request, attempt int)) (*client.Client, *recorder) {
+	t.Helper()
+	rec := &recorder{respond: respond}
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+	c := client.New(srv.URL, "test-key")
+	c.RetryBackoff = time.Millisecond
+	return c, rec
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestRetriesServerErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		statuses []int
+		calls    int
+		err      error
+	}{
+		{"success_after_500", []int{500, 502, 200}, 3, nil},
+		{"success_after_429", []int{429, 200}, 2, nil},
+		{"gives_up_after_max_retries", []int{503, 503, 503, 503, 503}, 4, client.ErrServer},
+		{"no_retry_on_404", []int{404, 200}, 1, client.ErrNotFound},
+		{"no_retry_on_501", []int{501, 200}, 1, client.ErrServer},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			api, rec := newTestClient(t, func(w http.ResponseWriter, _ *http.Request, attempt int) {
+				status := c.statuses[attempt-1]
+				if status != http.StatusOK {
+					writeJSON(w, status, map[string]string{"message": http.StatusText(status)})
+					return
+				}
+				writeJSON(w, status, map[string]interface{}{"tag": map[string]interface{}{"id": 7, "name": "дом"}})
+			})
+
+			r, err := api.GetTag(context.Background(), 7)
+			if c.err == nil && (err != nil || r.ID != 7) {
+				t.Errorf("GetTag = %+v, %v", r, err)
+			}
+			if c.err != nil && !errors.Is(err, c.err) {
+				t.Errorf("error %v, want %v", err, c.err)
+			}
+			if n := len(rec.calls()); n != c.calls {
+				t.Errorf("%d requests, want %d", n, c.calls)
+			}
+		})
+	}
+}
+
+// Пауза из Retry-After заменяет обычную паузу между повторами.
+func TestRetryAfter(t *testing.T) {
+	api, rec := newTestClient(t, func(w http.ResponseWriter, _ *http.Request, attempt int) {
+		if attempt == 1 {
+			w.Header().Set("Retry-After", "1")
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "Too many requests"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": 7})
+	})
+
+	start := time.Now()
+	if _, err := api.GetTag(context.Background(), 7); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least the 1s from Retry-After", elapsed)
+	}
+	if n := len(rec.calls()); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+}
+
+// Отмена контекста прерывает ожидание повтора.
+func TestRetryStopsOnCancel(t *testing.T) {
+	api, _ := newTestClient(t, func(w http.ResponseWriter, _ *http.Request, _ int) {
+		w.Header().Set("Retry-After", "30")
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"message": "Service unavailable"})
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := api.GetTag(ctx, 7); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %v, want context.DeadlineExceeded", err)
+	}
+}
+
+// Все попытки POST-запроса идут с одним Idempotency-Key, а разные запросы - с разными.
+func TestIdempotencyKeyReusedAcrossRetries(t *testing.T) {
+	api, rec := newTestClient(t, func(w http.ResponseWriter, _ *http.Request, attempt int) {
+		switch attempt {
+		case 1:
+			writeJSON(w, http.StatusBadGateway, map[string]string{"message": "Bad gateway"})
+		case 2:
+			writeJSON(w, http.StatusConflict, map[string]string{"message": "A request with this Idempotency-Key is still in progress"})
+		default:
+			writeJSON(w, http.StatusCreated, map[string]interface{}{"id": attempt})
+		}
+	})
+
+	ctx := context.Background()
+	if _, err := api.CreateReminder(ctx, client.Reminder{UserID: 1, Message: "Позвонить"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.CreateReminder(ctx, client.Reminder{UserID: 1, Message: "Написать"}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := rec.calls()
+	if len(calls) != 4 {
+		t.Fatalf("%d requests, want 4", len(calls))
+	}
+	key := calls[0].Header.Get(client.IdempotencyHeader)
+	if key == "" {
+		t.Fatal("POST without Idempotency-Key")
+	}
+	for i, r := range calls[1:3] {
+		if got := r.Header.Get(client.IdempotencyHeader); got != key {
+			t.Errorf("retry %d with key %q, want %q", i+1, got, key)
+		}
+	}
+	if next := calls[3].Header.Get(client.IdempotencyHeader); next == "" || next == key {
+		t.Errorf("second request with key %q, want a new one", next)
+	}
+	if auth := calls[0].Header.Get("Authorization"); auth != "Bearer test-key" {
+		t.Errorf("Authorization %q", auth)
+	}
+}
+
+func TestGetHasNoIdempotencyKey(t *testing.T) {
+	api, rec := newTestClient(t, func(w http.ResponseWriter, _ *http.Request, _ int) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": 7})
+	})
+	if _, err := api.GetTag(context.Background(), 7); err != nil {
+		t.Fatal(err)
+	}
+	if key := rec.calls()[0].Header.Get(client.IdempotencyHeader); key != "" {
+		t.Errorf("GET with Idempotency-Key %q", key)
+	}
+}
+
+// Итератор идёт по страницам через next_after_id, пока сервер не вернёт 0.
+func TestRemindersPagination(t *testing.T) {
+	const total = 5
+	api, rec := newTestClient(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+		if r.URL.Path != "/reminders/1" {
+			t.Errorf("path %s", r.URL.Path)
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		after, _ := strconv.Atoi(r.URL.Query().Get("after_id"))
+		var page []map[string]interface{}
+		for id := after + 1; id <= total && len(page) < limit; id++ {
+			page = append(page, map[string]interface{}{"id": id, "user_id": 1})
+		}
+		next := 0
+		if last := after + len(page); last < total {
+			next = last
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"reminders": page, "next_after_id": next})
+	})
+
+	reminders, err := api.Reminders(context.Background(), client.ReminderFilter{UserID: 1, Tag: "дом", PageSize: 2}).All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != total {
+		t.Fatalf("%d reminders, want %d", len(reminders), total)
+	}
+	for i, r := range reminders {
+		if r.ID != i+1 {
+			t.Errorf("reminder %d has id %d", i, r.ID)
+		}
+	}
+
+	calls := rec.calls()
+	if len(calls) != 3 {
+		t.Fatalf("%d pages requested, want 3", len(calls))
+	}
+	for i, want := range []string{"", "2", "4"} {
+		q := calls[i].URL.Query()
+		if got := q.Get("after_id"); got != want {
+			t.Errorf("page %d after_id=%q, want %q", i+1, got, want)
+		}
+		if q.Get("limit") != "2" || q.Get("tag") != "дом" {
+			t.Errorf("page %d query %s", i+1, calls[i].URL.RawQuery)
+		}
+	}
+}
+
+// Пустая выборка приходит как 404 и не считается ошибкой.
+func TestRemindersEmpty(t *testing.T) {
+	api, _ := newTestClient(t, func(w http.ResponseWriter, _ *http.Request, _ int) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "No reminders found"})
+	})
+	reminders, err := api.Reminders(context.Background(), client.ReminderFilter{}).All()
+	if err != nil || len(reminders) != 0 {
+		t.Errorf("All = %v, %v; want no reminders and no error", reminders, err)
+	}
+}
+
+// Ошибка на середине перебора останавливает итератор с уже полученными напоминаниями.
+func TestRemindersStopsOnError(t *testing.T) {
+	api, _ := newTestClient(t, func(w http.ResponseWriter, _ *http.Request, attempt int) {
+		if attempt == 1 {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"reminders": []map[string]int{{"id": 1}}, "next_after_id": 1})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid after_id"})
+	})
+	reminders, err := api.Reminders(context.Background(), client.ReminderFilter{}).All()
+	if len(reminders) != 1 || !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("All = %v, %v; want one reminder and a bad request error", reminders, err)
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Максимальный размер тела ответа с ошибкой, который сохраняется в APIError
+const maxErrorBody = 1 << 20
+
+// Категории ошибок API для errors.Is.
+var (
+	ErrBadRequest    = errors.New("bad request")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrUnprocessable = errors.New("unprocessable entity")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrServer        = errors.New("server error")
+)
+
+// APIError - ответ API с кодом 4xx или 5xx.
+type APIError struct {
+	StatusCode int
+	// Message - текст из поля error или message ответа
+	Message string
+	// Body - тело ответа, например BatchResponse откаченного пакета
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("reminders api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("reminders api: %d %s", e.StatusCode, e.Message)
+}
+
+// Is сопоставляет код ответа с категорией ошибки.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnprocessable:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// newAPIError читает и закрывает тело ответа с ошибкой.
+func newAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	apiErr.Body, _ = io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	// Ошибки проверки приходят в поле error, отсутствующие объекты - в message
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(apiErr.Body, &body) == nil {
+		apiErr.Message = body.Error
+		if apiErr.Message == "" {
+			apiErr.Message = body.Message
+		}
+	}
+	return apiErr
+}
+
+// decodeErrorBody разбирает тело ответа с ошибкой в out, если ошибка - ответ API с кодом status.
+// Так пакетные запросы возвращают результаты элементов вместе с ошибкой 422.
+func decodeErrorBody(err error, status int, out interface{}) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == status {
+		_ = json.Unmarshal(apiErr.Body, out)
+	}
+}
@@ -0,0 +1,326 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Размер страницы итератора напоминаний по умолчанию
+const defaultPageSize = 100
+
+// batchItems и batchIDs - тела пакетных запросов
+type batchItems struct {
+	Mode  string     `json:"mode,omitempty"`
+	Items []Reminder `json:"items"`
+}
+
+type batchIDs struct {
+	Mode string `json:"mode,omitempty"`
+	IDs  []int  `json:"ids"`
+}
+
+// ReminderFilter - условия выборки напоминаний. Нулевые поля не ограничивают выборку.
+type ReminderFilter struct {
+	UserID int
+	ListID int
+	Tag    string
+	// PageSize - сколько напоминаний запрашивается за раз, не больше 500
+	PageSize int
+}
+
+// ReminderIterator перебирает напоминания постранично:
+//
+//	it := c.Reminders(ctx, client.ReminderFilter{UserID: 1})
+//	for it.Next() {
+//		fmt.Println(it.Reminder().Message)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ReminderIterator struct {
+	c       *Client
+	ctx     context.Context
+	filter  ReminderFilter
+	page    []Reminder
+	pos     int
+	afterID int
+	done    bool
+	err     error
+}
+
+// Reminders возвращает итератор по напоминаниям, подходящим под filter, в порядке id.
+func (c *Client) Reminders(ctx context.Context, filter ReminderFilter) *ReminderIterator {
+	if filter.PageSize <= 0 {
+		filter.PageSize = defaultPageSize
+	}
+	return &ReminderIterator{c: c, ctx: ctx, filter: filter}
+}
+
+// Next переходит к следующему напоминанию и при необходимости запрашивает следующую страницу.
+// Возвращает false, когда напоминания закончились или произошла ошибка.
+func (it *ReminderIterator) Next() bool {
+	for it.pos >= len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.fetch()
+	}
+	it.pos++
+	return true
+}
+
+// Reminder возвращает текущее напоминание.
+func (it *ReminderIterator) Reminder() Reminder {
+	return it.page[it.pos-1]
+}
+
+// Err возвращает ошибку, на которой остановился перебор.
+func (it *ReminderIterator) Err() error {
+	return it.err
+}
+
+// All дочитывает оставшиеся напоминания в срез.
+func (it *ReminderIterator) All() ([]Reminder, error) {
+	var reminders []Reminder
+	for it.Next() {
+		reminders = append(reminders, it.Reminder())
+	}
+	return reminders, it.Err()
+}
+
+// fetch запрашивает следующую страницу.
+func (it *ReminderIterator) fetch() {
+	path := "/reminders"
+	if it.filter.UserID > 0 {
+		path = fmt.Sprintf("/reminders/%d", it.filter.UserID)
+	}
+	query := url.Values{"limit": {strconv.Itoa(it.filter.PageSize)}}
+	if it.afterID > 0 {
+		query.Set("after_id", strconv.Itoa(it.afterID))
+	}
+	if it.filter.ListID > 0 {
+		query.Set("list_id", strconv.Itoa(it.filter.ListID))
+	}
+	if it.filter.Tag != "" {
+		query.Set("tag", it.filter.Tag)
+	}
+
+	var resp struct {
+		Reminders   []Reminder `json:"reminders"`
+		NextAfterID int        `json:"next_after_id"`
+	}
+	err := it.c.doJSON(it.ctx, http.MethodGet, path, query, nil, &resp)
+	// Пустая выборка приходит как 404
+	if errors.Is(err, ErrNotFound) {
+		it.page, it.pos, it.done = nil, 0, true
+		return
+	}
+	if err != nil {
+		it.err = err
+		return
+	}
+	it.page, it.pos = resp.Reminders, 0
+	it.afterID = resp.NextAfterID
+	it.done = resp.NextAfterID == 0
+}
+
+// CreateReminder создаёт напоминание.
+func (c *Client) CreateReminder(ctx context.Context, r Reminder) (Reminder, error) {
+	var resp struct {
+		Reminder Reminder `json:"reminder"`
+	}
+	err := c.doJSON(ctx, http.MethodPost, "/reminders", nil, r, &resp)
+	return resp.Reminder, err
+}
+
+// UpdateReminder изменяет неотправленное напоминание.
+func (c *Client) UpdateReminder(ctx context.Context, id int, r Reminder) (Reminder, error) {
+	var resp struct {
+		Reminder Reminder `json:"reminder"`
+	}
+	err := c.doJSON(ctx, http.MethodPut, fmt.Sprintf("/reminders/%d", id), nil, r, &resp)
+	return resp.Reminder, err
+}
+
+// DeleteReminder удаляет неотправленное напоминание.
+func (c *Client) DeleteReminder(ctx context.Context, id int) error {
+	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/reminders/%d", id), nil, nil, nil)
+}
+
+// AckReminder подтверждает последнюю отправку напоминания и отменяет эскалацию.
+func (c *Client) AckReminder(ctx context.Context, id int) (Reminder, error) {
+	return c.reminderAction(ctx, fmt.Sprintf("/reminders/%d/ack", id), nil, nil)
+}
+
+// PauseReminder приостанавливает напоминание.
+func (c *Client) PauseReminder(ctx context.Context, id int, pause PauseRequest) (Reminder, error) {
+	return c.reminderAction(ctx, fmt.Sprintf("/reminders/%d/pause", id), nil, pause)
+}
+
+// ResumeReminder возобновляет напоминание. missed - ResumeSkip, ResumeCatchUp или пустая строка.
+func (c *Client) ResumeReminder(ctx context.Context, id int, missed string) (Reminder, error) {
+	return c.reminderAction(ctx, fmt.Sprintf("/reminders/%d/resume", id), missedQuery(missed), nil)
+}
+
+// reminderAction выполняет POST-действие над напоминанием и возвращает его новое состояние.
+func (c *Client) reminderAction(ctx context.Context, path string, query url.Values, in interface{}) (Reminder, error) {
+	var resp struct {
+		Reminder Reminder `json:"reminder"`
+	}
+	err := c.doJSON(ctx, http.MethodPost, path, query, in, &resp)
+	return resp.Reminder, err
+}
+
+// UploadAttachment загружает вложение к неотправленному напоминанию.
+// attachmentType - photo, document или voice.
+func (c *Client) UploadAttachment(ctx context.Context, id int, attachmentType, filename string, file io.Reader) (Reminder, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("type", attachmentType); err != nil {
+		return Reminder{}, err
+	}
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return Reminder{}, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return Reminder{}, fmt.Errorf("read attachment: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return Reminder{}, err
+	}
+
+	req := request{
+		method:      http.MethodPost,
+		path:        fmt.Sprintf("/reminders/%d/attachment", id),
+		contentType: form.FormDataContentType(),
+		body:        body.Bytes(),
+		accept:      "application/json",
+	}
+	var resp struct {
+		Reminder Reminder `json:"reminder"`
+	}
+	err = c.do(ctx, req, &resp)
+	return resp.Reminder, err
+}
+
+// DeleteAttachment удаляет вложение напоминания.
+func (c *Client) DeleteAttachment(ctx context.Context, id int) (Reminder, error) {
+	var resp struct {
+		Reminder Reminder `json:"reminder"`
+	}
+	err := c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/reminders/%d/attachment", id), nil, nil, &resp)
+	return resp.Reminder, err
+}
+
+// ReminderHistory возвращает историю доставки напоминания.
+func (c *Client) ReminderHistory(ctx context.Context, id int) ([]ReminderHistory, error) {
+	var resp struct {
+		History []ReminderHistory `json:"history"`
+	}
+	err := c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/reminders/%d/history", id), nil, nil, &resp)
+	return resp.History, err
+}
+
+// ReminderDeliveries возвращает доставку повторения occurrence по получателям,
+// при occurrence = 0 - последнего повторения.
+func (c *Client) ReminderDeliveries(ctx context.Context, id, occurrence int) (DeliveriesResponse, error) {
+	var query url.Values
+	if occurrence > 0 {
+		query = url.Values{"occurrence": {strconv.Itoa(occurrence)}}
+	}
+	var resp DeliveriesResponse
+	err := c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/reminders/%d/deliveries", id), query, nil, &resp)
+	return resp, err
+}
+
+// BatchCreateReminders создаёт несколько напоминаний. Если пакет откачен, вместе с ошибкой
+// ErrUnprocessable возвращаются результаты элементов.
+func (c *Client) BatchCreateReminders(ctx context.Context, mode string, items []Reminder) (BatchResponse, error) {
+	return c.batch(ctx, "/reminders:batchCreate", batchItems{Mode: mode, Items: items})
+}
+
+// BatchUpdateReminders изменяет несколько напоминаний, id каждого элемента обязателен.
+func (c *Client) BatchUpdateReminders(ctx context.Context, mode string, items []Reminder) (BatchResponse, error) {
+	return c.batch(ctx, "/reminders:batchUpdate", batchItems{Mode: mode, Items: items})
+}
+
+// BatchDeleteReminders удаляет несколько напоминаний.
+func (c *Client) BatchDeleteReminders(ctx context.Context, mode string, ids []int) (BatchResponse, error) {
+	return c.batch(ctx, "/reminders:batchDelete", batchIDs{Mode: mode, IDs: ids})
+}
+
+func (c *Client) batch(ctx context.Context, path string, in interface{}) (BatchResponse, error) {
+	var resp BatchResponse
+	err := c.doJSON(ctx, http.MethodPost, path, nil, in, &resp)
+	decodeErrorBody(err, http.StatusUnprocessableEntity, &resp)
+	return resp, err
+}
+
+// ExportReminders выгружает напоминания всех пользователей или пользователя userID
+// в формате FormatCSV или FormatNDJSON и пишет их в w.
+func (c *Client) ExportReminders(ctx context.Context, format string, userID int, w io.Writer) error {
+	query := userQuery(userID)
+	if query == nil {
+		query = url.Values{}
+	}
+	if format != "" {
+		query.Set("format", format)
+	}
+	return c.download(ctx, c.HTTPClient, "/reminders/export", query, w)
+}
+
+// ImportReminders загружает напоминания из CSV или NDJSON. Ошибки отдельных строк
+// возвращаются в ImportResponse.Errors, а не ошибкой.
+func (c *Client) ImportReminders(ctx context.Context, opts ImportOptions, file io.Reader) (ImportResponse, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return ImportResponse{}, fmt.Errorf("read import file: %w", err)
+	}
+	format := opts.Format
+	if format == "" {
+		format = FormatNDJSON
+	}
+	query := url.Values{"format": {format}}
+	if opts.Mode != "" {
+		query.Set("mode", opts.Mode)
+	}
+	if opts.DryRun {
+		query.Set("dry_run", "true")
+	}
+	contentType := "application/x-ndjson"
+	if format == FormatCSV {
+		contentType = "text/csv"
+	}
+
+	req := request{method: http.MethodPost, path: "/reminders/import", query: query, contentType: contentType, body: data, accept: "application/json"}
+	var resp ImportResponse
+	err = c.do(ctx, req, &resp)
+	return resp, err
+}
+
+// download выполняет GET-запрос и копирует тело ответа в w.
+func (c *Client) download(ctx context.Context, httpClient *http.Client, path string, query url.Values, w io.Writer) error {
+	resp, err := c.sendWith(ctx, httpClient, request{method: http.MethodGet, path: path, query: query})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// missedQuery возвращает параметр missed, если режим задан.
+func missedQuery(missed string) url.Values {
+	if missed == "" {
+		return nil
+	}
+	return url.Values{"missed": {missed}}
+}
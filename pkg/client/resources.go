@@ -0,0 +1,244 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListLists возвращает списки напоминаний всех пользователей или пользователя userID.
+func (c *Client) ListLists(ctx context.Context, userID int) ([]ReminderList, error) {
+	var resp struct {
+		Lists []ReminderList `json:"lists"`
+	}
+	err := c.doJSON(ctx, http.MethodGet, "/lists", userQuery(userID), nil, &resp)
+	return resp.Lists, err
+}
+
+// GetList возвращает список напоминаний.
+func (c *Client) GetList(ctx context.Context, id int) (ReminderList, error) {
+	return c.list(ctx, http.MethodGet, fmt.Sprintf("/lists/%d", id), nil)
+}
+
+// CreateList создаёт список напоминаний.
+func (c *Client) CreateList(ctx context.Context, list ReminderList) (ReminderList, error) {
+	return c.list(ctx, http.MethodPost, "/lists", list)
+}
+
+// UpdateList переименовывает список напоминаний.
+func (c *Client) UpdateList(ctx context.Context, id int, list ReminderList) (ReminderList, error) {
+	return c.list(ctx, http.MethodPut, fmt.Sprintf("/lists/%d", id), list)
+}
+
+// DeleteList удаляет список. Напоминания списка остаются без списка.
+func (c *Client) DeleteList(ctx context.Context, id int) error {
+	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/lists/%d", id), nil, nil, nil)
+}
+
+// PauseList приостанавливает неотправленные напоминания списка и возвращает их количество.
+func (c *Client) PauseList(ctx context.Context, id int, pause PauseRequest) (int, error) {
+	return c.updated(ctx, fmt.Sprintf("/lists/%d/pause", id), nil, pause)
+}
+
+// ResumeList возобновляет напоминания списка и возвращает их количество.
+func (c *Client) ResumeList(ctx context.Context, id int, missed string) (int, error) {
+	return c.updated(ctx, fmt.Sprintf("/lists/%d/resume", id), missedQuery(missed), nil)
+}
+
+func (c *Client) list(ctx context.Context, method, path string, in interface{}) (ReminderList, error) {
+	var resp struct {
+		List ReminderList `json:"list"`
+	}
+	err := c.doJSON(ctx, method, path, nil, in, &resp)
+	return resp.List, err
+}
+
+// updated выполняет массовое изменение и возвращает количество изменённых напоминаний.
+func (c *Client) updated(ctx context.Context, path string, query url.Values, in interface{}) (int, error) {
+	var resp struct {
+		Updated int `json:"updated"`
+	}
+	err := c.doJSON(ctx, http.MethodPost, path, query, in, &resp)
+	return resp.Updated, err
+}
+
+// ListTags возвращает метки всех пользователей или пользователя userID.
+func (c *Client) ListTags(ctx context.Context, userID int) ([]Tag, error) {
+	var resp struct {
+		Tags []Tag `json:"tags"`
+	}
+	err := c.doJSON(ctx, http.MethodGet, "/tags", userQuery(userID), nil, &resp)
+	return resp.Tags, err
+}
+
+// GetTag возвращает метку.
+func (c *Client) GetTag(ctx context.Context, id int) (Tag, error) {
+	return c.tag(ctx, http.MethodGet, fmt.Sprintf("/tags/%d", id), nil)
+}
+
+// CreateTag создаёт метку.
+func (c *Client) CreateTag(ctx context.Context, tag Tag) (Tag, error) {
+	return c.tag(ctx, http.MethodPost, "/tags", tag)
+}
+
+// UpdateTag переименовывает метку.
+func (c *Client) UpdateTag(ctx context.Context, id int, tag Tag) (Tag, error) {
+	return c.tag(ctx, http.MethodPut, fmt.Sprintf("/tags/%d", id), tag)
+}
+
+// DeleteTag удаляет метку и снимает её с напоминаний.
+func (c *Client) DeleteTag(ctx context.Context, id int) error {
+	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/tags/%d", id), nil, nil, nil)
+}
+
+func (c *Client) tag(ctx context.Context, method, path string, in interface{}) (Tag, error) {
+	var resp struct {
+		Tag Tag `json:"tag"`
+	}
+	err := c.doJSON(ctx, method, path, nil, in, &resp)
+	return resp.Tag, err
+}
+
+// ListEvents возвращает события всех пользователей или пользователя userID.
+func (c *Client) ListEvents(ctx context.Context, userID int) ([]Event, error) {
+	var resp struct {
+		Events []Event `json:"events"`
+	}
+	err := c.doJSON(ctx, http.MethodGet, "/events", userQuery(userID), nil, &resp)
+	return resp.Events, err
+}
+
+// GetEvent возвращает событие.
+func (c *Client) GetEvent(ctx context.Context, id int) (Event, error) {
+	var resp struct {
+		Event Event `json:"event"`
+	}
+	err := c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/events/%d", id), nil, nil, &resp)
+	return resp.Event, err
+}
+
+// CreateEvent создаёт событие, относительно которого задаются напоминания.
+func (c *Client) CreateEvent(ctx context.Context, event Event) (Event, error) {
+	var resp struct {
+		Event Event `json:"event"`
+	}
+	err := c.doJSON(ctx, http.MethodPost, "/events", nil, event, &resp)
+	return resp.Event, err
+}
+
+// UpdateEvent изменяет событие и возвращает количество перенесённых вместе с ним напоминаний.
+func (c *Client) UpdateEvent(ctx context.Context, id int, event Event) (Event, int, error) {
+	var resp struct {
+		Event       Event `json:"event"`
+		Rescheduled int   `json:"rescheduled"`
+	}
+	err := c.doJSON(ctx, http.MethodPut, fmt.Sprintf("/events/%d", id), nil, event, &resp)
+	return resp.Event, resp.Rescheduled, err
+}
+
+// DeleteEvent удаляет событие.
+func (c *Client) DeleteEvent(ctx context.Context, id int) error {
+	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/events/%d", id), nil, nil, nil)
+}
+
+// ListEscalationPolicies возвращает политики эскалации всех пользователей или пользователя userID.
+func (c *Client) ListEscalationPolicies(ctx context.Context, userID int) ([]EscalationPolicy, error) {
+	var resp struct {
+		EscalationPolicies []EscalationPolicy `json:"escalation_policies"`
+	}
+	err := c.doJSON(ctx, http.MethodGet, "/escalation-policies", userQuery(userID), nil, &resp)
+	return resp.EscalationPolicies, err
+}
+
+// GetEscalationPolicy возвращает политику эскалации.
+func (c *Client) GetEscalationPolicy(ctx context.Context, id int) (EscalationPolicy, error) {
+	return c.escalationPolicy(ctx, http.MethodGet, fmt.Sprintf("/escalation-policies/%d", id), nil)
+}
+
+// CreateEscalationPolicy создаёт политику эскалации.
+func (c *Client) CreateEscalationPolicy(ctx context.Context, policy EscalationPolicy) (EscalationPolicy, error) {
+	return c.escalationPolicy(ctx, http.MethodPost, "/escalation-policies", policy)
+}
+
+// UpdateEscalationPolicy изменяет политику эскалации.
+func (c *Client) UpdateEscalationPolicy(ctx context.Context, id int, policy EscalationPolicy) (EscalationPolicy, error) {
+	return c.escalationPolicy(ctx, http.MethodPut, fmt.Sprintf("/escalation-policies/%d", id), policy)
+}
+
+// DeleteEscalationPolicy удаляет политику эскалации.
+func (c *Client) DeleteEscalationPolicy(ctx context.Context, id int) error {
+	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/escalation-policies/%d", id), nil, nil, nil)
+}
+
+func (c *Client) escalationPolicy(ctx context.Context, method, path string, in interface{}) (EscalationPolicy, error) {
+	var resp struct {
+		EscalationPolicy EscalationPolicy `json:"escalation_policy"`
+	}
+	err := c.doJSON(ctx, method, path, nil, in, &resp)
+	return resp.EscalationPolicy, err
+}
+
+// ListWebhooks возвращает подписки всех пользователей или пользователя userID без секретов.
+func (c *Client) ListWebhooks(ctx context.Context, userID int) ([]Webhook, error) {
+	var resp struct {
+		Webhooks []Webhook `json:"webhooks"`
+	}
+	err := c.doJSON(ctx, http.MethodGet, "/webhooks", userQuery(userID), nil, &resp)
+	return resp.Webhooks, err
+}
+
+// GetWebhook возвращает подписку без секрета.
+func (c *Client) GetWebhook(ctx context.Context, id int) (Webhook, error) {
+	return c.webhook(ctx, http.MethodGet, fmt.Sprintf("/webhooks/%d", id), nil)
+}
+
+// CreateWebhook создаёт подписку. Секрет для проверки подписи есть только в этом ответе.
+func (c *Client) CreateWebhook(ctx context.Context, hook WebhookRequest) (Webhook, error) {
+	return c.webhook(ctx, http.MethodPost, "/webhooks", hook)
+}
+
+// UpdateWebhook изменяет подписку.
+func (c *Client) UpdateWebhook(ctx context.Context, id int, hook WebhookRequest) (Webhook, error) {
+	return c.webhook(ctx, http.MethodPut, fmt.Sprintf("/webhooks/%d", id), hook)
+}
+
+// DeleteWebhook удаляет подписку.
+func (c *Client) DeleteWebhook(ctx context.Context, id int) error {
+	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/webhooks/%d", id), nil, nil, nil)
+}
+
+// WebhookDeliveries возвращает последние доставки подписки. status - pending, delivered,
+// failed или пустая строка, limit = 0 - значение сервера по умолчанию.
+func (c *Client) WebhookDeliveries(ctx context.Context, id int, status string, limit int) ([]WebhookDelivery, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var resp struct {
+		Deliveries []WebhookDelivery `json:"deliveries"`
+	}
+	err := c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/webhooks/%d/deliveries", id), query, nil, &resp)
+	return resp.Deliveries, err
+}
+
+// RedeliverWebhook повторяет доставку события и возвращает результат новой доставки.
+func (c *Client) RedeliverWebhook(ctx context.Context, id, deliveryID int) (WebhookDelivery, error) {
+	var resp struct {
+		Delivery WebhookDelivery `json:"delivery"`
+	}
+	err := c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/webhooks/%d/deliveries/%d/redeliver", id, deliveryID), nil, nil, &resp)
+	return resp.Delivery, err
+}
+
+func (c *Client) webhook(ctx context.Context, method, path string, in interface{}) (Webhook, error) {
+	var resp struct {
+		Webhook Webhook `json:"webhook"`
+	}
+	err := c.doJSON(ctx, method, path, nil, in, &resp)
+	return resp.Webhook, err
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// StreamReminders подписывается на поток изменений напоминаний владельца ключа API и вызывает
+// fn для каждого события. lastEventID > 0 продолжает поток после этого события, иначе
+// передаются только новые. Поток идёт, пока не отменён ctx, не закрыто соединение или fn не
+// вернула ошибку. При обрыве достаточно вызвать метод снова с ID последнего события.
+func (c *Client) StreamReminders(ctx context.Context, lastEventID int, fn func(StreamEvent) error) error {
+	// Поток бессрочный, таймаут обычных запросов его бы оборвал
+	httpClient := *c.HTTPClient
+	httpClient.Timeout = 0
+
+	req := request{method: http.MethodGet, path: "/reminders/stream", accept: "text/event-stream"}
+	if lastEventID > 0 {
+		req.query = url.Values{"last_event_id": {strconv.Itoa(lastEventID)}}
+	}
+	resp, err := c.sendWith(ctx, &httpClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var data strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Пустая строка завершает событие, строки с двоеточием в начале - комментарии
+		if line == "" {
+			if data.Len() == 0 {
+				continue
+			}
+			var event StreamEvent
+			if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+				return fmt.Errorf("decode stream event: %w", err)
+			}
+			data.Reset()
+			if err := fn(event); err != nil {
+				return err
+			}
+			continue
+		}
+		if field, value, ok := strings.Cut(line, ":"); ok && field == "data" {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(value, " "))
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,382 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Reminder - напоминание.
+type Reminder struct {
+	ID                 int                 `json:"id"`
+	ExternalID         *string             `json:"external_id,omitempty"`
+	UserID             int                 `json:"user_id"`
+	Recipients         []ReminderRecipient `json:"recipients,omitempty"`
+	ListID             *int                `json:"list_id,omitempty"`
+	Tags               []Tag               `json:"tags,omitempty"`
+	Message            string              `json:"message"`
+	IsTemplate         bool                `json:"is_template"`
+	ParseMode          string              `json:"parse_mode,omitempty"`
+	AttachmentType     string              `json:"attachment_type,omitempty"`
+	AttachmentFileID   string              `json:"attachment_file_id,omitempty"`
+	AttachmentKey      string              `json:"attachment_key,omitempty"`
+	SendAt             time.Time           `json:"send_at"`
+	AnchorEventID      *int                `json:"anchor_event_id,omitempty"`
+	AnchorReminderID   *int                `json:"anchor_reminder_id,omitempty"`
+	AnchorOn           string              `json:"anchor_on,omitempty"`
+	AnchorOffset       int                 `json:"anchor_offset_minutes,omitempty"`
+	WaitingAnchor      bool                `json:"waiting_anchor,omitempty"`
+	TimeZone           string              `json:"time_zone,omitempty"`
+	Recurrence         string              `json:"recurrence,omitempty"`
+	SentCount          int                 `json:"sent_count"`
+	IsSent             bool                `json:"is_sent"`
+	Urgent             bool                `json:"urgent"`
+	Priority           string              `json:"priority,omitempty"`
+	EscalationPolicyID *int                `json:"escalation_policy_id,omitempty"`
+	AckedAt            *time.Time          `json:"acked_at,omitempty"`
+	DeliveryStatus     string              `json:"delivery_status,omitempty"`
+	DeliveryError      string              `json:"delivery_error,omitempty"`
+	DeferredUntil      *time.Time          `json:"deferred_until,omitempty"`
+	PausedAt           *time.Time          `json:"paused_at,omitempty"`
+	PausedUntil        *time.Time          `json:"paused_until,omitempty"`
+	ResumeMode         string              `json:"resume_mode,omitempty"`
+	MisfireSettings
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ReminderRecipient - дополнительный получатель напоминания: пользователь (Kind "user")
+// или группа либо канал Telegram (Kind "chat").
+type ReminderRecipient struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	UserID    *int      `json:"user_id,omitempty"`
+	ChatID    int64     `json:"chat_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReminderHistory - запись в истории доставки напоминания.
+type ReminderHistory struct {
+	ID            int        `json:"id"`
+	ReminderID    int        `json:"reminder_id"`
+	Action        string     `json:"action"`
+	Reason        string     `json:"reason,omitempty"`
+	SendAt        time.Time  `json:"send_at"`
+	DeferredUntil *time.Time `json:"deferred_until,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ReminderDelivery - результат доставки одного повторения напоминания в один чат.
+type ReminderDelivery struct {
+	ID          int       `json:"id"`
+	ReminderID  int       `json:"reminder_id"`
+	RecipientID *int      `json:"recipient_id,omitempty"`
+	UserID      *int      `json:"user_id,omitempty"`
+	ChatID      int64     `json:"chat_id"`
+	Occurrence  int       `json:"occurrence"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ReminderList - список напоминаний пользователя.
+type ReminderList struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Tag - метка напоминания. В запросах метку можно задать одним именем.
+type Tag struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Event - событие, от которого отсчитываются относительные напоминания.
+type Event struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	StartsAt  time.Time `json:"starts_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EscalationPolicy - шаги, которые выполняются, пока напоминание не подтверждено.
+type EscalationPolicy struct {
+	ID        int             `json:"id"`
+	UserID    int             `json:"user_id"`
+	Name      string          `json:"name"`
+	Steps     EscalationSteps `json:"steps"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// EscalationStep - шаг эскалации. AfterMinutes отсчитывается от отправки напоминания.
+type EscalationStep struct {
+	AfterMinutes  int    `json:"after_minutes"`
+	Action        string `json:"action"`
+	ChatID        int64  `json:"chat_id,omitempty"`
+	ContactUserID int    `json:"contact_user_id,omitempty"`
+}
+
+// EscalationSteps - шаги политики эскалации по порядку.
+type EscalationSteps []EscalationStep
+
+// User - пользователь сервиса.
+type User struct {
+	ID             int        `json:"id"`
+	DisplayName    string     `json:"display_name"`
+	TimeZone       string     `json:"time_zone,omitempty"`
+	Locale         string     `json:"locale,omitempty"`
+	DefaultChannel string     `json:"default_channel"`
+	QuietHours     QuietHours `json:"quiet_hours"`
+	DndUntil       *time.Time `json:"dnd_until,omitempty"`
+	PausedAt       *time.Time `json:"paused_at,omitempty"`
+	PausedUntil    *time.Time `json:"paused_until,omitempty"`
+	ResumeMode     string     `json:"resume_mode,omitempty"`
+	MisfireSettings
+	Digest        DigestSettings `json:"digest"`
+	TelegramLinks []TelegramLink `json:"telegram_links"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// TelegramLink - привязанный к пользователю чат Telegram. Чат, в который больше нельзя
+// писать, отключается с причиной в InactiveReason.
+type TelegramLink struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	ChatID         int64      `json:"chat_id"`
+	Active         bool       `json:"active"`
+	InactiveReason string     `json:"inactive_reason,omitempty"`
+	DeactivatedAt  *time.Time `json:"deactivated_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// QuietWindow - интервал тишины в часовом поясе пользователя, "HH:MM". Без Weekdays
+// действует каждый день.
+type QuietWindow struct {
+	Weekdays []string `json:"weekdays,omitempty"`
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+}
+
+// QuietHours - интервалы тишины пользователя.
+type QuietHours []QuietWindow
+
+// DigestSettings - доставка напоминаний сводкой: Mode "daily" или "weekly" в At,
+// недельная - в день Weekday. AgendaAt добавляет вечерний обзор завтрашних напоминаний.
+type DigestSettings struct {
+	Mode     string `json:"mode,omitempty"`
+	At       string `json:"at,omitempty"`
+	Weekday  string `json:"weekday,omitempty"`
+	AgendaAt string `json:"agenda_at,omitempty"`
+}
+
+// MisfireSettings - что делать с напоминаниями, которые подошли, пока бот не работал.
+type MisfireSettings struct {
+	Policy           string `json:"misfire_policy,omitempty"`
+	ThresholdMinutes int    `json:"misfire_threshold_minutes,omitempty"`
+}
+
+// APIKey - ключ API без самого ключа: он есть только в APIKeyResponse при выпуске.
+type APIKey struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Webhook - подписка на события напоминаний пользователя.
+type Webhook struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	URL       string     `json:"url"`
+	Secret    string     `json:"secret,omitempty"`
+	Events    StringList `json:"events"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// WebhookDelivery - доставка события подписке с результатом последней попытки.
+type WebhookDelivery struct {
+	ID            int           `json:"id"`
+	WebhookID     int           `json:"webhook_id"`
+	EventID       int           `json:"event_id"`
+	Event         *WebhookEvent `json:"event,omitempty"`
+	Status        string        `json:"status"`
+	Attempts      int           `json:"attempts"`
+	ResponseCode  int           `json:"response_code,omitempty"`
+	Error         string        `json:"error,omitempty"`
+	NextAttemptAt *time.Time    `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time    `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// WebhookEvent - событие из журнала, которое доставляется подписке. Data - напоминание
+// в момент события.
+type WebhookEvent struct {
+	ID         int             `json:"id"`
+	UserID     int             `json:"user_id"`
+	ReminderID int             `json:"reminder_id"`
+	Type       string          `json:"type"`
+	Data       json.RawMessage `json:"data"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// StringList - список строк, например типов событий подписки.
+type StringList []string
+
+// StreamEvent - событие потока изменений, то же, что тело запроса вебхука.
+type StreamEvent struct {
+	ID         int             `json:"id"`
+	Type       string          `json:"type"`
+	ReminderID int             `json:"reminder_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Режимы пакетных операций и импорта iCalendar
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+// Режимы импорта CSV и NDJSON
+const (
+	ImportModeCreate = "create"
+	ImportModeUpsert = "upsert"
+)
+
+// Форматы выгрузки и загрузки напоминаний
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Что сделать с повторениями, пропущенными за время паузы
+const (
+	ResumeSkip    = "skip"
+	ResumeCatchUp = "catch_up"
+)
+
+// Что сделать с напоминаниями удаляемого пользователя
+const (
+	DeletePolicyArchive = "archive"
+	DeletePolicyCascade = "cascade"
+)
+
+// UserRequest - профиль пользователя при создании и изменении. Nil в TelegramChatIDs
+// оставляет привязанные чаты без изменений, пустой список отвязывает все.
+type UserRequest struct {
+	DisplayName     string         `json:"display_name"`
+	TimeZone        string         `json:"time_zone"`
+	Locale          string         `json:"locale"`
+	DefaultChannel  string         `json:"default_channel"`
+	QuietHours      QuietHours     `json:"quiet_hours"`
+	TelegramChatIDs *[]int64       `json:"telegram_chat_ids,omitempty"`
+	Digest          DigestSettings `json:"digest"`
+	MisfireSettings
+}
+
+// WebhookRequest - подписка на события напоминаний. Пустой Secret при создании генерируется
+// сервером, при изменении оставляет прежний; Active по умолчанию true.
+type WebhookRequest struct {
+	UserID int        `json:"user_id"`
+	URL    string     `json:"url"`
+	Secret string     `json:"secret,omitempty"`
+	Events StringList `json:"events"`
+	Active *bool      `json:"active,omitempty"`
+}
+
+// PauseRequest - параметры паузы. Без Until пауза длится до явного возобновления.
+type PauseRequest struct {
+	Until      *time.Time `json:"until,omitempty"`
+	ResumeMode string     `json:"resume_mode,omitempty"`
+}
+
+// BatchItemResult - результат обработки одного элемента пакета.
+type BatchItemResult struct {
+	Index    int       `json:"index"`
+	ID       int       `json:"id,omitempty"`
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Reminder *Reminder `json:"reminder,omitempty"`
+}
+
+// BatchResponse - ответ пакетного запроса. При Failed > 0 часть элементов не применена,
+// при Committed = false не применено ничего.
+type BatchResponse struct {
+	Mode      string            `json:"mode"`
+	Committed bool              `json:"committed"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// ImportOptions - параметры загрузки напоминаний из CSV или NDJSON.
+type ImportOptions struct {
+	// Format - FormatCSV или FormatNDJSON (по умолчанию)
+	Format string
+	// Mode - ImportModeCreate (по умолчанию) или ImportModeUpsert
+	Mode string
+	// DryRun только проверяет файл, ничего не сохраняя
+	DryRun bool
+}
+
+// ImportLineError - ошибка в строке импортируемого файла.
+type ImportLineError struct {
+	Line       int    `json:"line"`
+	ExternalID string `json:"external_id,omitempty"`
+	Error      string `json:"error"`
+}
+
+// ImportResponse - итог импорта.
+type ImportResponse struct {
+	Format  string            `json:"format"`
+	Mode    string            `json:"mode"`
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Errors  []ImportLineError `json:"errors"`
+}
+
+// DeliveriesResponse - доставка одного повторения напоминания по получателям.
+type DeliveriesResponse struct {
+	Occurrence int                `json:"occurrence"`
+	Status     string             `json:"status,omitempty"`
+	Sent       int                `json:"sent"`
+	Failed     int                `json:"failed"`
+	Deliveries []ReminderDelivery `json:"deliveries"`
+}
+
+// APIKeyResponse - выпущенный ключ API. Key показывается только в этом ответе.
+type APIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// TelegramLinkResponse - одноразовая ссылка для привязки чата Telegram.
+type TelegramLinkResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CalendarFeedResponse - секретная ссылка на календарь пользователя.
+type CalendarFeedResponse struct {
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
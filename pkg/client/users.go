@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ListUsers возвращает всех пользователей.
+func (c *Client) ListUsers(ctx context.Context) ([]User, error) {
+	var resp struct {
+		Users []User `json:"users"`
+	}
+	err := c.doJSON(ctx, http.MethodGet, "/users", nil, nil, &resp)
+	return resp.Users, err
+}
+
+// GetUser возвращает профиль пользователя.
+func (c *Client) GetUser(ctx context.Context, id int) (User, error) {
+	return c.user(ctx, http.MethodGet, fmt.Sprintf("/users/%d", id), nil, nil)
+}
+
+// CreateUser создаёт пользователя.
+func (c *Client) CreateUser(ctx context.Context, user UserRequest) (User, error) {
+	return c.user(ctx, http.MethodPost, "/users", nil, user)
+}
+
+// UpdateUser изменяет профиль пользователя.
+func (c *Client) UpdateUser(ctx context.Context, id int, user UserRequest) (User, error) {
+	return c.user(ctx, http.MethodPut, fmt.Sprintf("/users/%d", id), nil, user)
+}
+
+// DeleteUser удаляет пользователя. policy - DeletePolicyArchive (по умолчанию)
+// или DeletePolicyCascade.
+func (c *Client) DeleteUser(ctx context.Context, id int, policy string) error {
+	var query url.Values
+	if policy != "" {
+		query = url.Values{"policy": {policy}}
+	}
+	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/users/%d", id), query, nil, nil)
+}
+
+// SetDnd откладывает доставку напоминаний пользователя до until.
+func (c *Client) SetDnd(ctx context.Context, id int, until time.Time) (User, error) {
+	body := struct {
+		Until time.Time `json:"until"`
+	}{Until: until}
+	return c.user(ctx, http.MethodPut, fmt.Sprintf("/users/%d/dnd", id), nil, body)
+}
+
+// ClearDnd выключает режим «не беспокоить».
+func (c *Client) ClearDnd(ctx context.Context, id int) (User, error) {
+	return c.user(ctx, http.MethodDelete, fmt.Sprintf("/users/%d/dnd", id), nil, nil)
+}
+
+// PauseUser приостанавливает все напоминания пользователя.
+func (c *Client) PauseUser(ctx context.Context, id int, pause PauseRequest) (User, error) {
+	return c.user(ctx, http.MethodPost, fmt.Sprintf("/users/%d/pause", id), nil, pause)
+}
+
+// ResumeUser снимает паузу пользователя и возвращает количество напоминаний,
+// просроченных за время паузы.
+func (c *Client) ResumeUser(ctx context.Context, id int, missed string) (User, int, error) {
+	var resp struct {
+		User    User `json:"user"`
+		Overdue int  `json:"overdue"`
+	}
+	err := c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/users/%d/resume", id), missedQuery(missed), nil, &resp)
+	return resp.User, resp.Overdue, err
+}
+
+func (c *Client) user(ctx context.Context, method, path string, query url.Values, in interface{}) (User, error) {
+	var resp struct {
+		User User `json:"user"`
+	}
+	err := c.doJSON(ctx, method, path, query, in, &resp)
+	return resp.User, err
+}
+
+// ListAPIKeys возвращает ключи API пользователя без самих ключей.
+func (c *Client) ListAPIKeys(ctx context.Context, userID int) ([]APIKey, error) {
+	var resp struct {
+		APIKeys []APIKey `json:"api_keys"`
+	}
+	err := c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/users/%d/api-keys", userID), nil, nil, &resp)
+	return resp.APIKeys, err
+}
+
+// CreateAPIKey выпускает ключ API. Сам ключ есть только в этом ответе.
+func (c *Client) CreateAPIKey(ctx context.Context, userID int, name string) (APIKeyResponse, error) {
+	body := struct {
+		Name string `json:"name,omitempty"`
+	}{Name: name}
+	var resp APIKeyResponse
+	err := c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/users/%d/api-keys", userID), nil, body, &resp)
+	return resp, err
+}
+
+// DeleteAPIKey отзывает ключ API.
+func (c *Client) DeleteAPIKey(ctx context.Context, userID, keyID int) error {
+	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/users/%d/api-keys/%d", userID, keyID), nil, nil, nil)
+}
+
+// CreateTelegramLink выпускает одноразовую ссылку для привязки чата Telegram.
+func (c *Client) CreateTelegramLink(ctx context.Context, userID int) (TelegramLinkResponse, error) {
+	var resp TelegramLinkResponse
+	err := c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/users/%d/telegram-link", userID), nil, nil, &resp)
+	return resp, err
+}
+
+// DeleteTelegramLink отвязывает чат Telegram от пользователя.
+func (c *Client) DeleteTelegramLink(ctx context.Context, userID int, chatID int64) error {
+	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/users/%d/telegram-link/%d", userID, chatID), nil, nil, nil)
+}
+
+// ExportCalendar выгружает напоминания пользователя в iCalendar и пишет их в w.
+func (c *Client) ExportCalendar(ctx context.Context, userID int, w io.Writer) error {
+	return c.download(ctx, c.HTTPClient, fmt.Sprintf("/users/%d/reminders.ics", userID), nil, w)
+}
+
+// ImportCalendar создаёт напоминания из файла .ics. mode - BatchModeBestEffort (по умолчанию)
+// или BatchModeAtomic, timeZone - зона для событий без неё. Если пакет откачен, вместе
+// с ошибкой ErrUnprocessable возвращаются результаты событий.
+func (c *Client) ImportCalendar(ctx context.Context, userID int, mode, timeZone string, file io.Reader) (BatchResponse, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return BatchResponse{}, fmt.Errorf("read calendar: %w", err)
+	}
+	query := url.Values{}
+	if mode != "" {
+		query.Set("mode", mode)
+	}
+	if timeZone != "" {
+		query.Set("time_zone", timeZone)
+	}
+
+	req := request{
+		method:      http.MethodPost,
+		path:        fmt.Sprintf("/users/%d/reminders.ics", userID),
+		query:       query,
+		contentType: "text/calendar",
+		body:        data,
+		accept:      "application/json",
+	}
+	var resp BatchResponse
+	err = c.do(ctx, req, &resp)
+	decodeErrorBody(err, http.StatusUnprocessableEntity, &resp)
+	return resp, err
+}
+
+// CreateCalendarFeed выпускает секретную ссылку на календарь. Предыдущая ссылка перестаёт работать.
+func (c *Client) CreateCalendarFeed(ctx context.Context, userID int) (CalendarFeedResponse, error) {
+	var resp CalendarFeedResponse
+	err := c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/users/%d/calendar-feed", userID), nil, nil, &resp)
+	return resp, err
+}
+
+// DeleteCalendarFeed отзывает ссылку на календарь.
+func (c *Client) DeleteCalendarFeed(ctx context.Context, userID int) error {
+	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/users/%d/calendar-feed", userID), nil, nil, nil)
+}
+
+// CalendarFeed читает календарь по токену ссылки и пишет его в w.
+func (c *Client) CalendarFeed(ctx context.Context, token string, w io.Writer) error {
+	return c.download(ctx, c.HTTPClient, "/calendar/"+url.PathEscape(token), nil, w)
+}